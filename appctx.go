@@ -0,0 +1,100 @@
+package ezapp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RunnerCount returns the number of runners registered on the AppCtx.
+func (appCtx AppCtx) RunnerCount() int {
+	return len(appCtx.runnerList)
+}
+
+// RunnerNames returns the names of the registered runners in registration
+// order. Runners added without a name are reported by position, e.g.
+// "runner-0", matching the names used in RunnerError.
+func (appCtx AppCtx) RunnerNames() []string {
+	names := make([]string, len(appCtx.runnerList))
+	for idx := range appCtx.runnerList {
+		names[idx] = appCtx.runnerName(idx)
+	}
+	return names
+}
+
+// HasCleanup reports whether a cleanup function or any cleanup step has been
+// registered on the AppCtx.
+func (appCtx AppCtx) HasCleanup() bool {
+	return appCtx.cleanupFunc != nil || len(appCtx.cleanupSteps) > 0
+}
+
+// CleanupSteps returns the names of the cleanup steps in the order they will
+// run. The function set with WithCleanup is reported as "cleanup".
+func (appCtx AppCtx) CleanupSteps() []string {
+	var steps []string
+	for _, step := range appCtx.orderedCleanupSteps() {
+		steps = append(steps, step.name)
+	}
+	return steps
+}
+
+// String returns a single-line summary of the AppCtx, e.g.
+// "AppCtx{runners: [http worker], cleanup: true}".
+func (appCtx AppCtx) String() string {
+	return fmt.Sprintf("AppCtx{runners: %v, cleanup: %t}", appCtx.RunnerNames(), appCtx.HasCleanup())
+}
+
+// Describe returns a multi-line, human-readable description of everything
+// registered on the AppCtx, intended for tooling and debugging output.
+//
+// Example output:
+//
+//	runners (2):
+//	  - http
+//	  - runner-1
+//	cleanup steps: cleanup, database
+//	state hooks: 1
+//	pre-shutdown hooks: 0
+//	post-run hooks: 0
+//	endpoints: http=http://localhost:8080
+//	components (1):
+//	  - database: *pgxpool.Pool (healthy)
+func (appCtx AppCtx) Describe() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "runners (%d):\n", appCtx.RunnerCount())
+	for _, name := range appCtx.RunnerNames() {
+		fmt.Fprintf(&b, "  - %s\n", name)
+	}
+
+	steps := appCtx.CleanupSteps()
+	if len(steps) == 0 {
+		b.WriteString("cleanup steps: none\n")
+	} else {
+		fmt.Fprintf(&b, "cleanup steps: %s\n", strings.Join(steps, ", "))
+	}
+
+	fmt.Fprintf(&b, "state hooks: %d\n", len(appCtx.stateHooks))
+	fmt.Fprintf(&b, "pre-shutdown hooks: %d\n", len(appCtx.preShutdownHooks))
+	fmt.Fprintf(&b, "post-run hooks: %d\n", len(appCtx.postRunHooks))
+
+	endpoints := make([]string, 0, len(appCtx.endpoints))
+	for _, endpoint := range appCtx.endpoints {
+		endpoints = append(endpoints, endpoint.Name+"="+endpoint.Address)
+	}
+	if len(endpoints) == 0 {
+		b.WriteString("endpoints: none\n")
+	} else {
+		fmt.Fprintf(&b, "endpoints: %s\n", strings.Join(endpoints, ", "))
+	}
+
+	fmt.Fprintf(&b, "components (%d):\n", len(appCtx.components))
+	for _, c := range appCtx.Components() {
+		if c.Healthy {
+			fmt.Fprintf(&b, "  - %s: %s (healthy)\n", c.Name, c.Type)
+		} else {
+			fmt.Fprintf(&b, "  - %s: %s\n", c.Name, c.Type)
+		}
+	}
+
+	return b.String()
+}
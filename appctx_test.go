@@ -0,0 +1,68 @@
+package ezapp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppCtxInspection(t *testing.T) {
+	appCtx, err := Construct(
+		WithNamedRunner("http", successfulRunner),
+		WithRunners(successfulRunner),
+		WithCleanup(successfulCleanup),
+		WithCleanupStep("database", successfulCleanup),
+		WithCleanupStep("cache", successfulCleanup),
+		WithStateHook(func(from, to State) {}),
+		WithEndpoint("http", "http://localhost:8080"),
+		WithComponent("database", &testPool{}),
+		WithComponent("cache", map[string]string{}),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, appCtx.RunnerCount())
+	assert.Equal(t, []string{"http", "runner-1"}, appCtx.RunnerNames())
+	assert.True(t, appCtx.HasCleanup())
+	assert.Equal(t, []string{"cleanup", "cache", "database"}, appCtx.CleanupSteps())
+	assert.Equal(t, "AppCtx{runners: [http runner-1], cleanup: true}", appCtx.String())
+
+	assert.Equal(t, "runners (2):\n"+
+		"  - http\n"+
+		"  - runner-1\n"+
+		"cleanup steps: cleanup, cache, database\n"+
+		"state hooks: 1\n"+
+		"pre-shutdown hooks: 0\n"+
+		"post-run hooks: 0\n"+
+		"endpoints: http=http://localhost:8080\n"+
+		"components (2):\n"+
+		"  - database: *ezapp.testPool (healthy)\n"+
+		"  - cache: map[string]string\n", appCtx.Describe())
+	assert.Equal(t, []ComponentInfo{
+		{Name: "database", Type: "*ezapp.testPool", Healthy: true},
+		{Name: "cache", Type: "map[string]string"},
+	}, appCtx.Components())
+}
+
+func TestAppCtxInspectionEmpty(t *testing.T) {
+	appCtx, err := Construct()
+	require.NoError(t, err)
+
+	assert.Zero(t, appCtx.RunnerCount())
+	assert.Empty(t, appCtx.RunnerNames())
+	assert.False(t, appCtx.HasCleanup())
+	assert.Empty(t, appCtx.CleanupSteps())
+	assert.Contains(t, appCtx.Describe(), "cleanup steps: none")
+	assert.Contains(t, appCtx.Describe(), "endpoints: none")
+	assert.Contains(t, appCtx.Describe(), "components (0):")
+	assert.Empty(t, appCtx.Components())
+}
+
+func TestAppCtxInspectionCleanupStepOnly(t *testing.T) {
+	appCtx, err := Construct(WithCleanupStep("database", func(context.Context) error { return nil }))
+	require.NoError(t, err)
+
+	assert.True(t, appCtx.HasCleanup())
+	assert.Equal(t, []string{"database"}, appCtx.CleanupSteps())
+}
@@ -0,0 +1,167 @@
+// Package appstate provides a small, concurrency-safe key-value store for
+// state shared between runners, such as feature toggles or draining flags,
+// with change notifications. ezapp creates one store per application and
+// exposes it as InitCtx.AppState.
+package appstate
+
+import (
+	"slices"
+	"sync"
+)
+
+// Change describes a modification of a single key.
+type Change struct {
+	Key string
+
+	// Old is the previous value, or nil if the key was not set.
+	Old any
+
+	// New is the new value, or nil if the key was deleted.
+	New any
+
+	// Deleted reports whether the key was removed.
+	Deleted bool
+}
+
+// subscription is a change listener registered with OnChange.
+type subscription struct {
+	key string
+	fn  func(Change)
+}
+
+// Store is a concurrency-safe key-value store. The zero value is not usable;
+// create stores with New.
+type Store struct {
+	mu     sync.RWMutex
+	values map[string]any
+
+	subsMu sync.Mutex
+	subs   map[int]subscription
+	nextID int
+
+	// notifyMu serializes notifications so that listeners observe changes
+	// in the order they were made.
+	notifyMu sync.Mutex
+}
+
+// New creates an empty Store.
+func New() *Store {
+	return &Store{
+		values: make(map[string]any),
+		subs:   make(map[int]subscription),
+	}
+}
+
+// Get returns the value stored under key and whether it was set.
+func (s *Store) Get(key string) (any, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// GetAs returns the value stored under key as a T. The boolean is false if
+// the key is not set or its value is not a T.
+//
+// Example:
+//
+//	draining, _ := appstate.GetAs[bool](ctx.AppState, "draining")
+func GetAs[T any](s *Store, key string) (T, bool) {
+	value, ok := s.Get(key)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	typed, ok := value.(T)
+	return typed, ok
+}
+
+// Set stores value under key and notifies the listeners of key.
+func (s *Store) Set(key string, value any) {
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	s.mu.Lock()
+	old := s.values[key]
+	s.values[key] = value
+	s.mu.Unlock()
+
+	s.notify(Change{Key: key, Old: old, New: value})
+}
+
+// Delete removes key and notifies its listeners. Deleting a key that is not
+// set does nothing.
+func (s *Store) Delete(key string) {
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	s.mu.Lock()
+	old, ok := s.values[key]
+	delete(s.values, key)
+	s.mu.Unlock()
+
+	if ok {
+		s.notify(Change{Key: key, Old: old, Deleted: true})
+	}
+}
+
+// Keys returns the keys currently set, in lexical order.
+func (s *Store) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// OnChange registers fn to be called after every change of key, or of any
+// key if key is empty. Listeners are called synchronously, in the order the
+// changes were made, from the goroutine making the change; they must not
+// block and must not modify the store. The returned function removes the
+// listener.
+//
+// Example:
+//
+//	stop := ctx.AppState.OnChange("feature.recommendations", func(c appstate.Change) {
+//	    enabled, _ := c.New.(bool)
+//	    recommender.SetEnabled(enabled)
+//	})
+//	defer stop()
+func (s *Store) OnChange(key string, fn func(Change)) func() {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+
+	id := s.nextID
+	s.nextID++
+	s.subs[id] = subscription{key: key, fn: fn}
+
+	return func() {
+		s.subsMu.Lock()
+		defer s.subsMu.Unlock()
+		delete(s.subs, id)
+	}
+}
+
+// notify calls the listeners matching the changed key in registration order.
+func (s *Store) notify(change Change) {
+	s.subsMu.Lock()
+	ids := make([]int, 0, len(s.subs))
+	for id, sub := range s.subs {
+		if sub.key == "" || sub.key == change.Key {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	listeners := make([]func(Change), 0, len(ids))
+	for _, id := range ids {
+		listeners = append(listeners, s.subs[id].fn)
+	}
+	s.subsMu.Unlock()
+
+	for _, fn := range listeners {
+		fn(change)
+	}
+}
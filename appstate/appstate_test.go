@@ -0,0 +1,85 @@
+package appstate
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreGetSet(t *testing.T) {
+	store := New()
+
+	_, ok := store.Get("draining")
+	assert.False(t, ok)
+
+	store.Set("draining", true)
+	store.Set("tenants", 3)
+
+	value, ok := store.Get("draining")
+	assert.True(t, ok)
+	assert.Equal(t, true, value)
+	assert.Equal(t, []string{"draining", "tenants"}, store.Keys())
+
+	tenants, ok := GetAs[int](store, "tenants")
+	assert.True(t, ok)
+	assert.Equal(t, 3, tenants)
+
+	_, ok = GetAs[string](store, "tenants")
+	assert.False(t, ok, "a value of a different type should not be returned")
+
+	store.Delete("tenants")
+	_, ok = store.Get("tenants")
+	assert.False(t, ok)
+}
+
+func TestStoreOnChange(t *testing.T) {
+	store := New()
+
+	var keyChanges, allChanges []Change
+	stop := store.OnChange("flag", func(c Change) { keyChanges = append(keyChanges, c) })
+	store.OnChange("", func(c Change) { allChanges = append(allChanges, c) })
+
+	store.Set("flag", true)
+	store.Set("other", 1)
+	store.Set("flag", false)
+	store.Delete("flag")
+	store.Delete("missing")
+
+	assert.Equal(t, []Change{
+		{Key: "flag", Old: nil, New: true},
+		{Key: "flag", Old: true, New: false},
+		{Key: "flag", Old: false, Deleted: true},
+	}, keyChanges)
+	assert.Len(t, allChanges, 4)
+
+	stop()
+	store.Set("flag", true)
+	assert.Len(t, keyChanges, 3, "removed listeners should not be called")
+	assert.Len(t, allChanges, 5)
+}
+
+func TestStoreConcurrentAccess(t *testing.T) {
+	store := New()
+	var mu sync.Mutex
+	changes := 0
+	store.OnChange("", func(Change) {
+		mu.Lock()
+		changes++
+		mu.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			store.Set("counter", i)
+			store.Get("counter")
+			store.Keys()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 50, changes)
+}
@@ -0,0 +1,34 @@
+package ezapp
+
+import (
+	"context"
+
+	"github.com/pgvanniekerk/ezapp/checkpoint"
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+)
+
+// WithCheckpointStore makes store available to checkpoint.Save and
+// checkpoint.Load through InitCtx.StartupCtx, the runner contexts, the
+// pre-shutdown hooks and the cleanup context, so that stateful runners can
+// persist their position during shutdown and resume from it at the next
+// start.
+//
+// Example:
+//
+//	ezapp.Run(initializer, ezapp.WithCheckpointStore(checkpoint.NewFileStore("/var/lib/orders/checkpoints")))
+func WithCheckpointStore(store checkpoint.Store) RunOption {
+	return func(settings *runopt.Settings) {
+		settings.CheckpointStore = store
+	}
+}
+
+// withCheckpointStore wraps fn so that its context carries store, if any.
+// It applies to runners and pre-shutdown hooks alike.
+func withCheckpointStore(store checkpoint.Store, fn func(ctx context.Context) error) func(ctx context.Context) error {
+	if store == nil {
+		return fn
+	}
+	return func(ctx context.Context) error {
+		return fn(checkpoint.WithStore(ctx, store))
+	}
+}
@@ -0,0 +1,96 @@
+// Package checkpoint persists small pieces of runner state, such as consumer
+// offsets or cursor positions, during shutdown so that the next run can
+// resume where the previous one left off. Values are stored in a pluggable
+// Store; ezapp makes the store configured with ezapp.WithCheckpointStore
+// available to Save and Load through the startup, runner and shutdown
+// contexts.
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned by Store.Load when no value is stored under a key.
+var ErrNotFound = errors.New("checkpoint not found")
+
+// ErrNoStore is returned by Save and Load when ctx carries no store.
+var ErrNoStore = errors.New("no checkpoint store configured")
+
+// Store persists checkpoint values by key. Implementations must be safe for
+// concurrent use.
+type Store interface {
+	// Load returns the value stored under key, or ErrNotFound.
+	Load(ctx context.Context, key string) ([]byte, error)
+
+	// Save stores value under key, replacing any previous value.
+	Save(ctx context.Context, key string, value []byte) error
+}
+
+// storeKey is the context key under which the store is carried.
+type storeKey struct{}
+
+// WithStore returns a copy of ctx carrying store for Save and Load.
+func WithStore(ctx context.Context, store Store) context.Context {
+	return context.WithValue(ctx, storeKey{}, store)
+}
+
+// StoreFromContext returns the store carried by ctx, or nil.
+func StoreFromContext(ctx context.Context) Store {
+	store, _ := ctx.Value(storeKey{}).(Store)
+	return store
+}
+
+// Save stores value, encoded as JSON, under key in the store carried by ctx.
+//
+// Example:
+//
+//	func (c *Consumer) Run(ctx context.Context) error {
+//	    ...
+//	    <-ctx.Done()
+//	    return checkpoint.Save(context.WithoutCancel(ctx), "orders-offset", c.offset)
+//	}
+func Save(ctx context.Context, key string, value any) error {
+	store := StoreFromContext(ctx)
+	if store == nil {
+		return ErrNoStore
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint %s: %w", key, err)
+	}
+	if err := store.Save(ctx, key, data); err != nil {
+		return fmt.Errorf("failed to save checkpoint %s: %w", key, err)
+	}
+	return nil
+}
+
+// Load decodes the value stored under key in the store carried by ctx into
+// value, and reports whether one was found. It returns false without error
+// if nothing has been saved under key yet.
+//
+// Example:
+//
+//	var offset int64
+//	if _, err := checkpoint.Load(ctx.StartupCtx, "orders-offset", &offset); err != nil {
+//	    return ezapp.AppCtx{}, err
+//	}
+func Load(ctx context.Context, key string, value any) (bool, error) {
+	store := StoreFromContext(ctx)
+	if store == nil {
+		return false, ErrNoStore
+	}
+	data, err := store.Load(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to load checkpoint %s: %w", key, err)
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return false, fmt.Errorf("failed to decode checkpoint %s: %w", key, err)
+	}
+	return true, nil
+}
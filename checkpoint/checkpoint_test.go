@@ -0,0 +1,56 @@
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type cursor struct {
+	Partition int   `json:"partition"`
+	Offset    int64 `json:"offset"`
+}
+
+func TestSaveLoad(t *testing.T) {
+	ctx := WithStore(context.Background(), NewMemoryStore())
+
+	var loaded cursor
+	found, err := Load(ctx, "orders", &loaded)
+	require.NoError(t, err)
+	assert.False(t, found, "nothing has been saved yet")
+
+	require.NoError(t, Save(ctx, "orders", cursor{Partition: 3, Offset: 42}))
+	found, err = Load(ctx, "orders", &loaded)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, cursor{Partition: 3, Offset: 42}, loaded)
+}
+
+func TestNoStore(t *testing.T) {
+	assert.ErrorIs(t, Save(context.Background(), "orders", 1), ErrNoStore)
+	_, err := Load(context.Background(), "orders", new(int))
+	assert.ErrorIs(t, err, ErrNoStore)
+	assert.Nil(t, StoreFromContext(context.Background()))
+}
+
+type failingStore struct{}
+
+func (failingStore) Load(context.Context, string) ([]byte, error) {
+	return []byte("not json"), nil
+}
+
+func (failingStore) Save(context.Context, string, []byte) error {
+	return errors.New("disk full")
+}
+
+func TestSaveLoadFailures(t *testing.T) {
+	ctx := WithStore(context.Background(), failingStore{})
+
+	assert.ErrorContains(t, Save(ctx, "orders", 1), "failed to save checkpoint orders: disk full")
+	assert.ErrorContains(t, Save(ctx, "orders", func() {}), "failed to encode checkpoint orders")
+	_, err := Load(ctx, "orders", new(int))
+	assert.ErrorContains(t, err, "failed to decode checkpoint orders")
+}
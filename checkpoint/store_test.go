@@ -0,0 +1,51 @@
+package checkpoint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "checkpoints")
+	store := NewFileStore(dir)
+	ctx := context.Background()
+
+	_, err := store.Load(ctx, "orders/eu")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, store.Save(ctx, "orders/eu", []byte("1")))
+	require.NoError(t, store.Save(ctx, "orders/eu", []byte("2")))
+	value, err := store.Load(ctx, "orders/eu")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("2"), value)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "keys are escaped into a single file and no temporary files remain")
+	assert.Equal(t, "orders%2Feu.json", entries[0].Name())
+
+	// A new store over the same directory sees the saved value
+	value, err = NewFileStore(dir).Load(ctx, "orders/eu")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("2"), value)
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	_, err := store.Load(ctx, "orders")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	value := []byte("1")
+	require.NoError(t, store.Save(ctx, "orders", value))
+	value[0] = '9'
+	loaded, err := store.Load(ctx, "orders")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1"), loaded, "the store should keep its own copy")
+}
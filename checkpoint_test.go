@@ -0,0 +1,50 @@
+package ezapp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pgvanniekerk/ezapp/checkpoint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunECheckpointStore(t *testing.T) {
+	store := checkpoint.NewMemoryStore()
+
+	run := func() int64 {
+		var resumed int64
+		err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+			if _, err := checkpoint.Load(ctx.StartupCtx, "offset", &resumed); err != nil {
+				return AppCtx{}, err
+			}
+			return Construct(
+				WithNamedRunner("consumer", func(ctx context.Context) error {
+					return checkpoint.Save(ctx, "offset", resumed+10)
+				}),
+				WithPreShutdownHook(func(ctx context.Context) error {
+					assert.NotNil(t, checkpoint.StoreFromContext(ctx))
+					return nil
+				}),
+				WithCleanup(func(ctx context.Context) error {
+					assert.NotNil(t, checkpoint.StoreFromContext(ctx))
+					return nil
+				}),
+			)
+		}, WithCheckpointStore(store))
+		require.NoError(t, err)
+		return resumed
+	}
+
+	assert.Equal(t, int64(0), run())
+	assert.Equal(t, int64(10), run(), "the second run should resume from the saved offset")
+}
+
+func TestRunEWithoutCheckpointStore(t *testing.T) {
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(WithRunners(func(ctx context.Context) error {
+			return checkpoint.Save(ctx, "offset", 1)
+		}))
+	})
+	assert.ErrorIs(t, err, checkpoint.ErrNoStore)
+}
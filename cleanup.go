@@ -0,0 +1,120 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/pgvanniekerk/ezapp/internal/trace"
+)
+
+// cleanupStep is a named cleanup function registered with WithCleanupStep.
+type cleanupStep struct {
+	name     string
+	fn       func(shutdownCtx context.Context) error
+	priority int
+}
+
+// WithCleanupStep is a functional option that registers a named cleanup step.
+// Cleanup steps run after the function set with WithCleanup, in reverse
+// registration order (like deferred calls), so that resources are released
+// in the opposite order to which they were acquired. Every step runs even if
+// an earlier one fails; each failure is reported as a CleanupError carrying
+// the step's name. Use WithCleanupStepPriority to order a step independently
+// of registration order.
+//
+// Example:
+//
+//	appCtx, err := Construct(
+//	    WithRunners(server.Run),
+//	    WithCleanupStep("database", func(ctx context.Context) error { return db.Close() }),
+//	    WithCleanupStep("cache", func(ctx context.Context) error { return cache.Close() }),
+//	)
+func WithCleanupStep(step string, fn func(shutdownCtx context.Context) error) option {
+	return func(appCtx *AppCtx) error {
+		appCtx.cleanupSteps = append(appCtx.cleanupSteps, cleanupStep{name: step, fn: fn})
+		return nil
+	}
+}
+
+// WithIndependentCleanup is a functional option that declares the named
+// cleanup steps independent of each other and of the steps around them.
+// Independent steps that would run one after another run concurrently
+// instead, and the next step waits until all of them have finished, cutting
+// shutdown time when many slow Close calls, such as connection drains or
+// multipart upload aborts, share the shutdown budget. The function set with
+// WithCleanup is named "cleanup". Construct fails if a name matches no
+// registered cleanup step.
+//
+// Example:
+//
+//	appCtx, err := Construct(
+//	    WithRunners(server.Run),
+//	    WithCleanupStep("database", closeDatabase),
+//	    WithCleanupStep("uploads", abortUploads),
+//	    WithCleanupStep("search", closeSearch),
+//	    // search and uploads close concurrently, then database
+//	    WithIndependentCleanup("uploads", "search"),
+//	)
+func WithIndependentCleanup(steps ...string) option {
+	return func(appCtx *AppCtx) error {
+		for _, step := range steps {
+			if step == "" {
+				return errors.New("independent cleanup requires a step name")
+			}
+			if appCtx.independentCleanups == nil {
+				appCtx.independentCleanups = make(map[string]bool)
+			}
+			appCtx.independentCleanups[step] = true
+		}
+		return nil
+	}
+}
+
+// cleanup runs the cleanup function and the cleanup steps, recording them in
+// the trace, and returns the joined CleanupError values of every failing step.
+// Consecutive independent steps run concurrently.
+func (appCtx *AppCtx) cleanup(shutdownCtx context.Context, lifecycle *trace.Buffer) error {
+	steps := appCtx.orderedCleanupSteps()
+	errs := make([]error, len(steps))
+
+	for start := 0; start < len(steps); {
+		end := start + 1
+		if appCtx.independentCleanups[steps[start].name] {
+			for end < len(steps) && appCtx.independentCleanups[steps[end].name] {
+				end++
+			}
+		}
+
+		if end-start == 1 {
+			errs[start] = steps[start].run(shutdownCtx, lifecycle)
+			start = end
+			continue
+		}
+
+		var wg sync.WaitGroup
+		for idx := start; idx < end; idx++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[idx] = steps[idx].run(shutdownCtx, lifecycle)
+			}()
+		}
+		wg.Wait()
+		start = end
+	}
+
+	return errors.Join(errs...)
+}
+
+// run runs the step, recording it in the trace, and returns a CleanupError if
+// it fails.
+func (step cleanupStep) run(shutdownCtx context.Context, lifecycle *trace.Buffer) error {
+	lifecycle.Record("cleanup step started", step.name, "")
+	if err := step.fn(shutdownCtx); err != nil {
+		lifecycle.Record("cleanup step finished", step.name, err.Error())
+		return &CleanupError{Step: step.name, Err: err}
+	}
+	lifecycle.Record("cleanup step finished", step.name, "")
+	return nil
+}
@@ -0,0 +1,81 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithIndependentCleanup(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+
+	// Both independent steps have to be running before either returns
+	var started sync.WaitGroup
+	started.Add(2)
+	independent := func(name string) func(context.Context) error {
+		return func(ctx context.Context) error {
+			started.Done()
+			started.Wait()
+			record(name)
+			return errors.New(name + " failed")
+		}
+	}
+	sequential := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			record(name)
+			return nil
+		}
+	}
+
+	appCtx, err := Construct(
+		WithCleanupStep("database", sequential("database")),
+		WithCleanupStep("uploads", independent("uploads")),
+		WithCleanupStep("search", independent("search")),
+		WithCleanupStep("listener", sequential("listener")),
+		WithIndependentCleanup("uploads", "search"),
+	)
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- appCtx.cleanup(context.Background(), nil)
+	}()
+
+	select {
+	case err = <-done:
+	case <-time.After(time.Second):
+		t.Fatal("independent cleanup steps should run concurrently")
+	}
+	assert.EqualError(t, err, "cleanup step search failed: search failed\ncleanup step uploads failed: uploads failed")
+	require.Len(t, events, 4)
+	assert.Equal(t, "listener", events[0])
+	assert.ElementsMatch(t, []string{"search", "uploads"}, events[1:3])
+	assert.Equal(t, "database", events[3], "the next step should wait for the independent ones")
+}
+
+func TestWithIndependentCleanupValidation(t *testing.T) {
+	_, err := Construct(WithIndependentCleanup(""))
+	assert.ErrorContains(t, err, "requires a step name")
+}
+
+func TestWithIndependentCleanupUnknownStep(t *testing.T) {
+	_, err := Construct(
+		WithCleanupStep("search", func(context.Context) error { return nil }),
+		WithIndependentCleanup("search", "serach"),
+	)
+	assert.EqualError(t, err, `independent cleanup step "serach" is not registered`)
+
+	_, err = Construct(WithIndependentCleanup("cleanup"))
+	assert.EqualError(t, err, `independent cleanup step "cleanup" is not registered`)
+}
@@ -0,0 +1,62 @@
+package ezapp
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/clock"
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+)
+
+// ErrClockSkew is returned by RunE when the clock check enabled with
+// WithClockCheck finds the local clock too far off.
+var ErrClockSkew = errors.New("local clock is skewed")
+
+// DefaultNTPServer is the NTP server queried by WithClockCheck when none is
+// given.
+const DefaultNTPServer = "pool.ntp.org"
+
+// clockFloor is a time the wall clock cannot plausibly be before; a clock
+// reading earlier was never set, as on some hosts without an RTC battery.
+var clockFloor = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// WithClockCheck checks the local clock at startup, before the initializer
+// runs, and fails with ErrClockSkew if it is more than maxSkew off the NTP
+// server (a host with an optional port, or DefaultNTPServer if empty) or is
+// before 2025. Services validating JWTs or other signed timestamps misbehave
+// in confusing ways on a skewed clock; this turns that into a clear startup
+// error. A server that cannot be reached only logs a warning, since it says
+// nothing about the clock. The query is bounded by the startup timeout.
+//
+// Example:
+//
+//	ezapp.Run(initializer, ezapp.WithClockCheck("time.google.com", 2*time.Second))
+func WithClockCheck(server string, maxSkew time.Duration) RunOption {
+	return func(settings *runopt.Settings) {
+		settings.ClockServer = cmp.Or(server, DefaultNTPServer)
+		settings.ClockMaxSkew = maxSkew
+	}
+}
+
+// checkClock compares the local clock with server and fails if it is more
+// than maxSkew off.
+func checkClock(ctx context.Context, logger *slog.Logger, server string, maxSkew time.Duration) error {
+	if now := time.Now(); now.Before(clockFloor) {
+		return fmt.Errorf("%w: wall clock reads %s", ErrClockSkew, now.UTC().Format(time.RFC3339))
+	}
+
+	offset, err := clock.Offset(ctx, server)
+	if err != nil {
+		logger.Warn("failed to check clock skew", "server", server, "error", err)
+		return nil
+	}
+	if offset.Abs() > maxSkew {
+		return fmt.Errorf("%w: %s off %s, more than the allowed %s", ErrClockSkew, offset.Abs(), server, maxSkew)
+	}
+	logger.Debug("clock skew within limit", "server", server, "offset", offset)
+	return nil
+}
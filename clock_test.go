@@ -0,0 +1,76 @@
+package ezapp
+
+import (
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSkewedNTP starts an SNTP server whose clock runs offset ahead of the
+// local one.
+func newSkewedNTP(t *testing.T, offset time.Duration) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		request := make([]byte, 48)
+		for {
+			_, addr, err := conn.ReadFrom(request)
+			if err != nil {
+				return
+			}
+			now := time.Now().Add(offset)
+			response := make([]byte, 48)
+			response[0], response[1] = 4<<3|4, 2
+			copy(response[24:32], request[40:48])
+			for _, at := range []int{32, 40} {
+				binary.BigEndian.PutUint32(response[at:], uint32(now.Unix()+2208988800))
+				binary.BigEndian.PutUint32(response[at+4:], uint32(uint64(now.Nanosecond())<<32/uint64(time.Second)))
+			}
+			_, _ = conn.WriteTo(response, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestCheckClock(t *testing.T) {
+	logger, handler := testutil.NewTestLogger(slog.LevelDebug)
+
+	assert.NoError(t, checkClock(context.Background(), logger, newSkewedNTP(t, 200*time.Millisecond), time.Second))
+
+	err := checkClock(context.Background(), logger, newSkewedNTP(t, -time.Minute), time.Second)
+	assert.ErrorIs(t, err, ErrClockSkew)
+
+	// An unreachable server is not evidence of skew
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, checkClock(ctx, logger, "127.0.0.1:1", time.Second))
+	assert.Contains(t, handler.Messages(), "failed to check clock skew")
+}
+
+func TestRunEClockCheck(t *testing.T) {
+	server := newSkewedNTP(t, time.Hour)
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		t.Fatal("initializer should not be invoked")
+		return AppCtx{}, nil
+	}, WithClockCheck(server, 5*time.Second))
+
+	assert.ErrorIs(t, err, ErrClockSkew)
+}
+
+func TestWithClockCheckDefaultServer(t *testing.T) {
+	settings := runopt.Settings{}
+	WithClockCheck("", time.Second)(&settings)
+
+	assert.Equal(t, DefaultNTPServer, settings.ClockServer)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"text/template"
+)
+
+// ezappModule is the module path of ezapp, required by generated projects.
+const ezappModule = "github.com/pgvanniekerk/ezapp"
+
+// goVersion is the go directive of generated go.mod files.
+const goVersion = "1.24"
+
+//go:embed templates
+var templates embed.FS
+
+// files maps the files of a generated project to their templates.
+var files = []struct {
+	name     string
+	template string
+}{
+	{name: "go.mod", template: "templates/go.mod.tmpl"},
+	{name: "main.go", template: "templates/main.go.tmpl"},
+	{name: "main_test.go", template: "templates/main_test.go.tmpl"},
+	{name: "Dockerfile", template: "templates/Dockerfile.tmpl"},
+	{name: ".dockerignore", template: "templates/dockerignore.tmpl"},
+	{name: "README.md", template: "templates/README.md.tmpl"},
+}
+
+// validName matches project names, which are used as directory and binary
+// names.
+var validName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// project holds the values the templates are rendered with.
+type project struct {
+	// Name is the name of the project, its directory and binary.
+	Name string
+
+	// Module is the module path of the project.
+	Module string
+
+	// GoVersion is the go directive of the project's go.mod.
+	GoVersion string
+
+	// EzappVersion is the version of ezapp to require, or empty if it is
+	// left to go mod tidy.
+	EzappVersion string
+}
+
+// newProject returns the project named name with the given module path,
+// which defaults to name.
+func newProject(name, module string) (project, error) {
+	if !validName.MatchString(name) {
+		return project{}, fmt.Errorf("invalid project name %q: use letters, digits, '-' and '_', starting with a letter", name)
+	}
+	if module == "" {
+		module = name
+	}
+	return project{
+		Name:         name,
+		Module:       module,
+		GoVersion:    goVersion,
+		EzappVersion: ezappVersion(),
+	}, nil
+}
+
+// ezappVersion returns the version of ezapp this command was built from, or
+// an empty string for development builds.
+func ezappVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Path != ezappModule || !strings.HasPrefix(info.Main.Version, "v") {
+		return ""
+	}
+	return info.Main.Version
+}
+
+// generate renders the project's files into dir, which must not exist or be
+// empty. Generated Go files are formatted with gofmt.
+func generate(dir string, p project) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("%s already exists and is not empty", dir)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	for _, file := range files {
+		content, err := render(file.template, p)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(file.name, ".go") {
+			if content, err = format.Source(content); err != nil {
+				return fmt.Errorf("failed to format %s: %w", file.name, err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(dir, file.name), content, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+	return nil
+}
+
+// render executes the template at path with p.
+func render(path string, p project) ([]byte, error) {
+	tmpl, err := template.ParseFS(templates, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", path, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, p); err != nil {
+		return nil, fmt.Errorf("failed to render template %s: %w", path, err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,88 @@
+// Command ezapp generates new ezapp projects.
+//
+// Usage:
+//
+//	ezapp new [-module path] [-dir directory] <name>
+//
+// The new command creates a project skeleton in directory (default ./<name>)
+// with a Config struct, an initializer serving HTTP with health checks and
+// graceful draining, tests, and a Dockerfile. The module path defaults to
+// name.
+//
+// Install it with:
+//
+//	go install github.com/pgvanniekerk/ezapp/cmd/ezapp@latest
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const usage = `usage: ezapp new [-module path] [-dir directory] <name>
+
+Commands:
+  new    generate a project skeleton
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command given by args and returns the exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	switch args[0] {
+	case "new":
+		return runNew(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "ezapp: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+}
+
+// runNew executes the new command.
+func runNew(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("new", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	module := flags.String("module", "", "module path of the project (default <name>)")
+	dir := flags.String("dir", "", "directory to generate the project in (default ./<name>)")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	p, err := newProject(flags.Arg(0), *module)
+	if err != nil {
+		fmt.Fprintf(stderr, "ezapp: %v\n", err)
+		return 1
+	}
+	if *dir == "" {
+		*dir = p.Name
+	}
+	if err := generate(*dir, p); err != nil {
+		fmt.Fprintf(stderr, "ezapp: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "Created %s in %s\n\nNext steps:\n  cd %s\n  go mod tidy\n  go test ./...\n  go run .\n",
+		p.Module, *dir, filepath.Clean(*dir))
+	return 0
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunNew(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "orders")
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"new", "-module", "example.com/orders", "-dir", dir, "orders"}, &stdout, &stderr)
+
+	require.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Created example.com/orders in "+dir)
+	for _, file := range files {
+		assert.FileExists(t, filepath.Join(dir, file.name))
+	}
+
+	goMod, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	require.NoError(t, err)
+	assert.Equal(t, "module example.com/orders\n\ngo "+goVersion+"\n", string(goMod))
+
+	for _, name := range []string{"main.go", "main_test.go"} {
+		_, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, name), nil, parser.AllErrors)
+		assert.NoError(t, err, "%s should be valid Go", name)
+	}
+	mainGo, err := os.ReadFile(filepath.Join(dir, "main.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(mainGo), `fmt.Fprintln(w, "Hello from orders!")`)
+
+	dockerfile, err := os.ReadFile(filepath.Join(dir, "Dockerfile"))
+	require.NoError(t, err)
+	assert.Contains(t, string(dockerfile), `ENTRYPOINT ["/orders"]`)
+}
+
+func TestRunNewExistingDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644))
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{"new", "-dir", dir, "orders"}, &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "already exists and is not empty")
+}
+
+func TestRunUsage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{name: "no command", args: nil, code: 2},
+		{name: "unknown command", args: []string{"build"}, code: 2},
+		{name: "missing name", args: []string{"new"}, code: 2},
+		{name: "invalid name", args: []string{"new", "../orders"}, code: 1},
+		{name: "help", args: []string{"help"}, code: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert.Equal(t, tt.code, run(tt.args, &stdout, &stderr))
+		})
+	}
+}
+
+func TestNewProject(t *testing.T) {
+	p, err := newProject("orders", "")
+	require.NoError(t, err)
+	assert.Equal(t, project{Name: "orders", Module: "orders", GoVersion: goVersion}, p,
+		"test binaries are development builds, so no ezapp version should be required")
+
+	_, err = newProject("1orders", "")
+	assert.ErrorContains(t, err, "invalid project name")
+}
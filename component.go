@@ -0,0 +1,115 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/pgvanniekerk/ezapp/health"
+)
+
+// component is a named dependency registered with WithComponent.
+type component struct {
+	name  string
+	value any
+
+	// check is the health check of a component registered by an option
+	// such as WithDatabase, for types that do not implement health.Healthy.
+	check health.CheckFunc
+}
+
+// WithComponent is a functional option that registers a dependency built by
+// the initializer, such as a database pool or a message broker client, so
+// the framework can wire its optional capabilities without extra code.
+//
+// If the component implements health.Healthy, its Healthy method is
+// registered with InitCtx.Health under name as a critical check once the
+// initializer returns, so a failing component flips readiness. Use
+// Health.Register directly for non-critical checks or custom timeouts.
+// Register pointer components as pointers: a value whose Healthy method has
+// a pointer receiver makes the run fail instead of skipping its check.
+// An empty name or a nil value is rejected by Construct.
+//
+// Example:
+//
+//	pool := NewPool(ctx.Config.DatabaseURL) // implements Healthy(ctx) error
+//	appCtx, err := Construct(
+//	    WithRunners(server.Run),
+//	    WithComponent("database", pool),
+//	)
+func WithComponent(name string, value any) option {
+	return func(appCtx *AppCtx) error {
+		if name == "" {
+			return errors.New("component name cannot be empty")
+		}
+		if value == nil {
+			return fmt.Errorf("component %q cannot be nil", name)
+		}
+		appCtx.components = append(appCtx.components, component{name: name, value: value})
+		return nil
+	}
+}
+
+// WithManagedComponent is a functional option that registers a component
+// like WithComponent together with the cleanup step that releases it, so
+// third-party types such as *sql.DB need no wrapper to take part in the
+// lifecycle. The cleanup step carries the component's name. If cleanup is
+// nil, the component must implement io.Closer and its Close method is used.
+//
+// Example:
+//
+//	db, err := sql.Open("pgx", ctx.Config.DatabaseURL)
+//	if err != nil {
+//	    return AppCtx{}, err
+//	}
+//	appCtx, err := Construct(
+//	    WithRunners(server.Run),
+//	    WithManagedComponent("database", db, nil), // closed with db.Close
+//	)
+func WithManagedComponent(name string, value any, cleanup func(shutdownCtx context.Context) error) option {
+	return func(appCtx *AppCtx) error {
+		if cleanup == nil {
+			closer, ok := value.(io.Closer)
+			if !ok {
+				return fmt.Errorf("component %q of type %T has no cleanup function and does not implement io.Closer", name, value)
+			}
+			cleanup = func(context.Context) error {
+				return closer.Close()
+			}
+		}
+		if err := WithComponent(name, value)(appCtx); err != nil {
+			return err
+		}
+		return WithCleanupStep(name, cleanup)(appCtx)
+	}
+}
+
+// ComponentInfo describes a component registered with WithComponent.
+type ComponentInfo struct {
+	// Name is the name the component was registered under.
+	Name string `json:"name"`
+
+	// Type is the Go type of the component, e.g. "*pgxpool.Pool".
+	Type string `json:"type"`
+
+	// Healthy reports whether the component implements health.Healthy and
+	// is therefore registered as a readiness check.
+	Healthy bool `json:"healthy"`
+}
+
+// Components describes the components registered on the AppCtx in
+// registration order.
+func (appCtx AppCtx) Components() []ComponentInfo {
+	components := make([]ComponentInfo, len(appCtx.components))
+	for idx, c := range appCtx.components {
+		_, healthy := c.value.(health.Healthy)
+		healthy = healthy || c.check != nil
+		components[idx] = ComponentInfo{
+			Name:    c.name,
+			Type:    fmt.Sprintf("%T", c.value),
+			Healthy: healthy,
+		}
+	}
+	return components
+}
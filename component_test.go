@@ -0,0 +1,104 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pgvanniekerk/ezapp/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPool struct {
+	err error
+}
+
+func (p *testPool) Healthy(ctx context.Context) error {
+	return p.err
+}
+
+func TestWithComponentRegistersHealthCheck(t *testing.T) {
+	pool := &testPool{}
+	var report health.Report
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithComponent("database", pool),
+			WithComponent("plain", struct{}{}),
+			WithRunners(func(context.Context) error {
+				pool.err = errors.New("pool closed")
+				report = ctx.Health.Check(context.Background())
+				return nil
+			}),
+		)
+	})
+	require.NoError(t, err)
+
+	require.Len(t, report.Checks, 1)
+	assert.Equal(t, "database", report.Checks[0].Name)
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.False(t, report.Ready())
+}
+
+func TestWithComponentDuplicateHealthCheck(t *testing.T) {
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		if err := ctx.Health.Register("database", func(context.Context) error { return nil }); err != nil {
+			return AppCtx{}, err
+		}
+		return Construct(WithComponent("database", &testPool{}), WithRunners(successfulRunner))
+	})
+
+	assert.ErrorContains(t, err, "failed to register component health check")
+}
+
+func TestWithComponentValueOfPointerComponent(t *testing.T) {
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(WithComponent("database", testPool{}), WithRunners(successfulRunner))
+	})
+
+	assert.ErrorContains(t, err, "register a *ezapp.testPool instead")
+}
+
+func TestWithComponentInvalid(t *testing.T) {
+	_, err := Construct(WithComponent("", &testPool{}))
+	assert.ErrorContains(t, err, "component name cannot be empty")
+
+	_, err = Construct(WithComponent("database", nil))
+	assert.ErrorContains(t, err, `component "database" cannot be nil`)
+}
+
+type closer struct {
+	closed bool
+}
+
+func (c *closer) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestWithManagedComponent(t *testing.T) {
+	db := &closer{}
+	var cacheReleased bool
+
+	appCtx, err := Construct(
+		WithManagedComponent("database", db, nil),
+		WithManagedComponent("cache", struct{}{}, createRecorderCleanup(&cacheReleased)),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"cache", "database"}, appCtx.CleanupSteps())
+	assert.Len(t, appCtx.Components(), 2)
+
+	require.NoError(t, appCtx.cleanup(context.Background(), nil))
+	assert.True(t, db.closed, "Close should be used when no cleanup is given")
+	assert.True(t, cacheReleased)
+}
+
+func TestWithManagedComponentWithoutCleanup(t *testing.T) {
+	_, err := Construct(WithManagedComponent("cache", struct{}{}, nil))
+	assert.ErrorContains(t, err, `component "cache" of type struct {} has no cleanup function and does not implement io.Closer`)
+
+	_, err = Construct(WithManagedComponent("", &closer{}, nil))
+	assert.ErrorContains(t, err, "component name cannot be empty")
+}
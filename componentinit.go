@@ -0,0 +1,75 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/retry"
+)
+
+// ErrComponentInit is returned by RunE when the init function of a component
+// registered with WithComponentInit still fails once its retries are
+// exhausted or the startup context is done.
+var ErrComponentInit = errors.New("component initialization failed")
+
+// componentInit is an init function registered with WithComponentInit.
+type componentInit struct {
+	name   string
+	policy retry.Policy
+	fn     func(ctx context.Context) error
+}
+
+// WithComponentInit is a functional option that registers the init function
+// of the component registered under name, such as connecting a pool or
+// pinging a database. After the initializer returns and before the preflight
+// checks, init runs under the startup context and is retried with policy, so
+// a dependency that is not yet accepting connections during a rolling
+// deploy delays startup instead of failing it immediately. Each retry is
+// logged at WARN unless policy sets its own OnRetry. Init functions run in
+// registration order; the first that still fails fails startup with
+// ErrComponentInit. Construct fails if no component is registered under name.
+//
+// Example:
+//
+//	appCtx, err := Construct(
+//	    WithRunners(server.Run),
+//	    WithDatabase("orders", db),
+//	    WithComponentInit("orders", retry.Default, db.PingContext),
+//	)
+func WithComponentInit(name string, policy retry.Policy, init func(ctx context.Context) error) option {
+	return func(appCtx *AppCtx) error {
+		if name == "" {
+			return errors.New("component init requires a component name")
+		}
+		if init == nil {
+			return fmt.Errorf("component %q init function cannot be nil", name)
+		}
+		appCtx.componentInits = append(appCtx.componentInits, componentInit{name: name, policy: policy, fn: init})
+		return nil
+	}
+}
+
+// runComponentInits runs the init functions of appCtx in registration order,
+// retrying each with its policy, and returns an error for the first that
+// still fails.
+func runComponentInits(ctx context.Context, logger *slog.Logger, appCtx AppCtx) error {
+	for _, c := range appCtx.componentInits {
+		policy := c.policy
+		if policy.OnRetry == nil {
+			policy.OnRetry = func(attempt int, err error, delay time.Duration) {
+				logger.Warn("component init failed, retrying", "component", c.name, "attempt", attempt, "delay", delay, "error", err)
+			}
+		}
+
+		started := time.Now()
+		if err := retry.Do(ctx, policy, c.fn); err != nil {
+			logger.Error("component init failed", "component", c.name, "error", err)
+			return fmt.Errorf("%w: %s: %w", ErrComponentInit, c.name, err)
+		}
+		logger.Debug("component initialized", "component", c.name, "duration", time.Since(started))
+	}
+	return nil
+}
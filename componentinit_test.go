@@ -0,0 +1,89 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/pgvanniekerk/ezapp/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunComponentInits(t *testing.T) {
+	logger, handler := testutil.NewTestLogger(slog.LevelDebug)
+	attempts := 0
+	appCtx, err := Construct(
+		WithComponent("database", struct{}{}),
+		WithComponentInit("database", retry.Policy{MaxAttempts: 3}, func(context.Context) error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("connection refused")
+			}
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, runComponentInits(context.Background(), logger, appCtx))
+	assert.Equal(t, 3, attempts)
+	component, ok := handler.Attr("component init failed, retrying", "component")
+	require.True(t, ok)
+	assert.Equal(t, "database", component.String())
+	assert.Contains(t, handler.Messages(), "component initialized")
+}
+
+func TestRunComponentInitsStartupDeadline(t *testing.T) {
+	logger, _ := testutil.NewTestLogger(slog.LevelDebug)
+	appCtx, err := Construct(
+		WithComponent("database", struct{}{}),
+		WithComponentInit("database", retry.Policy{InitialBackoff: time.Millisecond}, func(context.Context) error {
+			return errors.New("connection refused")
+		}),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = runComponentInits(ctx, logger, appCtx)
+	assert.ErrorIs(t, err, ErrComponentInit)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "retries should stop at the startup deadline")
+	assert.ErrorContains(t, err, "database: ")
+}
+
+func TestRunEComponentInitFailure(t *testing.T) {
+	var started bool
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithRunners(func(context.Context) error {
+				started = true
+				return nil
+			}),
+			WithComponent("broker", struct{}{}),
+			WithComponentInit("broker", retry.Policy{MaxAttempts: 1}, func(context.Context) error {
+				return errors.New("connection refused")
+			}),
+		)
+	})
+
+	assert.ErrorIs(t, err, ErrComponentInit)
+	assert.ErrorContains(t, err, "broker: gave up after 1 attempts: connection refused")
+	assert.False(t, started, "runners should not start after a failed component init")
+}
+
+func TestWithComponentInitValidation(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+
+	_, err := Construct(WithComponentInit("", retry.Default, noop))
+	assert.ErrorContains(t, err, "requires a component name")
+
+	_, err = Construct(WithComponent("database", struct{}{}), WithComponentInit("database", retry.Default, nil))
+	assert.ErrorContains(t, err, "cannot be nil")
+
+	_, err = Construct(WithComponentInit("database", retry.Default, noop))
+	assert.EqualError(t, err, `component "database" of the init function is not registered`)
+}
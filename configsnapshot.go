@@ -0,0 +1,42 @@
+package ezapp
+
+import (
+	"log/slog"
+
+	"github.com/pgvanniekerk/ezapp/internal/config"
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+)
+
+// WithConfigSnapshot persists the resolved configuration to the file at path
+// and, at the next start, logs each variable whose value changed since the
+// previous run, e.g. "configuration changed" variable=LOG_LEVEL from=info
+// to=debug, so that behaviour changes can be correlated with configuration
+// changes. Values of fields tagged `secret:"true"` are neither stored nor
+// logged; a digest reveals only that they changed. Failures to read or write
+// the snapshot are logged and do not stop the application.
+//
+// Example:
+//
+//	ezapp.Run(initializer, ezapp.WithConfigSnapshot("/var/lib/orders/config.json"))
+func WithConfigSnapshot(path string) RunOption {
+	return func(settings *runopt.Settings) {
+		settings.ConfigSnapshot = path
+	}
+}
+
+// logConfigChanges logs the differences between the snapshot stored at path
+// and current, then stores current in its place.
+func logConfigChanges(logger *slog.Logger, path string, current config.Snapshot) {
+	previous, err := config.LoadSnapshot(path)
+	if err != nil {
+		logger.Warn("failed to load previous configuration snapshot", "path", path, "error", err)
+	} else if previous.Hash != "" && previous.Hash != current.Hash {
+		for _, change := range current.Diff(previous) {
+			logger.Info("configuration changed", "variable", change.Variable, "from", change.From, "to", change.To)
+		}
+	}
+
+	if err := current.Save(path); err != nil {
+		logger.Warn("failed to save configuration snapshot", "path", path, "error", err)
+	}
+}
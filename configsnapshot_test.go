@@ -0,0 +1,34 @@
+package ezapp
+
+import (
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/pgvanniekerk/ezapp/internal/config"
+	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogConfigChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	logger, handler := testutil.NewTestLogger(slog.LevelDebug)
+
+	// The first run has nothing to compare with
+	first := config.Snapshot{Hash: "1", Values: map[string]string{"LOG_LEVEL": "info"}}
+	logConfigChanges(logger, path, first)
+	assert.Empty(t, handler.Messages())
+
+	second := config.Snapshot{Hash: "2", Values: map[string]string{"LOG_LEVEL": "debug"}}
+	logConfigChanges(logger, path, second)
+	require.Equal(t, []string{"configuration changed"}, handler.Messages())
+	from, _ := handler.Attr("configuration changed", "from")
+	to, _ := handler.Attr("configuration changed", "to")
+	assert.Equal(t, "info", from.String())
+	assert.Equal(t, "debug", to.String())
+
+	stored, err := config.LoadSnapshot(path)
+	require.NoError(t, err)
+	assert.Equal(t, second, stored)
+}
@@ -0,0 +1,74 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/coordination"
+)
+
+// WithShutdownCoordination is a functional option that makes the instance
+// hold a slot of a cluster-wide semaphore while it shuts down, so that only
+// as many replicas of a fleet as the semaphore allows restart at a time, even
+// outside Kubernetes. The slot is acquired before any other pre-shutdown hook
+// runs and released once cleanup has finished.
+//
+// Waiting for a slot counts towards EZAPP_SHUTDOWN_TIMEOUT. If no slot is
+// acquired in time, or the semaphore fails, the error is logged and the
+// instance shuts down regardless, since a shutdown cannot be refused.
+//
+// Example:
+//
+//	slotCfg, err := coordination.LoadConsulConfig()
+//	if err != nil {
+//	    return AppCtx{}, err
+//	}
+//	appCtx, err := Construct(
+//	    WithRunners(server.Run),
+//	    WithShutdownCoordination(coordination.NewConsul(slotCfg)),
+//	)
+func WithShutdownCoordination(semaphore coordination.Semaphore) option {
+	return func(appCtx *AppCtx) error {
+		if semaphore == nil {
+			return errors.New("shutdown coordination semaphore must not be nil")
+		}
+		appCtx.shutdownSlot = semaphore
+		return nil
+	}
+}
+
+// shutdownSlot returns a pre-shutdown hook acquiring a slot of semaphore and
+// a function releasing it again, bounded by timeout, if it was acquired.
+func shutdownSlot(logger *slog.Logger, semaphore coordination.Semaphore) (func(ctx context.Context) error, func(timeout time.Duration)) {
+	var acquired atomic.Bool
+
+	acquire := func(ctx context.Context) error {
+		logger.Info("waiting for shutdown slot")
+		startedAt := time.Now()
+		if err := semaphore.Acquire(ctx); err != nil {
+			logger.Error("failed to acquire shutdown slot, shutting down regardless", "error", err)
+			return nil
+		}
+		acquired.Store(true)
+		logger.Info("acquired shutdown slot", "waited", time.Since(startedAt))
+		return nil
+	}
+
+	release := func(timeout time.Duration) {
+		if !acquired.Load() {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := semaphore.Release(ctx); err != nil {
+			logger.Error("failed to release shutdown slot", "error", err)
+			return
+		}
+		logger.Info("released shutdown slot")
+	}
+
+	return acquire, release
+}
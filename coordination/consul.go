@@ -0,0 +1,361 @@
+package coordination
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/config"
+)
+
+// ConsulConfig configures a semaphore held in the Consul KV store. It is
+// typically loaded from the environment with LoadConsulConfig.
+type ConsulConfig struct {
+
+	// Address is the URL of the Consul agent's HTTP API.
+	Address string `env:"CONSUL_HTTP_ADDR,default=http://127.0.0.1:8500"`
+
+	// Token is the ACL token sent with every request, if set.
+	Token string `env:"CONSUL_HTTP_TOKEN" resolve:"true"`
+
+	// Prefix is the KV prefix under which the semaphore is kept, shared by
+	// every instance of the fleet, e.g. "service/orders/shutdown".
+	Prefix string `env:"EZAPP_SHUTDOWN_SLOT_PREFIX,required=true"`
+
+	// Limit is the number of instances that may hold a slot at once.
+	Limit int `env:"EZAPP_SHUTDOWN_SLOTS,default=1"`
+
+	// SessionTTL bounds how long a slot outlives an instance that died
+	// without releasing it.
+	SessionTTL time.Duration `env:"EZAPP_SHUTDOWN_SLOT_TTL,default=30s"`
+}
+
+// LoadConsulConfig loads a ConsulConfig from the environment:
+//
+//   - CONSUL_HTTP_ADDR: Consul agent URL (default: http://127.0.0.1:8500)
+//   - CONSUL_HTTP_TOKEN: ACL token
+//   - EZAPP_SHUTDOWN_SLOT_PREFIX: KV prefix of the semaphore (required)
+//   - EZAPP_SHUTDOWN_SLOTS: instances that may shut down at once (default: 1)
+//   - EZAPP_SHUTDOWN_SLOT_TTL: session TTL (default: 30s)
+func LoadConsulConfig() (ConsulConfig, error) {
+	cfg, err := config.LoadVar[ConsulConfig](config.WithPrefix("CONSUL_", "EZAPP_SHUTDOWN_SLOT"))
+	if err != nil {
+		return ConsulConfig{}, fmt.Errorf("failed to load consul semaphore configuration from environment: %w", err)
+	}
+	if cfg.Limit < 1 {
+		return ConsulConfig{}, fmt.Errorf("EZAPP_SHUTDOWN_SLOTS must be at least 1, got %d", cfg.Limit)
+	}
+	return cfg, nil
+}
+
+// Consul is a Semaphore following Consul's semaphore recipe: each instance
+// creates a session and a contender key under the prefix, and takes a slot
+// by adding its session to the holders recorded in the "<prefix>/.lock" key
+// with a check-and-set write. Holders whose session has expired are pruned,
+// so a crashed instance frees its slot once its session TTL has passed.
+type Consul struct {
+	cfg    ConsulConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	session   string
+	stopRenew context.CancelFunc
+	renewDone chan struct{}
+}
+
+// NewConsul creates a Consul semaphore from the given configuration.
+func NewConsul(cfg ConsulConfig) *Consul {
+	return &Consul{cfg: cfg, client: &http.Client{}}
+}
+
+// consulLock is the value of the lock key.
+type consulLock struct {
+	Limit   int             `json:"Limit"`
+	Holders map[string]bool `json:"Holders"`
+}
+
+// consulEntry is a key returned by the KV API.
+type consulEntry struct {
+	Key         string `json:"Key"`
+	Value       []byte `json:"Value"`
+	ModifyIndex uint64 `json:"ModifyIndex"`
+	Session     string `json:"Session"`
+}
+
+// lockKey is the key recording the holders of the semaphore.
+func (c *Consul) lockKey() string {
+	return strings.TrimSuffix(c.cfg.Prefix, "/") + "/.lock"
+}
+
+// contenderKey is the key held by session while it contends for a slot.
+func (c *Consul) contenderKey(session string) string {
+	return strings.TrimSuffix(c.cfg.Prefix, "/") + "/" + session
+}
+
+// Acquire creates a session, registers it as a contender and waits for a
+// free slot, watching the prefix with blocking queries.
+func (c *Consul) Acquire(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.session != "" {
+		return errors.New("semaphore slot already acquired")
+	}
+
+	session, err := c.createSession(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create consul session: %w", err)
+	}
+	c.startRenewing(session)
+
+	if err := c.acquire(ctx, session); err != nil {
+		c.destroySession(context.WithoutCancel(ctx), session)
+		return err
+	}
+	c.session = session
+	return nil
+}
+
+// acquire takes a slot for session.
+func (c *Consul) acquire(ctx context.Context, session string) error {
+	held, err := c.putKV(ctx, c.contenderKey(session), url.Values{"acquire": {session}}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to register semaphore contender: %w", err)
+	}
+	if !held {
+		return errors.New("failed to register semaphore contender: key held by another session")
+	}
+
+	var index uint64
+	for {
+		entries, next, err := c.list(ctx, index)
+		if err != nil {
+			return fmt.Errorf("failed to read semaphore: %w", err)
+		}
+
+		lock, lockIndex := c.liveLock(entries)
+		if len(lock.Holders) < c.cfg.Limit {
+			lock.Holders[session] = true
+			taken, err := c.writeLock(ctx, lock, lockIndex)
+			if err != nil {
+				return fmt.Errorf("failed to take semaphore slot: %w", err)
+			}
+			if taken {
+				return nil
+			}
+			// Another instance changed the lock first; read it again
+			index = 0
+			continue
+		}
+		index = next
+	}
+}
+
+// Release removes the instance from the holders and destroys its session,
+// which also deletes its contender key.
+func (c *Consul) Release(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.session == "" {
+		return nil
+	}
+	session := c.session
+
+	var err error
+	for {
+		var entries []consulEntry
+		entries, _, err = c.list(ctx, 0)
+		if err != nil {
+			break
+		}
+		lock, lockIndex := c.liveLock(entries)
+		if !lock.Holders[session] {
+			break
+		}
+		delete(lock.Holders, session)
+		var released bool
+		released, err = c.writeLock(ctx, lock, lockIndex)
+		if err != nil || released {
+			break
+		}
+	}
+
+	c.destroySession(ctx, session)
+	c.session = ""
+	if err != nil {
+		return fmt.Errorf("failed to release semaphore slot: %w", err)
+	}
+	return nil
+}
+
+// liveLock returns the lock recorded in entries, with holders whose
+// contender key is no longer held by a session pruned, and its modify index.
+func (c *Consul) liveLock(entries []consulEntry) (consulLock, uint64) {
+	lock := consulLock{Limit: c.cfg.Limit, Holders: make(map[string]bool)}
+	var lockIndex uint64
+	live := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Key == c.lockKey() {
+			lockIndex = entry.ModifyIndex
+			_ = json.Unmarshal(entry.Value, &lock)
+			continue
+		}
+		if entry.Session != "" {
+			live[entry.Session] = true
+		}
+	}
+
+	holders := make(map[string]bool, len(lock.Holders))
+	for holder := range lock.Holders {
+		if live[holder] {
+			holders[holder] = true
+		}
+	}
+	lock.Holders = holders
+	lock.Limit = c.cfg.Limit
+	return lock, lockIndex
+}
+
+// writeLock replaces the lock if it has not changed since index.
+func (c *Consul) writeLock(ctx context.Context, lock consulLock, index uint64) (bool, error) {
+	body, err := json.Marshal(lock)
+	if err != nil {
+		return false, err
+	}
+	return c.putKV(ctx, c.lockKey(), url.Values{"cas": {fmt.Sprint(index)}}, body)
+}
+
+// startRenewing keeps session alive until Release.
+func (c *Consul) startRenewing(session string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	c.stopRenew, c.renewDone = cancel, done
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(c.cfg.SessionTTL / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_, _ = c.do(ctx, http.MethodPut, "/v1/session/renew/"+session, nil, nil)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// createSession creates a session that deletes the keys it holds when it is
+// destroyed or expires.
+func (c *Consul) createSession(ctx context.Context) (string, error) {
+	body, err := json.Marshal(map[string]string{
+		"Name":      "ezapp-shutdown-slot",
+		"TTL":       c.cfg.SessionTTL.String(),
+		"Behavior":  "delete",
+		"LockDelay": "0s",
+	})
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.do(ctx, http.MethodPut, "/v1/session/create", nil, body)
+	if err != nil {
+		return "", err
+	}
+	var created struct {
+		ID string `json:"ID"`
+	}
+	if err := json.Unmarshal(resp.body, &created); err != nil {
+		return "", fmt.Errorf("invalid session response: %w", err)
+	}
+	return created.ID, nil
+}
+
+// destroySession stops renewing session and destroys it. Failures are
+// ignored, since the session expires after its TTL regardless.
+func (c *Consul) destroySession(ctx context.Context, session string) {
+	c.stopRenew()
+	<-c.renewDone
+	_, _ = c.do(ctx, http.MethodPut, "/v1/session/destroy/"+session, nil, nil)
+}
+
+// list returns the keys under the prefix. A non-zero index makes it a
+// blocking query that returns once the keys change, with the new index.
+func (c *Consul) list(ctx context.Context, index uint64) ([]consulEntry, uint64, error) {
+	query := url.Values{"recurse": {"true"}}
+	if index > 0 {
+		query.Set("index", fmt.Sprint(index))
+		query.Set("wait", "30s")
+	}
+	resp, err := c.do(ctx, http.MethodGet, "/v1/kv/"+strings.TrimSuffix(c.cfg.Prefix, "/")+"/", query, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var entries []consulEntry
+	if resp.status == http.StatusOK {
+		if err := json.Unmarshal(resp.body, &entries); err != nil {
+			return nil, 0, fmt.Errorf("invalid kv response: %w", err)
+		}
+	}
+	return entries, resp.index, nil
+}
+
+// putKV writes key and reports whether the write took effect, which
+// depends on the acquire or cas condition in query.
+func (c *Consul) putKV(ctx context.Context, key string, query url.Values, body []byte) (bool, error) {
+	resp, err := c.do(ctx, http.MethodPut, "/v1/kv/"+key, query, body)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(resp.body)) == "true", nil
+}
+
+// consulResponse is the relevant part of an HTTP API response.
+type consulResponse struct {
+	status int
+	index  uint64
+	body   []byte
+}
+
+// do sends a request to the HTTP API. Responses other than 200 OK, and 404
+// Not Found for reads, are errors.
+func (c *Consul) do(ctx context.Context, method, path string, query url.Values, body []byte) (consulResponse, error) {
+	endpoint := strings.TrimSuffix(c.cfg.Address, "/") + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return consulResponse{}, err
+	}
+	if c.cfg.Token != "" {
+		req.Header.Set("X-Consul-Token", c.cfg.Token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return consulResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return consulResponse{}, err
+	}
+	notFound := resp.StatusCode == http.StatusNotFound && method == http.MethodGet
+	if resp.StatusCode != http.StatusOK && !notFound {
+		return consulResponse{}, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	var index uint64
+	fmt.Sscan(resp.Header.Get("X-Consul-Index"), &index)
+	return consulResponse{status: resp.StatusCode, index: index, body: data}, nil
+}
@@ -0,0 +1,277 @@
+package coordination
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKV is an in-memory Consul agent implementing the session and KV
+// endpoints used by the semaphore.
+type fakeKV struct {
+	mu       sync.Mutex
+	index    uint64
+	changed  chan struct{}
+	sessions map[string]bool
+	entries  map[string]consulEntry
+}
+
+// newFakeKV starts an HTTP server backed by a fakeKV.
+func newFakeKV(t *testing.T) (*httptest.Server, *fakeKV) {
+	kv := &fakeKV{changed: make(chan struct{}), sessions: make(map[string]bool), entries: make(map[string]consulEntry)}
+	server := httptest.NewServer(http.HandlerFunc(kv.serve))
+	t.Cleanup(server.Close)
+	return server, kv
+}
+
+// bump records a change and wakes blocking queries. It must be called with
+// the lock held.
+func (kv *fakeKV) bump() uint64 {
+	kv.index++
+	close(kv.changed)
+	kv.changed = make(chan struct{})
+	return kv.index
+}
+
+// destroy destroys a session, deleting the keys it holds.
+func (kv *fakeKV) destroy(session string) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	delete(kv.sessions, session)
+	for key, entry := range kv.entries {
+		if entry.Session == session {
+			delete(kv.entries, key)
+		}
+	}
+	kv.bump()
+}
+
+// holders returns the sessions recorded in the lock key.
+func (kv *fakeKV) holders(key string) []string {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	var lock consulLock
+	_ = json.Unmarshal(kv.entries[key].Value, &lock)
+	var holders []string
+	for holder := range lock.Holders {
+		holders = append(holders, holder)
+	}
+	return holders
+}
+
+func (kv *fakeKV) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	path := r.URL.Path
+	query := r.URL.Query()
+
+	switch {
+	case path == "/v1/session/create":
+		kv.mu.Lock()
+		id := fmt.Sprintf("session-%d", kv.bump())
+		kv.sessions[id] = true
+		kv.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]string{"ID": id})
+
+	case strings.HasPrefix(path, "/v1/session/renew/"):
+		kv.mu.Lock()
+		live := kv.sessions[strings.TrimPrefix(path, "/v1/session/renew/")]
+		kv.mu.Unlock()
+		if !live {
+			w.WriteHeader(http.StatusNotFound)
+		}
+
+	case strings.HasPrefix(path, "/v1/session/destroy/"):
+		kv.destroy(strings.TrimPrefix(path, "/v1/session/destroy/"))
+		_, _ = w.Write([]byte("true"))
+
+	case strings.HasPrefix(path, "/v1/kv/") && r.Method == http.MethodGet:
+		kv.list(w, strings.TrimPrefix(path, "/v1/kv/"), query.Get("index"))
+
+	case strings.HasPrefix(path, "/v1/kv/") && r.Method == http.MethodPut:
+		key := strings.TrimPrefix(path, "/v1/kv/")
+		kv.mu.Lock()
+		defer kv.mu.Unlock()
+		entry, exists := kv.entries[key]
+		if session := query.Get("acquire"); session != "" {
+			if !kv.sessions[session] || (entry.Session != "" && entry.Session != session) {
+				_, _ = w.Write([]byte("false"))
+				return
+			}
+			entry.Session = session
+		}
+		if cas := query.Get("cas"); cas != "" {
+			index, _ := strconv.ParseUint(cas, 10, 64)
+			if (index == 0 && exists) || (index != 0 && entry.ModifyIndex != index) {
+				_, _ = w.Write([]byte("false"))
+				return
+			}
+		}
+		entry.Key, entry.Value = key, body
+		entry.ModifyIndex = kv.bump()
+		kv.entries[key] = entry
+		_, _ = w.Write([]byte("true"))
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// list serves a recursive KV read, blocking while index is current.
+func (kv *fakeKV) list(w http.ResponseWriter, prefix, index string) {
+	kv.mu.Lock()
+	if wait, _ := strconv.ParseUint(index, 10, 64); wait >= kv.index {
+		changed := kv.changed
+		kv.mu.Unlock()
+		select {
+		case <-changed:
+		case <-time.After(time.Second):
+		}
+		kv.mu.Lock()
+	}
+	defer kv.mu.Unlock()
+
+	var entries []consulEntry
+	for key, entry := range kv.entries {
+		if strings.HasPrefix(key, prefix) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(kv.index, 10))
+	if len(entries) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(entries)
+}
+
+func TestLoadConsulConfig(t *testing.T) {
+	t.Run("from environment", func(t *testing.T) {
+		t.Setenv("EZAPP_SHUTDOWN_SLOT_PREFIX", "service/orders/shutdown")
+		t.Setenv("EZAPP_SHUTDOWN_SLOTS", "2")
+
+		cfg, err := LoadConsulConfig()
+
+		require.NoError(t, err)
+		assert.Equal(t, "http://127.0.0.1:8500", cfg.Address)
+		assert.Equal(t, "service/orders/shutdown", cfg.Prefix)
+		assert.Equal(t, 2, cfg.Limit)
+		assert.Equal(t, 30*time.Second, cfg.SessionTTL)
+	})
+
+	t.Run("prefix required", func(t *testing.T) {
+		os.Unsetenv("EZAPP_SHUTDOWN_SLOT_PREFIX")
+
+		_, err := LoadConsulConfig()
+
+		assert.ErrorContains(t, err, "EZAPP_SHUTDOWN_SLOT_PREFIX")
+	})
+
+	t.Run("limit must be positive", func(t *testing.T) {
+		t.Setenv("EZAPP_SHUTDOWN_SLOT_PREFIX", "service/orders/shutdown")
+		t.Setenv("EZAPP_SHUTDOWN_SLOTS", "0")
+
+		_, err := LoadConsulConfig()
+
+		assert.ErrorContains(t, err, "EZAPP_SHUTDOWN_SLOTS")
+	})
+}
+
+// newTestSemaphore returns a semaphore for the fake agent at address.
+func newTestSemaphore(address string, limit int) *Consul {
+	return NewConsul(ConsulConfig{Address: address, Prefix: "service/orders/shutdown", Limit: limit, SessionTTL: time.Minute})
+}
+
+// acquireAsync acquires s in the background, reporting the result on the
+// returned channel.
+func acquireAsync(s Semaphore) <-chan error {
+	result := make(chan error, 1)
+	go func() { result <- s.Acquire(context.Background()) }()
+	return result
+}
+
+func TestConsulLimitsHolders(t *testing.T) {
+	server, kv := newFakeKV(t)
+	first := newTestSemaphore(server.URL, 1)
+	second := newTestSemaphore(server.URL, 1)
+
+	require.NoError(t, first.Acquire(context.Background()))
+	assert.Equal(t, []string{first.session}, kv.holders("service/orders/shutdown/.lock"))
+
+	acquired := acquireAsync(second)
+	select {
+	case err := <-acquired:
+		t.Fatalf("second instance acquired a slot while the first held it: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	require.NoError(t, first.Release(context.Background()))
+	select {
+	case err := <-acquired:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("second instance did not acquire the released slot")
+	}
+	assert.Equal(t, []string{second.session}, kv.holders("service/orders/shutdown/.lock"))
+
+	require.NoError(t, second.Release(context.Background()))
+	assert.Empty(t, kv.holders("service/orders/shutdown/.lock"))
+}
+
+func TestConsulAllowsLimitHolders(t *testing.T) {
+	server, kv := newFakeKV(t)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, newTestSemaphore(server.URL, 2).Acquire(context.Background()))
+	}
+
+	assert.Len(t, kv.holders("service/orders/shutdown/.lock"), 2)
+}
+
+func TestConsulPrunesExpiredHolders(t *testing.T) {
+	server, kv := newFakeKV(t)
+	crashed := newTestSemaphore(server.URL, 1)
+	require.NoError(t, crashed.Acquire(context.Background()))
+
+	acquired := acquireAsync(newTestSemaphore(server.URL, 1))
+	kv.destroy(crashed.session)
+
+	select {
+	case err := <-acquired:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("slot of an expired session was not freed")
+	}
+}
+
+func TestConsulAcquireCancelled(t *testing.T) {
+	server, kv := newFakeKV(t)
+	require.NoError(t, newTestSemaphore(server.URL, 1).Acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := newTestSemaphore(server.URL, 1).Acquire(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	assert.Len(t, kv.sessions, 1, "session of the cancelled contender should be destroyed")
+}
+
+func TestConsulReleaseWithoutAcquire(t *testing.T) {
+	assert.NoError(t, newTestSemaphore("http://127.0.0.1:0", 1).Release(context.Background()))
+}
@@ -0,0 +1,17 @@
+// Package coordination limits how many instances of a fleet shut down at
+// the same time, so that rolling restarts outside Kubernetes do not take
+// down more replicas than the fleet can spare.
+package coordination
+
+import "context"
+
+// Semaphore is a cluster-wide semaphore whose slots are held by instances
+// while they shut down.
+type Semaphore interface {
+
+	// Acquire blocks until the instance holds a slot or ctx is done.
+	Acquire(ctx context.Context) error
+
+	// Release gives up the slot held by the instance.
+	Release(ctx context.Context) error
+}
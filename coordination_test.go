@@ -0,0 +1,78 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSemaphore records acquire and release calls in events.
+type recordingSemaphore struct {
+	mu         sync.Mutex
+	events     *[]string
+	acquireErr error
+}
+
+func (s *recordingSemaphore) record(event string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*s.events = append(*s.events, event)
+}
+
+func (s *recordingSemaphore) Acquire(ctx context.Context) error {
+	s.record("acquire")
+	return s.acquireErr
+}
+
+func (s *recordingSemaphore) Release(ctx context.Context) error {
+	s.record("release")
+	return nil
+}
+
+func TestRunEShutdownCoordination(t *testing.T) {
+	var events []string
+	semaphore := &recordingSemaphore{events: &events}
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithRunners(failingRunner),
+			WithPreShutdownHook(func(context.Context) error {
+				semaphore.record("pre-shutdown")
+				return nil
+			}),
+			WithCleanup(func(context.Context) error {
+				semaphore.record("cleanup")
+				return nil
+			}),
+			WithShutdownCoordination(semaphore),
+		)
+	})
+
+	assert.ErrorContains(t, err, "runner failed")
+	assert.Equal(t, []string{"acquire", "pre-shutdown", "cleanup", "release"}, events,
+		"the slot should be held from before the pre-shutdown hooks until after cleanup")
+}
+
+func TestShutdownSlotAcquireFailure(t *testing.T) {
+	logger, handler := testutil.NewTestLogger(slog.LevelDebug)
+	var events []string
+	acquire, release := shutdownSlot(logger, &recordingSemaphore{events: &events, acquireErr: errors.New("consul unavailable")})
+
+	assert.NoError(t, acquire(context.Background()), "a failed acquire should not fail the shutdown")
+	release(time.Second)
+
+	assert.Equal(t, []string{"acquire"}, events, "a slot that was not acquired should not be released")
+	assert.Contains(t, handler.Messages(), "failed to acquire shutdown slot, shutting down regardless")
+}
+
+func TestWithShutdownCoordinationNil(t *testing.T) {
+	_, err := Construct(WithShutdownCoordination(nil))
+	require.Error(t, err)
+}
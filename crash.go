@@ -0,0 +1,100 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+)
+
+// WithCrashMarker detects unclean shutdowns using a marker file at path. The
+// marker is written when the application starts and removed only when Run or
+// RunE completes successfully, so it survives a failed initializer, runner or
+// cleanup, a panic, an OOM kill or SIGKILL. If it is found at the next start,
+// "recovering from crash" is logged, InitCtx.WasUncleanShutdown is set for the
+// initializer's recovery logic and the warmups registered with WithWarmup are
+// skipped to shorten the boot.
+// Failures to read or write the marker are logged and do not stop the
+// application.
+//
+// Example:
+//
+//	ezapp.Run(initializer, ezapp.WithCrashMarker("/var/lib/orders/running"))
+func WithCrashMarker(path string) RunOption {
+	return func(settings *runopt.Settings) {
+		settings.CrashMarker = path
+	}
+}
+
+// warmup is a non-essential startup step registered with WithWarmup.
+type warmup struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// WithWarmup is a functional option that registers a non-essential startup
+// step, such as priming a cache, run after the initializer returns and before
+// the runners start. Warmups receive the startup context and run in
+// registration order; a failing warmup is logged and does not stop the
+// application. They are skipped after an unclean shutdown detected with
+// WithCrashMarker.
+//
+// Example:
+//
+//	appCtx, err := Construct(
+//	    WithRunners(server.Run),
+//	    WithWarmup("product-cache", cache.Prime),
+//	)
+func WithWarmup(name string, fn func(ctx context.Context) error) option {
+	return func(appCtx *AppCtx) error {
+		if fn == nil {
+			return fmt.Errorf("warmup %s must not be nil", name)
+		}
+		appCtx.warmups = append(appCtx.warmups, warmup{name: name, fn: fn})
+		return nil
+	}
+}
+
+// runWarmups runs the warmups of appCtx, or skips them after an unclean
+// shutdown.
+func runWarmups(ctx context.Context, logger *slog.Logger, appCtx AppCtx, uncleanShutdown bool) {
+	for _, w := range appCtx.warmups {
+		if uncleanShutdown {
+			logger.Info("skipping warmup after unclean shutdown", "warmup", w.name)
+			continue
+		}
+		if err := w.fn(ctx); err != nil {
+			logger.Warn("warmup failed", "warmup", w.name, "error", err)
+		}
+	}
+}
+
+// markRunning writes the crash marker at path and reports whether a marker
+// left by an earlier run was found. The returned function removes the marker.
+func markRunning(logger *slog.Logger, path string) (bool, func()) {
+	previous, err := os.ReadFile(path)
+	unclean := err == nil
+	switch {
+	case unclean:
+		logger.Warn("recovering from crash", "marker", path, "previous_run", strings.TrimSpace(string(previous)))
+	case !errors.Is(err, fs.ErrNotExist):
+		logger.Warn("failed to read crash marker", "marker", path, "error", err)
+	}
+
+	marker := fmt.Sprintf("pid=%d started=%s\n", os.Getpid(), time.Now().UTC().Format(time.RFC3339))
+	if err := os.WriteFile(path, []byte(marker), 0o644); err != nil {
+		logger.Warn("failed to write crash marker", "marker", path, "error", err)
+		return unclean, func() {}
+	}
+	return unclean, func() {
+		if err := os.Remove(path); err != nil {
+			logger.Warn("failed to remove crash marker", "marker", path, "error", err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkRunning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "running")
+	logger, handler := testutil.NewTestLogger(slog.LevelDebug)
+
+	unclean, clear := markRunning(logger, path)
+	assert.False(t, unclean)
+	assert.FileExists(t, path)
+
+	// A second start while the marker exists is recovering from a crash
+	unclean, _ = markRunning(logger, path)
+	assert.True(t, unclean)
+	assert.Contains(t, handler.Messages(), "recovering from crash")
+
+	clear()
+	assert.NoFileExists(t, path)
+}
+
+func TestRunWarmups(t *testing.T) {
+	logger, handler := testutil.NewTestLogger(slog.LevelDebug)
+	var ran []string
+	appCtx, err := Construct(
+		WithWarmup("cache", func(context.Context) error {
+			ran = append(ran, "cache")
+			return errors.New("cache unavailable")
+		}),
+		WithWarmup("templates", func(context.Context) error {
+			ran = append(ran, "templates")
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+
+	runWarmups(context.Background(), logger, appCtx, false)
+	assert.Equal(t, []string{"cache", "templates"}, ran, "a failing warmup should not stop the others")
+	assert.Contains(t, handler.Messages(), "warmup failed")
+
+	ran = nil
+	runWarmups(context.Background(), logger, appCtx, true)
+	assert.Empty(t, ran, "warmups should be skipped after an unclean shutdown")
+
+	_, err = Construct(WithWarmup("nil", nil))
+	assert.ErrorContains(t, err, "must not be nil")
+}
+
+func TestRunECrashMarker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "running")
+	run := func() (unclean, warmed bool) {
+		err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+			unclean = ctx.WasUncleanShutdown
+			return Construct(
+				WithRunners(successfulRunner),
+				WithWarmup("cache", func(context.Context) error {
+					warmed = true
+					return nil
+				}),
+			)
+		}, WithCrashMarker(path))
+		require.NoError(t, err)
+		return unclean, warmed
+	}
+
+	unclean, warmed := run()
+	assert.False(t, unclean)
+	assert.True(t, warmed)
+	assert.NoFileExists(t, path, "a clean exit should remove the marker")
+
+	// Simulate a run that was killed before it could remove the marker
+	require.NoError(t, os.WriteFile(path, []byte("pid=1\n"), 0o644))
+	unclean, warmed = run()
+	assert.True(t, unclean)
+	assert.False(t, warmed)
+}
+
+func TestRunECrashMarkerKeptOnFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "running")
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(WithRunners(failingRunner))
+	}, WithCrashMarker(path))
+	require.Error(t, err)
+	assert.FileExists(t, path, "a failed run should keep the marker")
+
+	require.NoError(t, os.Remove(path))
+	assert.Panics(t, func() {
+		_ = RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+			panic("initializer bug")
+		}, WithCrashMarker(path))
+	})
+	assert.FileExists(t, path, "a panic should keep the marker")
+}
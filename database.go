@@ -0,0 +1,56 @@
+package ezapp
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/pgvanniekerk/ezapp/preflight"
+)
+
+// WithDatabase is a functional option that registers db, a database/sql pool,
+// as a managed component named name, so ORM clients built on one, such as
+// Ent and GORM, need no lifecycle glue:
+//
+//   - a preflight check named "sql:<name>" pings db under the startup
+//     context, failing startup if the database cannot be reached
+//   - db.PingContext is registered with InitCtx.Health as a critical check
+//   - a cleanup step named name closes db
+//
+// Example with GORM, whose *gorm.DB wraps a *sql.DB:
+//
+//	gormDB, err := gorm.Open(postgres.Open(ctx.Config.DatabaseURL))
+//	if err != nil {
+//	    return AppCtx{}, err
+//	}
+//	db, err := gormDB.DB()
+//	if err != nil {
+//	    return AppCtx{}, err
+//	}
+//	appCtx, err := Construct(
+//	    WithRunners(server.Run),
+//	    WithDatabase("orders", db),
+//	)
+//
+// Example with Ent, whose client is built on a *sql.DB:
+//
+//	db, err := sql.Open("pgx", ctx.Config.DatabaseURL)
+//	if err != nil {
+//	    return AppCtx{}, err
+//	}
+//	client := ent.NewClient(ent.Driver(entsql.OpenDB(dialect.Postgres, db)))
+//	appCtx, err := Construct(
+//	    WithRunners(server.Run),
+//	    WithDatabase("orders", db),
+//	)
+func WithDatabase(name string, db *sql.DB) option {
+	return func(appCtx *AppCtx) error {
+		if db == nil {
+			return fmt.Errorf("component %q cannot be nil", name)
+		}
+		if err := WithManagedComponent(name, db, nil)(appCtx); err != nil {
+			return err
+		}
+		appCtx.components[len(appCtx.components)-1].check = db.PingContext
+		return WithPreflightChecks(preflight.New("sql:"+name, db.PingContext))(appCtx)
+	}
+}
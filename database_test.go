@@ -0,0 +1,97 @@
+package ezapp
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pgvanniekerk/ezapp/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pingConnector opens connections whose pings fail while err is set.
+type pingConnector struct {
+	err atomic.Pointer[error]
+}
+
+func (c *pingConnector) Connect(context.Context) (driver.Conn, error) {
+	return pingConn{connector: c}, nil
+}
+
+func (c *pingConnector) Driver() driver.Driver {
+	return nil
+}
+
+type pingConn struct {
+	driver.Conn
+	connector *pingConnector
+}
+
+func (c pingConn) Ping(context.Context) error {
+	if err := c.connector.err.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+func (pingConn) Close() error {
+	return nil
+}
+
+func TestWithDatabase(t *testing.T) {
+	connector := &pingConnector{}
+	db := sql.OpenDB(connector)
+	var report health.Report
+	var components []ComponentInfo
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		appCtx, err := Construct(
+			WithDatabase("orders", db),
+			WithRunners(func(context.Context) error {
+				down := errors.New("connection refused")
+				connector.err.Store(&down)
+				report = ctx.Health.Check(context.Background())
+				return nil
+			}),
+		)
+		components = appCtx.Components()
+		return appCtx, err
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []ComponentInfo{{Name: "orders", Type: "*sql.DB", Healthy: true}}, components)
+	require.Len(t, report.Checks, 1)
+	assert.Equal(t, "orders", report.Checks[0].Name)
+	assert.False(t, report.Ready())
+	assert.ErrorContains(t, db.Ping(), "database is closed", "cleanup should close the database")
+}
+
+func TestWithDatabaseUnreachable(t *testing.T) {
+	connector := &pingConnector{}
+	down := errors.New("connection refused")
+	connector.err.Store(&down)
+	var ran bool
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithDatabase("orders", sql.OpenDB(connector)),
+			WithRunners(func(context.Context) error {
+				ran = true
+				return nil
+			}),
+		)
+	})
+
+	assert.ErrorIs(t, err, ErrPreflight)
+	assert.ErrorContains(t, err, "connection refused")
+	assert.False(t, ran, "runners should not start when the database is unreachable")
+}
+
+func TestWithDatabaseNil(t *testing.T) {
+	_, err := Construct(WithDatabase("orders", nil))
+	assert.ErrorContains(t, err, `component "orders" cannot be nil`)
+}
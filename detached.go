@@ -0,0 +1,129 @@
+package ezapp
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+)
+
+// Detach runs fn in the background with a context that keeps the values of
+// ctx, such as its logger, but is not cancelled with it. It is meant for work
+// that must finish even once the request or runner that started it has been
+// cancelled, such as writing an audit record, and replaces spawning a
+// goroutine that shutdown knows nothing about.
+//
+// When ctx is, or derives from, the context of one of the application's
+// runners, as do the request contexts of an httprunner.Server, shutdown waits
+// for the detached work after the runners have returned and before cleanup,
+// so the resources it uses are still open. The wait is bounded by the
+// shutdown timeout; work still running then is logged as "detached work
+// abandoned" and left behind. A failure of fn is logged under name. Work
+// detached once the wait has begun, such as from cleanup, runs before Detach
+// returns, and work detached from any other context is not waited on.
+//
+// Example:
+//
+//	func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+//	    ...
+//	    ezapp.Detach(r.Context(), "audit", func(ctx context.Context) error {
+//	        return h.audit.Write(ctx, "order deleted", id)
+//	    })
+//	}
+func Detach(ctx context.Context, name string, fn func(ctx context.Context) error) {
+	ctx = context.WithoutCancel(ctx)
+	run := func() {
+		if err := fn(ctx); err != nil {
+			LoggerFromContext(ctx).Error("detached work failed", "work", name, "error", err)
+		}
+	}
+
+	work, _ := ctx.Value(detachedKey{}).(*detachedWork)
+	if work == nil {
+		go run()
+		return
+	}
+	if !work.add(name) {
+		run()
+		return
+	}
+	go func() {
+		defer work.done(name)
+		run()
+	}()
+}
+
+// detachedKey is the context key under which the detached work of the
+// application is stored.
+type detachedKey struct{}
+
+// detachedWork tracks the work started with Detach that shutdown waits for.
+type detachedWork struct {
+	mu      sync.Mutex
+	closed  bool
+	running map[string]int
+	wg      sync.WaitGroup
+}
+
+// add registers work under name, unless the wait for detached work has begun.
+func (w *detachedWork) add(name string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return false
+	}
+	if w.running == nil {
+		w.running = make(map[string]int)
+	}
+	w.running[name]++
+	w.wg.Add(1)
+	return true
+}
+
+// done marks work registered under name as finished.
+func (w *detachedWork) done(name string) {
+	w.mu.Lock()
+	w.running[name]--
+	if w.running[name] == 0 {
+		delete(w.running, name)
+	}
+	w.mu.Unlock()
+	w.wg.Done()
+}
+
+// wait stops accepting detached work and waits for the work running to
+// finish or for ctx to be done. It returns the names of the work abandoned
+// when ctx is done first, in lexical order.
+func (w *detachedWork) wait(ctx context.Context) []string {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	names := make([]string, 0, len(w.running))
+	for name := range w.running {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// waitDetached waits for the detached work of the application and logs the
+// work abandoned when ctx is done first.
+func waitDetached(ctx context.Context, logger *slog.Logger, work *detachedWork) {
+	if abandoned := work.wait(ctx); len(abandoned) > 0 {
+		logger.Error("detached work abandoned", "work", abandoned, "error", ctx.Err())
+	}
+}
@@ -0,0 +1,155 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/httprunner"
+	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetachKeepsValuesWithoutCancellation(t *testing.T) {
+	logger, handler := testutil.NewTestLogger(slog.LevelDebug)
+	ctx, cancel := context.WithCancel(ContextWithLogger(context.Background(), logger))
+	cancel()
+
+	done := make(chan error, 1)
+	Detach(ctx, "audit", func(ctx context.Context) error {
+		done <- ctx.Err()
+		return errors.New("write failed")
+	})
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err, "detached work should not be cancelled with its parent")
+	case <-time.After(time.Second):
+		t.Fatal("detached work did not run")
+	}
+	assert.Eventually(t, func() bool {
+		value, ok := handler.Attr("detached work failed", "work")
+		return ok && value.String() == "audit"
+	}, time.Second, time.Millisecond)
+}
+
+func TestDetachedWorkWait(t *testing.T) {
+	work := &detachedWork{}
+	ctx := context.WithValue(context.Background(), detachedKey{}, work)
+
+	var finished atomic.Bool
+	Detach(ctx, "audit", func(ctx context.Context) error {
+		time.Sleep(20 * time.Millisecond)
+		finished.Store(true)
+		return nil
+	})
+
+	assert.Empty(t, work.wait(context.Background()))
+	assert.True(t, finished.Load(), "wait should return once the work has finished")
+
+	// Work detached once the wait has begun runs inline
+	var inline bool
+	Detach(ctx, "late", func(ctx context.Context) error {
+		inline = true
+		return nil
+	})
+	assert.True(t, inline)
+}
+
+func TestDetachedWorkWaitAbandons(t *testing.T) {
+	work := &detachedWork{}
+	ctx := context.WithValue(context.Background(), detachedKey{}, work)
+
+	release := make(chan struct{})
+	defer close(release)
+	Detach(ctx, "stuck", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	Detach(ctx, "audit", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+
+	waitCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, []string{"audit", "stuck"}, work.wait(waitCtx))
+}
+
+func TestRunEWaitsForDetachedWork(t *testing.T) {
+	var written atomic.Bool
+	var writtenBeforeCleanup bool
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithRunners(func(ctx context.Context) error {
+				Detach(ctx, "audit", func(ctx context.Context) error {
+					time.Sleep(20 * time.Millisecond)
+					written.Store(true)
+					return nil
+				})
+				return nil
+			}),
+			WithCleanup(func(ctx context.Context) error {
+				writtenBeforeCleanup = written.Load()
+				return nil
+			}),
+		)
+	})
+
+	require.NoError(t, err)
+	assert.True(t, writtenBeforeCleanup, "cleanup should run after the detached work")
+}
+
+func TestDetachFromHTTPHandler(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	errDone := errors.New("request sent")
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, event)
+	}
+
+	err = RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		server := httprunner.New(&http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Detach(r.Context(), "audit", func(ctx context.Context) error {
+				time.Sleep(50 * time.Millisecond)
+				record("audit")
+				return nil
+			})
+		})}, httprunner.WithListener(listener))
+
+		return Construct(
+			WithNamedRunner("http", server.Run),
+			WithNamedRunner("client", func(ctx context.Context) error {
+				resp, err := http.Get("http://" + listener.Addr().String())
+				if err != nil {
+					return err
+				}
+				resp.Body.Close()
+				return errDone
+			}),
+			WithCleanupStep("database", func(context.Context) error {
+				record("cleanup")
+				return nil
+			}),
+		)
+	})
+
+	require.ErrorIs(t, err, errDone)
+	assert.Equal(t, []string{"audit", "cleanup"}, order,
+		"shutdown should wait for work detached from a request before cleanup")
+}
@@ -0,0 +1,75 @@
+package ezapp
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Endpoint describes an address the application serves, such as an HTTP
+// listener or a debug page. Endpoints are listed in the development mode
+// startup banner.
+type Endpoint struct {
+	// Name is a short description of the endpoint, e.g. "http" or "metrics".
+	Name string
+
+	// Address is where the endpoint can be reached, e.g. "http://localhost:8080".
+	Address string
+}
+
+// WithEndpoint records an endpoint served by the application so that it is
+// listed in the startup banner printed in development mode.
+//
+// Development mode is enabled by setting EZAPP_DEV=1 and switches the
+// framework defaults for local runs:
+//   - Logs are written as human-readable text at DEBUG level unless EZAPP_LOG_LEVEL is set
+//   - The startup timeout defaults to 60 seconds instead of 15
+//   - Variables are read from a .env file in the working directory, without overriding the environment
+//   - Missing required variables are logged as warnings and left at their zero value
+//   - A banner summarising the application and its endpoints is printed before the runners start
+//
+// Example:
+//
+//	return ezapp.Construct(
+//	    ezapp.WithRunners(server.Run),
+//	    ezapp.WithEndpoint("http", "http://localhost:8080"),
+//	)
+func WithEndpoint(name, address string) option {
+	return func(appCtx *AppCtx) error {
+		appCtx.endpoints = append(appCtx.endpoints, Endpoint{Name: name, Address: address})
+		return nil
+	}
+}
+
+// devBanner holds the details printed in the development mode startup banner.
+type devBanner struct {
+	Environment string
+	ConfigHash  string
+	Runners     int
+	Endpoints   []Endpoint
+}
+
+// printDevBanner writes a human-readable summary of the application to w.
+func printDevBanner(w io.Writer, banner devBanner) {
+	environment := banner.Environment
+	if environment == "" {
+		environment = "(none)"
+	}
+
+	var b strings.Builder
+	b.WriteString("\n=== ezapp development mode ===\n")
+	fmt.Fprintf(&b, "  environment: %s\n", environment)
+	fmt.Fprintf(&b, "  config hash: %s\n", banner.ConfigHash)
+	fmt.Fprintf(&b, "  runners:     %d\n", banner.Runners)
+	if len(banner.Endpoints) == 0 {
+		b.WriteString("  endpoints:   (none registered)\n")
+	} else {
+		b.WriteString("  endpoints:\n")
+		for _, endpoint := range banner.Endpoints {
+			fmt.Fprintf(&b, "    %-12s %s\n", endpoint.Name, endpoint.Address)
+		}
+	}
+	b.WriteString("==============================\n\n")
+
+	_, _ = io.WriteString(w, b.String())
+}
@@ -0,0 +1,45 @@
+package ezapp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithEndpoint(t *testing.T) {
+	appCtx, err := Construct(
+		WithEndpoint("http", "http://localhost:8080"),
+		WithEndpoint("metrics", "http://localhost:9090/metrics"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []Endpoint{
+		{Name: "http", Address: "http://localhost:8080"},
+		{Name: "metrics", Address: "http://localhost:9090/metrics"},
+	}, appCtx.endpoints)
+}
+
+func TestPrintDevBanner(t *testing.T) {
+	var out strings.Builder
+	printDevBanner(&out, devBanner{
+		Environment: "local",
+		ConfigHash:  "abc123",
+		Runners:     2,
+		Endpoints:   []Endpoint{{Name: "http", Address: "http://localhost:8080"}},
+	})
+
+	banner := out.String()
+	assert.Contains(t, banner, "environment: local")
+	assert.Contains(t, banner, "config hash: abc123")
+	assert.Contains(t, banner, "runners:     2")
+	assert.Contains(t, banner, "http         http://localhost:8080")
+}
+
+func TestPrintDevBannerDefaults(t *testing.T) {
+	var out strings.Builder
+	printDevBanner(&out, devBanner{})
+
+	banner := out.String()
+	assert.Contains(t, banner, "environment: (none)")
+	assert.Contains(t, banner, "endpoints:   (none registered)")
+}
@@ -0,0 +1,71 @@
+package ezapp
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pgvanniekerk/ezapp/discovery"
+)
+
+// WithServiceRegistration is a functional option that registers the instance
+// with a service discovery backend once the application is running, that is
+// once all of its runners have been started, and deregisters it in a
+// pre-shutdown hook, before the runners are cancelled, so that peers only
+// route to the instance while it can serve. In hold mode, registration waits
+// for the release.
+//
+// A failed registration fails the application, since an instance that
+// cannot be discovered will never receive traffic.
+//
+// Example:
+//
+//	consulCfg, err := discovery.LoadConsulConfig()
+//	if err != nil {
+//	    return AppCtx{}, err
+//	}
+//	appCtx, err := Construct(
+//	    WithRunners(server.Run),
+//	    WithServiceRegistration(discovery.NewConsul(consulCfg)),
+//	)
+func WithServiceRegistration(registrar discovery.Registrar) option {
+	var (
+		registered  atomic.Bool
+		runningOnce sync.Once
+	)
+	running := make(chan struct{})
+	onRunning := func(_, to State) {
+		if to == StateRunning {
+			runningOnce.Do(func() { close(running) })
+		}
+	}
+
+	register := func(ctx context.Context) error {
+		select {
+		case <-running:
+		case <-ctx.Done():
+			return nil
+		}
+		if err := registrar.Register(ctx); err != nil {
+			return err
+		}
+		registered.Store(true)
+
+		<-ctx.Done()
+		return nil
+	}
+
+	deregister := func(ctx context.Context) error {
+		if !registered.Load() {
+			return nil
+		}
+		return registrar.Deregister(ctx)
+	}
+
+	return func(appCtx *AppCtx) error {
+		appCtx.addRunner("service-registration", register)
+		appCtx.stateHooks = append(appCtx.stateHooks, onRunning)
+		appCtx.addPreShutdownHook(0, deregister)
+		return nil
+	}
+}
@@ -0,0 +1,173 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/config"
+)
+
+// ConsulConfig configures registration with a Consul agent. It is typically
+// loaded from the environment with LoadConsulConfig.
+type ConsulConfig struct {
+
+	// Address is the URL of the Consul agent's HTTP API.
+	Address string `env:"CONSUL_HTTP_ADDR,default=http://127.0.0.1:8500"`
+
+	// Token is the ACL token sent with every request, if set.
+	Token string `env:"CONSUL_HTTP_TOKEN" resolve:"true"`
+
+	// ServiceName is the name the instance is registered under.
+	ServiceName string `env:"EZAPP_SERVICE_NAME,required=true"`
+
+	// ServiceID uniquely identifies the instance. Defaults to
+	// "<ServiceName>-<hostname>".
+	ServiceID string `env:"EZAPP_SERVICE_ID"`
+
+	// ServiceAddress is the address other services use to reach the
+	// instance. If empty, Consul uses the agent's address.
+	ServiceAddress string `env:"EZAPP_SERVICE_ADDRESS"`
+
+	// ServicePort is the port other services use to reach the instance.
+	ServicePort int `env:"EZAPP_SERVICE_PORT"`
+
+	// Tags are attached to the registration. In the environment they are
+	// separated by a pipe, e.g. "http|v2".
+	Tags []string `env:"EZAPP_SERVICE_TAGS"`
+
+	// CheckURL is an HTTP endpoint, typically the readiness endpoint, that
+	// Consul polls to determine the instance's health. No check is
+	// registered if empty.
+	CheckURL string `env:"EZAPP_SERVICE_CHECK_URL"`
+
+	// CheckInterval is how often Consul polls CheckURL.
+	CheckInterval time.Duration `env:"EZAPP_SERVICE_CHECK_INTERVAL,default=10s"`
+}
+
+// LoadConsulConfig loads a ConsulConfig from the environment:
+//
+//   - CONSUL_HTTP_ADDR: Consul agent URL (default: http://127.0.0.1:8500)
+//   - CONSUL_HTTP_TOKEN: ACL token
+//   - EZAPP_SERVICE_NAME: service name (required)
+//   - EZAPP_SERVICE_ID: instance ID (default: <name>-<hostname>)
+//   - EZAPP_SERVICE_ADDRESS, EZAPP_SERVICE_PORT: advertised address and port
+//   - EZAPP_SERVICE_TAGS: pipe separated tags, e.g. http|v2
+//   - EZAPP_SERVICE_CHECK_URL, EZAPP_SERVICE_CHECK_INTERVAL: HTTP health check
+func LoadConsulConfig() (ConsulConfig, error) {
+	cfg, err := config.LoadVar[ConsulConfig](config.WithPrefix("CONSUL_", "EZAPP_SERVICE_"))
+	if err != nil {
+		return ConsulConfig{}, fmt.Errorf("failed to load consul configuration from environment: %w", err)
+	}
+
+	if cfg.ServiceID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return ConsulConfig{}, fmt.Errorf("failed to determine hostname for service ID: %w", err)
+		}
+		cfg.ServiceID = cfg.ServiceName + "-" + hostname
+	}
+
+	return cfg, nil
+}
+
+// Consul is a Registrar backed by the HTTP API of a local Consul agent.
+type Consul struct {
+	cfg    ConsulConfig
+	client *http.Client
+}
+
+// NewConsul creates a Consul registrar from the given configuration.
+func NewConsul(cfg ConsulConfig) *Consul {
+	return &Consul{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// consulRegistration is the request body of the agent service registration endpoint.
+type consulRegistration struct {
+	ID      string              `json:"ID"`
+	Name    string              `json:"Name"`
+	Address string              `json:"Address,omitempty"`
+	Port    int                 `json:"Port,omitempty"`
+	Tags    []string            `json:"Tags,omitempty"`
+	Check   *consulServiceCheck `json:"Check,omitempty"`
+}
+
+// consulServiceCheck is an HTTP check attached to a service registration.
+type consulServiceCheck struct {
+	HTTP                           string `json:"HTTP"`
+	Interval                       string `json:"Interval"`
+	DeregisterCriticalServiceAfter string `json:"DeregisterCriticalServiceAfter"`
+}
+
+// Register registers the instance with the Consul agent.
+func (c *Consul) Register(ctx context.Context) error {
+	registration := consulRegistration{
+		ID:      c.cfg.ServiceID,
+		Name:    c.cfg.ServiceName,
+		Address: c.cfg.ServiceAddress,
+		Port:    c.cfg.ServicePort,
+		Tags:    c.cfg.Tags,
+	}
+	if c.cfg.CheckURL != "" {
+		registration.Check = &consulServiceCheck{
+			HTTP:     c.cfg.CheckURL,
+			Interval: c.cfg.CheckInterval.String(),
+			// Reap instances that died without deregistering.
+			DeregisterCriticalServiceAfter: "1m",
+		}
+	}
+
+	body, err := json.Marshal(registration)
+	if err != nil {
+		return fmt.Errorf("failed to encode consul registration: %w", err)
+	}
+
+	if err := c.put(ctx, "/v1/agent/service/register", body); err != nil {
+		return fmt.Errorf("failed to register service %q with consul: %w", c.cfg.ServiceID, err)
+	}
+	return nil
+}
+
+// Deregister removes the instance from the Consul agent.
+func (c *Consul) Deregister(ctx context.Context) error {
+	path := "/v1/agent/service/deregister/" + url.PathEscape(c.cfg.ServiceID)
+	if err := c.put(ctx, path, nil); err != nil {
+		return fmt.Errorf("failed to deregister service %q from consul: %w", c.cfg.ServiceID, err)
+	}
+	return nil
+}
+
+// put sends a PUT request to the agent API and checks the response status.
+func (c *Consul) put(ctx context.Context, path string, body []byte) error {
+	endpoint := strings.TrimSuffix(c.cfg.Address, "/") + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.cfg.Token != "" {
+		req.Header.Set("X-Consul-Token", c.cfg.Token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// consulRequest is a request recorded by the fake Consul agent.
+type consulRequest struct {
+	Method string
+	Path   string
+	Token  string
+	Body   []byte
+}
+
+// newFakeConsul starts an HTTP server recording agent API requests.
+func newFakeConsul(t *testing.T, status int) (*httptest.Server, func() []consulRequest) {
+	var mu sync.Mutex
+	var requests []consulRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, consulRequest{
+			Method: r.Method,
+			Path:   r.URL.Path,
+			Token:  r.Header.Get("X-Consul-Token"),
+			Body:   body,
+		})
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []consulRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]consulRequest{}, requests...)
+	}
+}
+
+func TestLoadConsulConfig(t *testing.T) {
+	t.Run("from environment", func(t *testing.T) {
+		os.Setenv("EZAPP_SERVICE_NAME", "orders")
+		os.Setenv("EZAPP_SERVICE_PORT", "8080")
+		os.Setenv("EZAPP_SERVICE_TAGS", "http|v2")
+		defer func() {
+			os.Unsetenv("EZAPP_SERVICE_NAME")
+			os.Unsetenv("EZAPP_SERVICE_PORT")
+			os.Unsetenv("EZAPP_SERVICE_TAGS")
+		}()
+
+		cfg, err := LoadConsulConfig()
+
+		require.NoError(t, err)
+		hostname, _ := os.Hostname()
+		assert.Equal(t, "http://127.0.0.1:8500", cfg.Address)
+		assert.Equal(t, "orders", cfg.ServiceName)
+		assert.Equal(t, "orders-"+hostname, cfg.ServiceID)
+		assert.Equal(t, 8080, cfg.ServicePort)
+		assert.Equal(t, []string{"http", "v2"}, cfg.Tags)
+		assert.Equal(t, 10*time.Second, cfg.CheckInterval)
+	})
+
+	t.Run("service name required", func(t *testing.T) {
+		os.Unsetenv("EZAPP_SERVICE_NAME")
+
+		_, err := LoadConsulConfig()
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "EZAPP_SERVICE_NAME")
+	})
+}
+
+func TestConsulRegister(t *testing.T) {
+	server, requests := newFakeConsul(t, http.StatusOK)
+	consul := NewConsul(ConsulConfig{
+		Address:       server.URL,
+		Token:         "secret",
+		ServiceName:   "orders",
+		ServiceID:     "orders-1",
+		ServicePort:   8080,
+		Tags:          []string{"http"},
+		CheckURL:      "http://10.0.0.1:8080/readyz",
+		CheckInterval: 5 * time.Second,
+	})
+
+	require.NoError(t, consul.Register(context.Background()))
+
+	recorded := requests()
+	require.Len(t, recorded, 1)
+	assert.Equal(t, http.MethodPut, recorded[0].Method)
+	assert.Equal(t, "/v1/agent/service/register", recorded[0].Path)
+	assert.Equal(t, "secret", recorded[0].Token)
+
+	var registration consulRegistration
+	require.NoError(t, json.Unmarshal(recorded[0].Body, &registration))
+	assert.Equal(t, "orders-1", registration.ID)
+	assert.Equal(t, "orders", registration.Name)
+	assert.Equal(t, 8080, registration.Port)
+	require.NotNil(t, registration.Check)
+	assert.Equal(t, "http://10.0.0.1:8080/readyz", registration.Check.HTTP)
+	assert.Equal(t, "5s", registration.Check.Interval)
+}
+
+func TestConsulDeregister(t *testing.T) {
+	server, requests := newFakeConsul(t, http.StatusOK)
+	consul := NewConsul(ConsulConfig{Address: server.URL + "/", ServiceName: "orders", ServiceID: "orders 1"})
+
+	require.NoError(t, consul.Deregister(context.Background()))
+
+	recorded := requests()
+	require.Len(t, recorded, 1)
+	assert.Equal(t, "/v1/agent/service/deregister/orders 1", recorded[0].Path)
+}
+
+func TestConsulErrorStatus(t *testing.T) {
+	server, _ := newFakeConsul(t, http.StatusForbidden)
+	consul := NewConsul(ConsulConfig{Address: server.URL, ServiceName: "orders", ServiceID: "orders-1"})
+
+	err := consul.Register(context.Background())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `failed to register service "orders-1" with consul`)
+	assert.Contains(t, err.Error(), "403")
+}
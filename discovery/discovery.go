@@ -0,0 +1,16 @@
+// Package discovery registers application instances with a service
+// discovery backend so that they can be found by other services.
+package discovery
+
+import "context"
+
+// Registrar registers and deregisters the running instance with a service
+// discovery backend.
+type Registrar interface {
+
+	// Register announces the instance to the discovery backend.
+	Register(ctx context.Context) error
+
+	// Deregister removes the instance from the discovery backend.
+	Deregister(ctx context.Context) error
+}
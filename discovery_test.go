@@ -0,0 +1,132 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingRegistrar records calls made by WithServiceRegistration.
+type recordingRegistrar struct {
+	mu          sync.Mutex
+	calls       []string
+	registerErr error
+}
+
+func (r *recordingRegistrar) Register(ctx context.Context) error {
+	r.record("register")
+	return r.registerErr
+}
+
+func (r *recordingRegistrar) Deregister(ctx context.Context) error {
+	r.record("deregister")
+	return nil
+}
+
+func (r *recordingRegistrar) record(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *recordingRegistrar) Calls() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.calls...)
+}
+
+// TestWithServiceRegistration tests registration and deregistration ordering
+// This test verifies that:
+// - The instance is not registered before the application is running
+// - The instance is registered once the application is running
+// - The instance is deregistered before the runners are cancelled
+func TestWithServiceRegistration(t *testing.T) {
+	registrar := &recordingRegistrar{}
+
+	appCtx, err := Construct(WithServiceRegistration(registrar))
+	require.NoError(t, err)
+	require.Len(t, appCtx.runnerList, 1)
+	require.Len(t, appCtx.preShutdownHooks, 1)
+	require.Len(t, appCtx.stateHooks, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- appCtx.runnerList[0](ctx)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, registrar.Calls(), "registration should wait for the application to be running")
+	appCtx.stateHooks[0](StateCreated, StateStarting)
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, registrar.Calls(), "registration should wait for the application to be running")
+	appCtx.stateHooks[0](StateStarting, StateRunning)
+
+	assert.Eventually(t, func() bool {
+		return len(registrar.Calls()) == 1
+	}, time.Second, time.Millisecond)
+
+	// Deregistration happens in the pre-shutdown hook, while the runner
+	// context is still active.
+	require.NoError(t, appCtx.preShutdownHooks[0](context.Background()))
+	cancel()
+
+	assert.NoError(t, <-done)
+	assert.Equal(t, []string{"register", "deregister"}, registrar.Calls())
+}
+
+// TestWithServiceRegistrationFailure tests a failed registration
+// This test verifies that:
+// - A registration error is returned by the runner
+// - No deregistration is attempted for an instance that was never registered
+func TestWithServiceRegistrationFailure(t *testing.T) {
+	registrar := &recordingRegistrar{registerErr: errors.New("consul unavailable")}
+
+	appCtx, err := Construct(WithServiceRegistration(registrar))
+	require.NoError(t, err)
+
+	appCtx.stateHooks[0](StateStarting, StateRunning)
+	err = appCtx.runnerList[0](context.Background())
+	assert.EqualError(t, err, "consul unavailable")
+
+	require.NoError(t, appCtx.preShutdownHooks[0](context.Background()))
+	assert.Equal(t, []string{"register"}, registrar.Calls())
+}
+
+// TestWithServiceRegistrationInApp tests registration in a running app
+// This test verifies that:
+// - The instance is registered once the application is running
+// - The instance is deregistered when the application shuts down
+// - An app shutting down before it is running is never registered
+func TestWithServiceRegistrationInApp(t *testing.T) {
+	registrar := &recordingRegistrar{}
+	errDone := errors.New("done")
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithServiceRegistration(registrar),
+			WithRunners(func(context.Context) error {
+				assert.Eventually(t, func() bool {
+					return len(registrar.Calls()) == 1
+				}, time.Second, time.Millisecond)
+				return errDone
+			}),
+		)
+	})
+
+	require.ErrorIs(t, err, errDone)
+	assert.Equal(t, []string{"register", "deregister"}, registrar.Calls())
+
+	neverRunning := &recordingRegistrar{}
+	appCtx, err := Construct(WithServiceRegistration(neverRunning))
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NoError(t, appCtx.runnerList[0](ctx))
+	assert.Empty(t, neverRunning.Calls())
+}
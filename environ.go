@@ -0,0 +1,62 @@
+package ezapp
+
+import (
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/pgvanniekerk/ezapp/internal/config"
+)
+
+// EnvSnapshot is an immutable snapshot of the environment variables taken at
+// startup, returned by InitCtx.Environ. Overlay variables of the environment
+// selected by EZAPP_ENV are already merged over their base variables, as for
+// Config, so values that need custom parsing are read consistently with it.
+type EnvSnapshot struct {
+	vars map[string]string
+}
+
+// newEnvSnapshot returns the snapshot of environ, in the "key=value" form of
+// os.Environ, with the overlay of environment merged.
+func newEnvSnapshot(environ []string, environment string) EnvSnapshot {
+	vars := make(map[string]string, len(environ))
+	for _, entry := range environ {
+		if key, value, ok := strings.Cut(entry, "="); ok {
+			vars[key] = value
+		}
+	}
+	config.ApplyOverlay(vars, environment)
+	return EnvSnapshot{vars: vars}
+}
+
+// Lookup returns the value of the variable key and whether it was set.
+func (s EnvSnapshot) Lookup(key string) (string, bool) {
+	value, ok := s.vars[key]
+	return value, ok
+}
+
+// Get returns the value of the variable key, or an empty string if it was not
+// set.
+func (s EnvSnapshot) Get(key string) string {
+	return s.vars[key]
+}
+
+// Keys returns the names of the variables in the snapshot, sorted.
+func (s EnvSnapshot) Keys() []string {
+	return slices.Sorted(maps.Keys(s.vars))
+}
+
+// Map returns a copy of the variables in the snapshot.
+func (s EnvSnapshot) Map() map[string]string {
+	return maps.Clone(s.vars)
+}
+
+// loadEnviron returns the environment a run reads its configuration from: the
+// variables supplied through the run settings, or the process environment.
+func loadEnviron(supplied []string) []string {
+	if supplied != nil {
+		return supplied
+	}
+	return os.Environ()
+}
@@ -0,0 +1,51 @@
+package ezapp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEnvSnapshot(t *testing.T) {
+	snapshot := newEnvSnapshot([]string{"PORT=8080", "STAGING_PORT=9090", "EMPTY=", "DSN=a=b"}, "staging")
+
+	value, ok := snapshot.Lookup("PORT")
+	assert.True(t, ok)
+	assert.Equal(t, "9090", value, "overlay variables should be merged")
+
+	value, ok = snapshot.Lookup("EMPTY")
+	assert.True(t, ok)
+	assert.Empty(t, value)
+
+	_, ok = snapshot.Lookup("MISSING")
+	assert.False(t, ok)
+	assert.Equal(t, "a=b", snapshot.Get("DSN"))
+	assert.Equal(t, []string{"DSN", "EMPTY", "PORT", "STAGING_PORT"}, snapshot.Keys())
+}
+
+func TestEnvSnapshotMapIsCopy(t *testing.T) {
+	snapshot := newEnvSnapshot([]string{"PORT=8080"}, "")
+
+	vars := snapshot.Map()
+	vars["PORT"] = "9090"
+
+	assert.Equal(t, "8080", snapshot.Get("PORT"))
+}
+
+func TestRunEEnviron(t *testing.T) {
+	t.Setenv("TEST_VALUE", "from process")
+	t.Setenv("FEATURE_SEARCH", "on")
+
+	var environ EnvSnapshot
+	var cfg TestConfig
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		environ = ctx.Environ()
+		cfg = ctx.Config
+		return Construct()
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "on", environ.Get("FEATURE_SEARCH"))
+	assert.Equal(t, cfg.TestValue, environ.Get("TEST_VALUE"))
+}
@@ -0,0 +1,71 @@
+package ezapp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultEnvoyAdminURL is the address of the Envoy admin interface of an
+// Istio sidecar.
+const DefaultEnvoyAdminURL = "http://localhost:15000"
+
+// WithEnvoyDrain is a functional option that drains the inbound listeners of
+// an Envoy sidecar, such as Istio's, when shutdown begins. A pre-shutdown
+// hook calls the admin endpoint /drain_listeners?graceful&inboundonly at
+// adminURL (DefaultEnvoyAdminURL if empty), so the sidecar stops accepting
+// new connections and closes idle ones, and then waits drainPeriod for
+// in-flight requests to finish before the runners are cancelled. Without it,
+// requests routed to the pod while it stops its servers fail with 503s.
+//
+// The hook is bounded by the shutdown timeout, which should exceed
+// drainPeriod. A sidecar that cannot be reached is logged like any other
+// failing pre-shutdown hook and does not prevent shutdown.
+//
+// Example:
+//
+//	appCtx, err := Construct(
+//	    WithNamedRunner("http", server.Run),
+//	    WithEnvoyDrain("", 5*time.Second),
+//	)
+func WithEnvoyDrain(adminURL string, drainPeriod time.Duration) option {
+	if adminURL == "" {
+		adminURL = DefaultEnvoyAdminURL
+	}
+	drainURL := strings.TrimSuffix(adminURL, "/") + "/drain_listeners?graceful&inboundonly"
+
+	return WithPreShutdownHook(func(ctx context.Context) error {
+		if err := drainEnvoy(ctx, drainURL); err != nil {
+			return err
+		}
+
+		timer := time.NewTimer(drainPeriod)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("envoy drain period interrupted: %w", ctx.Err())
+		}
+	})
+}
+
+// drainEnvoy asks the Envoy admin interface to drain its listeners.
+func drainEnvoy(ctx context.Context, drainURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, drainURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create envoy drain request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to drain envoy listeners: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to drain envoy listeners: unexpected status %s", resp.Status)
+	}
+	return nil
+}
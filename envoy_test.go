@@ -0,0 +1,78 @@
+package ezapp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithEnvoyDrain(t *testing.T) {
+	var mu sync.Mutex
+	var drainedAt time.Time
+	var drainQuery string
+	sidecar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodPost && r.URL.Path == "/drain_listeners" {
+			drainedAt = time.Now()
+			drainQuery = r.URL.RawQuery
+		}
+	}))
+	defer sidecar.Close()
+
+	var cancelledAt time.Time
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithNamedRunner("http", func(ctx context.Context) error {
+				<-ctx.Done()
+				cancelledAt = time.Now()
+				return nil
+			}),
+			WithNamedRunner("failing", failingRunner),
+			WithEnvoyDrain(sidecar.URL+"/", 50*time.Millisecond),
+		)
+	})
+	assert.ErrorContains(t, err, "runner failed")
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.False(t, drainedAt.IsZero(), "Envoy should have been drained")
+	assert.Equal(t, "graceful&inboundonly", drainQuery)
+	assert.GreaterOrEqual(t, cancelledAt.Sub(drainedAt), 50*time.Millisecond, "Runners should be cancelled after the drain period")
+}
+
+func TestWithEnvoyDrainFailure(t *testing.T) {
+	sidecar := httptest.NewServer(http.NotFoundHandler())
+	defer sidecar.Close()
+
+	appCtx, err := Construct(WithEnvoyDrain(sidecar.URL, time.Hour))
+	require.NoError(t, err)
+	require.Len(t, appCtx.preShutdownHooks, 1)
+
+	err = appCtx.preShutdownHooks[0](context.Background())
+	assert.ErrorContains(t, err, "unexpected status 404 Not Found")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = appCtx.preShutdownHooks[0](ctx)
+	assert.ErrorContains(t, err, "failed to drain envoy listeners")
+}
+
+func TestWithEnvoyDrainInterrupted(t *testing.T) {
+	sidecar := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer sidecar.Close()
+
+	appCtx, err := Construct(WithEnvoyDrain(sidecar.URL, time.Hour))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = appCtx.preShutdownHooks[0](ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,71 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/pgvanniekerk/ezapp/internal/app"
+)
+
+// ErrConfigLoad is returned by RunE when the configuration could not be
+// loaded from the environment or the supplied configuration is invalid.
+var ErrConfigLoad = errors.New("failed to load configuration")
+
+// ErrInitTimeout is returned by RunE when the initializer fails after the
+// startup context has expired.
+var ErrInitTimeout = errors.New("initialization timed out")
+
+// RunnerError reports the failure of a single runner. Runners added with
+// WithNamedRunner carry their given name; other runners are named after
+// their position, e.g. "runner-0".
+//
+// Example:
+//
+//	var runnerErr *ezapp.RunnerError
+//	if errors.As(err, &runnerErr) {
+//	    log.Printf("runner %s failed: %v", runnerErr.Name, runnerErr.Err)
+//	}
+type RunnerError struct {
+	Name string
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *RunnerError) Error() string {
+	return fmt.Sprintf("runner %s failed: %v", e.Name, e.Err)
+}
+
+// Unwrap returns the error returned by the runner.
+func (e *RunnerError) Unwrap() error {
+	return e.Err
+}
+
+// nameRunner wraps r so that its failures are reported as a RunnerError
+// with the given name.
+func nameRunner(name string, r app.Runner) app.Runner {
+	return func(ctx context.Context) error {
+		if err := r(ctx); err != nil {
+			return &RunnerError{Name: name, Err: err}
+		}
+		return nil
+	}
+}
+
+// CleanupError reports the failure of a single cleanup step. The function set
+// with WithCleanup is reported as the "cleanup" step; steps added with
+// WithCleanupStep carry their given name.
+type CleanupError struct {
+	Step string
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *CleanupError) Error() string {
+	return fmt.Sprintf("cleanup step %s failed: %v", e.Step, e.Err)
+}
+
+// Unwrap returns the error returned by the cleanup step.
+func (e *CleanupError) Unwrap() error {
+	return e.Err
+}
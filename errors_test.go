@@ -0,0 +1,87 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunEConfigLoadError(t *testing.T) {
+	type RequiredConfig struct {
+		Value string `env:"EZAPP_ERRORS_TEST_VALUE,required=true"`
+	}
+	t.Setenv("EZAPP_DEV", "")
+
+	err := RunE(func(ctx InitCtx[RequiredConfig]) (AppCtx, error) {
+		return Construct()
+	})
+
+	assert.ErrorIs(t, err, ErrConfigLoad)
+}
+
+func TestRunEInitTimeout(t *testing.T) {
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		<-ctx.StartupCtx.Done()
+		return AppCtx{}, ctx.StartupCtx.Err()
+	}, WithStartupTimeout(10*time.Millisecond))
+
+	assert.ErrorIs(t, err, ErrInitTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRunERunnerError(t *testing.T) {
+	runErr := errors.New("boom")
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithRunners(successfulRunner),
+			WithNamedRunner("worker", func(ctx context.Context) error { return runErr }),
+		)
+	})
+
+	var runnerErr *RunnerError
+	require.ErrorAs(t, err, &runnerErr)
+	assert.Equal(t, "worker", runnerErr.Name)
+	assert.ErrorIs(t, err, runErr)
+}
+
+func TestRunERunnerErrorDefaultName(t *testing.T) {
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(WithRunners(successfulRunner, failingRunner))
+	})
+
+	var runnerErr *RunnerError
+	require.ErrorAs(t, err, &runnerErr)
+	assert.Equal(t, "runner-1", runnerErr.Name)
+}
+
+func TestRunECleanupError(t *testing.T) {
+	var order []string
+	step := func(name string, err error) func(context.Context) error {
+		return func(context.Context) error {
+			order = append(order, name)
+			return err
+		}
+	}
+	closeErr := errors.New("close failed")
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithRunners(successfulRunner),
+			WithCleanup(step("cleanup", nil)),
+			WithCleanupStep("database", step("database", closeErr)),
+			WithCleanupStep("cache", step("cache", nil)),
+		)
+	})
+
+	assert.Equal(t, []string{"cleanup", "cache", "database"}, order, "steps should run in reverse registration order")
+
+	var cleanupErr *CleanupError
+	require.ErrorAs(t, err, &cleanupErr)
+	assert.Equal(t, "database", cleanupErr.Step)
+	assert.ErrorIs(t, err, closeErr)
+}
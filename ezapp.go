@@ -1,11 +1,28 @@
 package ezapp
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
+	"github.com/pgvanniekerk/ezapp/appstate"
+	"github.com/pgvanniekerk/ezapp/checkpoint"
+	"github.com/pgvanniekerk/ezapp/coordination"
+	"github.com/pgvanniekerk/ezapp/health"
 	"github.com/pgvanniekerk/ezapp/internal/app"
+	"github.com/pgvanniekerk/ezapp/internal/chaos"
 	"github.com/pgvanniekerk/ezapp/internal/config"
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+	"github.com/pgvanniekerk/ezapp/internal/trace"
+	"github.com/pgvanniekerk/ezapp/metrics"
+	"github.com/pgvanniekerk/ezapp/preflight"
+	"github.com/pgvanniekerk/ezapp/resources"
 	"log/slog"
+	"maps"
 	"os"
+	"slices"
+	"strconv"
+	"time"
 )
 
 // InitCtx provides the initialization context passed to an Initializer function.
@@ -23,21 +40,128 @@ type InitCtx[Config any] struct {
 
 	// Logger is a configured slog.Logger instance ready for use.
 	// The log level is controlled by the EZAPP_LOG_LEVEL environment variable
-	// (default: INFO). Supports DEBUG, INFO, WARN, ERROR.
+	// (default: INFO). Supports DEBUG, INFO, WARN, ERROR. EZAPP_LOG_LEVELS
+	// overrides the level of loggers created with Logger.With("component",
+	// name), and of records logged with a runner's context, by name. The
+	// logger is also carried by StartupCtx and the runner contexts, and is
+	// returned by LoggerFromContext.
 	Logger *slog.Logger
 
 	// Config contains the application configuration loaded from environment variables
 	// using the Netflix go-env package. The Config type should be a struct with
 	// appropriate `env` tags for field mapping.
 	Config Config
+
+	// ConfigHash is a deterministic SHA-256 hash of the resolved Config.
+	// Replicas that resolved identical configuration share the same hash,
+	// allowing fleet tooling to detect configuration drift. The hash is
+	// also logged at startup and reported in the health Info under
+	// ConfigHashKey.
+	ConfigHash string
+
+	// InstanceID identifies this run of the application among the replicas
+	// of a fleet and across restarts. It is generated at startup from the
+	// hostname, the PID and a random suffix, and is attached to every log
+	// record and to the health report under "instance_id", reported by the
+	// ezapp_instance_info metric and included in the ShutdownReport.
+	InstanceID string
+
+	// Environment is the deployment environment selected by the EZAPP_ENV
+	// environment variable (e.g. "staging"), or empty if none was selected.
+	// When set, variables prefixed with the upper-cased environment name
+	// (e.g. STAGING_PORT) override their base counterparts (PORT) before
+	// Config is populated, and every log record carries an "environment"
+	// attribute.
+	Environment string
+
+	// Health is the registry for dependency checks (DB pings, broker
+	// metadata, downstream HTTP calls). Register checks during
+	// initialization and serve the aggregated report by mounting
+	// Health.Handler() on an HTTP server, typically at /readyz. Components
+	// that are still initializing are reported with Health.Starting.
+	Health *health.Registry
+
+	// Runtime is a handle to the running application that allows runners to
+	// be added after startup. It can be retained and used once the
+	// application is running; calls made before then return ErrNotRunning.
+	Runtime *Runtime
+
+	// AppState is a key-value store for small state shared between runners,
+	// such as feature toggles or draining flags, with change notifications
+	// through AppState.OnChange. It replaces package-level variables.
+	AppState *appstate.Store
+
+	// Resources records open handles, such as files, sockets and temporary
+	// directories, held by components and runners. Resources that are still
+	// open once cleanup has completed are logged as "resource not released"
+	// and, with WithResourceRelease, released.
+	Resources *resources.Registry
+
+	// Metrics collects the application's metrics. It reports the lifecycle
+	// state and, unless WithoutRuntimeMetrics is used, Go runtime and
+	// process metrics; serve Metrics.Handler() at e.g. /metrics for
+	// Prometheus to scrape. Register application metrics with
+	// Metrics.Register.
+	Metrics *metrics.Registry
+
+	// WasUncleanShutdown reports whether the previous run did not shut down
+	// cleanly, as detected with WithCrashMarker, so that the initializer can
+	// run recovery logic.
+	WasUncleanShutdown bool
+
+	// Kubernetes identifies the pod the application runs in when
+	// WithKubernetesMetadata is used, and is empty otherwise.
+	Kubernetes KubernetesMetadata
+
+	// SelfTest reports whether the application is run by SelfTest, in which
+	// case the initializer should wire stub or ephemeral dependencies
+	// instead of real ones.
+	SelfTest bool
+
+	// environ is the snapshot returned by Environ.
+	environ EnvSnapshot
+}
+
+// Environ returns an immutable snapshot of the environment variables taken at
+// startup, from which Config was loaded. Use it for values that need custom
+// parsing rather than reading os.Getenv, so that tests can supply a fake
+// environment with ezapptest.WithEnviron.
+//
+// Example:
+//
+//	for _, key := range ctx.Environ().Keys() {
+//	    if name, ok := strings.CutPrefix(key, "FEATURE_"); ok {
+//	        flags[name] = ctx.Environ().Get(key) == "on"
+//	    }
+//	}
+func (c InitCtx[Config]) Environ() EnvSnapshot {
+	return c.environ
 }
 
 // AppCtx represents the application context containing all the runners
 // that will be executed concurrently by the application framework.
 // This is typically constructed using the Construct function with functional options.
 type AppCtx struct {
-	runnerList  []app.Runner
-	cleanupFunc func(shutdownCtx context.Context) error
+	runnerList   []app.Runner
+	runnerNames  []string
+	cleanupFunc  func(shutdownCtx context.Context) error
+	cleanupSteps []cleanupStep
+	stateHooks   []func(from, to State)
+
+	preShutdownHooks      []func(ctx context.Context) error
+	preShutdownPriorities []int
+	postRunHooks          []func(report ShutdownReport)
+	endpoints             []Endpoint
+	components            []component
+	shutdownTimeouts      map[string]time.Duration
+	unheldRunners         map[string]bool
+	independentCleanups   map[string]bool
+	componentInits        []componentInit
+	metricsExporters      []metricsExporter
+	warmups               []warmup
+	shutdownSlot          coordination.Semaphore
+	preflightChecks       []preflight.Check
+	smokeChecks           []smokeCheck
 }
 
 // Initializer is a function type that takes an InitCtx and returns an AppCtx.
@@ -84,11 +208,45 @@ type option func(*AppCtx) error
 //	appCtx, err := Construct(WithRunners(serverRunner, anotherRunner))
 func WithRunners(runners ...app.Runner) option {
 	return func(appCtx *AppCtx) error {
-		appCtx.runnerList = append(appCtx.runnerList, runners...)
+		for _, r := range runners {
+			appCtx.addRunner("", r)
+		}
 		return nil
 	}
 }
 
+// WithNamedRunner is a functional option that adds a single runner with a name.
+// The name identifies the runner in a RunnerError if it fails; runners added
+// with WithRunners are named after their position instead, e.g. "runner-0".
+//
+// Example:
+//
+//	appCtx, err := Construct(
+//	    WithNamedRunner("http", server.Run),
+//	    WithNamedRunner("queue-consumer", consumer.Run),
+//	)
+func WithNamedRunner(name string, r app.Runner) option {
+	return func(appCtx *AppCtx) error {
+		appCtx.addRunner(name, r)
+		return nil
+	}
+}
+
+// addRunner appends r to the runner list under the given name. An empty
+// name is resolved to the runner's position when the app is run.
+func (appCtx *AppCtx) addRunner(name string, r app.Runner) {
+	appCtx.runnerList = append(appCtx.runnerList, r)
+	appCtx.runnerNames = append(appCtx.runnerNames, name)
+}
+
+// runnerName returns the name of the runner at position idx.
+func (appCtx *AppCtx) runnerName(idx int) string {
+	if idx < len(appCtx.runnerNames) && appCtx.runnerNames[idx] != "" {
+		return appCtx.runnerNames[idx]
+	}
+	return "runner-" + strconv.Itoa(idx)
+}
+
 // WithCleanup is a functional option that sets a cleanup function for the AppCtx.
 // The cleanup function is called after all runners have completed, allowing for
 // graceful cleanup of resources like database connections, file handles, etc.
@@ -119,6 +277,39 @@ func WithCleanup(cleanupFunc func(shutdownCtx context.Context) error) option {
 	}
 }
 
+// WithPreShutdownHook is a functional option that registers a hook executed
+// once shutdown has been initiated (by SIGINT/SIGTERM, a failing runner, or
+// all runners returning) but before the runner contexts are cancelled.
+//
+// This allows the application to deregister from service discovery or mark
+// itself unhealthy at the load balancer while still serving in-flight and
+// newly routed traffic for a short while. Hooks run in reverse registration
+// order, like cleanup steps, unless ordered with WithPreShutdownHookPriority;
+// a failing hook is logged and does not prevent shutdown.
+//
+// The hook receives a context bounded by the shutdown timeout (controlled by
+// the EZAPP_SHUTDOWN_TIMEOUT environment variable, default 15 seconds).
+//
+// Example:
+//
+//	appCtx, err := Construct(
+//	    WithRunners(server.Run),
+//	    WithPreShutdownHook(func(ctx context.Context) error {
+//	        if err := registry.Deregister(ctx, instanceID); err != nil {
+//	            return err
+//	        }
+//	        // Give load balancers time to observe the deregistration
+//	        time.Sleep(5 * time.Second)
+//	        return nil
+//	    }),
+//	)
+func WithPreShutdownHook(hook func(ctx context.Context) error) option {
+	return func(appCtx *AppCtx) error {
+		appCtx.addPreShutdownHook(0, hook)
+		return nil
+	}
+}
+
 // Construct builds an AppCtx using the provided functional options.
 // This is the primary way to configure an application context with runners
 // and other configuration options.
@@ -149,6 +340,35 @@ func Construct(options ...option) (AppCtx, error) {
 		}
 	}
 
+	// Runner names identify runners at runtime, so they must be unique
+	seen := make(map[string]struct{}, len(appCtx.runnerList))
+	for _, name := range appCtx.RunnerNames() {
+		if _, exists := seen[name]; exists {
+			return AppCtx{}, fmt.Errorf("duplicate runner name %q", name)
+		}
+		seen[name] = struct{}{}
+	}
+	components := make(map[string]struct{}, len(appCtx.components))
+	for _, c := range appCtx.components {
+		components[c.name] = struct{}{}
+	}
+	for _, c := range appCtx.componentInits {
+		if _, exists := components[c.name]; !exists {
+			return AppCtx{}, fmt.Errorf("component %q of the init function is not registered", c.name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(appCtx.unheldRunners)) {
+		if _, exists := seen[name]; !exists {
+			return AppCtx{}, fmt.Errorf("unheld runner %q is not registered", name)
+		}
+	}
+	steps := appCtx.CleanupSteps()
+	for _, step := range slices.Sorted(maps.Keys(appCtx.independentCleanups)) {
+		if !slices.Contains(steps, step) {
+			return AppCtx{}, fmt.Errorf("independent cleanup step %q is not registered", step)
+		}
+	}
+
 	return appCtx, nil
 }
 
@@ -162,15 +382,28 @@ func Construct(options ...option) (AppCtx, error) {
 // 4. Invokes the provided initializer function to build the application
 // 5. Runs all configured runners concurrently with graceful shutdown
 // 6. Performs cleanup operations after all runners complete
+// 7. Reports the outcome to post-run hooks before exiting
 //
-// This function does not return - it handles all error cases by logging
-// and calling logger.Fatal() to terminate the application. It will block
-// until all runners complete successfully or an error occurs.
+// Run blocks until all runners complete successfully or an error occurs.
+// If the program is started with the --validate flag, Run validates the
+// wiring with ValidateWiring and returns instead of running the application;
+// --list-components additionally prints the description of the AppCtx and
+// --manifest the application's manifest (see Manifest). With the --self-test
+// flag, Run runs the application as a self-test with SelfTest.
+// Failures are logged and terminate the process with exit code 1; use RunE
+// to handle them instead.
 //
 // Environment Variables:
 //   - EZAPP_LOG_LEVEL: Controls logging verbosity (DEBUG, INFO, WARN, ERROR, etc.)
-//   - EZAPP_STARTUP_TIMEOUT: Timeout in seconds for initialization (default: 15)
-//   - EZAPP_SHUTDOWN_TIMEOUT: Timeout in seconds for graceful shutdown (default: 15)
+//   - EZAPP_LOG_LEVELS: Per-component log levels, e.g. "kafka=debug,http=warn"
+//   - EZAPP_ENV: Selects an environment overlay (e.g. staging reads STAGING_PORT over PORT)
+//   - EZAPP_STARTUP_TIMEOUT: Timeout for initialization, e.g. 30s (default: 15s, see WithStartupTimeout)
+//   - EZAPP_SHUTDOWN_TIMEOUT: Timeout for graceful shutdown, e.g. 30s (default: 15s, see WithShutdownTimeout)
+//   - EZAPP_SHUTDOWN_DEADLINE: Deadline split across the phases of shutdown, e.g. 25s (see WithShutdownDeadline)
+//   - EZAPP_STARTUP_BUDGET: Soft startup budget that only warns when exceeded, e.g. 5s (see WithStartupBudget)
+//   - EZAPP_WATCHDOG_GRACE: Time beyond the shutdown budgets before a hung shutdown is aborted (default: 10s, see WithWatchdogGrace)
+//   - EZAPP_CHAOS: Enables failure injection for resilience testing (see below)
+//   - EZAPP_DEV: Enables local development mode (see WithEndpoint)
 //   - Plus any variables defined in your Config struct
 //
 // Example:
@@ -185,72 +418,530 @@ func Construct(options ...option) (AppCtx, error) {
 //	        server := NewServer(ctx.Config.Port, ctx.Logger)
 //	        return ezapp.Construct(ezapp.WithRunners(server.Run))
 //	    })
-//	    // This point is never reached - Run() handles application lifecycle
+//	    // Run returns here after a clean shutdown, or after a successful
+//	    // --validate, --manifest, --list-components or --self-test run
 //	}
-func Run[Config any](initializer Initializer[Config]) {
+func Run[Config any](initializer Initializer[Config], options ...RunOption) {
+	run, options := runMode[Config](os.Args[1:], options)
+	if err := run(initializer, options...); err != nil {
+		os.Exit(1)
+	}
+}
 
-	// Load logger
+// runMode selects how Run runs the application from the command-line
+// arguments args: RunE by default, or ValidateWiring or SelfTest for their
+// flags, with the options the mode needs appended to options.
+func runMode[Config any](args []string, options []RunOption) (func(Initializer[Config], ...RunOption) error, []RunOption) {
+	switch {
+	case hasFlag(args, ListComponentsFlag):
+		return ValidateWiring[Config], append(slices.Clip(options), func(settings *runopt.Settings) {
+			settings.Describe = os.Stdout
+		})
+	case hasFlag(args, ManifestFlag):
+		return ValidateWiring[Config], append(slices.Clip(options), func(settings *runopt.Settings) {
+			settings.Manifest = os.Stdout
+		})
+	case hasFlag(args, ValidateFlag):
+		return ValidateWiring[Config], options
+	case hasFlag(args, SelfTestFlag):
+		return SelfTest[Config], options
+	}
+	return RunE[Config], options
+}
+
+// RunE runs the application like Run, but returns an error instead of
+// terminating the process when loading, initialization, a runner or cleanup
+// fails. The error has already been logged when it is returned.
+//
+// Example:
+//
+//	if err := ezapp.RunE(initializer); err != nil {
+//	    // handle the failure, e.g. report it before exiting
+//	}
+func RunE[Config any](initializer Initializer[Config], options ...RunOption) error {
+	startup := newStartupTimer()
+	var settings runopt.Settings
+	for _, opt := range options {
+		opt(&settings)
+	}
+
+	// In development mode, read variables from a local .env file before
+	// anything else consults the environment
+	devMode := config.DevMode()
+	var dotEnvErr error
+	if devMode {
+		_, dotEnvErr = config.LoadDotEnv(".env")
+	}
+
+	// Load logger, redacting what it logs if requested
 	logger := config.LoadLogger()
+	if settings.LogRedactor != nil {
+		logger = slog.New(&redactHandler{next: logger.Handler(), redact: settings.LogRedactor})
+	}
+	if dotEnvErr != nil {
+		logger.Warn("failed to load .env file", "error", dotEnvErr)
+	}
+
+	// Identify this run on every log record
+	instanceID := newInstanceID()
+	logger = logger.With(InstanceIDKey, instanceID)
+
+	// Record the selected environment on every log record
+	environment := config.Environment()
+	if environment != "" {
+		logger = logger.With("environment", environment)
+	}
 
-	// Load configuration from environment variables
-	cfg, err := config.LoadVar[Config]()
+	// Record the pod the application runs in if requested
+	var kubernetes KubernetesMetadata
+	if settings.KubernetesMetadata {
+		kubernetes = loadKubernetesMetadata()
+		logger = logger.With(kubernetes.logAttrs()...)
+	}
+	if settings.LoggerReady != nil {
+		settings.LoggerReady(logger)
+	}
+
+	// Load configuration from environment variables, or from those supplied
+	// through the run settings, and from the encrypted file given to
+	// WithSOPSFile. In development mode, missing required variables are
+	// reported rather than treated as fatal
+	environ := loadEnviron(settings.Environ)
+	if settings.SOPSFile != "" {
+		merged, added, err := mergeSOPSFile(environ, settings.SOPSFile, settings.SOPSKeys)
+		if err != nil {
+			logger.Error("failed to load configuration", "error", err)
+			return fmt.Errorf("%w: %w", ErrConfigLoad, err)
+		}
+		environ = merged
+		logger.Info("loaded encrypted configuration", "file", settings.SOPSFile, "variables", added)
+	}
+	loadOptions := []config.LoadOption{config.WithEnviron(environ)}
+	if devMode {
+		loadOptions = append(loadOptions, config.RelaxRequired(func(key string) {
+			logger.Warn("required environment variable not set, using zero value", "variable", key)
+		}))
+	}
+	cfg, err := loadConfig[Config](settings, loadOptions...)
 	if err != nil {
 		logger.Error("failed to load configuration", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("%w: %w", ErrConfigLoad, err)
+	}
+	if len(settings.StrictEnvPrefixes) > 0 {
+		if err := checkStrictEnv[Config](logger, environ, settings.StrictEnvPrefixes, devMode); err != nil {
+			logger.Error("failed to load configuration", "error", err)
+			return fmt.Errorf("%w: %w", ErrConfigLoad, err)
+		}
+	}
+
+	// Hash the resolved configuration for change detection
+	configHash, err := config.Hash(cfg)
+	if err != nil {
+		logger.Error("failed to hash configuration", "error", err)
+		return fmt.Errorf("failed to hash configuration: %w", err)
+	}
+	logger.Info("configuration loaded", ConfigHashKey, configHash)
+	if settings.ConfigSnapshot != "" && !settings.ValidateOnly {
+		logConfigChanges(logger, settings.ConfigSnapshot, config.NewSnapshot(cfg, configHash))
+	}
+
+	// Load chaos injection settings, which are only active when EZAPP_CHAOS is set
+	chaosCfg, err := chaos.Load()
+	if err != nil {
+		logger.Error("failed to load chaos configuration", "error", err)
+		return fmt.Errorf("failed to load chaos configuration: %w", err)
+	}
+	if chaosCfg.Enabled {
+		logger.Warn("chaos injection enabled",
+			"runner_failure_rate", chaosCfg.RunnerFailureRate,
+			"max_failure_delay", chaosCfg.MaxFailureDelay,
+			"cleanup_delay", chaosCfg.CleanupDelay,
+			"dropped_signals", chaosCfg.DroppedSignals,
+		)
 	}
 
-	// Create a startup context with timeout
-	startupCtx, err := config.StartupCtx()
+	// Create a startup context with timeout. A timeout supplied through
+	// WithStartupTimeout replaces the default but not EZAPP_STARTUP_TIMEOUT.
+	startupTimeout, err := config.ParseTimeout("EZAPP_STARTUP_TIMEOUT", cmp.Or(settings.StartupTimeout, config.DefaultStartupTimeout()))
 	if err != nil {
 		logger.Error("failed to create startup context", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to create startup context: %w", err)
+	}
+	startupCtx, cancelStartup := context.WithTimeout(context.Background(), startupTimeout)
+	defer cancelStartup()
+	metadata := AppMetadata{
+		InstanceID:  instanceID,
+		Environment: environment,
+		ConfigHash:  configHash,
+		Kubernetes:  kubernetes,
+	}
+	startupCtx = contextWithApp(startupCtx, logger, metadata)
+	if settings.CheckpointStore != nil {
+		startupCtx = checkpoint.WithStore(startupCtx, settings.CheckpointStore)
+	}
+
+	// Check the local clock against an NTP server if requested
+	if settings.ClockServer != "" && !settings.ValidateOnly {
+		if err := checkClock(startupCtx, logger, settings.ClockServer, settings.ClockMaxSkew); err != nil {
+			logger.Error("clock check failed", "error", err)
+			return err
+		}
+		startup.mark("clock")
+	}
+
+	// Resolve the shutdown timeout that bounds the pre-shutdown hooks and cleanup.
+	// A timeout supplied through WithShutdownTimeout replaces the default but not
+	// EZAPP_SHUTDOWN_TIMEOUT.
+	shutdownTimeout, err := config.ParseTimeout("EZAPP_SHUTDOWN_TIMEOUT", cmp.Or(settings.ShutdownTimeout, config.DefaultShutdownTimeout))
+	if err != nil {
+		logger.Error("failed to load shutdown timeout", "error", err)
+		return fmt.Errorf("failed to load shutdown timeout: %w", err)
+	}
+
+	// Resolve the deadline apportioned across the phases of shutdown. A
+	// deadline supplied through WithShutdownDeadline is used when
+	// EZAPP_SHUTDOWN_DEADLINE is not set.
+	shutdownDeadline, err := config.ParseTimeout("EZAPP_SHUTDOWN_DEADLINE", settings.ShutdownDeadline)
+	if err != nil {
+		logger.Error("failed to load shutdown deadline", "error", err)
+		return fmt.Errorf("failed to load shutdown deadline: %w", err)
+	}
+
+	// Resolve the soft startup budget. A budget supplied through
+	// WithStartupBudget is used when EZAPP_STARTUP_BUDGET is not set.
+	startup.budget, err = config.ParseTimeout("EZAPP_STARTUP_BUDGET", settings.StartupBudget)
+	if err != nil {
+		logger.Error("failed to load startup budget", "error", err)
+		return fmt.Errorf("failed to load startup budget: %w", err)
+	}
+
+	// Resolve the grace period of the shutdown watchdog. A grace supplied
+	// through WithWatchdogGrace is used when EZAPP_WATCHDOG_GRACE is not set.
+	grace, err := config.ParseTimeout("EZAPP_WATCHDOG_GRACE", watchdogGrace(settings))
+	if err != nil {
+		logger.Error("failed to load watchdog grace", "error", err)
+		return fmt.Errorf("failed to load watchdog grace: %w", err)
+	}
+	startup.mark("config")
+
+	// Create the health registry, reporting the instance ID, the config hash
+	// and the pod metadata if any
+	healthRegistry := health.NewRegistry()
+	healthRegistry.SetInfo(InstanceIDKey, instanceID)
+	healthRegistry.SetInfo(ConfigHashKey, configHash)
+	for _, field := range kubernetes.fields() {
+		healthRegistry.SetInfo(field[0], field[1])
+	}
+
+	// Record the lifecycle in a trace, and in hold mode, make runners wait
+	// to start until the application is released
+	rt := &Runtime{trace: trace.New(trace.DefaultSize)}
+	if settings.Hold || config.HoldMode() {
+		rt.hold = newHoldGate()
+	}
+
+	// Detect an unclean shutdown of the previous run. The marker is only
+	// cleared once the application has completed successfully
+	var uncleanShutdown bool
+	clearMarker := func() {}
+	if settings.CrashMarker != "" && !settings.ValidateOnly {
+		uncleanShutdown, clearMarker = markRunning(logger, settings.CrashMarker)
 	}
 
 	// Create initialization context
 	initCtx := InitCtx[Config]{
-		StartupCtx: startupCtx,
-		Logger:     logger,
-		Config:     cfg,
+		StartupCtx:  startupCtx,
+		Logger:      logger,
+		Config:      cfg,
+		ConfigHash:  configHash,
+		InstanceID:  instanceID,
+		Environment: environment,
+		Health:      healthRegistry,
+		Runtime:     rt,
+		AppState:    appstate.New(),
+		Resources:   resources.New(),
+		Metrics:     newMetricsRegistry(settings),
+		Kubernetes:  kubernetes,
+
+		WasUncleanShutdown: uncleanShutdown,
+		SelfTest:           settings.SelfTest,
+
+		environ: newEnvSnapshot(environ, environment),
 	}
 
 	// Invoke the initializer to get the app context
 	appCtx, err := initializer(initCtx)
+	if err != nil && errors.Is(startupCtx.Err(), context.DeadlineExceeded) {
+		logger.Error("initialization timed out", "error", err, "timeout", startupTimeout)
+		return fmt.Errorf("%w after %s: %w", ErrInitTimeout, startupTimeout, err)
+	}
 	if err != nil {
 		logger.Error("initialization failed", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("initialization failed: %w", err)
+	}
+
+	// Register the health checks of components implementing health.Healthy
+	// or registered with a check, such as databases
+	for _, c := range appCtx.components {
+		var (
+			registered bool
+			err        error
+		)
+		if c.check != nil {
+			registered, err = true, initCtx.Health.Register(c.name, c.check)
+		} else {
+			registered, err = initCtx.Health.RegisterComponent(c.name, c.value)
+		}
+		if err != nil {
+			logger.Error("failed to register component health check", "component", c.name, "error", err)
+			return fmt.Errorf("failed to register component health check: %w", err)
+		}
+		if registered {
+			logger.Debug("registered component health check", "component", c.name)
+		}
+	}
+
+	startup.mark("initializer")
+
+	// When only validating the wiring, release what the initializer
+	// acquired instead of running the app
+	if settings.ValidateOnly {
+		manifest := newManifest(config.Schema[Config](), appCtx, initCtx.Health)
+		return validated(logger, metadata, appCtx, manifest, settings, shutdownTimeout)
+	}
+
+	// Initialize the components, retrying until their dependencies are up
+	if err := runComponentInits(startupCtx, logger, appCtx); err != nil {
+		return err
+	}
+	if len(appCtx.componentInits) > 0 {
+		startup.mark("component init")
+	}
+
+	// Verify that critical dependencies are reachable before starting
+	if err := runPreflightChecks(startupCtx, logger, appCtx); err != nil {
+		return err
+	}
+	if len(appCtx.preflightChecks) > 0 {
+		startup.mark("preflight")
+	}
+
+	// Run the self-tests of the components when self-testing
+	if settings.SelfTest {
+		if err := runComponentSelfTests(startupCtx, logger, appCtx); err != nil {
+			return err
+		}
+	}
+
+	// Run the non-essential warmups unless recovering from a crash
+	runWarmups(startupCtx, logger, appCtx, uncleanShutdown)
+	if len(appCtx.warmups) > 0 {
+		startup.mark("warmups")
+	}
+
+	// Share the logger, the metadata, the checkpoint store and the Results
+	// that result runners record their values in with every runner and the
+	// cleanup through a base context, so they are attached once rather than
+	// per runner
+	results := &Results{}
+	baseCtx := context.WithValue(contextWithApp(context.Background(), logger, metadata), resultsKey{}, results)
+	if settings.CheckpointStore != nil {
+		baseCtx = checkpoint.WithStore(baseCtx, settings.CheckpointStore)
+	}
+	detached := &detachedWork{}
+	baseCtx = context.WithValue(baseCtx, detachedKey{}, detached)
+
+	// Split the shutdown deadline, if any, across the phases of shutdown
+	var budget *shutdownBudget
+	if shutdownDeadline > 0 {
+		hasHooks := len(appCtx.preShutdownHooks) > 0 || appCtx.shutdownSlot != nil
+		hasCleanup := appCtx.cleanupFunc != nil || len(appCtx.cleanupSteps) > 0
+		budget = newShutdownBudget(shutdownDeadline, hasHooks, hasCleanup)
+	}
+
+	// Apply chaos injection to runners, bound their shutdown, attribute
+	// their failures, give them their own logger and hold them in hold mode
+	wrap := func(name string, r app.Runner) app.Runner {
+		r = chaosCfg.WrapRunner(r)
+		if timeout, ok := appCtx.runnerShutdownTimeout(name, shutdownTimeout); ok || budget != nil {
+			r = stopWithin(logger, name, func() time.Duration {
+				if budget == nil {
+					return timeout
+				}
+				if ok {
+					return min(timeout, budget.remaining(phaseRunners))
+				}
+				return budget.remaining(phaseRunners)
+			}, r)
+		}
+		r = nameRunner(name, withLogger(logger.With("runner", name), r))
+		if rt.hold != nil && !appCtx.unheldRunners[name] {
+			r = rt.hold.wrap(r)
+		}
+		return r
+	}
+	runnerNames := appCtx.RunnerNames()
+	runnerList := make([]app.Runner, 0, len(appCtx.runnerList))
+	for idx, r := range appCtx.runnerList {
+		runnerList = append(runnerList, wrap(runnerNames[idx], r))
+	}
+
+	// Print a banner summarising the app for local runs
+	if devMode {
+		printDevBanner(os.Stderr, devBanner{
+			Environment: environment,
+			ConfigHash:  configHash,
+			Runners:     len(runnerList),
+			Endpoints:   appCtx.endpoints,
+		})
 	}
 
 	// Create and run the app
-	application := app.New(appCtx.runnerList, logger)
+	appOptions := []app.Option{
+		app.WithRunnerNames(runnerNames),
+		app.WithBaseContext(baseCtx),
+		app.WithIgnoredSignals(chaosCfg.DroppedSignals),
+		app.WithPreShutdownTimeout(shutdownTimeout),
+		app.WithTrace(rt.trace),
+	}
+	appOptions = append(appOptions, app.WithTransitionHook(func(from, to State) {
+		if to == StateRunning {
+			startup.finish(logger, "runners")
+		}
+	}))
+	var application *app.App
+	var smokeTest *selfTest
+	if settings.SelfTest {
+		// Run the smoke checks once the runners have started, then stop
+		smokeTest = newSelfTest()
+		appOptions = append(appOptions, app.WithTransitionHook(func(from, to State) {
+			if to == StateRunning {
+				ctx, cancel := context.WithTimeout(contextWithApp(context.Background(), logger, metadata), startupTimeout)
+				smokeTest.start(ctx, logger, initCtx.Health, appCtx.smokeChecks, func() {
+					cancel()
+					application.Shutdown()
+				})
+			}
+		}))
+	}
+	var dog *watchdog
+	if grace > 0 {
+		// Abort the process if the runners hang once the pre-shutdown
+		// hooks and the runners have used up their budgets
+		dog = newWatchdog(logger, budget.watchdogBudget(2*shutdownTimeout)+grace, rt.Trace)
+		appOptions = append(appOptions, app.WithTransitionHook(func(from, to State) {
+			if to == StateDraining {
+				dog.arm()
+			}
+		}))
+	}
+	if budget != nil {
+		appOptions = append(appOptions, app.WithTransitionHook(func(from, to State) {
+			if to == StateDraining {
+				budget.start()
+			}
+		}))
+	}
+	for _, hook := range appCtx.stateHooks {
+		appOptions = append(appOptions, app.WithTransitionHook(hook))
+	}
+	preShutdownHooks := appCtx.orderedPreShutdownHooks()
+	releaseSlot := func(time.Duration) {}
+	if appCtx.shutdownSlot != nil {
+		var acquireSlot func(ctx context.Context) error
+		acquireSlot, releaseSlot = shutdownSlot(logger, appCtx.shutdownSlot)
+		preShutdownHooks = append([]func(ctx context.Context) error{acquireSlot}, preShutdownHooks...)
+	}
+	for _, hook := range preShutdownHooks {
+		appOptions = append(appOptions, app.WithPreShutdownHook(budget.hook(withAppContext(logger, metadata, withCheckpointStore(settings.CheckpointStore, hook)))))
+	}
+	application = app.New(runnerList, logger, appOptions...)
+	initCtx.Metrics.Register(lifecycleCollector(application, instanceID))
+	initCtx.Metrics.Register(startup.collector())
+	initCtx.Runtime.attach(application, wrap)
+	if rt.Held() {
+		logger.Info("runners held until released", "runners", appCtx.RunnerNames())
+		stopRelease := releaseOnSignal(logger, rt.hold)
+		defer stopRelease()
+	}
+	stopMetricsExport := startMetricsExport(logger, initCtx.Metrics, appCtx.metricsExporters, shutdownTimeout)
+	startedAt := time.Now()
 	appErr := application.Run()
+	dog.stop()
+	stopMetricsExport()
+	if smokeTest != nil {
+		appErr = errors.Join(appErr, smokeTest.wait())
+	}
+
+	// Wait for the work detached by the runners, which may still need the
+	// resources released by cleanup
+	detachedCtx, cancelDetached := context.WithTimeout(baseCtx, shutdownTimeout)
+	detachedCtx, cancelPhase := budget.context(detachedCtx, phaseCleanup)
+	waitDetached(detachedCtx, logger, detached)
+	cancelPhase()
+	cancelDetached()
 
 	// After app completes, run cleanup if provided
-	if appCtx.cleanupFunc != nil {
+	var cleanupErr error
+	if appCtx.cleanupFunc != nil || len(appCtx.cleanupSteps) > 0 {
 
-		// Create a shutdown context with the configured timeout
-		shutdownCtx, err := config.ShutdownCtx()
-		if err != nil {
-			logger.Error("failed to create shutdown context", "error", err)
-			os.Exit(1)
-		}
+		// Create a shutdown context with the configured timeout, carrying
+		// the logger and the results of the result runners
+		shutdownCtx, cancelShutdown := context.WithTimeout(baseCtx, shutdownTimeout)
+		shutdownCtx, cancelPhase := budget.context(shutdownCtx, phaseCleanup)
 
-		// Run cleanup function
-		if cleanupErr := appCtx.cleanupFunc(shutdownCtx); cleanupErr != nil {
+		// Run cleanup function and steps
+		chaosCfg.DelayCleanup(shutdownCtx)
+		cleanupErr = appCtx.cleanup(shutdownCtx, rt.trace)
+		cancelPhase()
+		cancelShutdown()
+		if cleanupErr != nil {
 			logger.Error("cleanup failed", "error", cleanupErr)
-			// If the app ran successfully but cleanup failed, fatal exit
-			if appErr == nil {
-				logger.Error("application cleanup failed", "error", cleanupErr)
-				os.Exit(1)
-			}
 		}
 	}
+	releaseSlot(shutdownTimeout)
+	unreleased := reportResources(logger, initCtx.Resources, settings.ReleaseResources)
+	application.Finish(errors.Join(appErr, cleanupErr))
 
-	// If the app failed, fatal exit
+	// Dump the lifecycle trace if the application failed, and capture
+	// post-mortem profiles if it failed abnormally
+	lifecycle := rt.Trace()
+	if appErr != nil || cleanupErr != nil {
+		logTrace(logger, lifecycle)
+	}
+	var profiles []string
+	if settings.ProfileDir != "" && abnormalExit(appErr, cleanupErr) {
+		profiles = captureProfiles(logger, settings.ProfileDir, instanceID)
+	}
+
+	// Report the outcome to the post-run hooks before exiting
+	runPostRunHooks(logger, appCtx.postRunHooks, ShutdownReport{
+		InstanceID: instanceID,
+		State:      application.State(),
+		StartedAt:  startedAt,
+		StoppedAt:  time.Now(),
+		RunErr:     appErr,
+		CleanupErr: cleanupErr,
+		Results:    results,
+		Profiles:   profiles,
+		Trace:      lifecycle,
+		Unreleased: unreleased,
+	}, PostRunHookTimeout)
+
+	// If the app ran successfully but cleanup failed, report the cleanup failure
+	if cleanupErr != nil && appErr == nil {
+		logger.Error("application cleanup failed", "error", cleanupErr)
+		return fmt.Errorf("application cleanup failed: %w", cleanupErr)
+	}
+
+	// If the app failed, report the failure
 	if appErr != nil {
 		logger.Error("application failed", "error", appErr)
-		os.Exit(1)
+		return fmt.Errorf("application failed: %w", errors.Join(appErr, cleanupErr))
 	}
 
 	// Application completed successfully
+	clearMarker()
 	logger.Info("application completed successfully")
+	return nil
 }
@@ -3,9 +3,11 @@ package ezapp
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/pgvanniekerk/ezapp/internal/app"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -14,7 +16,7 @@ import (
 type TestConfig struct {
 	Port        int    `env:"PORT" default:"8080"`
 	DatabaseURL string `env:"DATABASE_URL" default:"test://localhost"`
-	TestValue   string `env:"TEST_VALUE" default:"test"`
+	TestValue   string `env:"TEST_VALUE" default:"test" resolve:"true"`
 }
 
 
@@ -249,10 +251,16 @@ func TestInitCtxPopulation(t *testing.T) {
 		// Verify InitCtx was populated correctly
 		assert.NotNil(t, capturedInitCtx.StartupCtx, "StartupCtx should not be nil")
 		assert.NotNil(t, capturedInitCtx.Logger, "Logger should not be nil")
+		assert.NotNil(t, capturedInitCtx.Health, "Health registry should not be nil")
+		assert.NotNil(t, capturedInitCtx.Runtime, "Runtime should not be nil")
+		assert.NotNil(t, capturedInitCtx.AppState, "AppState should not be nil")
 		
 		// Verify context has timeout
 		_, hasDeadline := capturedInitCtx.StartupCtx.Deadline()
 		assert.True(t, hasDeadline, "StartupCtx should have deadline")
+
+		// Verify config hash is populated
+		assert.Len(t, capturedInitCtx.ConfigHash, 64, "ConfigHash should be a SHA-256 hex digest")
 		
 		// Verify config is populated (even if with zero values)
 		// The fact that we got here means config loading succeeded
@@ -263,22 +271,139 @@ func TestInitCtxPopulation(t *testing.T) {
 	}
 }
 
-/*
-NOTE: The following tests cannot be implemented because they would trigger logger.Fatal() 
-which calls os.Exit() and terminates the test process. To properly test these scenarios,
-we would need:
-1. Dependency injection to mock the logger
-2. Process isolation (running tests in separate processes)
-3. A testable version of Run() that doesn't call Fatal
-
-The scenarios we cannot test directly:
-- TestRunConfigurationLoadingFailure (invalid config struct)
-- TestRunStartupContextFailure (invalid EZAPP_STARTUP_TIMEOUT)
-- TestRunInitializerFailure (initializer returns error)
-- TestRunApplicationFailure (runner returns error)
-- TestRunCleanupFailure (cleanup function returns error)
-- TestRunCleanupWithApplicationFailure (both app and cleanup fail)
-
-These scenarios are covered by the logic in the Run function and would call logger.Fatal()
-with appropriate error messages, but cannot be tested without process termination.
-*/
\ No newline at end of file
+// TestRunEInitializerFailure tests that RunE reports initializer failures
+// This test verifies that:
+// - The error returned by the initializer is wrapped and returned
+// - No runners are started
+func TestRunEInitializerFailure(t *testing.T) {
+	initErr := errors.New("initialization failed")
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return AppCtx{}, initErr
+	})
+
+	assert.ErrorIs(t, err, initErr)
+}
+
+// TestRunEApplicationFailure tests that RunE reports runner and cleanup failures
+// This test verifies that:
+// - A failing runner is reported through the returned error
+// - A failing cleanup is reported alongside the runner error
+func TestRunEApplicationFailure(t *testing.T) {
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(WithRunners(failingRunner), WithCleanup(failingCleanup))
+	})
+
+	assert.ErrorContains(t, err, "runner failed")
+	assert.ErrorContains(t, err, "cleanup failed")
+}
+
+// TestRunECleanupFailure tests that RunE reports a cleanup failure after a successful run
+func TestRunECleanupFailure(t *testing.T) {
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(WithRunners(successfulRunner), WithCleanup(failingCleanup))
+	})
+
+	assert.ErrorContains(t, err, "application cleanup failed")
+}
+
+// TestRunEStartupContextFailure tests that RunE reports an invalid startup timeout
+func TestRunEStartupContextFailure(t *testing.T) {
+	t.Setenv("EZAPP_STARTUP_TIMEOUT", "not-a-number")
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		t.Fatal("initializer should not be invoked")
+		return AppCtx{}, nil
+	})
+
+	assert.ErrorContains(t, err, "failed to create startup context")
+}
+
+// TestRunWithStateHook tests that state hooks observe the full lifecycle
+// This test verifies that:
+// - Hooks registered with WithStateHook are called for every transition
+// - A successful run ends in StateStopped
+func TestRunWithStateHook(t *testing.T) {
+	var states []State
+
+	initializer := func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithRunners(successfulRunner),
+			WithStateHook(func(from, to State) {
+				states = append(states, to)
+			}),
+		)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Run(initializer)
+	}()
+
+	select {
+	case <-done:
+		assert.Equal(t, []State{StateStarting, StateRunning, StateDraining, StateStopping, StateStopped}, states)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not complete within timeout")
+	}
+}
+
+// TestRunWithPreShutdownHook tests that pre-shutdown hooks are executed
+// This test verifies that:
+// - Hooks registered with WithPreShutdownHook run during shutdown
+// - The application is draining while the hook runs
+func TestRunWithPreShutdownHook(t *testing.T) {
+	var hookState State
+
+	initializer := func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		var current State
+		return Construct(
+			WithRunners(successfulRunner),
+			WithStateHook(func(from, to State) {
+				current = to
+			}),
+			WithPreShutdownHook(func(ctx context.Context) error {
+				hookState = current
+				return nil
+			}),
+		)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Run(initializer)
+	}()
+
+	select {
+	case <-done:
+		assert.Equal(t, StateDraining, hookState, "Pre-shutdown hook should run while draining")
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not complete within timeout")
+	}
+}
+
+// BenchmarkRunE measures the framework's overhead per run, including wrapping
+// and starting runners that return immediately
+func BenchmarkRunE(b *testing.B) {
+	b.Setenv("EZAPP_LOG_LEVEL", "ERROR")
+	for _, count := range []int{1, 100, 1000} {
+		runners := make([]app.Runner, count)
+		for idx := range runners {
+			runners[idx] = func(ctx context.Context) error { return nil }
+		}
+		initializer := func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+			return Construct(WithRunners(runners...))
+		}
+
+		b.Run(fmt.Sprintf("runners=%d", count), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if err := RunE(initializer, WithoutRuntimeMetrics()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
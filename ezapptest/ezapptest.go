@@ -0,0 +1,50 @@
+// Package ezapptest provides helpers for testing applications built with ezapp.
+package ezapptest
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/pgvanniekerk/ezapp"
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+)
+
+// WithConfig returns a run option that passes cfg to the initializer instead
+// of loading the configuration from environment variables, so tests do not
+// need to set and restore process environment variables.
+//
+// The type of cfg must match the Config type parameter of the run; otherwise
+// RunE returns an error before the initializer is invoked.
+//
+// Example:
+//
+//	err := ezapp.RunE(initializer, ezapptest.WithConfig(MyConfig{
+//	    Port:        0,
+//	    DatabaseURL: "postgres://localhost/test",
+//	}))
+func WithConfig[Config any](cfg Config) ezapp.RunOption {
+	return func(settings *runopt.Settings) {
+		settings.Config = cfg
+	}
+}
+
+// WithEnviron returns a run option that loads the configuration from vars
+// instead of the process environment, and makes InitCtx.Environ return them,
+// so tests can run against a fake environment. Framework variables such as
+// EZAPP_LOG_LEVEL are still read from the process environment.
+//
+// Example:
+//
+//	err := ezapp.RunE(initializer, ezapptest.WithEnviron(map[string]string{
+//	    "PORT":           "0",
+//	    "FEATURE_SEARCH": "on",
+//	}))
+func WithEnviron(vars map[string]string) ezapp.RunOption {
+	environ := make([]string, 0, len(vars))
+	for _, key := range slices.Sorted(maps.Keys(vars)) {
+		environ = append(environ, key+"="+vars[key])
+	}
+	return func(settings *runopt.Settings) {
+		settings.Environ = environ
+	}
+}
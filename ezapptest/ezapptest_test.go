@@ -0,0 +1,61 @@
+package ezapptest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pgvanniekerk/ezapp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testConfig struct {
+	Port        int    `env:"EZAPPTEST_PORT,required=true"`
+	DatabaseURL string `env:"EZAPPTEST_DATABASE_URL" default:"env://localhost"`
+}
+
+func TestWithConfig(t *testing.T) {
+	want := testConfig{Port: 9000, DatabaseURL: "test://localhost"}
+
+	var got testConfig
+	err := ezapp.RunE(func(ctx ezapp.InitCtx[testConfig]) (ezapp.AppCtx, error) {
+		got = ctx.Config
+		return ezapp.Construct(ezapp.WithRunners(func(ctx context.Context) error {
+			return nil
+		}))
+	}, WithConfig(want))
+
+	require.NoError(t, err, "required variables must not be consulted")
+	assert.Equal(t, want, got)
+}
+
+func TestWithConfigTypeMismatch(t *testing.T) {
+	initialized := false
+	err := ezapp.RunE(func(ctx ezapp.InitCtx[testConfig]) (ezapp.AppCtx, error) {
+		initialized = true
+		return ezapp.Construct()
+	}, WithConfig(struct{ Other string }{}))
+
+	assert.ErrorContains(t, err, "failed to load configuration")
+	assert.False(t, initialized, "initializer should not be invoked")
+}
+
+func TestWithEnviron(t *testing.T) {
+	t.Setenv("EZAPPTEST_DATABASE_URL", "env://process")
+
+	var got testConfig
+	var environ ezapp.EnvSnapshot
+	err := ezapp.RunE(func(ctx ezapp.InitCtx[testConfig]) (ezapp.AppCtx, error) {
+		got = ctx.Config
+		environ = ctx.Environ()
+		return ezapp.Construct()
+	}, WithEnviron(map[string]string{
+		"EZAPPTEST_PORT":    "9000",
+		"EZAPPTEST_FEATURE": "on",
+	}))
+
+	require.NoError(t, err)
+	assert.Equal(t, testConfig{Port: 9000}, got, "the process environment must not be consulted")
+	assert.Equal(t, []string{"EZAPPTEST_FEATURE", "EZAPPTEST_PORT"}, environ.Keys())
+	assert.Equal(t, "on", environ.Get("EZAPPTEST_FEATURE"))
+}
@@ -0,0 +1,207 @@
+// Package grpcrunner runs a grpc.Server as an ezapp runner, with default
+// interceptors recovering from panics, enforcing deadlines and reporting
+// calls to the application's logs, metrics and traces.
+package grpcrunner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/loadshed"
+	"google.golang.org/grpc"
+)
+
+// DefaultShutdownTimeout bounds grpc.Server.GracefulStop when the runner's
+// context is cancelled and no timeout is set with WithShutdownTimeout.
+const DefaultShutdownTimeout = 10 * time.Second
+
+// Server runs a grpc.Server as a runner. Services are registered on it
+// directly, as it implements grpc.ServiceRegistrar, and every call passes
+// through the interceptors described at New.
+type Server struct {
+	addr            string
+	listener        net.Listener
+	logger          *slog.Logger
+	shutdownTimeout time.Duration
+	defaultDeadline time.Duration
+	requestID       bool
+	accessLog       bool
+	tracing         bool
+	shedder         *loadshed.Shedder
+	serverOptions   []grpc.ServerOption
+	server          *grpc.Server
+
+	inFlight atomic.Int64
+	panics   atomic.Int64
+	callsMu  sync.Mutex
+	calls    map[callKey]*callStats
+}
+
+// serverOption represents a functional option for configuring a Server.
+// This type is not exported to ensure only predefined options can be used.
+type serverOption func(*Server)
+
+// WithListener serves on listener, e.g. one returned by ezapp.Listen,
+// instead of listening on the server's address.
+func WithListener(listener net.Listener) serverOption {
+	return func(s *Server) {
+		s.listener = listener
+	}
+}
+
+// WithLogger sets the logger that calls, panics and shutdown progress are
+// reported to, instead of slog.Default.
+func WithLogger(logger *slog.Logger) serverOption {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// WithShutdownTimeout bounds grpc.Server.GracefulStop once the runner's
+// context is cancelled, instead of DefaultShutdownTimeout.
+func WithShutdownTimeout(timeout time.Duration) serverOption {
+	return func(s *Server) {
+		s.shutdownTimeout = timeout
+	}
+}
+
+// WithLoadShedder admits calls through shedder, rejecting those over its
+// limit with ResourceExhausted, or Unavailable while it is under pressure
+// (see loadshed.UnaryServerInterceptor). Rejected calls are counted and
+// logged like others.
+func WithLoadShedder(shedder *loadshed.Shedder) serverOption {
+	return func(s *Server) {
+		s.shedder = shedder
+	}
+}
+
+// WithServerOptions passes options, such as credentials or further
+// interceptors, on to grpc.NewServer. Interceptors added this way run inside
+// the default ones, so they see the call's logger and request ID.
+func WithServerOptions(options ...grpc.ServerOption) serverOption {
+	return func(s *Server) {
+		s.serverOptions = append(s.serverOptions, options...)
+	}
+}
+
+// New returns a Server listening on addr. Its grpc.Server is created with a
+// unary and a stream interceptor that, for every call:
+//
+//   - reject it with DeadlineExceeded if its deadline has already passed,
+//     and bound it with WithDefaultDeadline if the client set none;
+//   - attach the server's logger, returned by ezapp.LoggerFromContext, and,
+//     with WithRequestID, the request ID to its context;
+//   - with WithTracing, record it in a span continuing the caller's trace;
+//   - recover from a panic in the handler, logging it with its stack and
+//     returning Internal instead of crashing the application;
+//   - count it by method and status code for Collector and, with
+//     WithAccessLog, log it;
+//   - with WithLoadShedder, admit it through the shedder.
+//
+// Example:
+//
+//	server := grpcrunner.New(":9090",
+//	    grpcrunner.WithLogger(ctx.Logger),
+//	    grpcrunner.WithRequestID(),
+//	    grpcrunner.WithAccessLog(),
+//	    grpcrunner.WithTracing(),
+//	)
+//	pb.RegisterOrdersServer(server, orders)
+//	ctx.Metrics.Register(server.Collector())
+//	return ezapp.Construct(ezapp.WithNamedRunner("grpc", server.Run))
+func New(addr string, options ...serverOption) *Server {
+	s := &Server{
+		addr:            addr,
+		logger:          slog.Default(),
+		shutdownTimeout: DefaultShutdownTimeout,
+		calls:           make(map[callKey]*callStats),
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+
+	unary := []grpc.UnaryServerInterceptor{s.unaryInterceptor}
+	stream := []grpc.StreamServerInterceptor{s.streamInterceptor}
+	if s.shedder != nil {
+		unary = append(unary, loadshed.UnaryServerInterceptor(s.shedder))
+		stream = append(stream, loadshed.StreamServerInterceptor(s.shedder))
+	}
+	serverOptions := append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}, s.serverOptions...)
+	s.server = grpc.NewServer(serverOptions...)
+	return s
+}
+
+// RegisterService registers a service and its implementation with the
+// underlying grpc.Server, so that generated registration functions accept
+// the Server.
+func (s *Server) RegisterService(desc *grpc.ServiceDesc, impl any) {
+	s.server.RegisterService(desc, impl)
+}
+
+// GRPCServer returns the underlying grpc.Server, e.g. to register the
+// reflection or health services.
+func (s *Server) GRPCServer() *grpc.Server {
+	return s.server
+}
+
+// InFlight returns the number of calls currently being handled.
+func (s *Server) InFlight() int64 {
+	return s.inFlight.Load()
+}
+
+// Run serves until ctx is cancelled, then stops the server gracefully: new
+// calls are refused and Run waits for the calls in flight to complete. If
+// they have not completed within the shutdown timeout, their connections
+// are closed. It returns an error if the server fails to listen or serve.
+func (s *Server) Run(ctx context.Context) error {
+	listener := s.listener
+	if listener == nil {
+		var err error
+		if listener, err = net.Listen("tcp", s.addr); err != nil {
+			return fmt.Errorf("grpc server failed: %w", err)
+		}
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("grpc server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	s.logger.Info("stopping grpc server", "in_flight", s.InFlight())
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	timer := time.NewTimer(s.shutdownTimeout)
+	defer timer.Stop()
+	select {
+	case <-stopped:
+		s.logger.Info("stopped grpc server")
+	case <-timer.C:
+		s.logger.Warn("grpc graceful stop timed out, closing connections", "in_flight", s.InFlight())
+		s.server.Stop()
+		<-stopped
+	}
+
+	if err := <-serveErr; err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return fmt.Errorf("grpc server failed: %w", err)
+	}
+	return nil
+}
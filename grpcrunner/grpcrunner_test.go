@@ -0,0 +1,454 @@
+package grpcrunner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/httprunner"
+	"github.com/pgvanniekerk/ezapp/internal/logctx"
+	"github.com/pgvanniekerk/ezapp/internal/requestid"
+	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/pgvanniekerk/ezapp/loadshed"
+	"github.com/pgvanniekerk/ezapp/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// echo implements the test.Echo service: Echo returns the result of
+// handle, and Count streams the request ID of the call three times.
+type echo struct {
+	handle func(ctx context.Context, in string) (string, error)
+}
+
+var echoDesc = grpc.ServiceDesc{
+	ServiceName: "test.Echo",
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Echo",
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			in := &wrapperspb.StringValue{}
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/test.Echo/Echo"}
+			return interceptor(ctx, in, info, func(ctx context.Context, req any) (any, error) {
+				out, err := srv.(*echo).handle(ctx, req.(*wrapperspb.StringValue).GetValue())
+				return wrapperspb.String(out), err
+			})
+		},
+	}},
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "Count",
+		ServerStreams: true,
+		Handler: func(_ any, stream grpc.ServerStream) error {
+			if _, ok := logctx.From(stream.Context()); !ok {
+				return errors.New("stream context carries no logger")
+			}
+			for range 3 {
+				if err := stream.SendMsg(wrapperspb.String(requestid.From(stream.Context()))); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}},
+}
+
+// startServer runs a Server for impl on an in-memory listener until the test
+// ends and returns it with a client connection to it.
+func startServer(t *testing.T, impl *echo, options []serverOption, dialOptions ...grpc.DialOption) (*Server, *grpc.ClientConn) {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := New("", append([]serverOption{WithListener(listener)}, options...)...)
+	server.RegisterService(&echoDesc, impl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	return server, dial(t, listener, dialOptions...)
+}
+
+// dial returns a client connection to listener, closed when the test ends.
+func dial(t *testing.T, listener *bufconn.Listener, dialOptions ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+	dialOptions = append(dialOptions,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	conn, err := grpc.NewClient("passthrough:///bufconn", dialOptions...)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	return conn
+}
+
+func callEcho(ctx context.Context, conn *grpc.ClientConn, in string, opts ...grpc.CallOption) (string, error) {
+	out := &wrapperspb.StringValue{}
+	err := conn.Invoke(ctx, "/test.Echo/Echo", wrapperspb.String(in), out, opts...)
+	return out.GetValue(), err
+}
+
+func callCount(ctx context.Context, conn *grpc.ClientConn) ([]string, error) {
+	stream, err := conn.NewStream(ctx, &echoDesc.Streams[0], "/test.Echo/Count")
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(&wrapperspb.StringValue{}); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	var ids []string
+	for {
+		out := &wrapperspb.StringValue{}
+		if err := stream.RecvMsg(out); errors.Is(err, io.EOF) {
+			return ids, nil
+		} else if err != nil {
+			return ids, err
+		}
+		ids = append(ids, out.GetValue())
+	}
+}
+
+// sample returns the value of the sample named name with the given labels.
+func sample(t *testing.T, collector metrics.Collector, name string, labels map[string]string) float64 {
+	t.Helper()
+	for _, s := range collector() {
+		if s.Name == name && (labels == nil || assert.ObjectsAreEqual(labels, s.Labels)) {
+			return s.Value
+		}
+	}
+	t.Fatalf("no sample %s with labels %v", name, labels)
+	return 0
+}
+
+func TestServerRequestIDAndAccessLog(t *testing.T) {
+	logger, records := testutil.NewTestLogger(slog.LevelInfo)
+	impl := &echo{handle: func(ctx context.Context, in string) (string, error) {
+		callLogger, ok := logctx.From(ctx)
+		if !ok {
+			return "", errors.New("call context carries no logger")
+		}
+		callLogger.Info("handling")
+		return requestid.From(ctx), nil
+	}}
+	server, conn := startServer(t, impl, []serverOption{WithLogger(logger), WithRequestID(), WithAccessLog()})
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), RequestIDMetadata, "upstream-42")
+	out, err := callEcho(ctx, conn, "hello", grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, "upstream-42", out)
+	assert.Equal(t, []string{"upstream-42"}, header.Get(RequestIDMetadata))
+	id, ok := records.Attr("handling", "request_id")
+	require.True(t, ok)
+	assert.Equal(t, "upstream-42", id.String())
+
+	code, ok := records.Attr("grpc call", "code")
+	require.True(t, ok)
+	assert.Equal(t, "OK", code.String())
+	method, _ := records.Attr("grpc call", "method")
+	assert.Equal(t, "/test.Echo/Echo", method.String())
+
+	out, err = callEcho(context.Background(), conn, "hello")
+	require.NoError(t, err)
+	assert.Len(t, out, 32, "a call without an ID should be assigned one")
+
+	assert.Equal(t, float64(2), sample(t, server.Collector(), "grpc_server_handled_total", map[string]string{"method": "/test.Echo/Echo", "code": "OK"}))
+	assert.Equal(t, float64(0), sample(t, server.Collector(), "grpc_server_in_flight_calls", nil))
+}
+
+func TestServerStream(t *testing.T) {
+	_, conn := startServer(t, &echo{}, []serverOption{WithRequestID()})
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), RequestIDMetadata, "stream-7")
+	ids, err := callCount(ctx, conn)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stream-7", "stream-7", "stream-7"}, ids, "the stream's context should carry the request ID")
+}
+
+func TestServerRecoversPanic(t *testing.T) {
+	logger, records := testutil.NewTestLogger(slog.LevelInfo)
+	impl := &echo{handle: func(_ context.Context, in string) (string, error) {
+		if in == "panic" {
+			panic("nil map write")
+		}
+		return in, nil
+	}}
+	server, conn := startServer(t, impl, []serverOption{WithLogger(logger), WithAccessLog()})
+
+	_, err := callEcho(context.Background(), conn, "panic")
+	assert.Equal(t, codes.Internal, status.Code(err))
+	panicValue, ok := records.Attr("grpc handler panicked", "panic")
+	require.True(t, ok)
+	assert.Equal(t, "nil map write", panicValue.String())
+	stack, _ := records.Attr("grpc handler panicked", "stack")
+	assert.Contains(t, stack.String(), "grpcrunner")
+	code, _ := records.Attr("grpc call", "code")
+	assert.Equal(t, "Internal", code.String())
+
+	out, err := callEcho(context.Background(), conn, "still serving")
+	require.NoError(t, err)
+	assert.Equal(t, "still serving", out)
+
+	assert.Equal(t, float64(1), sample(t, server.Collector(), "grpc_server_panics_total", nil))
+	assert.Equal(t, float64(1), sample(t, server.Collector(), "grpc_server_handled_total", map[string]string{"method": "/test.Echo/Echo", "code": "Internal"}))
+}
+
+func TestServerDefaultDeadline(t *testing.T) {
+	impl := &echo{handle: func(ctx context.Context, _ string) (string, error) {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return "none", nil
+		}
+		return time.Until(deadline).Round(time.Minute).String(), nil
+	}}
+	_, conn := startServer(t, impl, []serverOption{WithDefaultDeadline(time.Hour)})
+
+	out, err := callEcho(context.Background(), conn, "")
+	require.NoError(t, err)
+	assert.Equal(t, "1h0m0s", out, "a call without a deadline should get the default one")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	out, err = callEcho(ctx, conn, "")
+	require.NoError(t, err)
+	assert.Equal(t, "10m0s", out, "the client's deadline should be kept")
+}
+
+func TestServerDeadlineExceeded(t *testing.T) {
+	server := New("")
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Echo/Echo"}
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	called := false
+	_, err := server.unaryInterceptor(expired, nil, info, func(context.Context, any) (any, error) {
+		called = true
+		return nil, nil
+	})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.False(t, called, "a call whose deadline has passed should not be handled")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = server.unaryInterceptor(ctx, nil, info, func(ctx context.Context, _ any) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err), "a handler returning ctx.Err() should report the deadline, not Unknown")
+}
+
+func TestServerLoadShedder(t *testing.T) {
+	shedder := loadshed.New(loadshed.WithMaxConcurrency(1))
+	impl := &echo{handle: func(_ context.Context, in string) (string, error) {
+		return in, nil
+	}}
+	server, conn := startServer(t, impl, []serverOption{WithLoadShedder(shedder)})
+
+	out, err := callEcho(context.Background(), conn, "admitted")
+	require.NoError(t, err)
+	assert.Equal(t, "admitted", out)
+
+	release, ok := shedder.Acquire()
+	require.True(t, ok)
+	defer release()
+	var trailer metadata.MD
+	_, err = callEcho(context.Background(), conn, "shed", grpc.Trailer(&trailer))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"1000"}, trailer.Get("grpc-retry-pushback-ms"))
+	_, err = callCount(context.Background(), conn)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "streams should be admitted through the shedder too")
+
+	assert.Equal(t, float64(1), sample(t, server.Collector(), "grpc_server_handled_total", map[string]string{"method": "/test.Echo/Echo", "code": "ResourceExhausted"}),
+		"rejected calls should be counted")
+}
+
+func TestClientInterceptors(t *testing.T) {
+	impl := &echo{handle: func(ctx context.Context, _ string) (string, error) {
+		return requestid.From(ctx), nil
+	}}
+	_, conn := startServer(t, impl, []serverOption{WithRequestID()},
+		grpc.WithChainUnaryInterceptor(UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(StreamClientInterceptor()),
+	)
+
+	ctx := httprunner.ContextWithRequestID(context.Background(), "from-http")
+	out, err := callEcho(ctx, conn, "")
+	require.NoError(t, err)
+	assert.Equal(t, "from-http", out, "the request ID should be passed on to the called service")
+
+	ids, err := callCount(ctx, conn)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"from-http", "from-http", "from-http"}, ids)
+
+	explicit := metadata.AppendToOutgoingContext(ctx, RequestIDMetadata, "explicit")
+	out, err = callEcho(explicit, conn, "")
+	require.NoError(t, err)
+	assert.Equal(t, "explicit", out, "an ID already in the metadata should be kept")
+}
+
+func TestServerRunGracefulStop(t *testing.T) {
+	listener := bufconn.Listen(1 << 20)
+	started := make(chan struct{})
+	impl := &echo{handle: func(context.Context, string) (string, error) {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		return "done", nil
+	}}
+	server := New("", WithListener(listener))
+	server.RegisterService(&echoDesc, impl)
+	conn := dial(t, listener)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Run(ctx)
+	}()
+
+	result := make(chan error, 1)
+	go func() {
+		out, err := callEcho(context.Background(), conn, "")
+		if err == nil && out != "done" {
+			err = errors.New("unexpected reply " + out)
+		}
+		result <- err
+	}()
+	<-started
+	cancel()
+
+	assert.NoError(t, <-result, "a call in flight should complete during shutdown")
+	assert.NoError(t, <-done)
+}
+
+func TestServerRunShutdownTimeout(t *testing.T) {
+	logger, records := testutil.NewTestLogger(slog.LevelInfo)
+	listener := bufconn.Listen(1 << 20)
+	started := make(chan struct{})
+	impl := &echo{handle: func(ctx context.Context, _ string) (string, error) {
+		close(started)
+		<-ctx.Done()
+		return "", ctx.Err()
+	}}
+	server := New("", WithListener(listener), WithLogger(logger), WithShutdownTimeout(20*time.Millisecond))
+	server.RegisterService(&echoDesc, impl)
+	conn := dial(t, listener)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Run(ctx)
+	}()
+	go func() {
+		_, _ = callEcho(context.Background(), conn, "")
+	}()
+	<-started
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the shutdown timeout")
+	}
+	assert.Contains(t, records.Messages(), "grpc graceful stop timed out, closing connections")
+}
+
+// recordSpans installs a global tracer provider recording spans and the W3C
+// trace context propagator until the test ends.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return recorder
+}
+
+func TestServerTracing(t *testing.T) {
+	recorder := recordSpans(t)
+	logger, records := testutil.NewTestLogger(slog.LevelInfo)
+	impl := &echo{handle: func(ctx context.Context, in string) (string, error) {
+		callLogger, _ := logctx.From(ctx)
+		callLogger.Info("handling")
+		switch in {
+		case "missing":
+			return "", status.Error(codes.NotFound, "no such order")
+		case "broken":
+			return "", status.Error(codes.Internal, "database is down")
+		}
+		return in, nil
+	}}
+	_, conn := startServer(t, impl, []serverOption{WithLogger(logger), WithTracing()},
+		grpc.WithChainUnaryInterceptor(UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(StreamClientInterceptor()),
+	)
+
+	_, err := callEcho(context.Background(), conn, "hello")
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	server, client := spans[0], spans[1]
+	assert.Equal(t, "test.Echo/Echo", server.Name())
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+	assert.Equal(t, trace.SpanKindClient, client.SpanKind())
+	assert.Equal(t, client.SpanContext().TraceID(), server.SpanContext().TraceID(), "the server should continue the client's trace")
+	assert.Equal(t, client.SpanContext().SpanID(), server.Parent().SpanID())
+	assert.Contains(t, server.Attributes(), attribute.String("rpc.service", "test.Echo"))
+	traceID, ok := records.Attr("handling", "trace_id")
+	require.True(t, ok)
+	assert.Equal(t, server.SpanContext().TraceID().String(), traceID.String())
+
+	_, err = callEcho(context.Background(), conn, "missing")
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = callEcho(context.Background(), conn, "broken")
+	assert.Equal(t, codes.Internal, status.Code(err))
+	spans = recorder.Ended()
+	require.Len(t, spans, 6)
+	assert.Equal(t, otelcodes.Unset, spans[2].Status().Code, "a client error should not fail the server span")
+	assert.Equal(t, otelcodes.Error, spans[3].Status().Code)
+	assert.Equal(t, otelcodes.Error, spans[4].Status().Code)
+	assert.Equal(t, otelcodes.Error, spans[5].Status().Code)
+
+	_, err = callCount(context.Background(), conn)
+	require.NoError(t, err)
+	spans = recorder.Ended()
+	require.Len(t, spans, 8, "the client span of a stream should end with the stream")
+	assert.Equal(t, spans[6].SpanContext().TraceID(), spans[7].SpanContext().TraceID())
+}
@@ -0,0 +1,238 @@
+package grpcrunner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/logctx"
+	"github.com/pgvanniekerk/ezapp/internal/requestid"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDMetadata is the metadata key carrying the ID that correlates a
+// call across services and log lines, the gRPC counterpart of
+// httprunner.RequestIDHeader.
+const RequestIDMetadata = "x-request-id"
+
+// WithRequestID assigns every call an ID, taken from its x-request-id
+// metadata if it carries a valid one or generated otherwise, and echoes it
+// in the response header. The call's context carries the ID, returned by
+// httprunner.RequestIDFromContext, and a logger recording it under
+// "request_id", returned by ezapp.LoggerFromContext. The ID is passed on to
+// the services the handler calls by UnaryClientInterceptor and
+// StreamClientInterceptor over gRPC, and by httprunner.RequestIDTransport
+// over HTTP.
+func WithRequestID() serverOption {
+	return func(s *Server) {
+		s.requestID = true
+	}
+}
+
+// WithAccessLog logs every call once it has been handled, with its method,
+// status code, duration and, with WithRequestID, its ID. Calls are logged
+// through the server's logger at INFO level if they succeed and at WARN
+// level otherwise.
+func WithAccessLog() serverOption {
+	return func(s *Server) {
+		s.accessLog = true
+	}
+}
+
+// WithDefaultDeadline bounds calls arriving without a deadline to timeout,
+// so that a client that sets none cannot hold a handler indefinitely.
+// Deadlines set by clients are kept, and propagate to the calls the handler
+// makes with the call's context.
+func WithDefaultDeadline(timeout time.Duration) serverOption {
+	return func(s *Server) {
+		s.defaultDeadline = timeout
+	}
+}
+
+// unaryInterceptor observes unary calls.
+func (s *Server) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	var resp any
+	err := s.observe(ctx, info.FullMethod, func(ctx context.Context) error {
+		var err error
+		resp, err = handler(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// streamInterceptor observes streaming calls, handing the handler a stream
+// whose context carries what observe attached.
+func (s *Server) streamInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return s.observe(stream.Context(), info.FullMethod, func(ctx context.Context) error {
+		return handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+	})
+}
+
+// serverStream is a grpc.ServerStream with a replaced context.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// observe runs call, the handling of the call to method, with the default
+// interceptor behaviour described at New.
+func (s *Server) observe(ctx context.Context, method string, call func(ctx context.Context) error) (err error) {
+	s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	started := time.Now()
+
+	logger := s.logger
+	if s.requestID {
+		id := incomingRequestID(ctx)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadata, id))
+		logger = logger.With("request_id", id)
+		ctx = requestid.With(ctx, id)
+	}
+	if s.tracing {
+		var span trace.Span
+		ctx, span = startServerSpan(ctx, method)
+		defer func() {
+			endSpan(span, err, true)
+		}()
+		if sc := span.SpanContext(); sc.IsValid() {
+			logger = logger.With("trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+		}
+	}
+	ctx = logctx.With(ctx, logger)
+
+	defer func() {
+		code := status.Code(err)
+		duration := time.Since(started)
+		s.record(method, code, duration)
+		if s.accessLog {
+			level := slog.LevelInfo
+			if code != codes.OK {
+				level = slog.LevelWarn
+			}
+			logger.Log(ctx, level, "grpc call", "method", method, "code", code.String(), "duration", duration)
+		}
+	}()
+
+	deadline, ok := ctx.Deadline()
+	if ok && !time.Now().Before(deadline) {
+		return status.Error(codes.DeadlineExceeded, "deadline exceeded before the call was handled")
+	}
+	if !ok && s.defaultDeadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.defaultDeadline)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.panics.Add(1)
+			logger.Error("grpc handler panicked", "method", method, "panic", r, "stack", string(debug.Stack()))
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+
+	err = call(ctx)
+	if _, isStatus := status.FromError(err); !isStatus && (errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)) {
+		// Report the deadline or cancellation instead of Unknown
+		err = status.FromContextError(err).Err()
+	}
+	return err
+}
+
+// incomingRequestID returns the request ID in the incoming metadata of ctx.
+func incomingRequestID(ctx context.Context) string {
+	if values := metadata.ValueFromIncomingContext(ctx, RequestIDMetadata); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// outgoingContext returns ctx with the request ID it carries added to its
+// outgoing metadata, unless the metadata already has one.
+func outgoingContext(ctx context.Context) context.Context {
+	id := requestid.From(ctx)
+	if id == "" {
+		return ctx
+	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(RequestIDMetadata)) > 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, RequestIDMetadata, id)
+}
+
+// UnaryClientInterceptor returns a client interceptor that sends the request
+// ID carried by the context of an outgoing call, as assigned by
+// WithRequestID or httprunner.WithRequestID, in its x-request-id metadata.
+// It records the call in a client span of the global tracer provider,
+// sending its trace context with the global propagator so that a server
+// using WithTracing continues the trace. The call's deadline is propagated
+// by gRPC itself.
+//
+// Example:
+//
+//	conn, err := grpc.NewClient(inventoryAddr,
+//	    grpc.WithTransportCredentials(creds),
+//	    grpc.WithChainUnaryInterceptor(grpcrunner.UnaryClientInterceptor()),
+//	    grpc.WithChainStreamInterceptor(grpcrunner.StreamClientInterceptor()),
+//	)
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (err error) {
+		ctx, span := startClientSpan(outgoingContext(ctx), method)
+		defer func() {
+			endSpan(span, err, false)
+		}()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor is the streaming counterpart of
+// UnaryClientInterceptor. The span of a stream ends once the stream does.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, span := startClientSpan(outgoingContext(ctx), method)
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			endSpan(span, err, false)
+			return nil, err
+		}
+		return &clientStream{ClientStream: stream, span: span, serverStreams: desc.ServerStreams}, nil
+	}
+}
+
+// clientStream is a grpc.ClientStream ending its span once the stream ends:
+// when RecvMsg fails or, if the server sends a single message, returns it.
+type clientStream struct {
+	grpc.ClientStream
+	span          trace.Span
+	serverStreams bool
+	once          sync.Once
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil || !s.serverStreams {
+		spanErr := err
+		if errors.Is(err, io.EOF) {
+			spanErr = nil
+		}
+		s.once.Do(func() {
+			endSpan(s.span, spanErr, false)
+		})
+	}
+	return err
+}
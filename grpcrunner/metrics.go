@@ -0,0 +1,94 @@
+package grpcrunner
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/metrics"
+	"google.golang.org/grpc/codes"
+)
+
+// callKey identifies the calls counted together: those to one method that
+// ended with one status code.
+type callKey struct {
+	method string
+	code   codes.Code
+}
+
+// callStats accumulates the calls sharing a callKey.
+type callStats struct {
+	count   int64
+	seconds float64
+}
+
+// record counts a call to method that ended with code after duration.
+func (s *Server) record(method string, code codes.Code, duration time.Duration) {
+	s.callsMu.Lock()
+	defer s.callsMu.Unlock()
+	key := callKey{method: method, code: code}
+	stats, ok := s.calls[key]
+	if !ok {
+		stats = &callStats{}
+		s.calls[key] = stats
+	}
+	stats.count++
+	stats.seconds += duration.Seconds()
+}
+
+// Collector returns a metrics collector reporting the calls handled by
+// method and status code, the time spent handling them, the calls in flight
+// and the handler panics recovered.
+//
+// Example:
+//
+//	ctx.Metrics.Register(server.Collector())
+func (s *Server) Collector() metrics.Collector {
+	return func() []metrics.Sample {
+		samples := []metrics.Sample{
+			{
+				Name:  "grpc_server_in_flight_calls",
+				Help:  "Number of calls being handled.",
+				Type:  metrics.Gauge,
+				Value: float64(s.InFlight()),
+			},
+			{
+				Name:  "grpc_server_panics_total",
+				Help:  "Number of handler panics recovered.",
+				Type:  metrics.Counter,
+				Value: float64(s.panics.Load()),
+			},
+		}
+
+		s.callsMu.Lock()
+		keys := make([]callKey, 0, len(s.calls))
+		for key := range s.calls {
+			keys = append(keys, key)
+		}
+		slices.SortFunc(keys, func(a, b callKey) int {
+			return cmp.Or(cmp.Compare(a.method, b.method), cmp.Compare(a.code, b.code))
+		})
+		for _, key := range keys {
+			stats := s.calls[key]
+			labels := map[string]string{"method": key.method, "code": key.code.String()}
+			samples = append(samples,
+				metrics.Sample{
+					Name:   "grpc_server_handled_total",
+					Help:   "Number of calls handled, by method and status code.",
+					Type:   metrics.Counter,
+					Labels: labels,
+					Value:  float64(stats.count),
+				},
+				metrics.Sample{
+					Name:   "grpc_server_handling_seconds_total",
+					Help:   "Time spent handling calls, by method and status code.",
+					Type:   metrics.Counter,
+					Labels: labels,
+					Value:  stats.seconds,
+				},
+			)
+		}
+		s.callsMu.Unlock()
+		return samples
+	}
+}
@@ -0,0 +1,112 @@
+package grpcrunner
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tracerName identifies the spans started by this package.
+const tracerName = "github.com/pgvanniekerk/ezapp/grpcrunner"
+
+// WithTracing traces every call with OpenTelemetry. A server span named
+// after the method is started as a child of the trace context carried by
+// the call's metadata, and its trace and span IDs are recorded by the call's
+// logger under "trace_id" and "span_id". Spans are recorded by the global
+// tracer provider and the trace context extracted with the global
+// propagator, as set with otel.SetTracerProvider and
+// otel.SetTextMapPropagator; until they are set, tracing has no effect.
+func WithTracing() serverOption {
+	return func(s *Server) {
+		s.tracing = true
+	}
+}
+
+// startServerSpan starts the span of a call to method received with ctx.
+func startServerSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	return otel.Tracer(tracerName).Start(ctx, strings.TrimPrefix(method, "/"),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(rpcAttributes(method)...),
+	)
+}
+
+// startClientSpan starts the span of an outgoing call to method and adds
+// its trace context to the outgoing metadata of the returned context.
+func startClientSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, strings.TrimPrefix(method, "/"),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(rpcAttributes(method)...),
+	)
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md), span
+}
+
+// endSpan ends span, recording the status code of err. Server spans are
+// marked as failed only for codes reporting a server error, as the
+// OpenTelemetry conventions for RPC spans prescribe.
+func endSpan(span trace.Span, err error, server bool) {
+	code := status.Code(err)
+	span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(code)))
+	if code != codes.OK && (!server || serverError(code)) {
+		span.SetStatus(otelcodes.Error, status.Convert(err).Message())
+	}
+	span.End()
+}
+
+// serverError reports whether code reports a failure of the server rather
+// than of the call it was sent.
+func serverError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
+
+// rpcAttributes describes a call to method, e.g. "/orders.Orders/Create".
+func rpcAttributes(method string) []attribute.KeyValue {
+	service, name, _ := strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	return []attribute.KeyValue{
+		attribute.String("rpc.system", "grpc"),
+		attribute.String("rpc.service", service),
+		attribute.String("rpc.method", name),
+	}
+}
+
+// metadataCarrier carries a trace context in gRPC metadata.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
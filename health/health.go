@@ -0,0 +1,396 @@
+// Package health aggregates dependency checks (database pings, broker
+// metadata requests, downstream HTTP calls) into a single readiness report
+// that can be served as a /readyz endpoint.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is the timeout applied to a check that does not configure
+// its own timeout with WithTimeout.
+const DefaultTimeout = 5 * time.Second
+
+// Status describes the aggregated or individual state of health checks.
+type Status string
+
+const (
+	// StatusUp indicates that all checks passed.
+	StatusUp Status = "up"
+
+	// StatusDegraded indicates that only non-critical checks failed. A
+	// degraded application is still considered ready.
+	StatusDegraded Status = "degraded"
+
+	// StatusDown indicates that at least one critical check failed.
+	StatusDown Status = "down"
+
+	// StatusStarting indicates that a component registered with
+	// Registry.Starting is still initializing. A starting application is
+	// not ready.
+	StatusStarting Status = "starting"
+)
+
+// CheckFunc verifies a single dependency. It must respect ctx, which carries
+// the check's timeout, and return a non-nil error if the dependency is
+// unavailable.
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult is the outcome of a single check as reported by Registry.Check.
+type CheckResult struct {
+	Name      string        `json:"name"`
+	Status    Status        `json:"status"`
+	Critical  bool          `json:"critical"`
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"duration"`
+	CheckedAt time.Time     `json:"checked_at"`
+	Cached    bool          `json:"cached"`
+}
+
+// Report is the aggregated outcome of all registered checks.
+type Report struct {
+	Status Status        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+
+	// Info holds the static details set with SetInfo, such as the pod name.
+	Info map[string]string `json:"info,omitempty"`
+
+	// Starting lists the components that are still initializing, longest
+	// starting first, if the status is StatusStarting.
+	Starting []StartingComponent `json:"starting,omitempty"`
+}
+
+// StartingComponent describes a component or runner that is still
+// initializing, as reported by Registry.Check.
+type StartingComponent struct {
+	Name    string        `json:"name"`
+	Since   time.Time     `json:"since"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// Ready reports whether the aggregated status permits serving traffic,
+// which is the case for both StatusUp and StatusDegraded.
+func (r Report) Ready() bool {
+	return r.Status == StatusUp || r.Status == StatusDegraded
+}
+
+// checkOption represents a functional option for configuring a check.
+// This type is not exported to ensure only predefined options can be used.
+type checkOption func(*check)
+
+// WithTimeout sets the maximum duration a single execution of the check may
+// take before it is considered failed. Defaults to DefaultTimeout.
+func WithTimeout(timeout time.Duration) checkOption {
+	return func(c *check) {
+		c.timeout = timeout
+	}
+}
+
+// WithCacheTTL caches the result of the check for the given duration so that
+// frequent readiness probes do not hammer the dependency. By default results
+// are not cached.
+func WithCacheTTL(ttl time.Duration) checkOption {
+	return func(c *check) {
+		c.cacheTTL = ttl
+	}
+}
+
+// NonCritical marks the check as non-critical. A failing non-critical check
+// degrades the aggregated status to StatusDegraded instead of StatusDown,
+// leaving the application ready.
+func NonCritical() checkOption {
+	return func(c *check) {
+		c.critical = false
+	}
+}
+
+// check is a registered dependency check with its cached last result.
+type check struct {
+	name     string
+	fn       CheckFunc
+	timeout  time.Duration
+	cacheTTL time.Duration
+	critical bool
+
+	mu   sync.Mutex
+	last *CheckResult
+}
+
+// Registry holds dependency checks and aggregates their results.
+// It is safe for concurrent use.
+type Registry struct {
+	mu       sync.RWMutex
+	checks   map[string]*check
+	info     map[string]string
+	starting map[*StartingComponent]struct{}
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		checks:   make(map[string]*check),
+		starting: make(map[*StartingComponent]struct{}),
+	}
+}
+
+// Register adds a named dependency check to the registry. Checks are
+// critical by default; use NonCritical to allow the application to stay
+// ready while the check fails. Returns an error if the name is empty, the
+// check function is nil, or a check with the same name is already registered.
+func (r *Registry) Register(name string, fn CheckFunc, options ...checkOption) error {
+	if name == "" {
+		return fmt.Errorf("health check name must not be empty")
+	}
+	if fn == nil {
+		return fmt.Errorf("health check %q must not be nil", name)
+	}
+
+	c := &check{
+		name:     name,
+		fn:       fn,
+		timeout:  DefaultTimeout,
+		critical: true,
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.checks[name]; exists {
+		return fmt.Errorf("health check %q is already registered", name)
+	}
+	r.checks[name] = c
+
+	return nil
+}
+
+// SetInfo records a static detail about the instance, such as the pod or node
+// it runs on, that is included in every Report under Info.
+func (r *Registry) SetInfo(key, value string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.info == nil {
+		r.info = make(map[string]string)
+	}
+	r.info[key] = value
+}
+
+// Starting records that the named component or runner is initializing, such
+// as a cache being loaded, and returns the function to call once it is done.
+// Until then, reports have StatusStarting and list the component with the
+// time it has been starting for, so that the handler responds 503 Service
+// Unavailable with the reason readiness has not been reached. The checks are
+// not run while the application is starting. Calling done more than once has
+// no further effect.
+//
+// Example:
+//
+//	done := ctx.Health.Starting("product-cache")
+//	go func() {
+//	    defer done()
+//	    cache.Load(context.Background())
+//	}()
+func (r *Registry) Starting(name string) (done func()) {
+	component := &StartingComponent{Name: name, Since: time.Now()}
+
+	r.mu.Lock()
+	r.starting[component] = struct{}{}
+	r.mu.Unlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.starting, component)
+	}
+}
+
+// CheckInfo describes a registered check without running it.
+type CheckInfo struct {
+	Name     string `json:"name"`
+	Critical bool   `json:"critical"`
+}
+
+// Registered describes the registered checks in name order.
+func (r *Registry) Registered() []CheckInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	infos := make([]CheckInfo, 0, len(r.checks))
+	for _, c := range r.checks {
+		infos = append(infos, CheckInfo{Name: c.name, Critical: c.critical})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// Healthy is implemented by components that can verify their own health, such
+// as a database pool pinging its server. Components implementing it can be
+// registered with RegisterComponent without writing a separate check.
+type Healthy interface {
+	Healthy(ctx context.Context) error
+}
+
+// RegisterComponent registers the Healthy method of component as a check
+// under name if component implements Healthy, and reports whether it did.
+// Components that do not implement Healthy are ignored. A component passed
+// by value whose Healthy method has a pointer receiver is rejected, since
+// its check would otherwise be silently skipped. Other registration errors
+// are those of Register.
+func (r *Registry) RegisterComponent(name string, component any, options ...checkOption) (bool, error) {
+	healthy, ok := component.(Healthy)
+	if !ok {
+		if needsPointer(component) {
+			return false, fmt.Errorf("component %q of type %T implements Healthy only with a pointer receiver: register a *%T instead", name, component, component)
+		}
+		return false, nil
+	}
+	if err := r.Register(name, healthy.Healthy, options...); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// needsPointer reports whether component is a non-pointer value whose
+// pointer type implements Healthy.
+func needsPointer(component any) bool {
+	t := reflect.TypeOf(component)
+	return t != nil && t.Kind() != reflect.Ptr && reflect.PointerTo(t).Implements(reflect.TypeFor[Healthy]())
+}
+
+// Check runs all registered checks concurrently, each under its own timeout,
+// and returns the aggregated report. Results within their cache TTL are
+// reused instead of re-running the check. Checks are reported in name order.
+// While components are starting, the checks are not run and the report
+// lists the starting components instead.
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make([]*check, 0, len(r.checks))
+	for _, c := range r.checks {
+		checks = append(checks, c)
+	}
+	info := maps.Clone(r.info)
+	starting := make([]StartingComponent, 0, len(r.starting))
+	for component := range r.starting {
+		starting = append(starting, *component)
+	}
+	r.mu.RUnlock()
+
+	if len(starting) > 0 {
+		now := time.Now()
+		for idx := range starting {
+			starting[idx].Elapsed = now.Sub(starting[idx].Since)
+		}
+		sort.Slice(starting, func(i, j int) bool {
+			if !starting[i].Since.Equal(starting[j].Since) {
+				return starting[i].Since.Before(starting[j].Since)
+			}
+			return starting[i].Name < starting[j].Name
+		})
+		return Report{Status: StatusStarting, Checks: []CheckResult{}, Info: info, Starting: starting}
+	}
+
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].name < checks[j].name
+	})
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for idx, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[idx] = c.run(ctx)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: results, Info: info}
+	for _, result := range results {
+		if result.Status == StatusUp {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusDown
+			break
+		}
+		report.Status = StatusDegraded
+	}
+
+	return report
+}
+
+// Handler returns an http.Handler that serves the aggregated report as JSON.
+// It responds with 200 OK when the application is ready (up or degraded) and
+// 503 Service Unavailable otherwise, including while it is starting.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := r.Check(req.Context())
+
+		statusCode := http.StatusOK
+		if !report.Ready() {
+			statusCode = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
+
+// run executes the check, reusing the cached result if it is still fresh.
+func (c *check) run(ctx context.Context) CheckResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.last != nil && c.cacheTTL > 0 && time.Since(c.last.CheckedAt) < c.cacheTTL {
+		cached := *c.last
+		cached.Cached = true
+		return cached
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	// Run the check in its own goroutine so that a check ignoring its
+	// context cannot hold up the report beyond the timeout.
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.fn(checkCtx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-checkCtx.Done():
+		err = fmt.Errorf("health check timed out: %w", checkCtx.Err())
+	}
+
+	result := CheckResult{
+		Name:      c.name,
+		Status:    StatusUp,
+		Critical:  c.critical,
+		Duration:  time.Since(start),
+		CheckedAt: start,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	c.last = &result
+	return result
+}
@@ -0,0 +1,258 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func passingCheck(ctx context.Context) error {
+	return nil
+}
+
+func failingCheck(ctx context.Context) error {
+	return errors.New("dependency unavailable")
+}
+
+func TestRegistryRegister(t *testing.T) {
+	registry := NewRegistry()
+
+	require.NoError(t, registry.Register("db", passingCheck))
+
+	err := registry.Register("db", passingCheck)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already registered")
+
+	assert.Error(t, registry.Register("", passingCheck))
+	assert.Error(t, registry.Register("nil", nil))
+}
+
+func TestRegistryCheckStatus(t *testing.T) {
+	testCases := []struct {
+		name     string
+		register func(r *Registry)
+		expected Status
+		ready    bool
+	}{
+		{
+			name:     "no checks",
+			register: func(r *Registry) {},
+			expected: StatusUp,
+			ready:    true,
+		},
+		{
+			name: "all passing",
+			register: func(r *Registry) {
+				_ = r.Register("db", passingCheck)
+				_ = r.Register("kafka", passingCheck)
+			},
+			expected: StatusUp,
+			ready:    true,
+		},
+		{
+			name: "non-critical failing",
+			register: func(r *Registry) {
+				_ = r.Register("db", passingCheck)
+				_ = r.Register("recommendations", failingCheck, NonCritical())
+			},
+			expected: StatusDegraded,
+			ready:    true,
+		},
+		{
+			name: "critical failing",
+			register: func(r *Registry) {
+				_ = r.Register("db", failingCheck)
+				_ = r.Register("recommendations", failingCheck, NonCritical())
+			},
+			expected: StatusDown,
+			ready:    false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			registry := NewRegistry()
+			tc.register(registry)
+
+			report := registry.Check(context.Background())
+
+			assert.Equal(t, tc.expected, report.Status)
+			assert.Equal(t, tc.ready, report.Ready())
+		})
+	}
+}
+
+func TestRegistryCheckTimeout(t *testing.T) {
+	registry := NewRegistry()
+	require.NoError(t, registry.Register("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}, WithTimeout(20*time.Millisecond)))
+
+	start := time.Now()
+	report := registry.Check(context.Background())
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "check should be abandoned after its timeout")
+	require.Len(t, report.Checks, 1)
+	assert.Equal(t, StatusDown, report.Checks[0].Status)
+	assert.Contains(t, report.Checks[0].Error, "timed out")
+}
+
+func TestRegistryCheckCache(t *testing.T) {
+	var calls atomic.Int32
+	registry := NewRegistry()
+	require.NoError(t, registry.Register("db", func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	}, WithCacheTTL(time.Minute)))
+
+	first := registry.Check(context.Background())
+	second := registry.Check(context.Background())
+
+	assert.Equal(t, int32(1), calls.Load(), "cached result should be reused")
+	assert.False(t, first.Checks[0].Cached)
+	assert.True(t, second.Checks[0].Cached)
+}
+
+func TestRegistryHandler(t *testing.T) {
+	registry := NewRegistry()
+	require.NoError(t, registry.Register("db", passingCheck))
+	require.NoError(t, registry.Register("cache", failingCheck, NonCritical()))
+
+	recorder := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var report Report
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+	assert.Equal(t, StatusDegraded, report.Status)
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, "cache", report.Checks[0].Name, "checks should be reported in name order")
+	assert.Equal(t, "dependency unavailable", report.Checks[0].Error)
+
+	require.NoError(t, registry.Register("broker", failingCheck))
+	recorder = httptest.NewRecorder()
+	registry.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+}
+
+type healthyComponent struct {
+	err error
+}
+
+func (c healthyComponent) Healthy(ctx context.Context) error {
+	return c.err
+}
+
+func TestRegistryRegisterComponent(t *testing.T) {
+	registry := NewRegistry()
+
+	registered, err := registry.RegisterComponent("db", healthyComponent{err: errors.New("pool closed")})
+	require.NoError(t, err)
+	assert.True(t, registered)
+
+	registered, err = registry.RegisterComponent("cache", struct{}{})
+	require.NoError(t, err)
+	assert.False(t, registered, "components without a Healthy method should be ignored")
+
+	_, err = registry.RegisterComponent("db", healthyComponent{})
+	assert.ErrorContains(t, err, "already registered")
+
+	report := registry.Check(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Len(t, report.Checks, 1)
+}
+
+type pointerComponent struct{}
+
+func (c *pointerComponent) Healthy(ctx context.Context) error {
+	return nil
+}
+
+func TestRegistryRegisterComponentPointerReceiver(t *testing.T) {
+	registry := NewRegistry()
+
+	_, err := registry.RegisterComponent("queue", pointerComponent{})
+	assert.ErrorContains(t, err, `component "queue" of type health.pointerComponent implements Healthy only with a pointer receiver: register a *health.pointerComponent instead`)
+
+	registered, err := registry.RegisterComponent("queue", &pointerComponent{})
+	require.NoError(t, err)
+	assert.True(t, registered)
+
+	registered, err = registry.RegisterComponent("none", nil)
+	require.NoError(t, err)
+	assert.False(t, registered)
+}
+
+func TestRegistryRegistered(t *testing.T) {
+	registry := NewRegistry()
+	require.NoError(t, registry.Register("postgres", func(context.Context) error { return nil }))
+	require.NoError(t, registry.Register("cache", func(context.Context) error { return nil }, NonCritical()))
+
+	assert.Equal(t, []CheckInfo{
+		{Name: "cache", Critical: false},
+		{Name: "postgres", Critical: true},
+	}, registry.Registered())
+	assert.Empty(t, NewRegistry().Registered())
+}
+
+func TestRegistrySetInfo(t *testing.T) {
+	registry := NewRegistry()
+	assert.Nil(t, registry.Check(context.Background()).Info)
+
+	registry.SetInfo("pod", "api-7d9f-abcde")
+	registry.SetInfo("namespace", "payments")
+
+	report := registry.Check(context.Background())
+	assert.Equal(t, map[string]string{"pod": "api-7d9f-abcde", "namespace": "payments"}, report.Info)
+
+	report.Info["pod"] = "changed"
+	assert.Equal(t, "api-7d9f-abcde", registry.Check(context.Background()).Info["pod"], "reports should not share the info map")
+}
+
+func TestRegistryStarting(t *testing.T) {
+	registry := NewRegistry()
+	require.NoError(t, registry.Register("db", failingCheck))
+	registry.SetInfo("pod", "orders-0")
+
+	doneCache := registry.Starting("cache")
+	doneConsumer := registry.Starting("consumer")
+
+	recorder := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	var report Report
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+	assert.Equal(t, StatusStarting, report.Status)
+	assert.False(t, report.Ready())
+	assert.Empty(t, report.Checks, "checks should not run while starting")
+	assert.Equal(t, map[string]string{"pod": "orders-0"}, report.Info)
+	require.Len(t, report.Starting, 2)
+	assert.Equal(t, "cache", report.Starting[0].Name, "the longest starting component should be listed first")
+	assert.Equal(t, "consumer", report.Starting[1].Name)
+	assert.Positive(t, report.Starting[0].Elapsed)
+
+	doneCache()
+	doneCache()
+	report = registry.Check(context.Background())
+	require.Len(t, report.Starting, 1)
+	assert.Equal(t, "consumer", report.Starting[0].Name)
+
+	doneConsumer()
+	report = registry.Check(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Empty(t, report.Starting)
+	require.Len(t, report.Checks, 1)
+}
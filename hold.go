@@ -0,0 +1,173 @@
+package ezapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/pgvanniekerk/ezapp/internal/app"
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+)
+
+// WithHold starts the application in hold mode, as does setting EZAPP_HOLD=1.
+// The initializer runs and dependencies are connected as usual, but every
+// runner waits before starting until the application is released, keeping a
+// pre-warmed standby instance ready for a fast blue/green failover.
+//
+// The application is released by Runtime.Release, by a POST to the handler
+// returned by Runtime.HoldHandler, or by sending the process SIGUSR1 (on Unix
+// systems). Shutting down while held stops the waiting runners without
+// starting them. Runners named with WithUnheldRunners, such as the admin
+// server mounting HoldHandler and the server answering health probes, start
+// straight away.
+//
+// Example:
+//
+//	ezapp.Run(initializer, ezapp.WithHold())
+func WithHold() RunOption {
+	return func(settings *runopt.Settings) {
+		settings.Hold = true
+	}
+}
+
+// WithUnheldRunners is a functional option that exempts the named runners
+// from hold mode, so they start straight away while the other runners are
+// held. Exempt the runner serving Runtime.HoldHandler, or the application can
+// never be released over HTTP, and the one serving the health and readiness
+// probes, or a held standby fails its liveness probe. Naming a runner that is
+// not registered makes Construct fail.
+//
+// Example:
+//
+//	adminMux.Handle("/admin/hold", ctx.Runtime.HoldHandler())
+//	adminMux.Handle("/healthz", ctx.Health.Handler())
+//	appCtx, err := Construct(
+//	    WithNamedRunner("http", server.Run),
+//	    WithNamedRunner("admin", adminServer.Run),
+//	    WithUnheldRunners("admin"),
+//	)
+func WithUnheldRunners(names ...string) option {
+	return func(appCtx *AppCtx) error {
+		for _, name := range names {
+			if name == "" {
+				return errors.New("unheld runner requires a runner name")
+			}
+			if appCtx.unheldRunners == nil {
+				appCtx.unheldRunners = make(map[string]bool)
+			}
+			appCtx.unheldRunners[name] = true
+		}
+		return nil
+	}
+}
+
+// holdGate keeps runners from starting until it is released.
+type holdGate struct {
+	once     sync.Once
+	released chan struct{}
+}
+
+// newHoldGate returns a gate that holds runners until released.
+func newHoldGate() *holdGate {
+	return &holdGate{released: make(chan struct{})}
+}
+
+// release opens the gate and reports whether this call opened it.
+func (g *holdGate) release() bool {
+	opened := false
+	g.once.Do(func() {
+		close(g.released)
+		opened = true
+	})
+	return opened
+}
+
+// held reports whether the gate is still closed.
+func (g *holdGate) held() bool {
+	select {
+	case <-g.released:
+		return false
+	default:
+		return true
+	}
+}
+
+// wrap returns a runner that waits for the gate to open before running r.
+// If ctx is cancelled first, r is never started.
+func (g *holdGate) wrap(r app.Runner) app.Runner {
+	return func(ctx context.Context) error {
+		select {
+		case <-g.released:
+			return r(ctx)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// releaseOnSignal releases gate when the process receives the release signal,
+// and logs the release however it happens. The returned function stops
+// listening.
+func releaseOnSignal(logger *slog.Logger, gate *holdGate) func() {
+	sigChan := make(chan os.Signal, 1)
+	notifyRelease(sigChan)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-sigChan:
+			gate.release()
+		case <-gate.released:
+		case <-done:
+			return
+		}
+		logger.Info("runners released")
+	}()
+
+	return func() {
+		signal.Stop(sigChan)
+		close(done)
+	}
+}
+
+// Held reports whether the application is in hold mode and has not been
+// released yet.
+func (rt *Runtime) Held() bool {
+	return rt.hold != nil && rt.hold.held()
+}
+
+// Release starts the runners of an application held by WithHold or
+// EZAPP_HOLD, and reports whether this call released it. It is a no-op when
+// the application is not held.
+func (rt *Runtime) Release() bool {
+	return rt.hold != nil && rt.hold.release()
+}
+
+// HoldHandler returns an http.Handler for an admin endpoint controlling hold
+// mode. A GET responds with {"held": true|false}; a POST releases the
+// application and responds with the resulting state.
+//
+// Example:
+//
+//	adminMux.Handle("/admin/hold", ctx.Runtime.HoldHandler())
+func (rt *Runtime) HoldHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			rt.Release()
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]bool{"held": rt.Held()})
+	})
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package ezapp
+
+import "os"
+
+// notifyRelease is a no-op on platforms without SIGUSR1; held applications
+// are released through the Runtime instead.
+func notifyRelease(ch chan<- os.Signal) {}
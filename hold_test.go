@@ -0,0 +1,165 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithHold(t *testing.T) {
+	var runtime *Runtime
+	initialized := make(chan struct{})
+	started := make(chan struct{})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+			runtime = ctx.Runtime
+			close(initialized)
+			return Construct(WithNamedRunner("worker", func(context.Context) error {
+				close(started)
+				return nil
+			}))
+		}, WithHold())
+	}()
+
+	<-initialized
+	select {
+	case <-started:
+		t.Fatal("Runners should be held until released")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.True(t, runtime.Held())
+
+	assert.True(t, runtime.Release())
+	assert.False(t, runtime.Release(), "Releasing twice should be a no-op")
+	assert.False(t, runtime.Held())
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not complete after release")
+	}
+	select {
+	case <-started:
+	default:
+		t.Fatal("Runner should have started after release")
+	}
+}
+
+func TestWithUnheldRunnersReleaseOverHTTP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	adminStarted := make(chan struct{})
+	workerStarted := make(chan struct{})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+			server := &http.Server{Handler: ctx.Runtime.HoldHandler()}
+			return Construct(
+				WithNamedRunner("worker", func(context.Context) error {
+					close(workerStarted)
+					return nil
+				}),
+				WithNamedRunner("admin", func(context.Context) error {
+					close(adminStarted)
+					go func() {
+						<-workerStarted
+						_ = server.Close()
+					}()
+					if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+						return err
+					}
+					return nil
+				}),
+				WithUnheldRunners("admin"),
+			)
+		}, WithHold())
+	}()
+
+	select {
+	case <-adminStarted:
+	case <-time.After(2 * time.Second):
+		t.Fatal("An unheld runner should start while the app is held")
+	}
+	select {
+	case <-workerStarted:
+		t.Fatal("Other runners should stay held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	resp, err := http.Post("http://"+listener.Addr().String()+"/admin/hold", "", nil)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.JSONEq(t, `{"held": false}`, string(body))
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not complete after the release over HTTP")
+	}
+}
+
+func TestWithUnheldRunnersUnknown(t *testing.T) {
+	_, err := Construct(WithNamedRunner("http", successfulRunner), WithUnheldRunners("admni"))
+	assert.ErrorContains(t, err, `unheld runner "admni" is not registered`)
+
+	_, err = Construct(WithUnheldRunners(""))
+	assert.ErrorContains(t, err, "unheld runner requires a runner name")
+}
+
+func TestWithoutHold(t *testing.T) {
+	t.Setenv("EZAPP_HOLD", "")
+
+	var runtime *Runtime
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		runtime = ctx.Runtime
+		return Construct(WithRunners(successfulRunner))
+	})
+	require.NoError(t, err)
+
+	assert.False(t, runtime.Held())
+	assert.False(t, runtime.Release())
+}
+
+func TestHoldGateShutdownWhileHeld(t *testing.T) {
+	gate := newHoldGate()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := gate.wrap(func(context.Context) error {
+		t.Fatal("a held runner should not start once shut down")
+		return nil
+	})(ctx)
+	assert.NoError(t, err)
+}
+
+func TestHoldHandler(t *testing.T) {
+	runtime := &Runtime{hold: newHoldGate()}
+	handler := runtime.HoldHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/hold", nil))
+	assert.JSONEq(t, `{"held": true}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/hold", nil))
+	assert.JSONEq(t, `{"held": false}`, rec.Body.String())
+	assert.False(t, runtime.Held())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/admin/hold", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
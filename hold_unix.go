@@ -0,0 +1,14 @@
+//go:build unix
+
+package ezapp
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyRelease relays the signal that releases a held application to ch.
+func notifyRelease(ch chan<- os.Signal) {
+	signal.Notify(ch, syscall.SIGUSR1)
+}
@@ -0,0 +1,24 @@
+//go:build unix
+
+package ezapp
+
+import (
+	"io"
+	"log/slog"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleaseOnSignal(t *testing.T) {
+	gate := newHoldGate()
+	stop := releaseOnSignal(slog.New(slog.NewTextHandler(io.Discard, nil)), gate)
+	defer stop()
+
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGUSR1))
+
+	assert.Eventually(t, func() bool { return !gate.held() }, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,146 @@
+package httprunner
+
+import (
+	"bufio"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WithAccessLog logs every request once it has been handled, with its
+// method, path, status, response size, duration and, with WithRequestID, its
+// ID. Requests are logged through the server's logger (see WithLogger) at
+// INFO level, or WARN if slower than WithSlowThreshold. Requests rejected
+// while draining or by the load shedder are logged too. Options sample the
+// requests logged, so that busy or uninteresting paths do not flood the logs.
+//
+// Example:
+//
+//	server := httprunner.New(&http.Server{Handler: mux},
+//	    httprunner.WithLogger(ctx.Logger),
+//	    httprunner.WithAccessLog(
+//	        httprunner.WithSampleRate("/healthz", 0),  // never log probes
+//	        httprunner.WithSampleRate("/api/", 0.1),   // log 10% of API requests
+//	        httprunner.WithSlowThreshold(time.Second), // but every slow one
+//	    ),
+//	)
+func WithAccessLog(options ...accessLogOption) serverOption {
+	return func(s *Server) {
+		accessLog := &accessLog{}
+		for _, option := range options {
+			option(accessLog)
+		}
+		sort.SliceStable(accessLog.rates, func(i, j int) bool {
+			return len(accessLog.rates[i].prefix) > len(accessLog.rates[j].prefix)
+		})
+		s.accessLog = accessLog
+	}
+}
+
+// accessLogOption represents a functional option for configuring the access
+// log.
+type accessLogOption func(*accessLog)
+
+// WithSampleRate logs only the given fraction, between 0 and 1, of the
+// requests whose path starts with prefix. The rate of the longest matching
+// prefix applies; requests matching none are all logged. Slow requests and
+// server errors are logged regardless of the rate.
+func WithSampleRate(prefix string, rate float64) accessLogOption {
+	return func(a *accessLog) {
+		a.rates = append(a.rates, sampleRate{prefix: prefix, rate: min(max(rate, 0), 1)})
+	}
+}
+
+// WithSlowThreshold logs requests taking at least threshold at WARN level,
+// whatever their sample rate.
+func WithSlowThreshold(threshold time.Duration) accessLogOption {
+	return func(a *accessLog) {
+		a.slow = threshold
+	}
+}
+
+// sampleRate is the fraction of requests under a path prefix that are logged.
+type sampleRate struct {
+	prefix string
+	rate   float64
+}
+
+// accessLog logs handled requests, as configured by WithAccessLog.
+type accessLog struct {
+	rates []sampleRate // longest prefix first
+	slow  time.Duration
+}
+
+// serve handles r with next and logs it to logger if sampled.
+func (a *accessLog) serve(logger *slog.Logger, next http.Handler, w http.ResponseWriter, r *http.Request) {
+	recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+	startedAt := time.Now()
+	next.ServeHTTP(recorder, r)
+	duration := time.Since(startedAt)
+
+	level := slog.LevelInfo
+	if a.slow > 0 && duration >= a.slow {
+		level = slog.LevelWarn
+	} else if recorder.status < http.StatusInternalServerError && !a.sampled(r.URL.Path) {
+		return
+	}
+	logger.LogAttrs(r.Context(), level, "http request",
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.Int("status", recorder.status),
+		slog.Int64("bytes", recorder.bytes),
+		slog.Duration("duration", duration),
+	)
+}
+
+// sampled reports whether a request for path should be logged, according to
+// the rate of the longest prefix it matches.
+func (a *accessLog) sampled(path string) bool {
+	for _, rate := range a.rates {
+		if strings.HasPrefix(path, rate.prefix) {
+			return rate.rate >= 1 || rand.Float64() < rate.rate
+		}
+	}
+	return true
+}
+
+// responseRecorder records the status and size of a response for the access
+// log. It passes flushes and hijacks through, so streaming handlers keep
+// working.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+func (r *responseRecorder) Flush() {
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(r.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the wrapped ResponseWriter for http.ResponseController.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
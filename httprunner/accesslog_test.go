@@ -0,0 +1,105 @@
+package httprunner
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessLog(t *testing.T) {
+	logger, records := testutil.NewTestLogger(slog.LevelInfo)
+	server := New(&http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = io.WriteString(w, "short and stout")
+	})}, WithRequestID(), WithAccessLog(), WithLogger(logger))
+
+	req := httptest.NewRequest(http.MethodPost, "/pot", nil)
+	req.Header.Set(RequestIDHeader, "brew-1")
+	server.server.Handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	for key, want := range map[string]any{
+		"method":     http.MethodPost,
+		"path":       "/pot",
+		"status":     int64(http.StatusTeapot),
+		"bytes":      int64(len("short and stout")),
+		"request_id": "brew-1",
+	} {
+		value, ok := records.Attr("http request", key)
+		require.True(t, ok, "access log should record %s", key)
+		assert.Equal(t, want, value.Any(), key)
+	}
+	_, ok := records.Attr("http request", "duration")
+	assert.True(t, ok)
+}
+
+func TestAccessLogDraining(t *testing.T) {
+	logger, records := testutil.NewTestLogger(slog.LevelInfo)
+	server := New(&http.Server{Handler: http.NotFoundHandler()}, WithAccessLog(), WithLogger(logger))
+	server.draining.Store(true)
+
+	server.server.Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	status, ok := records.Attr("http request", "status")
+	require.True(t, ok)
+	assert.Equal(t, int64(http.StatusServiceUnavailable), status.Int64())
+	_, ok = records.Attr("http request", "request_id")
+	assert.False(t, ok, "without WithRequestID no ID should be logged")
+}
+
+func TestAccessLogSampling(t *testing.T) {
+	logger, records := testutil.NewTestLogger(slog.LevelInfo)
+	server := New(&http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/slow":
+			time.Sleep(20 * time.Millisecond)
+		case "/api/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})}, WithLogger(logger), WithAccessLog(
+		WithSampleRate("/", 0),
+		WithSampleRate("/api/", 0),
+		WithSampleRate("/api/orders", 1),
+		WithSlowThreshold(10*time.Millisecond),
+	))
+
+	var logged []string
+	for _, path := range []string{"/healthz", "/api/users", "/api/orders/7", "/api/slow", "/api/broken"} {
+		before := len(records.Records())
+		server.server.Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		for _, record := range records.Records()[before:] {
+			logged = append(logged, record.Level.String()+" "+path)
+		}
+	}
+	assert.Equal(t, []string{"INFO /api/orders/7", "WARN /api/slow", "INFO /api/broken"}, logged,
+		"the longest prefix should decide, and slow requests and server errors should bypass sampling")
+}
+
+func TestAccessLogSampleRate(t *testing.T) {
+	accessLog := &accessLog{rates: []sampleRate{{prefix: "/", rate: 0.5}}}
+
+	var sampled int
+	for range 1000 {
+		if accessLog.sampled("/") {
+			sampled++
+		}
+	}
+	assert.InDelta(t, 500, sampled, 100)
+}
+
+func TestNewFromConfigAccessLog(t *testing.T) {
+	server := NewFromConfig(Config{
+		AccessLog:              true,
+		AccessLogSampling:      []string{"/=0.5", "/healthz=0"},
+		AccessLogSlowThreshold: time.Second,
+	}, http.NotFoundHandler())
+
+	require.NotNil(t, server.accessLog)
+	assert.Equal(t, []sampleRate{{"/healthz", 0}, {"/", 0.5}}, server.accessLog.rates)
+	assert.Equal(t, time.Second, server.accessLog.slow)
+}
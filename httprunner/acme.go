@@ -0,0 +1,54 @@
+package httprunner
+
+import (
+	"crypto/tls"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// WithCertificates serves HTTPS with the certificates returned by get for
+// each TLS handshake, instead of a key pair read from files. Use it to plug
+// in a certificate source such as an ACME client solving DNS-01 challenges,
+// which autocert does not support.
+func WithCertificates(get func(hello *tls.ClientHelloInfo) (*tls.Certificate, error)) serverOption {
+	return func(s *Server) {
+		s.getCertificate = get
+	}
+}
+
+// WithAutocert serves HTTPS with certificates obtained and renewed
+// automatically from an ACME certificate authority such as Let's Encrypt by
+// manager, so small deployments get TLS without a reverse proxy. Challenges
+// are answered with TLS-ALPN-01 on the server itself, so the server must be
+// reachable on port 443 under every domain manager's HostPolicy allows.
+//
+// Example:
+//
+//	server := httprunner.New(&http.Server{Addr: ":443", Handler: mux},
+//	    httprunner.WithAutocert(&autocert.Manager{
+//	        Prompt:     autocert.AcceptTOS,
+//	        HostPolicy: autocert.HostWhitelist("example.com"),
+//	        Cache:      autocert.DirCache("/var/cache/acme"),
+//	    }),
+//	)
+func WithAutocert(manager *autocert.Manager) serverOption {
+	return func(s *Server) {
+		s.getCertificate = manager.GetCertificate
+		s.nextProtos = append(s.nextProtos, acme.ALPNProto)
+	}
+}
+
+// newAutocertManager returns the ACME certificate manager configured by cfg.
+func newAutocertManager(cfg Config) *autocert.Manager {
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.ACMEDomains...),
+		Cache:      autocert.DirCache(cfg.ACMECacheDir),
+		Email:      cfg.ACMEEmail,
+	}
+	if cfg.ACMEDirectoryURL != "" {
+		manager.Client = &acme.Client{DirectoryURL: cfg.ACMEDirectoryURL}
+	}
+	return manager
+}
@@ -0,0 +1,83 @@
+package httprunner
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+func TestServerWithCertificates(t *testing.T) {
+	ca := newTestCA(t)
+	cert := ca.issue(t, 2, x509.ExtKeyUsageServerAuth)
+	var served []string
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := New(&http.Server{Handler: http.NotFoundHandler()}, WithListener(listener),
+		WithCertificates(func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			served = append(served, hello.ServerName)
+			return &cert, nil
+		}))
+	serve(t, server)
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:    ca.pool(),
+		ServerName: "127.0.0.1",
+	}}}
+	resp, err := client.Get("https://" + listener.Addr().String())
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Len(t, served, 1)
+}
+
+func TestServerWithAutocert(t *testing.T) {
+	manager := &autocert.Manager{Prompt: autocert.AcceptTOS}
+	server := New(&http.Server{}, WithAutocert(manager))
+
+	require.NoError(t, server.configureTLS())
+	assert.NotNil(t, server.server.TLSConfig.GetCertificate)
+	assert.Contains(t, server.server.TLSConfig.NextProtos, acme.ALPNProto,
+		"TLS-ALPN-01 challenges should be negotiable")
+}
+
+func TestNewFromConfigACME(t *testing.T) {
+	cfg := Config{
+		ACME:             true,
+		ACMEDomains:      []string{"example.com"},
+		ACMEEmail:        "ops@example.com",
+		ACMECacheDir:     t.TempDir(),
+		ACMEDirectoryURL: "https://acme-staging-v02.api.letsencrypt.org/directory",
+	}
+	require.NoError(t, cfg.Validate())
+	server := NewFromConfig(cfg, http.NotFoundHandler())
+	assert.NotNil(t, server.getCertificate)
+
+	manager := newAutocertManager(cfg)
+	assert.Equal(t, "ops@example.com", manager.Email)
+	assert.Equal(t, cfg.ACMEDirectoryURL, manager.Client.DirectoryURL)
+	assert.NoError(t, manager.HostPolicy(t.Context(), "example.com"))
+	assert.Error(t, manager.HostPolicy(t.Context(), "other.com"))
+}
+
+func TestLoadConfigACME(t *testing.T) {
+	t.Setenv("HTTP_ACME", "true")
+	t.Setenv("HTTP_ACME_DOMAINS", "example.com|www.example.com")
+	t.Setenv("HTTP_ACME_CACHE_DIR", "/var/cache/acme")
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.True(t, cfg.ACME)
+	assert.Equal(t, []string{"example.com", "www.example.com"}, cfg.ACMEDomains)
+
+	err = Config{ACME: true, H2C: true}.Validate()
+	assert.ErrorContains(t, err, "HTTP_ACME requires HTTP_ACME_DOMAINS")
+	assert.ErrorContains(t, err, "HTTP_ACME requires HTTP_ACME_CACHE_DIR")
+	assert.ErrorContains(t, err, "HTTP_H2C cannot be combined with TLS")
+}
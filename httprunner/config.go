@@ -0,0 +1,251 @@
+package httprunner
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/config"
+)
+
+// Config declares the settings of an http.Server and its Server, so that the
+// server's hardening is configured rather than coded. It is typically loaded
+// from the environment with LoadConfig and passed to NewFromConfig, or
+// embedded in an application's own Config struct.
+type Config struct {
+
+	// Addr is the address to listen on, unless a listener is supplied with
+	// WithListener.
+	Addr string `env:"HTTP_ADDR,default=:8080"`
+
+	// ReadTimeout bounds reading an entire request, including its body.
+	// Zero means no timeout.
+	ReadTimeout time.Duration `env:"HTTP_READ_TIMEOUT"`
+
+	// ReadHeaderTimeout bounds reading the request headers, protecting
+	// against clients that trickle them in.
+	ReadHeaderTimeout time.Duration `env:"HTTP_READ_HEADER_TIMEOUT,default=10s"`
+
+	// WriteTimeout bounds writing a response. Zero means no timeout, which
+	// streaming handlers need.
+	WriteTimeout time.Duration `env:"HTTP_WRITE_TIMEOUT"`
+
+	// IdleTimeout bounds how long a keep-alive connection waits for its next
+	// request.
+	IdleTimeout time.Duration `env:"HTTP_IDLE_TIMEOUT,default=2m"`
+
+	// MaxHeaderBytes bounds the size of the request headers.
+	MaxHeaderBytes int `env:"HTTP_MAX_HEADER_BYTES,default=1048576"`
+
+	// TLSCertFile and TLSKeyFile, if set, serve HTTPS with the certificate
+	// and key in the given PEM files. Both or neither must be set.
+	TLSCertFile string `env:"HTTP_TLS_CERT_FILE"`
+	TLSKeyFile  string `env:"HTTP_TLS_KEY_FILE"`
+
+	// TLSClientCAFile, if set, requires clients to present a certificate
+	// issued by one of the CAs in the given PEM bundle (mutual TLS).
+	TLSClientCAFile string `env:"HTTP_TLS_CLIENT_CA_FILE"`
+
+	// ACME serves HTTPS with certificates for ACMEDomains obtained
+	// automatically from an ACME certificate authority, Let's Encrypt unless
+	// ACMEDirectoryURL is set, and cached in ACMECacheDir. The server must be
+	// reachable on port 443 under each domain (see WithAutocert).
+	ACME             bool     `env:"HTTP_ACME"`
+	ACMEDomains      []string `env:"HTTP_ACME_DOMAINS"`
+	ACMEEmail        string   `env:"HTTP_ACME_EMAIL"`
+	ACMECacheDir     string   `env:"HTTP_ACME_CACHE_DIR"`
+	ACMEDirectoryURL string   `env:"HTTP_ACME_DIRECTORY_URL"`
+
+	// H2C serves HTTP/2 without TLS alongside HTTP/1, for clients such as
+	// gRPC-Web proxies and service meshes that speak HTTP/2 in cleartext.
+	H2C bool `env:"HTTP_H2C"`
+
+	// ShutdownTimeout bounds http.Server.Shutdown once the runner's context
+	// is cancelled.
+	ShutdownTimeout time.Duration `env:"HTTP_SHUTDOWN_TIMEOUT,default=10s"`
+
+	// ConnectionDrainTimeout, if positive, serves requests received while
+	// draining with Connection: close and waits up to the timeout for the
+	// open connections to be closed (see WithConnectionDrain).
+	ConnectionDrainTimeout time.Duration `env:"HTTP_CONNECTION_DRAIN_TIMEOUT"`
+
+	// RequestID assigns every request an X-Request-ID and logs it with every
+	// line logged while handling the request (see WithRequestID).
+	RequestID bool `env:"HTTP_REQUEST_ID,default=true"`
+
+	// AccessLog logs every request once it has been handled (see
+	// WithAccessLog).
+	AccessLog bool `env:"HTTP_ACCESS_LOG,default=true"`
+
+	// AccessLogSampling lists the sample rates of path prefixes as
+	// prefix=rate, e.g. /healthz=0 or /api/=0.1 (see WithSampleRate).
+	AccessLogSampling []string `env:"HTTP_ACCESS_LOG_SAMPLING"`
+
+	// AccessLogSlowThreshold, if positive, logs requests taking at least as
+	// long at WARN level, whatever their sample rate (see WithSlowThreshold).
+	AccessLogSlowThreshold time.Duration `env:"HTTP_ACCESS_LOG_SLOW_THRESHOLD"`
+}
+
+// LoadConfig loads a Config from the environment and validates it:
+//
+//   - HTTP_ADDR: listen address (default: :8080)
+//   - HTTP_READ_TIMEOUT: request read timeout (default: none)
+//   - HTTP_READ_HEADER_TIMEOUT: header read timeout (default: 10s)
+//   - HTTP_WRITE_TIMEOUT: response write timeout (default: none)
+//   - HTTP_IDLE_TIMEOUT: keep-alive idle timeout (default: 2m)
+//   - HTTP_MAX_HEADER_BYTES: maximum header size (default: 1048576)
+//   - HTTP_TLS_CERT_FILE, HTTP_TLS_KEY_FILE: certificate and key PEM files
+//   - HTTP_TLS_CLIENT_CA_FILE: CA bundle verifying client certificates
+//   - HTTP_ACME: obtain certificates automatically (default: false)
+//   - HTTP_ACME_DOMAINS: pipe separated domains, e.g. example.com|www.example.com
+//   - HTTP_ACME_EMAIL: contact email for the ACME account
+//   - HTTP_ACME_CACHE_DIR: directory caching the account key and certificates
+//   - HTTP_ACME_DIRECTORY_URL: ACME directory, e.g. Let's Encrypt staging
+//   - HTTP_H2C: serve cleartext HTTP/2 (default: false)
+//   - HTTP_SHUTDOWN_TIMEOUT: graceful shutdown timeout (default: 10s)
+//   - HTTP_CONNECTION_DRAIN_TIMEOUT: reverse-proxy connection drain (default: none)
+//   - HTTP_REQUEST_ID: assign and propagate request IDs (default: true)
+//   - HTTP_ACCESS_LOG: log every request (default: true)
+//   - HTTP_ACCESS_LOG_SAMPLING: pipe separated sample rates, e.g. /healthz=0|/api/=0.1
+//   - HTTP_ACCESS_LOG_SLOW_THRESHOLD: log slower requests at WARN (default: none)
+func LoadConfig() (Config, error) {
+	cfg, err := config.LoadVar[Config](config.WithPrefix("HTTP_"))
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to load http configuration from environment: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Validate reports settings that cannot be served: negative timeouts or
+// header limits, half a TLS key pair, a client CA without TLS, ACME without
+// domains or a cache, cleartext HTTP/2 with TLS, and malformed access log
+// sample rates.
+func (c Config) Validate() error {
+	var errs []error
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"HTTP_READ_TIMEOUT", c.ReadTimeout},
+		{"HTTP_READ_HEADER_TIMEOUT", c.ReadHeaderTimeout},
+		{"HTTP_WRITE_TIMEOUT", c.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", c.IdleTimeout},
+		{"HTTP_SHUTDOWN_TIMEOUT", c.ShutdownTimeout},
+		{"HTTP_CONNECTION_DRAIN_TIMEOUT", c.ConnectionDrainTimeout},
+		{"HTTP_ACCESS_LOG_SLOW_THRESHOLD", c.AccessLogSlowThreshold},
+	} {
+		if timeout.value < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative, got %s", timeout.name, timeout.value))
+		}
+	}
+	if c.MaxHeaderBytes < 0 {
+		errs = append(errs, fmt.Errorf("HTTP_MAX_HEADER_BYTES must not be negative, got %d", c.MaxHeaderBytes))
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		errs = append(errs, errors.New("HTTP_TLS_CERT_FILE and HTTP_TLS_KEY_FILE must be set together"))
+	}
+	if c.TLSClientCAFile != "" && c.TLSCertFile == "" && !c.ACME {
+		errs = append(errs, errors.New("HTTP_TLS_CLIENT_CA_FILE requires TLS"))
+	}
+	if c.ACME {
+		if len(c.ACMEDomains) == 0 {
+			errs = append(errs, errors.New("HTTP_ACME requires HTTP_ACME_DOMAINS"))
+		}
+		if c.ACMECacheDir == "" {
+			errs = append(errs, errors.New("HTTP_ACME requires HTTP_ACME_CACHE_DIR, or certificates are requested again on every start"))
+		}
+		if c.TLSCertFile != "" {
+			errs = append(errs, errors.New("HTTP_ACME cannot be combined with HTTP_TLS_CERT_FILE"))
+		}
+	}
+	if c.H2C && (c.TLSCertFile != "" || c.ACME) {
+		errs = append(errs, errors.New("HTTP_H2C cannot be combined with TLS, which negotiates HTTP/2 itself"))
+	}
+	if _, err := parseSampleRates(c.AccessLogSampling); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// parseSampleRates parses HTTP_ACCESS_LOG_SAMPLING entries of the form
+// prefix=rate.
+func parseSampleRates(entries []string) ([]sampleRate, error) {
+	rates := make([]sampleRate, 0, len(entries))
+	for _, entry := range entries {
+		prefix, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("HTTP_ACCESS_LOG_SAMPLING entry %q must be of the form prefix=rate", entry)
+		}
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("HTTP_ACCESS_LOG_SAMPLING rate of %q must be between 0 and 1, got %q", prefix, value)
+		}
+		rates = append(rates, sampleRate{prefix: prefix, rate: rate})
+	}
+	return rates, nil
+}
+
+// NewFromConfig returns a Server serving handler with the settings of cfg.
+// Options are applied after the settings, so they can override them.
+//
+// Example:
+//
+//	cfg, err := httprunner.LoadConfig()
+//	if err != nil {
+//	    return ezapp.AppCtx{}, err
+//	}
+//	server := httprunner.NewFromConfig(cfg, mux, httprunner.WithLogger(ctx.Logger))
+func NewFromConfig(cfg Config, handler http.Handler, options ...serverOption) *Server {
+	server := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+	if cfg.H2C {
+		server.Protocols = new(http.Protocols)
+		server.Protocols.SetHTTP1(true)
+		server.Protocols.SetUnencryptedHTTP2(true)
+	}
+
+	var configured []serverOption
+	if cfg.ShutdownTimeout > 0 {
+		configured = append(configured, WithShutdownTimeout(cfg.ShutdownTimeout))
+	}
+	if cfg.ConnectionDrainTimeout > 0 {
+		configured = append(configured, WithConnectionDrain(cfg.ConnectionDrainTimeout))
+	}
+	if cfg.TLSCertFile != "" {
+		configured = append(configured, WithTLS(cfg.TLSCertFile, cfg.TLSKeyFile))
+	}
+	if cfg.ACME {
+		configured = append(configured, WithAutocert(newAutocertManager(cfg)))
+	}
+	if cfg.TLSClientCAFile != "" {
+		configured = append(configured, WithClientCA(cfg.TLSClientCAFile))
+	}
+	if cfg.RequestID {
+		configured = append(configured, WithRequestID())
+	}
+	if cfg.AccessLog {
+		var accessLogOptions []accessLogOption
+		rates, _ := parseSampleRates(cfg.AccessLogSampling) // checked by Validate
+		for _, rate := range rates {
+			accessLogOptions = append(accessLogOptions, WithSampleRate(rate.prefix, rate.rate))
+		}
+		if cfg.AccessLogSlowThreshold > 0 {
+			accessLogOptions = append(accessLogOptions, WithSlowThreshold(cfg.AccessLogSlowThreshold))
+		}
+		configured = append(configured, WithAccessLog(accessLogOptions...))
+	}
+	return New(server, append(configured, options...)...)
+}
@@ -0,0 +1,141 @@
+package httprunner
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigDefaults(t *testing.T) {
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, Config{
+		Addr:              ":8080",
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+		MaxHeaderBytes:    1 << 20,
+		ShutdownTimeout:   10 * time.Second,
+		RequestID:         true,
+		AccessLog:         true,
+	}, cfg)
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("HTTP_ADDR", ":9090")
+	t.Setenv("HTTP_READ_TIMEOUT", "5s")
+	t.Setenv("HTTP_WRITE_TIMEOUT", "30s")
+	t.Setenv("HTTP_MAX_HEADER_BYTES", "8192")
+	t.Setenv("HTTP_H2C", "true")
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, ":9090", cfg.Addr)
+	assert.Equal(t, 5*time.Second, cfg.ReadTimeout)
+	assert.Equal(t, 30*time.Second, cfg.WriteTimeout)
+	assert.Equal(t, 8192, cfg.MaxHeaderBytes)
+	assert.True(t, cfg.H2C)
+
+	t.Setenv("HTTP_TLS_CERT_FILE", "server.crt")
+	_, err = LoadConfig()
+	assert.ErrorContains(t, err, "must be set together")
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, Config{}.Validate())
+
+	err := Config{ReadTimeout: -time.Second, MaxHeaderBytes: -1}.Validate()
+	assert.ErrorContains(t, err, "HTTP_READ_TIMEOUT must not be negative")
+	assert.ErrorContains(t, err, "HTTP_MAX_HEADER_BYTES must not be negative")
+
+	err = Config{TLSClientCAFile: "ca.crt"}.Validate()
+	assert.ErrorContains(t, err, "HTTP_TLS_CLIENT_CA_FILE requires")
+
+	err = Config{TLSCertFile: "server.crt", TLSKeyFile: "server.key", H2C: true}.Validate()
+	assert.ErrorContains(t, err, "HTTP_H2C cannot be combined with TLS")
+
+	assert.NoError(t, Config{AccessLogSampling: []string{"/healthz=0", "/api/=0.25"}}.Validate())
+	err = Config{AccessLogSampling: []string{"/healthz"}}.Validate()
+	assert.ErrorContains(t, err, "must be of the form prefix=rate")
+	err = Config{AccessLogSampling: []string{"/api/=1.5"}}.Validate()
+	assert.ErrorContains(t, err, "must be between 0 and 1")
+}
+
+func TestNewFromConfig(t *testing.T) {
+	cfg := Config{
+		Addr:              ":9090",
+		ReadTimeout:       time.Second,
+		ReadHeaderTimeout: 2 * time.Second,
+		WriteTimeout:      3 * time.Second,
+		IdleTimeout:       4 * time.Second,
+		MaxHeaderBytes:    4096,
+		ShutdownTimeout:   5 * time.Second,
+	}
+	server := NewFromConfig(cfg, http.NotFoundHandler(), WithShutdownTimeout(6*time.Second))
+
+	assert.Equal(t, ":9090", server.server.Addr)
+	assert.Equal(t, time.Second, server.server.ReadTimeout)
+	assert.Equal(t, 2*time.Second, server.server.ReadHeaderTimeout)
+	assert.Equal(t, 3*time.Second, server.server.WriteTimeout)
+	assert.Equal(t, 4*time.Second, server.server.IdleTimeout)
+	assert.Equal(t, 4096, server.server.MaxHeaderBytes)
+	assert.Nil(t, server.server.Protocols)
+	assert.Equal(t, 6*time.Second, server.shutdownTimeout, "options should override the config")
+	assert.False(t, server.requestID)
+	assert.Nil(t, server.accessLog)
+}
+
+// serveConfig runs a Server built from cfg until the test ends and returns
+// its address
+func serveConfig(t *testing.T, cfg Config, options ...serverOption) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	serve(t, NewFromConfig(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto)
+	}), append(options, WithListener(listener))...))
+	return listener.Addr().String()
+}
+
+// serve runs server until the test ends
+func serve(t *testing.T, server *Server) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+}
+
+func TestNewFromConfigH2C(t *testing.T) {
+	addr := serveConfig(t, Config{H2C: true})
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+
+	resp, err := client.Get("http://" + addr)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "HTTP/2.0", string(body))
+}
+
+func TestNewFromConfigTLS(t *testing.T) {
+	certFile, keyFile, pool := writeTestCertificate(t)
+	addr := serveConfig(t, Config{TLSCertFile: certFile, TLSKeyFile: keyFile})
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	resp, err := client.Get("https://" + addr)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotNil(t, resp.TLS)
+}
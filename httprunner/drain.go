@@ -0,0 +1,134 @@
+package httprunner
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/metrics"
+)
+
+// WithConnectionDrain makes draining friendly to reverse proxies and load
+// balancers, such as AWS ALB or Cloudflare, that keep connections to the
+// server alive and keep routing requests to it for a while after it has
+// started draining. Instead of being rejected with 503, requests received
+// while draining are served with Connection: close, so that the proxy retires
+// each connection after its next response rather than having it closed under
+// a request in flight, which it reports to clients as a 502. Drain then waits
+// for the open connections to be closed, for up to timeout, before waiting
+// for the requests in flight. Connections still open after timeout are closed
+// by the shutdown.
+//
+// The timeout should cover the proxy's deregistration delay, during which it
+// may still send requests. Use Collector to export the drain metrics.
+//
+// Example:
+//
+//	server := httprunner.New(&http.Server{Handler: mux},
+//	    httprunner.WithConnectionDrain(30*time.Second), // ALB deregistration delay
+//	)
+func WithConnectionDrain(timeout time.Duration) serverOption {
+	return func(s *Server) {
+		s.connDrainTimeout = timeout
+	}
+}
+
+// trackConnections counts the server's open connections, calling any
+// ConnState hook already set on it.
+func (s *Server) trackConnections() {
+	next := s.server.ConnState
+	s.server.ConnState = func(conn net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			s.openConns.Add(1)
+		case http.StateClosed, http.StateHijacked:
+			s.openConns.Add(-1)
+		}
+		if next != nil {
+			next(conn, state)
+		}
+	}
+}
+
+// OpenConnections returns the number of connections currently open,
+// including idle keep-alive connections but not hijacked ones.
+func (s *Server) OpenConnections() int64 {
+	return s.openConns.Load()
+}
+
+// drainConnections waits for the open connections to be closed, for up to the
+// connection drain timeout or until ctx is done.
+func (s *Server) drainConnections(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	timer := time.NewTimer(s.connDrainTimeout)
+	defer timer.Stop()
+
+	for {
+		open := s.OpenConnections()
+		if open == 0 {
+			s.logger.Info("drained http connections")
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+			s.logger.Warn("http connections still open after drain timeout", "open_connections", open, "timeout", s.connDrainTimeout)
+			return nil
+		case <-ctx.Done():
+			s.logger.Warn("http connection drain interrupted", "open_connections", open)
+			return fmt.Errorf("drain interrupted with %d connections open: %w", open, ctx.Err())
+		}
+	}
+}
+
+// Collector returns a metrics collector reporting the server's requests in
+// flight and open connections and, once draining has started, the requests
+// rejected while draining and the time the drain took.
+//
+// Example:
+//
+//	ctx.Metrics.Register(server.Collector())
+func (s *Server) Collector() metrics.Collector {
+	return func() []metrics.Sample {
+		var draining float64
+		if s.Draining() {
+			draining = 1
+		}
+		return []metrics.Sample{
+			{
+				Name:  "http_server_in_flight_requests",
+				Help:  "Number of requests being handled.",
+				Type:  metrics.Gauge,
+				Value: float64(s.InFlight()),
+			},
+			{
+				Name:  "http_server_open_connections",
+				Help:  "Number of open client connections, including idle keep-alive connections.",
+				Type:  metrics.Gauge,
+				Value: float64(s.OpenConnections()),
+			},
+			{
+				Name:  "http_server_draining",
+				Help:  "Whether the server has started draining.",
+				Type:  metrics.Gauge,
+				Value: draining,
+			},
+			{
+				Name:  "http_server_drain_rejected_requests_total",
+				Help:  "Number of requests rejected because the server was draining.",
+				Type:  metrics.Counter,
+				Value: float64(s.drainRejected.Load()),
+			},
+			{
+				Name:  "http_server_drain_seconds",
+				Help:  "Time the last completed drain took.",
+				Type:  metrics.Gauge,
+				Value: time.Duration(s.drainDuration.Load()).Seconds(),
+			},
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package httprunner
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerConnectionDrain(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := New(&http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	})},
+		WithListener(listener),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithConnectionDrain(time.Second),
+	)
+	serve(t, server)
+	url := "http://" + listener.Addr().String()
+
+	// A proxy keeps its connection alive between requests
+	client := &http.Client{Transport: &http.Transport{}}
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	assert.False(t, resp.Close)
+	require.Eventually(t, func() bool { return server.OpenConnections() == 1 }, time.Second, time.Millisecond)
+
+	drained := make(chan error, 1)
+	go func() { drained <- server.Drain(context.Background()) }()
+	require.Eventually(t, server.Draining, time.Second, time.Millisecond)
+
+	// Requests still routed to the server are served and retire the connection
+	resp, err = client.Get(url)
+	require.NoError(t, err)
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, resp.Close, "responses while draining should close the connection")
+
+	select {
+	case err := <-drained:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("drain should complete once the connection is closed")
+	}
+	assert.Zero(t, server.OpenConnections())
+}
+
+func TestServerConnectionDrainTimeout(t *testing.T) {
+	server := New(&http.Server{},
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithConnectionDrain(20*time.Millisecond),
+	)
+	server.openConns.Add(1)
+
+	start := time.Now()
+	require.NoError(t, server.Drain(context.Background()), "an idle connection should not fail the drain")
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	server = New(&http.Server{},
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithConnectionDrain(time.Minute),
+	)
+	server.openConns.Add(1)
+	assert.ErrorContains(t, server.Drain(ctx), "drain interrupted with 1 connections open")
+}
+
+func TestServerCollector(t *testing.T) {
+	server := New(&http.Server{}, WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	server.openConns.Add(2)
+
+	value := func(name string) float64 {
+		for _, sample := range server.Collector()() {
+			if sample.Name == name {
+				return sample.Value
+			}
+		}
+		t.Fatalf("no sample %s", name)
+		return 0
+	}
+	assert.Equal(t, float64(2), value("http_server_open_connections"))
+	assert.Zero(t, value("http_server_draining"))
+
+	require.NoError(t, server.Drain(context.Background()))
+	server.server.Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, float64(1), value("http_server_draining"))
+	assert.Equal(t, float64(1), value("http_server_drain_rejected_requests_total"))
+
+	registry := metrics.NewRegistry()
+	registry.Register(server.Collector())
+	assert.Len(t, registry.Gather(), 5)
+}
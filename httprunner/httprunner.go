@@ -0,0 +1,303 @@
+// Package httprunner runs an http.Server as an ezapp runner with graceful
+// request draining on shutdown.
+package httprunner
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/loadshed"
+)
+
+// DefaultShutdownTimeout bounds http.Server.Shutdown when the runner's
+// context is cancelled and no timeout is set with WithShutdownTimeout.
+const DefaultShutdownTimeout = 10 * time.Second
+
+// drainProgressInterval is how often Drain logs the number of requests still
+// in flight.
+const drainProgressInterval = time.Second
+
+// Server runs an http.Server as a runner. Its handler is wrapped with
+// middleware that counts in-flight requests and, once draining has started,
+// rejects new requests with 503 Service Unavailable and Connection: close so
+// that clients and load balancers move to other instances.
+type Server struct {
+	server           *http.Server
+	listener         net.Listener
+	logger           *slog.Logger
+	shutdownTimeout  time.Duration
+	notifiers        []func()
+	shedder          *loadshed.Shedder
+	certFile         string
+	keyFile          string
+	clientCAFile     string
+	clientCertCheck  func(chains [][]*x509.Certificate) error
+	getCertificate   func(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
+	nextProtos       []string
+	connDrainTimeout time.Duration
+	requestID        bool
+	accessLog        *accessLog
+
+	inFlight      atomic.Int64
+	openConns     atomic.Int64
+	drainRejected atomic.Int64
+	drainDuration atomic.Int64
+	draining      atomic.Bool
+	drainOnce     sync.Once
+	shuttingDown  chan struct{}
+}
+
+// serverOption represents a functional option for configuring a Server.
+// This type is not exported to ensure only predefined options can be used.
+type serverOption func(*Server)
+
+// WithListener serves on listener, e.g. one returned by ezapp.Listen,
+// instead of listening on the server's Addr.
+func WithListener(listener net.Listener) serverOption {
+	return func(s *Server) {
+		s.listener = listener
+	}
+}
+
+// WithLogger sets the logger that drain progress is reported to, instead of
+// slog.Default.
+func WithLogger(logger *slog.Logger) serverOption {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// WithShutdownTimeout bounds http.Server.Shutdown once the runner's context
+// is cancelled, instead of DefaultShutdownTimeout.
+func WithShutdownTimeout(timeout time.Duration) serverOption {
+	return func(s *Server) {
+		s.shutdownTimeout = timeout
+	}
+}
+
+// WithShutdownNotifier registers notify to be called once draining starts,
+// ahead of the runner's context being cancelled. Use it to tell long-lived
+// streaming connections, such as WebSockets or server-sent events, to wind
+// down, e.g. by sending a close frame. http.Server.Shutdown neither closes
+// nor waits for hijacked connections, so they rely on this notification.
+// Notifiers run in registration order; the option can be repeated.
+func WithShutdownNotifier(notify func()) serverOption {
+	return func(s *Server) {
+		s.notifiers = append(s.notifiers, notify)
+	}
+}
+
+// WithTLS serves HTTPS with the certificate and key in the given PEM files.
+// The files are reloaded when they change, so rotated certificates are served
+// without a restart.
+func WithTLS(certFile, keyFile string) serverOption {
+	return func(s *Server) {
+		s.certFile, s.keyFile = certFile, keyFile
+	}
+}
+
+// WithLoadShedder admits requests through shedder, rejecting those over its
+// limit with 503 Service Unavailable. Requests rejected while draining are not
+// counted against it.
+func WithLoadShedder(shedder *loadshed.Shedder) serverOption {
+	return func(s *Server) {
+		s.shedder = shedder
+	}
+}
+
+// New returns a Server running server. The server's Handler, or
+// http.DefaultServeMux if nil, is wrapped with the draining middleware.
+//
+// Register Drain as a pre-shutdown hook so that requests are drained while
+// the application is still up, before Run shuts the server down:
+//
+//	server := httprunner.New(&http.Server{Addr: ":8080", Handler: mux},
+//	    httprunner.WithLogger(ctx.Logger))
+//	return ezapp.Construct(
+//	    ezapp.WithNamedRunner("http", server.Run),
+//	    ezapp.WithPreShutdownHook(server.Drain),
+//	)
+func New(server *http.Server, options ...serverOption) *Server {
+	s := &Server{
+		server:          server,
+		logger:          slog.Default(),
+		shutdownTimeout: DefaultShutdownTimeout,
+		shuttingDown:    make(chan struct{}),
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+
+	handler := server.Handler
+	if handler == nil {
+		handler = http.DefaultServeMux
+	}
+	if s.shedder != nil {
+		handler = loadshed.Middleware(s.shedder, handler)
+	}
+	handler = s.middleware(handler)
+	if s.requestID || s.accessLog != nil {
+		handler = s.correlate(handler)
+	}
+	server.Handler = handler
+	s.trackConnections()
+	return s
+}
+
+// middleware counts in-flight requests and rejects new ones while draining,
+// unless the connections are drained with WithConnectionDrain.
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+
+		if s.draining.Load() {
+			w.Header().Set("Connection", "close")
+			if s.connDrainTimeout <= 0 {
+				s.drainRejected.Add(1)
+				http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// InFlight returns the number of requests currently being handled.
+func (s *Server) InFlight() int64 {
+	return s.inFlight.Load()
+}
+
+// Draining reports whether draining has started.
+func (s *Server) Draining() bool {
+	return s.draining.Load()
+}
+
+// ShuttingDown returns a channel that is closed once draining starts.
+// Streaming handlers can select on it to end their streams gracefully:
+//
+//	select {
+//	case <-server.ShuttingDown():
+//	    return conn.Close(websocket.StatusGoingAway, "server shutting down")
+//	case msg := <-messages:
+//	    ...
+//	}
+func (s *Server) ShuttingDown() <-chan struct{} {
+	return s.shuttingDown
+}
+
+// startDraining starts rejecting new requests and notifies streaming
+// connections. Only the first call has an effect.
+func (s *Server) startDraining() {
+	s.drainOnce.Do(func() {
+		s.draining.Store(true)
+		s.logger.Info("draining http requests", "in_flight", s.InFlight())
+		close(s.shuttingDown)
+		for _, notify := range s.notifiers {
+			notify()
+		}
+	})
+}
+
+// Drain starts rejecting new requests, notifies streaming connections and
+// waits for the requests in flight to complete or for ctx to be done, logging
+// progress every second. With WithConnectionDrain, it first waits for the
+// open connections to be closed. It has the signature of a pre-shutdown hook.
+func (s *Server) Drain(ctx context.Context) error {
+	s.startDraining()
+	startedAt := time.Now()
+	defer func() {
+		s.drainDuration.Store(int64(time.Since(startedAt)))
+	}()
+	if s.connDrainTimeout > 0 {
+		if err := s.drainConnections(ctx); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	lastReport := time.Now()
+
+	for {
+		inFlight := s.InFlight()
+		if inFlight == 0 {
+			s.logger.Info("drained http requests")
+			return nil
+		}
+		if time.Since(lastReport) >= drainProgressInterval {
+			s.logger.Info("waiting for http requests to drain", "in_flight", inFlight)
+			lastReport = time.Now()
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			s.logger.Warn("http request drain interrupted", "in_flight", inFlight)
+			return fmt.Errorf("drain interrupted with %d requests in flight: %w", inFlight, ctx.Err())
+		}
+	}
+}
+
+// Run serves until ctx is cancelled, then starts draining if it has not
+// started yet and shuts the server down gracefully within the shutdown
+// timeout. It returns an error if the server fails to serve or to shut down.
+//
+// Unless the http.Server sets its own BaseContext, the contexts of requests
+// derive from ctx, so they carry its values, such as the runner's logger and
+// the tracking of ezapp.Detach, but are not cancelled with it: requests in
+// flight when ctx is cancelled still complete while the server shuts down.
+func (s *Server) Run(ctx context.Context) error {
+	if s.server.BaseContext == nil {
+		base := context.WithoutCancel(ctx)
+		s.server.BaseContext = func(net.Listener) context.Context {
+			return base
+		}
+	}
+	tlsEnabled := s.certFile != "" || s.getCertificate != nil
+	if tlsEnabled {
+		if err := s.configureTLS(); err != nil {
+			return fmt.Errorf("http server failed: %w", err)
+		}
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		switch {
+		case s.listener != nil && tlsEnabled:
+			serveErr <- s.server.ServeTLS(s.listener, "", "")
+		case s.listener != nil:
+			serveErr <- s.server.Serve(s.listener)
+		case tlsEnabled:
+			serveErr <- s.server.ListenAndServeTLS("", "")
+		default:
+			serveErr <- s.server.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("http server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	s.startDraining()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	if err := s.server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("http server shutdown failed: %w", err)
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("http server failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package httprunner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/loadshed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, handler http.Handler) (*Server, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := New(&http.Server{Handler: handler},
+		WithListener(listener),
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithShutdownTimeout(time.Second),
+	)
+	return server, "http://" + listener.Addr().String()
+}
+
+func TestServerRun(t *testing.T) {
+	server, url := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Run(ctx) }()
+
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(url)
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode == http.StatusOK && string(body) == "ok"
+	}, 2*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.True(t, server.Draining())
+}
+
+func TestServerRunBaseContext(t *testing.T) {
+	type key struct{}
+	inHandler := make(chan struct{})
+	release := make(chan struct{})
+	server, url := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(inHandler)
+		<-release
+		value, _ := r.Context().Value(key{}).(string)
+		_, _ = io.WriteString(w, value+" "+fmt.Sprint(r.Context().Err()))
+	}))
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "runner"))
+	done := make(chan error, 1)
+	go func() { done <- server.Run(ctx) }()
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-inHandler
+	cancel()
+	close(release)
+
+	assert.Equal(t, "runner <nil>", <-body,
+		"requests should carry the runner's values without being cancelled with it")
+	require.NoError(t, <-done)
+}
+
+func TestServerDrain(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	server, url := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		_, _ = io.WriteString(w, "done")
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = server.Run(ctx) }()
+
+	slow := make(chan int, 1)
+	go func() {
+		for {
+			resp, err := http.Get(url)
+			if err != nil {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			resp.Body.Close()
+			slow <- resp.StatusCode
+			return
+		}
+	}()
+	<-started
+	assert.Equal(t, int64(1), server.InFlight())
+
+	drained := make(chan error, 1)
+	go func() { drained <- server.Drain(context.Background()) }()
+	require.Eventually(t, server.Draining, time.Second, time.Millisecond)
+
+	// New requests are rejected while the slow one completes
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.True(t, resp.Close, "rejected responses should close the connection")
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-slow)
+	require.NoError(t, <-drained)
+	assert.Zero(t, server.InFlight())
+}
+
+func TestServerDrainInterrupted(t *testing.T) {
+	server := New(&http.Server{}, WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	server.inFlight.Add(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := server.Drain(ctx)
+	assert.ErrorContains(t, err, "drain interrupted with 1 requests in flight")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestServerRunListenFailure(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer busy.Close()
+
+	server := New(&http.Server{Addr: busy.Addr().String()})
+	err = server.Run(context.Background())
+	assert.ErrorContains(t, err, "http server failed")
+}
+
+func TestServerShutdownNotifier(t *testing.T) {
+	var notified []string
+	server := New(&http.Server{},
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithShutdownNotifier(func() { notified = append(notified, "first") }),
+		WithShutdownNotifier(func() { notified = append(notified, "second") }),
+	)
+
+	// A streaming handler ends its stream once notified
+	server.inFlight.Add(1)
+	go func() {
+		<-server.ShuttingDown()
+		server.inFlight.Add(-1)
+	}()
+
+	require.NoError(t, server.Drain(context.Background()))
+	require.NoError(t, server.Drain(context.Background()))
+	assert.Equal(t, []string{"first", "second"}, notified, "notifiers should run once, in order")
+}
+
+func TestServerLoadShedder(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	shedder := loadshed.New(loadshed.WithMaxConcurrency(1))
+	server := New(&http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})}, WithLoadShedder(shedder))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server.server.Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+
+	close(release)
+	<-done
+	assert.Equal(t, uint64(1), shedder.Stats().Rejected)
+}
@@ -0,0 +1,95 @@
+package httprunner
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pgvanniekerk/ezapp/internal/logctx"
+	"github.com/pgvanniekerk/ezapp/internal/requestid"
+)
+
+// RequestIDHeader is the header carrying the ID that correlates a request
+// across services and log lines.
+const RequestIDHeader = "X-Request-ID"
+
+// WithRequestID assigns every request an ID, taken from its X-Request-ID
+// header if it carries a valid one or generated otherwise, and echoes it in
+// the response's X-Request-ID header. The request's context carries the ID,
+// returned by RequestIDFromContext, and a logger recording it under
+// "request_id", returned by ezapp.LoggerFromContext, so every line logged
+// while handling the request can be correlated. Use RequestIDTransport to
+// pass the ID on to the services the handler calls.
+func WithRequestID() serverOption {
+	return func(s *Server) {
+		s.requestID = true
+	}
+}
+
+// RequestIDFromContext returns the ID of the request being handled, including
+// gRPC calls handled by grpcrunner, or an empty string if ctx carries none.
+func RequestIDFromContext(ctx context.Context) string {
+	return requestid.From(ctx)
+}
+
+// ContextWithRequestID returns a copy of ctx carrying id, which
+// RequestIDTransport and the grpcrunner client interceptors pass on. Use it
+// to continue a correlation outside an HTTP handler, e.g. in a message
+// consumer.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return requestid.With(ctx, id)
+}
+
+// RequestIDTransport returns a RoundTripper that sets the X-Request-ID header
+// of outgoing requests to the ID carried by their context, unless they
+// already have one, and then sends them with base, or http.DefaultTransport if
+// nil.
+//
+// Example:
+//
+//	client := &http.Client{Transport: httprunner.RequestIDTransport(nil)}
+//	req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, inventoryURL, nil)
+//	resp, err := client.Do(req)
+func RequestIDTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		id := RequestIDFromContext(req.Context())
+		if id == "" || req.Header.Get(RequestIDHeader) != "" {
+			return base.RoundTrip(req)
+		}
+		req = req.Clone(req.Context())
+		req.Header.Set(RequestIDHeader, id)
+		return base.RoundTrip(req)
+	})
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// correlate assigns the request ID and logs the access, as enabled by
+// WithRequestID and WithAccessLog.
+func (s *Server) correlate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := s.logger
+		if s.requestID {
+			id := r.Header.Get(RequestIDHeader)
+			if !requestid.Valid(id) {
+				id = requestid.New()
+			}
+			w.Header().Set(RequestIDHeader, id)
+			logger = logger.With("request_id", id)
+			ctx := ContextWithRequestID(logctx.With(r.Context(), logger), id)
+			r = r.WithContext(ctx)
+		}
+		if s.accessLog == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		s.accessLog.serve(logger, next, w, r)
+	})
+}
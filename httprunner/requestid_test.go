@@ -0,0 +1,70 @@
+package httprunner
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pgvanniekerk/ezapp/internal/logctx"
+	"github.com/pgvanniekerk/ezapp/internal/requestid"
+	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	logger, records := testutil.NewTestLogger(slog.LevelInfo)
+	server := New(&http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestLogger, ok := logctx.From(r.Context())
+		require.True(t, ok, "the request context should carry a logger")
+		requestLogger.Info("handling")
+		_, _ = io.WriteString(w, RequestIDFromContext(r.Context()))
+	})}, WithRequestID(), WithLogger(logger))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "upstream-42")
+	rec := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(rec, req)
+	assert.Equal(t, "upstream-42", rec.Header().Get(RequestIDHeader))
+	assert.Equal(t, "upstream-42", rec.Body.String())
+	id, ok := records.Attr("handling", "request_id")
+	require.True(t, ok)
+	assert.Equal(t, "upstream-42", id.String())
+
+	for _, invalid := range []string{"", "has space", strings.Repeat("x", requestid.MaxLength+1)} {
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(RequestIDHeader, invalid)
+		rec = httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(rec, req)
+		assert.Len(t, rec.Header().Get(RequestIDHeader), 32, "invalid ID %q should be replaced", invalid)
+		assert.Equal(t, rec.Header().Get(RequestIDHeader), rec.Body.String())
+	}
+}
+
+func TestRequestIDTransport(t *testing.T) {
+	var received []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get(RequestIDHeader))
+	}))
+	defer upstream.Close()
+	client := &http.Client{Transport: RequestIDTransport(nil)}
+
+	send := func(req *http.Request) {
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	ctx := ContextWithRequestID(t.Context(), "abc")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL, nil)
+	send(req)
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL, nil)
+	req.Header.Set(RequestIDHeader, "explicit")
+	send(req)
+	req, _ = http.NewRequestWithContext(t.Context(), http.MethodGet, upstream.URL, nil)
+	send(req)
+
+	assert.Equal(t, []string{"abc", "explicit", ""}, received)
+}
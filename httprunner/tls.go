@@ -0,0 +1,194 @@
+package httprunner
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// tlsReloadInterval is how often the TLS files are checked for changes.
+const tlsReloadInterval = time.Second
+
+// WithClientCA requires clients to present a certificate issued by one of
+// the CAs in the PEM bundle caFile, for mutual TLS between internal services.
+// It has no effect without WithTLS. Like the server's key pair, the bundle is
+// reloaded when the file changes, so CA rotations take effect without a
+// restart.
+func WithClientCA(caFile string) serverOption {
+	return func(s *Server) {
+		s.clientCAFile = caFile
+	}
+}
+
+// WithClientCertCheck calls check with the verified chains of every client
+// certificate, leaf first, rejecting the connection if it returns an error.
+// Use it for revocation checks against a CRL or an OCSP responder, or to
+// allow only certain identities. It has no effect without WithClientCA.
+//
+// Example:
+//
+//	httprunner.WithClientCertCheck(func(chains [][]*x509.Certificate) error {
+//	    if revoked.Contains(chains[0][0].SerialNumber) {
+//	        return errors.New("client certificate revoked")
+//	    }
+//	    return nil
+//	})
+func WithClientCertCheck(check func(chains [][]*x509.Certificate) error) serverOption {
+	return func(s *Server) {
+		s.clientCertCheck = check
+	}
+}
+
+// configureTLS sets up the server's TLS configuration to serve the
+// certificates of WithCertificates or WithAutocert, or else the key pair given
+// to WithTLS, and to verify clients against the CA bundle given to
+// WithClientCA, reloading the files when they change.
+func (s *Server) configureTLS() error {
+	files := &tlsFiles{
+		certFile: s.certFile,
+		keyFile:  s.keyFile,
+		caFile:   s.clientCAFile,
+		interval: tlsReloadInterval,
+		logger:   s.logger,
+	}
+	if err := files.reload(); err != nil {
+		return err
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if s.server.TLSConfig != nil {
+		cfg = s.server.TLSConfig.Clone()
+	}
+	cfg.GetCertificate = s.getCertificate
+	if cfg.GetCertificate == nil {
+		cfg.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, _ := files.current()
+			return cert, nil
+		}
+	}
+	cfg.NextProtos = append(cfg.NextProtos, s.nextProtos...)
+	if s.clientCAFile != "" {
+		// Clients are verified against the current bundle here rather than
+		// through ClientCAs, which cannot be swapped once serving
+		cfg.ClientAuth = tls.RequireAnyClientCert
+		cfg.VerifyConnection = func(state tls.ConnectionState) error {
+			_, pool := files.current()
+			return verifyClient(state.PeerCertificates, pool, s.clientCertCheck)
+		}
+	}
+	s.server.TLSConfig = cfg
+	return nil
+}
+
+// verifyClient verifies the certificates presented by a client, leaf first,
+// against pool and passes the verified chains to check, if any.
+func verifyClient(certs []*x509.Certificate, pool *x509.CertPool, check func([][]*x509.Certificate) error) error {
+	if len(certs) == 0 {
+		return errors.New("client certificate required")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	chains, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         pool,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to verify client certificate: %w", err)
+	}
+	if check != nil {
+		return check(chains)
+	}
+	return nil
+}
+
+// tlsFiles holds the key pair and client CA bundle loaded from files, either
+// of which may be omitted, reloading them when the files' modification times
+// change.
+type tlsFiles struct {
+	certFile, keyFile, caFile string
+	interval                  time.Duration
+	logger                    *slog.Logger
+
+	mu       sync.Mutex
+	checked  time.Time
+	loaded   bool
+	modTimes [3]time.Time
+	cert     *tls.Certificate
+	pool     *x509.CertPool
+}
+
+// current returns the loaded key pair and client CA bundle, reloading them
+// first if they have changed since they were last checked more than interval
+// ago. A failed reload is logged and the previous files are kept, so that a
+// half-written rotation does not take the server down.
+func (f *tlsFiles) current() (*tls.Certificate, *x509.CertPool) {
+	f.mu.Lock()
+	due := time.Since(f.checked) >= f.interval
+	f.mu.Unlock()
+
+	if due {
+		if err := f.reload(); err != nil {
+			f.logger.Error("failed to reload tls files", "error", err)
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.cert, f.pool
+}
+
+// reload loads the files if their modification times have changed.
+func (f *tlsFiles) reload() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.checked = time.Now()
+
+	var modTimes [3]time.Time
+	for idx, name := range []string{f.certFile, f.keyFile, f.caFile} {
+		if name == "" {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return fmt.Errorf("failed to load tls files: %w", err)
+		}
+		modTimes[idx] = info.ModTime()
+	}
+	if f.loaded && modTimes == f.modTimes {
+		return nil
+	}
+
+	var cert *tls.Certificate
+	if f.certFile != "" {
+		pair, err := tls.LoadX509KeyPair(f.certFile, f.keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load tls key pair: %w", err)
+		}
+		cert = &pair
+	}
+	var pool *x509.CertPool
+	if f.caFile != "" {
+		data, err := os.ReadFile(f.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to load client ca bundle: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("failed to load client ca bundle: no certificates in %s", f.caFile)
+		}
+	}
+
+	if f.loaded {
+		f.logger.Info("reloaded tls files", "cert_file", f.certFile, "client_ca_file", f.caFile)
+	}
+	f.cert, f.pool, f.modTimes, f.loaded = cert, pool, modTimes, true
+	return nil
+}
@@ -0,0 +1,187 @@
+package httprunner
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCA issues certificates for tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, der: der}
+}
+
+// pool returns a pool trusting the CA
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+// writeCA writes the CA certificate to a PEM file in dir and returns its path
+func (ca *testCA) writeCA(t *testing.T, dir string) string {
+	path := filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.der}), 0o600))
+	return path
+}
+
+// issue returns a key pair for 127.0.0.1 with the given usage, signed by the CA
+func (ca *testCA) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage) tls.Certificate {
+	certPEM, keyPEM := ca.issuePEM(t, serial, usage)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	return cert
+}
+
+// issuePEM returns the PEM certificate and key of a key pair for 127.0.0.1
+func (ca *testCA) issuePEM(t *testing.T, serial int64, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeKeyPair writes a server key pair issued by the CA to dir and returns
+// the paths of its files
+func (ca *testCA) writeKeyPair(t *testing.T, dir string, serial int64) (string, string) {
+	certPEM, keyPEM := ca.issuePEM(t, serial, x509.ExtKeyUsageServerAuth)
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+	return certFile, keyFile
+}
+
+// writeTestCertificate writes a server key pair for 127.0.0.1 to PEM files,
+// returning their paths and a pool trusting it
+func writeTestCertificate(t *testing.T) (string, string, *x509.CertPool) {
+	ca := newTestCA(t)
+	certFile, keyFile := ca.writeKeyPair(t, t.TempDir(), 2)
+	return certFile, keyFile, ca.pool()
+}
+
+// getWithCertificate makes a request to addr presenting certs
+func getWithCertificate(addr string, roots *x509.CertPool, certs ...tls.Certificate) error {
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      roots,
+		Certificates: certs,
+	}}}
+	resp, err := client.Get("https://" + addr)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func TestServerMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile := ca.writeKeyPair(t, dir, 2)
+	addr := serveConfig(t, Config{TLSCertFile: certFile, TLSKeyFile: keyFile, TLSClientCAFile: ca.writeCA(t, dir)})
+
+	assert.NoError(t, getWithCertificate(addr, ca.pool(), ca.issue(t, 3, x509.ExtKeyUsageClientAuth)))
+	assert.Error(t, getWithCertificate(addr, ca.pool()), "a client without a certificate should be rejected")
+	assert.Error(t, getWithCertificate(addr, ca.pool(), newTestCA(t).issue(t, 3, x509.ExtKeyUsageClientAuth)),
+		"a client certificate from another CA should be rejected")
+	assert.Error(t, getWithCertificate(addr, ca.pool(), ca.issue(t, 4, x509.ExtKeyUsageServerAuth)),
+		"a certificate not for client authentication should be rejected")
+}
+
+func TestServerClientCertCheck(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile := ca.writeKeyPair(t, dir, 2)
+	addr := serveConfig(t, Config{TLSCertFile: certFile, TLSKeyFile: keyFile}, WithClientCA(ca.writeCA(t, dir)),
+		WithClientCertCheck(func(chains [][]*x509.Certificate) error {
+			if chains[0][0].SerialNumber.Int64() == 4 {
+				return errors.New("client certificate revoked")
+			}
+			return nil
+		}))
+
+	assert.NoError(t, getWithCertificate(addr, ca.pool(), ca.issue(t, 3, x509.ExtKeyUsageClientAuth)))
+	assert.Error(t, getWithCertificate(addr, ca.pool(), ca.issue(t, 4, x509.ExtKeyUsageClientAuth)))
+}
+
+func TestServerTLSFilesMissing(t *testing.T) {
+	server := New(&http.Server{}, WithTLS("missing.crt", "missing.key"))
+	assert.ErrorContains(t, server.Run(t.Context()), "failed to load tls files")
+}
+
+func TestTLSFilesReload(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	certFile, keyFile := ca.writeKeyPair(t, dir, 2)
+	files := &tlsFiles{certFile: certFile, keyFile: keyFile, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	require.NoError(t, files.reload())
+
+	serial := func() int64 {
+		cert, _ := files.current()
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		require.NoError(t, err)
+		return leaf.SerialNumber.Int64()
+	}
+	assert.Equal(t, int64(2), serial())
+
+	// A rotated key pair is picked up once its files change
+	ca.writeKeyPair(t, dir, 3)
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, later, later))
+	assert.Equal(t, int64(3), serial())
+
+	// A broken rotation keeps the previous key pair
+	require.NoError(t, os.WriteFile(certFile, []byte("not a certificate"), 0o600))
+	later = later.Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, later, later))
+	assert.Equal(t, int64(3), serial())
+}
@@ -0,0 +1,31 @@
+package ezapp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+)
+
+// InstanceIDKey is the log attribute, metric label and health info key under
+// which the instance ID is reported.
+const InstanceIDKey = "instance_id"
+
+// ConfigHashKey is the log attribute and health info key under which the hash
+// of the resolved configuration is reported.
+const ConfigHashKey = "config_hash"
+
+// newInstanceID generates an ID identifying this run of the application,
+// "<host>-<pid>-<random>", e.g. "orders-api-4121-3fa85f64". The hostname and
+// PID make it readable; the random suffix keeps it unique across restarts that
+// reuse both, as containers do.
+func newInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "unknown"
+	}
+
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(suffix))
+}
@@ -0,0 +1,76 @@
+package ezapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewInstanceID(t *testing.T) {
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	id := newInstanceID()
+
+	pattern := fmt.Sprintf(`^%s-%d-[0-9a-f]{8}$`, regexp.QuoteMeta(hostname), os.Getpid())
+	assert.Regexp(t, pattern, id)
+	assert.NotEqual(t, id, newInstanceID(), "every run should get a distinct ID")
+}
+
+func TestRunEInstanceID(t *testing.T) {
+	var instanceID, body string
+	var info map[string]string
+	var report ShutdownReport
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		instanceID = ctx.InstanceID
+		info = ctx.Health.Check(context.Background()).Info
+		return Construct(
+			WithNamedRunner("probe", func(context.Context) error {
+				recorder := httptest.NewRecorder()
+				ctx.Metrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+				body = recorder.Body.String()
+				return nil
+			}),
+			WithPostRunHook(func(r ShutdownReport) {
+				report = r
+			}),
+		)
+	})
+	require.NoError(t, err)
+
+	require.NotEmpty(t, instanceID)
+	assert.Equal(t, instanceID, info[InstanceIDKey])
+	assert.Contains(t, body, fmt.Sprintf(`ezapp_instance_info{instance_id=%q} 1`, instanceID))
+	assert.Equal(t, instanceID, report.InstanceID)
+}
+
+func TestRunEConfigHashHealthInfo(t *testing.T) {
+	var configHash string
+	var response struct {
+		Info map[string]string `json:"info"`
+	}
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		configHash = ctx.ConfigHash
+		recorder := httptest.NewRecorder()
+		ctx.Health.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			return AppCtx{}, err
+		}
+		return Construct()
+	})
+	require.NoError(t, err)
+
+	require.Len(t, configHash, 64)
+	assert.Equal(t, configHash, response.Info[ConfigHashKey],
+		"the health endpoint should report the config hash so replicas can be compared")
+}
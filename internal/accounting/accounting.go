@@ -0,0 +1,79 @@
+// Package accounting attributes process resources, goroutines and CPU time,
+// to the runners that own them, using the pprof labels applied to each
+// runner's goroutines.
+package accounting
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+
+	"github.com/pgvanniekerk/ezapp/runner"
+)
+
+// LabelKey is the pprof label key that carries the runner name. Goroutines
+// started by a runner inherit the label, so profiles can be segmented by it.
+const LabelKey = runner.LabelKey
+
+// GoroutinesByRunner returns the number of live goroutines per runner, keyed
+// by the value of the LabelKey pprof label. Goroutines without the label are
+// counted under the empty key.
+func GoroutinesByRunner() (map[string]int, error) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return nil, fmt.Errorf("failed to write goroutine profile: %w", err)
+	}
+	return parseGoroutineProfile(&buf)
+}
+
+// parseGoroutineProfile aggregates a goroutine profile written with debug=1.
+// Each record starts with "<count> @ <pcs>" and is optionally followed by a
+// "# labels: {...}" line.
+func parseGoroutineProfile(profile *bytes.Buffer) (map[string]int, error) {
+	counts := make(map[string]int)
+
+	var (
+		pending    int
+		hasPending bool
+		runner     string
+	)
+	flush := func() {
+		if hasPending {
+			counts[runner] += pending
+		}
+		pending, hasPending, runner = 0, false, ""
+	}
+
+	scanner := bufio.NewScanner(profile)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if countStr, _, ok := strings.Cut(line, " @ "); ok && !strings.HasPrefix(line, "#") {
+			count, err := strconv.Atoi(countStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid goroutine profile record %q: %w", line, err)
+			}
+			flush()
+			pending, hasPending = count, true
+			continue
+		}
+
+		if labels, ok := strings.CutPrefix(line, "# labels: "); ok && hasPending {
+			var parsed map[string]string
+			if err := json.Unmarshal([]byte(labels), &parsed); err == nil {
+				runner = parsed[LabelKey]
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read goroutine profile: %w", err)
+	}
+	flush()
+
+	return counts, nil
+}
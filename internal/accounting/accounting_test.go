@@ -0,0 +1,53 @@
+package accounting
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGoroutineProfile(t *testing.T) {
+	profile := bytes.NewBufferString(`goroutine profile: total 6
+1 @ 0x440e11 0x47cb9d
+#	0x4ce010	runtime/pprof.writeRuntimeProfile+0xb0	/usr/local/go/src/runtime/pprof/pprof.go:848
+
+3 @ 0x47d82a 0x480985
+# labels: {"runner":"http"}
+#	0x480984	time.Sleep+0x164	/usr/local/go/src/runtime/time.go:368
+
+2 @ 0x47d82a 0x480986
+# labels: {"other":"x", "runner":"worker"}
+#	0x480984	time.Sleep+0x164	/usr/local/go/src/runtime/time.go:368
+`)
+
+	counts, err := parseGoroutineProfile(profile)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"": 1, "http": 3, "worker": 2}, counts)
+}
+
+func TestGoroutinesByRunner(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	started := make(chan struct{}, 2)
+	pprof.Do(context.Background(), pprof.Labels(LabelKey, "accounting-test"), func(ctx context.Context) {
+		for range 2 {
+			go func() {
+				started <- struct{}{}
+				<-stop
+			}()
+		}
+	})
+	<-started
+	<-started
+
+	require.Eventually(t, func() bool {
+		counts, err := GoroutinesByRunner()
+		return err == nil && counts["accounting-test"] == 2
+	}, time.Second, 10*time.Millisecond)
+}
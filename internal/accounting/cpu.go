@@ -0,0 +1,67 @@
+package accounting
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime/pprof"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+// CPUByRunner samples a CPU profile for window, or until ctx is done, and
+// returns the CPU time spent per runner during the sample, keyed by the
+// value of the LabelKey pprof label. CPU time without the label is counted
+// under the empty key. Only one CPU profile can run in a process at a time,
+// so it fails while another is being taken, e.g. by a continuous profiler.
+func CPUByRunner(ctx context.Context, window time.Duration) (map[string]time.Duration, error) {
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+	}
+
+	timer := time.NewTimer(window)
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+	timer.Stop()
+	pprof.StopCPUProfile()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return parseCPUProfile(&buf)
+}
+
+// parseCPUProfile sums the CPU nanoseconds of a CPU profile, as written by
+// pprof.StartCPUProfile, by the LabelKey label.
+func parseCPUProfile(r io.Reader) (map[string]time.Duration, error) {
+	prof, err := profile.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CPU profile: %w", err)
+	}
+
+	cpu := -1
+	for idx, typ := range prof.SampleType {
+		if typ.Type == "cpu" {
+			cpu = idx
+		}
+	}
+	if cpu < 0 {
+		return nil, errors.New("invalid CPU profile: no cpu sample type")
+	}
+
+	totals := make(map[string]time.Duration)
+	for _, sample := range prof.Sample {
+		var runner string
+		if values := sample.Label[LabelKey]; len(values) > 0 {
+			runner = values[0]
+		}
+		totals[runner] += time.Duration(sample.Value[cpu])
+	}
+	return totals, nil
+}
@@ -0,0 +1,86 @@
+package accounting
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeProfile writes a profile with sampleTypes holding samples, the values
+// of each labelled with the runner, if any, keyed by them.
+func writeProfile(t *testing.T, sampleTypes []*profile.ValueType, samples map[string][][]int64) *bytes.Buffer {
+	t.Helper()
+	prof := &profile.Profile{SampleType: sampleTypes, PeriodType: sampleTypes[len(sampleTypes)-1], Period: 10_000_000}
+	for runner, values := range samples {
+		for _, v := range values {
+			sample := &profile.Sample{Value: v}
+			if runner != "" {
+				sample.Label = map[string][]string{LabelKey: {runner}}
+			}
+			prof.Sample = append(prof.Sample, sample)
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, prof.Write(&buf))
+	return &buf
+}
+
+func TestParseCPUProfile(t *testing.T) {
+	buf := writeProfile(t, []*profile.ValueType{
+		{Type: "samples", Unit: "count"},
+		{Type: "cpu", Unit: "nanoseconds"},
+	}, map[string][][]int64{
+		"":       {{1, 10_000_000}},
+		"http":   {{3, 30_000_000}, {1, 10_000_000}},
+		"worker": {{2, 20_000_000}},
+	})
+
+	totals, err := parseCPUProfile(buf)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{
+		"":       10 * time.Millisecond,
+		"http":   40 * time.Millisecond,
+		"worker": 20 * time.Millisecond,
+	}, totals)
+}
+
+func TestParseCPUProfileInvalid(t *testing.T) {
+	_, err := parseCPUProfile(bytes.NewBufferString("not a profile"))
+	assert.ErrorContains(t, err, "failed to read CPU profile")
+
+	buf := writeProfile(t, []*profile.ValueType{{Type: "alloc_space", Unit: "bytes"}}, map[string][][]int64{"http": {{1024}}})
+	_, err = parseCPUProfile(buf)
+	assert.ErrorContains(t, err, "no cpu sample type")
+}
+
+func TestCPUByRunner(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+	pprof.Do(context.Background(), pprof.Labels(LabelKey, "cpu-test"), func(ctx context.Context) {
+		go func() {
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+			}
+		}()
+	})
+
+	totals, err := CPUByRunner(context.Background(), 300*time.Millisecond)
+	require.NoError(t, err)
+	assert.Positive(t, totals["cpu-test"], "the busy runner should be attributed CPU time")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = CPUByRunner(ctx, time.Minute)
+	assert.ErrorIs(t, err, context.Canceled)
+}
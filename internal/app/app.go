@@ -2,28 +2,143 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/pgvanniekerk/ezapp/internal/accounting"
+	"github.com/pgvanniekerk/ezapp/internal/trace"
 	"golang.org/x/sync/errgroup"
 	"log/slog"
 	"os"
 	"os/signal"
+	"runtime/pprof"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 )
 
-func New(runnerList []Runner, logger *slog.Logger) App {
-	return App{
+// ErrNotRunning is returned by AddRunner when the app is not accepting new
+// runners, i.e. before it has started or once shutdown has been initiated.
+var ErrNotRunning = errors.New("application is not running")
+
+// ErrRunnerNotFound is returned by StopRunner when no runner with the given
+// name is running.
+var ErrRunnerNotFound = errors.New("runner not found")
+
+// ErrDuplicateRunner is returned by AddRunner when a runner with the same
+// name is already running.
+var ErrDuplicateRunner = errors.New("runner with this name is already running")
+
+func New(runnerList []Runner, logger *slog.Logger, options ...Option) *App {
+	a := &App{
 		runnerList: runnerList,
 		logger:     logger,
 	}
+	for _, opt := range options {
+		opt(a)
+	}
+	return a
 }
 
 type App struct {
-	runnerList []Runner
-	logger     *slog.Logger
+	runnerList      []Runner
+	runnerNames     []string
+	logger          *slog.Logger
+	ignoredSignals  int
+	transitionHooks []TransitionHook
+
+	preShutdownHooks   []func(context.Context) error
+	preShutdownTimeout time.Duration
+	trace              *trace.Buffer
+	baseCtx            context.Context
+	schedule           *Schedule
+
+	state        atomic.Int32
+	transitionMu sync.Mutex
+	dispatching  bool
+	pending      [][2]State
+
+	// Runner tracking, guarded by runMu. Runners can only be added while
+	// accepting is true, which is the case from startup until shutdown is
+	// initiated or every runner has returned.
+	runMu            sync.Mutex
+	accepting        bool
+	running          map[string]*runnerHandle
+	errGrp           *errgroup.Group
+	runCtx           context.Context
+	initiateShutdown func(wait bool)
+}
+
+// State returns the current lifecycle state of the app.
+func (a *App) State() State {
+	return State(a.state.Load())
 }
 
-func (a App) Run() error {
+// Finish moves the app into its terminal state once cleanup has completed:
+// StateFailed if err is non-nil, StateStopped otherwise.
+func (a *App) Finish(err error) {
+	a.transition(StateStopping)
+	if err != nil {
+		a.transition(StateFailed)
+		return
+	}
+	a.transition(StateStopped)
+}
+
+// debugEnabled reports whether the logger records DEBUG messages. Lifecycle
+// debug messages with attributes are only built when it does, as boxing the
+// attributes allocates even if the message is then discarded.
+func (a *App) debugEnabled() bool {
+	return a.logger.Enabled(context.Background(), slog.LevelDebug)
+}
+
+// transition moves the app to the given state and calls the transition hooks.
+// Transitions to the current or an earlier state, or out of a terminal state,
+// are ignored so that concurrent shutdown triggers cannot move the lifecycle
+// backwards.
+//
+// The hooks are called without holding the transition lock, so a hook may
+// itself cause a transition, e.g. by calling Shutdown. Transitions are
+// delivered to the hooks one at a time and in order: a transition made while
+// hooks are running is delivered by the goroutine running them once they
+// return.
+func (a *App) transition(to State) {
+	a.transitionMu.Lock()
+	from := a.State()
+	if to <= from || from.Terminal() {
+		a.transitionMu.Unlock()
+		return
+	}
+	a.state.Store(int32(to))
+	if a.debugEnabled() {
+		a.logger.Debug("application state changed", "from", from.String(), "to", to.String())
+	}
+	a.trace.Record("state changed", "", transitionDetail(from, to))
+
+	a.pending = append(a.pending, [2]State{from, to})
+	if a.dispatching {
+		a.transitionMu.Unlock()
+		return
+	}
+	a.dispatching = true
+	for idx := 0; idx < len(a.pending); idx++ {
+		next := a.pending[idx]
+		a.transitionMu.Unlock()
+		for _, hook := range a.transitionHooks {
+			hook(next[0], next[1])
+		}
+		a.transitionMu.Lock()
+	}
+	a.pending = a.pending[:0]
+	a.dispatching = false
+	a.transitionMu.Unlock()
+}
+
+func (a *App) Run() error {
 	a.logger.Debug("start application")
+	a.transition(StateStarting)
 
 	// Create a termination context with a cancel function that is
 	// used to signal application termination.
@@ -33,29 +148,101 @@ func (a App) Run() error {
 
 	// Asynchronously listen for SIGINT, SIGTERM. If signaled,
 	// the termCtx will be canceled and propagated to all runnable
-	// invocations.
-	go a.terminationSignaller(termFunc)
+	// invocations. The signaller is stopped and waited on before
+	// Run returns so that no signal handling outlives the app.
+	a.logger.Debug("starting termination signaller")
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	a.logger.Debug("started listening for SIGINT and SIGTERM")
+	stopSignaller := make(chan struct{})
+	signallerDone := make(chan struct{})
+	go func() {
+		defer close(signallerDone)
+		a.terminationSignaller(sigChan, termFunc, stopSignaller)
+	}()
+	defer func() {
+		close(stopSignaller)
+		<-signallerDone
+	}()
 	a.logger.Debug("started termination signaller")
 
-	// Create an error group with context that will be used to
-	// asynchronously invoke each runnable.
-	// Should an error occur, the error group will automatically
-	// cancel the context, propagating to each runnable - starting
-	// the shutdown process.
-	errGrp, ctx := errgroup.WithContext(termCtx)
+	// Create a run context that is passed to each runnable. It is
+	// only cancelled once shutdown has been initiated and the
+	// pre-shutdown hooks have completed, so runners keep serving
+	// while e.g. service discovery deregistration is in flight.
+	baseCtx := context.Background()
+	if a.baseCtx != nil {
+		baseCtx = context.WithoutCancel(a.baseCtx)
+	}
+	runCtx, cancelRunners := context.WithCancel(baseCtx)
+	defer cancelRunners()
+
+	// Shutdown is initiated once. A call made while it is in progress,
+	// e.g. by a transition or pre-shutdown hook calling Shutdown, returns
+	// immediately unless wait is set, in which case it waits for the
+	// runners to have been cancelled.
+	var shutdownStarted atomic.Bool
+	shutdownDone := make(chan struct{})
+	initiateShutdown := func(wait bool) {
+		if !shutdownStarted.CompareAndSwap(false, true) {
+			if wait {
+				<-shutdownDone
+			}
+			return
+		}
+		defer close(shutdownDone)
+
+		a.runMu.Lock()
+		a.accepting = false
+		a.runMu.Unlock()
+
+		a.trace.Record("shutdown initiated", "", "")
+		a.transition(StateDraining)
+		a.runPreShutdownHooks()
+		cancelRunners()
+		a.trace.Record("runners cancelled", "", "")
+	}
+
+	// Create an error group that will be used to asynchronously
+	// invoke each runnable.
+	// Should an error occur, shutdown is initiated, propagating
+	// cancellation to each runnable.
+	errGrp := &errgroup.Group{}
 	a.logger.Debug("created error group")
 
-	// Invoke each runnable through the error group.
+	// Invoke each runnable through the error group. Runners added later
+	// through AddRunner join the same error group.
+	a.runMu.Lock()
+	a.errGrp = errGrp
+	a.runCtx = runCtx
+	a.initiateShutdown = initiateShutdown
+	a.running = make(map[string]*runnerHandle, len(a.runnerList))
+	a.accepting = true
 	for idx := range a.runnerList {
-		errGrp.Go(func() error {
-			return a.runnerList[idx](ctx)
-		})
+		a.startRunnerLocked(a.runnerName(idx), a.runnerList[idx])
 	}
+	a.stopAcceptingIfIdleLocked()
+	a.runMu.Unlock()
 	a.logger.Debug("started runnable invocations via error group")
+	a.transition(StateRunning)
+
+	// Initiate shutdown as soon as a termination signal is received.
+	waitDone := make(chan struct{})
+	defer close(waitDone)
+	go func() {
+		select {
+		case <-termCtx.Done():
+			initiateShutdown(false)
+		case <-waitDone:
+		}
+	}()
 
 	// Wait for an error or for all runnable invocations to finalize
 	// and return.
 	err := errGrp.Wait()
+	a.trace.Record("all runners returned", "", "")
+	initiateShutdown(true)
+	a.transition(StateStopping)
 	if err != nil {
 		return fmt.Errorf("failed to invoke runnable: %w", err)
 	}
@@ -64,24 +251,236 @@ func (a App) Run() error {
 	return nil
 }
 
-// terminationSignaller is a helper function that listens for SIGINT and SIGTERM
-// and cancels the given termFunc.
-func (a App) terminationSignaller(termFunc context.CancelFunc) {
-	a.logger.Debug("starting termination signaller")
+// AddRunner starts r under the given name while the app is running. The runner
+// joins the originally configured runners: it receives the same run context,
+// a failure initiates shutdown and is returned from Run, and Run waits for it
+// to return. Names must be unique among running runners.
+//
+// ErrNotRunning is returned before the app has started and once shutdown has
+// been initiated.
+func (a *App) AddRunner(name string, r Runner) error {
+	if name == "" {
+		return errors.New("runner name must not be empty")
+	}
 
-	// Listen for SIGINT and SIGTERM and notify via sigChan.
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	a.logger.Debug("started listening for SIGINT and SIGTERM")
+	a.runMu.Lock()
+	defer a.runMu.Unlock()
 
-	// Wait for signal then cancel termCtx.
-	<-sigChan
-	termFunc()
-	a.logger.Debug("received SIGINT or SIGTERM, terminating")
+	if !a.accepting {
+		return ErrNotRunning
+	}
+	if _, exists := a.running[name]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicateRunner, name)
+	}
 
-	// Free/Release signal processing objects.
-	signal.Stop(sigChan)
-	close(sigChan)
-	a.logger.Debug("stopped listening for SIGINT and SIGTERM")
+	a.startRunnerLocked(name, r)
+	if a.debugEnabled() {
+		a.logger.Debug("added runner", "runner", name)
+	}
+	return nil
+}
+
+// StopRunner cancels the context of the named runner and waits for it to
+// return or for ctx to be done. A runner stopped this way does not initiate
+// shutdown; its error, if any other than context.Canceled, is returned
+// instead. If it was the last running runner, the app finishes as if every
+// runner had returned.
+//
+// ErrRunnerNotFound is returned if no runner with the given name is running.
+func (a *App) StopRunner(ctx context.Context, name string) error {
+	a.runMu.Lock()
+	handle, ok := a.running[name]
+	a.runMu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrRunnerNotFound, name)
+	}
 
+	if a.debugEnabled() {
+		a.logger.Debug("stopping runner", "runner", name)
+	}
+	handle.stopped.Store(true)
+	handle.cancel()
+
+	select {
+	case <-handle.done:
+		if a.debugEnabled() {
+			a.logger.Debug("stopped runner", "runner", name)
+		}
+		return handle.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown initiates a graceful shutdown of the running app, as a termination
+// signal would: the pre-shutdown hooks run and the runners' contexts are then
+// cancelled. It returns without waiting for the app to stop, and has no
+// effect before the app has started or once shutdown is in progress, so it
+// may be called from transition and pre-shutdown hooks.
+func (a *App) Shutdown() {
+	a.runMu.Lock()
+	initiateShutdown := a.initiateShutdown
+	a.runMu.Unlock()
+
+	if initiateShutdown != nil {
+		initiateShutdown(false)
+	}
+}
+
+// Runners returns the names of the runners that are currently running, in
+// lexical order.
+func (a *App) Runners() []string {
+	a.runMu.Lock()
+	defer a.runMu.Unlock()
+
+	names := make([]string, 0, len(a.running))
+	for name := range a.running {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// runnerName returns the name of the configured runner at position idx.
+func (a *App) runnerName(idx int) string {
+	if idx < len(a.runnerNames) && a.runnerNames[idx] != "" {
+		return a.runnerNames[idx]
+	}
+	return "runner-" + strconv.Itoa(idx)
+}
+
+// runnerHandle tracks a single running runner.
+type runnerHandle struct {
+	cancel  context.CancelFunc
+	done    chan struct{}
+	stopped atomic.Bool
+
+	// err is the result of a runner stopped through StopRunner. It is
+	// written before done is closed.
+	err error
+}
+
+// startRunnerLocked invokes r through the error group with its own context
+// derived from the run context, labelled with the runner name. The caller
+// must hold runMu and accepting must be true.
+func (a *App) startRunnerLocked(name string, r Runner) {
+	ctx, cancel := context.WithCancel(a.runCtx)
+	handle := &runnerHandle{cancel: cancel, done: make(chan struct{})}
+	a.running[name] = handle
+
+	a.trace.Record("runner started", name, "")
+	a.errGrp.Go(func() error {
+		// Label the runner's goroutines so that CPU and goroutine profiles
+		// are segmented by runner and resource usage can be attributed to
+		// it (see the accounting package).
+		var err error
+		a.schedule.wait(EventStart, name)
+		pprof.Do(ctx, pprof.Labels(accounting.LabelKey, name), func(ctx context.Context) {
+			err = r(ctx)
+		})
+		a.schedule.wait(EventReturn, name)
+		cancel()
+		a.trace.Record("runner returned", name, errorDetail(err))
+
+		// A runner stopped on request reports its result to StopRunner
+		// rather than failing the app.
+		if handle.stopped.Load() {
+			if !errors.Is(err, context.Canceled) {
+				handle.err = err
+			}
+			err = nil
+		}
+
+		a.runMu.Lock()
+		delete(a.running, name)
+		a.stopAcceptingIfIdleLocked()
+		a.runMu.Unlock()
+		close(handle.done)
+
+		if err != nil {
+			a.initiateShutdown(false)
+		}
+		return err
+	})
+}
+
+// stopAcceptingIfIdleLocked stops accepting new runners once every runner has
+// returned. This guarantees that the error group is never added to after its
+// Wait may have returned. The caller must hold runMu.
+func (a *App) stopAcceptingIfIdleLocked() {
+	if len(a.running) == 0 {
+		a.accepting = false
+	}
+}
+
+// runPreShutdownHooks invokes each pre-shutdown hook in registration order.
+// Hook failures are logged but do not prevent shutdown from proceeding.
+func (a *App) runPreShutdownHooks() {
+	if len(a.preShutdownHooks) == 0 {
+		return
+	}
+	if a.debugEnabled() {
+		a.logger.Debug("running pre-shutdown hooks", "count", len(a.preShutdownHooks))
+	}
+
+	ctx := context.Background()
+	if a.preShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.preShutdownTimeout)
+		defer cancel()
+	}
+
+	for idx, hook := range a.preShutdownHooks {
+		subject := fmt.Sprintf("hook-%d", idx)
+		a.trace.Record("pre-shutdown hook started", subject, "")
+		err := hook(ctx)
+		a.trace.Record("pre-shutdown hook finished", subject, errorDetail(err))
+		if err != nil {
+			a.logger.Error("pre-shutdown hook failed", "hook", idx, "error", err)
+		}
+	}
+	a.logger.Debug("finished pre-shutdown hooks")
+}
+
+// terminationSignaller is a helper function that waits for SIGINT or SIGTERM
+// on sigChan and cancels the given termFunc. It returns once a signal has been
+// handled or stop is closed.
+func (a *App) terminationSignaller(sigChan chan os.Signal, termFunc context.CancelFunc, stop <-chan struct{}) {
+
+	// Free/Release signal processing objects on return.
+	defer func() {
+		signal.Stop(sigChan)
+		a.logger.Debug("stopped listening for SIGINT and SIGTERM")
+	}()
+
+	// Wait for signal then cancel termCtx. The first ignoredSignals
+	// signals are dropped.
+	for ignored := 0; ; ignored++ {
+		var sig os.Signal
+		select {
+		case sig = <-sigChan:
+		case <-stop:
+			return
+		}
+
+		if ignored < a.ignoredSignals {
+			a.trace.Record("signal ignored", "", sig.String())
+			a.logger.Warn("ignoring SIGINT or SIGTERM", "ignored", ignored+1, "of", a.ignoredSignals)
+			continue
+		}
+
+		a.trace.Record("signal received", "", sig.String())
+		termFunc()
+		a.logger.Debug("received SIGINT or SIGTERM, terminating")
+		return
+	}
+}
+
+// errorDetail returns the message of err for a trace event, or an empty
+// string if err is nil.
+func errorDetail(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
 }
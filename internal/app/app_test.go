@@ -3,14 +3,19 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"runtime/pprof"
+	"slices"
+	"strings"
 	"sync"
 	"syscall"
 	"testing"
 	"time"
 
 	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/pgvanniekerk/ezapp/internal/trace"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -443,3 +448,380 @@ func TestAppRunConcurrentExecution(t *testing.T) {
 	}
 	mu.Unlock()
 }
+
+// TestAppIgnoredSignals tests that the configured number of signals is dropped
+// This test verifies that:
+// - The first n SIGTERM signals do not cancel the runners
+// - The signal after that initiates shutdown
+func TestAppIgnoredSignals(t *testing.T) {
+	logger, logs := createTestLogger()
+
+	started := make(chan struct{})
+	runners := []Runner{longRunningRunner(started)}
+
+	app := New(runners, logger, WithIgnoredSignals(1))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Run()
+	}()
+	<-started
+
+	process, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err, "Should find current process")
+
+	// The first signal should be ignored
+	require.NoError(t, process.Signal(syscall.SIGTERM))
+	select {
+	case <-done:
+		t.Fatal("App should not stop on an ignored signal")
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.Contains(t, logs.Messages(), "ignoring SIGINT or SIGTERM")
+
+	// The second signal should terminate the app
+	require.NoError(t, process.Signal(syscall.SIGTERM))
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(1 * time.Second):
+		t.Fatal("App should have completed after the second signal")
+	}
+}
+
+// TestAppPreShutdownHooksRunBeforeCancellation tests pre-shutdown hook ordering
+// This test verifies that:
+// - Pre-shutdown hooks run after shutdown is initiated
+// - Runner contexts are still active while the hooks run
+// - Hooks run in registration order and failures do not block shutdown
+func TestAppPreShutdownHooksRunBeforeCancellation(t *testing.T) {
+	logger, logs := createTestLogger()
+
+	var runCtx context.Context
+	started := make(chan struct{})
+	runners := []Runner{
+		func(ctx context.Context) error {
+			runCtx = ctx
+			close(started)
+			<-ctx.Done()
+			return nil
+		},
+		func(ctx context.Context) error {
+			<-started
+			return errors.New("trigger shutdown")
+		},
+	}
+
+	var order []string
+	app := New(runners, logger,
+		WithPreShutdownHook(func(ctx context.Context) error {
+			order = append(order, "first")
+			assert.NoError(t, runCtx.Err(), "runner context should not be cancelled during pre-shutdown hooks")
+			return errors.New("deregistration failed")
+		}),
+		WithPreShutdownHook(func(ctx context.Context) error {
+			order = append(order, "second")
+			_, hasDeadline := ctx.Deadline()
+			assert.True(t, hasDeadline, "hook context should carry the pre-shutdown timeout")
+			return nil
+		}),
+		WithPreShutdownTimeout(time.Second),
+	)
+
+	err := app.Run()
+
+	assert.ErrorContains(t, err, "trigger shutdown")
+	assert.Equal(t, []string{"first", "second"}, order)
+	assert.Error(t, runCtx.Err(), "runner context should be cancelled after the hooks")
+	assert.Contains(t, logs.Messages(), "pre-shutdown hook failed")
+}
+
+// TestAppPreShutdownHooksRunOnce tests that hooks run exactly once
+// This test verifies that pre-shutdown hooks run once even when runners
+// complete on their own without a shutdown trigger
+func TestAppPreShutdownHooksRunOnce(t *testing.T) {
+	logger, _ := createTestLogger()
+
+	calls := 0
+	app := New([]Runner{successfulRunner, successfulRunner}, logger,
+		WithPreShutdownHook(func(ctx context.Context) error {
+			calls++
+			return nil
+		}),
+	)
+
+	require.NoError(t, app.Run())
+	assert.Equal(t, 1, calls)
+}
+
+// TestAppWithBaseContext tests that runners see the values, but not the
+// cancellation, of the base context
+func TestAppWithBaseContext(t *testing.T) {
+	type key struct{}
+	logger, _ := createTestLogger()
+	baseCtx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "orders"))
+	cancel()
+
+	var value any
+	var runErr error
+	application := New([]Runner{func(ctx context.Context) error {
+		value = ctx.Value(key{})
+		runErr = ctx.Err()
+		return nil
+	}}, logger, WithBaseContext(baseCtx))
+
+	require.NoError(t, application.Run())
+	assert.Equal(t, "orders", value)
+	assert.NoError(t, runErr, "cancellation of the base context should not reach the runners")
+}
+
+// TestAppAddRunner tests that runners can be added while the app is running
+// This test verifies that:
+// - Added runners are tracked alongside the configured runners
+// - Run waits for added runners before returning
+// - Duplicate names are rejected while the runner is running
+func TestAppAddRunner(t *testing.T) {
+	logger, _ := createTestLogger()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	app := New([]Runner{func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}}, logger, WithRunnerNames([]string{"main"}))
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- app.Run() }()
+	<-started
+
+	addedRelease := make(chan struct{})
+	require.NoError(t, app.AddRunner("added", func(ctx context.Context) error {
+		<-addedRelease
+		return nil
+	}))
+	assert.Equal(t, []string{"added", "main"}, app.Runners())
+	assert.ErrorIs(t, app.AddRunner("added", successfulRunner), ErrDuplicateRunner)
+
+	// The app keeps running while the added runner is running
+	close(release)
+	select {
+	case <-runErr:
+		t.Fatal("Run should wait for added runners")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Equal(t, []string{"added"}, app.Runners())
+
+	close(addedRelease)
+	require.NoError(t, <-runErr)
+	assert.Empty(t, app.Runners())
+	assert.ErrorIs(t, app.AddRunner("late", successfulRunner), ErrNotRunning)
+}
+
+// TestAppAddRunnerFailure tests that a failing added runner shuts the app down
+func TestAppAddRunnerFailure(t *testing.T) {
+	logger, _ := createTestLogger()
+	started := make(chan struct{})
+	app := New([]Runner{longRunningRunner(started)}, logger)
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- app.Run() }()
+	<-started
+
+	require.NoError(t, app.AddRunner("failing", failingRunner))
+
+	select {
+	case err := <-runErr:
+		assert.ErrorContains(t, err, "runner failed")
+	case <-time.After(time.Second):
+		t.Fatal("App should shut down when an added runner fails")
+	}
+}
+
+// TestAppAddRunnerBeforeRun tests that runners cannot be added before Run
+func TestAppAddRunnerBeforeRun(t *testing.T) {
+	logger, _ := createTestLogger()
+	app := New([]Runner{successfulRunner}, logger)
+
+	assert.ErrorIs(t, app.AddRunner("early", successfulRunner), ErrNotRunning)
+	assert.Error(t, app.AddRunner("", successfulRunner))
+}
+
+// TestAppShutdown tests that Shutdown stops a running app like a termination
+// signal and has no effect before the app has started
+func TestAppShutdown(t *testing.T) {
+	logger, _ := createTestLogger()
+	started := make(chan struct{})
+	app := New([]Runner{func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return nil
+	}}, logger)
+	app.Shutdown()
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- app.Run() }()
+	<-started
+
+	app.Shutdown()
+	select {
+	case err := <-runErr:
+		assert.NoError(t, err)
+		assert.Equal(t, StateStopping, app.State())
+	case <-time.After(time.Second):
+		t.Fatal("App should shut down when Shutdown is called")
+	}
+}
+
+// TestAppStopRunner tests that a single runner can be stopped at runtime
+// This test verifies that:
+// - Only the named runner's context is cancelled
+// - The app keeps running with the remaining runners
+// - Stopping an unknown runner returns ErrRunnerNotFound
+func TestAppStopRunner(t *testing.T) {
+	logger, _ := createTestLogger()
+	mainStarted := make(chan struct{})
+	featureStarted := make(chan struct{})
+	app := New([]Runner{longRunningRunner(mainStarted), longRunningRunner(featureStarted)}, logger,
+		WithRunnerNames([]string{"main", "feature"}))
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- app.Run() }()
+	<-mainStarted
+	<-featureStarted
+
+	require.NoError(t, app.StopRunner(context.Background(), "feature"))
+	assert.Equal(t, []string{"main"}, app.Runners())
+	assert.Equal(t, StateRunning, app.State())
+
+	select {
+	case <-runErr:
+		t.Fatal("App should keep running after stopping a single runner")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	assert.ErrorIs(t, app.StopRunner(context.Background(), "feature"), ErrRunnerNotFound)
+
+	// Stopping the last runner finishes the app
+	require.NoError(t, app.StopRunner(context.Background(), "main"))
+	require.NoError(t, <-runErr)
+}
+
+// TestAppStopRunnerError tests that a stopped runner's error is returned from
+// StopRunner instead of failing the app
+func TestAppStopRunnerError(t *testing.T) {
+	logger, _ := createTestLogger()
+	started := make(chan struct{})
+	stopErr := errors.New("flush failed")
+	app := New([]Runner{longRunningRunner(started)}, logger)
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- app.Run() }()
+	<-started
+
+	require.NoError(t, app.AddRunner("feature", func(ctx context.Context) error {
+		<-ctx.Done()
+		return stopErr
+	}))
+	assert.ErrorIs(t, app.StopRunner(context.Background(), "feature"), stopErr)
+	assert.Equal(t, StateRunning, app.State())
+
+	require.NoError(t, app.StopRunner(context.Background(), "runner-0"))
+	require.NoError(t, <-runErr)
+}
+
+// TestAppStopRunnerTimeout tests that StopRunner gives up when its context is done
+func TestAppStopRunnerTimeout(t *testing.T) {
+	logger, _ := createTestLogger()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	app := New([]Runner{func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}}, logger)
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- app.Run() }()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, app.StopRunner(ctx, "runner-0"), context.DeadlineExceeded)
+
+	close(release)
+	require.NoError(t, <-runErr)
+}
+
+// TestAppRunnerPprofLabels tests that runners run with their name as a pprof label
+func TestAppRunnerPprofLabels(t *testing.T) {
+	logger, _ := createTestLogger()
+	var label string
+	app := New([]Runner{func(ctx context.Context) error {
+		label, _ = pprof.Label(ctx, "runner")
+		return nil
+	}}, logger, WithRunnerNames([]string{"labelled"}))
+
+	require.NoError(t, app.Run())
+	assert.Equal(t, "labelled", label)
+}
+
+// TestAppTrace tests that lifecycle events are recorded in the trace
+// This test verifies that:
+// - Runners starting and returning are recorded with their name and error
+// - Shutdown, pre-shutdown hooks and state transitions are recorded in order
+func TestAppTrace(t *testing.T) {
+	logger, _ := createTestLogger()
+	buffer := trace.New(0)
+	app := New([]Runner{failingRunner}, logger,
+		WithRunnerNames([]string{"worker"}),
+		WithTrace(buffer),
+		WithPreShutdownHook(func(ctx context.Context) error { return nil }),
+	)
+
+	require.Error(t, app.Run())
+
+	events, _ := buffer.Events()
+	var recorded []string
+	for _, event := range events {
+		recorded = append(recorded, strings.Join(strings.Fields(event.Name+" "+event.Subject+" "+event.Detail), " "))
+	}
+	assert.Contains(t, recorded, "runner started worker")
+	assert.Contains(t, recorded, "runner returned worker runner failed")
+
+	// Runners return concurrently with the transitions, which are ordered
+	ordered := slices.DeleteFunc(slices.Clone(recorded), func(event string) bool {
+		return strings.HasPrefix(event, "runner ")
+	})
+	assert.Equal(t, []string{
+		"state changed created -> starting",
+		"state changed starting -> running",
+		"shutdown initiated",
+		"state changed running -> draining",
+		"pre-shutdown hook started hook-0",
+		"pre-shutdown hook finished hook-0",
+		"runners cancelled",
+		"all runners returned",
+		"state changed draining -> stopping",
+	}, ordered)
+}
+
+// BenchmarkAppRun measures the overhead of starting and waiting for runners
+// that return immediately
+func BenchmarkAppRun(b *testing.B) {
+	logger := slog.New(slog.DiscardHandler)
+	for _, count := range []int{1, 100, 1000} {
+		runners := make([]Runner, count)
+		for idx := range runners {
+			runners[idx] = func(ctx context.Context) error { return nil }
+		}
+
+		b.Run(fmt.Sprintf("runners=%d", count), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if err := New(runners, logger, WithTrace(trace.New(trace.DefaultSize))).Run(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/trace"
+)
+
+type Option func(*App)
+
+// WithIgnoredSignals makes the app ignore the first n SIGINT/SIGTERM signals
+// before initiating shutdown.
+func WithIgnoredSignals(n int) Option {
+	return func(a *App) {
+		a.ignoredSignals = n
+	}
+}
+
+// WithTransitionHook registers a hook that is called after every lifecycle
+// state transition.
+func WithTransitionHook(hook TransitionHook) Option {
+	return func(a *App) {
+		a.transitionHooks = append(a.transitionHooks, hook)
+	}
+}
+
+// WithPreShutdownHook registers a hook that is run once shutdown has been
+// initiated but before the runner contexts are cancelled. Hooks run in
+// registration order.
+func WithPreShutdownHook(hook func(context.Context) error) Option {
+	return func(a *App) {
+		a.preShutdownHooks = append(a.preShutdownHooks, hook)
+	}
+}
+
+// WithPreShutdownTimeout bounds the context passed to the pre-shutdown hooks.
+// A zero timeout leaves the context without a deadline.
+func WithPreShutdownTimeout(timeout time.Duration) Option {
+	return func(a *App) {
+		a.preShutdownTimeout = timeout
+	}
+}
+
+// WithTrace records the lifecycle events of the app, such as state
+// transitions, signals, pre-shutdown hooks and runners starting and
+// returning, in buffer.
+func WithTrace(buffer *trace.Buffer) Option {
+	return func(a *App) {
+		a.trace = buffer
+	}
+}
+
+// WithBaseContext makes the values of ctx visible to every runner, including
+// those added with AddRunner. The values are attached once rather than per
+// runner; cancellation of ctx is not propagated to the runners.
+func WithBaseContext(ctx context.Context) Option {
+	return func(a *App) {
+		a.baseCtx = ctx
+	}
+}
+
+// WithRunnerNames names the configured runners by position. Runners without a
+// name, or beyond the end of names, are named "runner-<index>". Names must be
+// unique.
+func WithRunnerNames(names []string) Option {
+	return func(a *App) {
+		a.runnerNames = names
+	}
+}
+
+// WithSchedule holds the runners at each Event until they are released through
+// schedule, making the order in which they start and return deterministic.
+// It is meant for tests.
+func WithSchedule(schedule *Schedule) Option {
+	return func(a *App) {
+		a.schedule = schedule
+	}
+}
@@ -1,5 +1,5 @@
 package app
 
-import "context"
+import "github.com/pgvanniekerk/ezapp/runner"
 
-type Runner func(context.Context) error
+type Runner = runner.Runner
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"context"
+	"sync"
+)
+
+// Event is a point in a runner's life at which a Schedule holds it.
+type Event int
+
+const (
+	// EventStart is reached by a runner just before it is invoked.
+	EventStart Event = iota
+
+	// EventReturn is reached by a runner once it has returned, before its
+	// result is handled, i.e. before a failure initiates shutdown.
+	EventReturn
+)
+
+// String returns the lower-case name of the event.
+func (e Event) String() string {
+	switch e {
+	case EventStart:
+		return "start"
+	case EventReturn:
+		return "return"
+	default:
+		return "unknown"
+	}
+}
+
+// Schedule makes the interleaving of runner starts and returns deterministic,
+// for tests of ordering guarantees that would otherwise depend on goroutine
+// scheduling and sleeps. Each runner of an app run with a schedule is held at
+// every Event until the test releases it with Step. Once released, a runner
+// proceeds concurrently until its next event, so releasing a runner at
+// EventStart and then waiting for it at EventReturn guarantees it has run:
+//
+//	schedule := NewSchedule()
+//	a := New(runners, logger, WithRunnerNames([]string{"a", "b"}), WithSchedule(schedule))
+//	go a.Run()
+//	schedule.Step(ctx, EventStart, "b")
+//	schedule.Step(ctx, EventStart, "a")
+//	schedule.Step(ctx, EventReturn, "a") // a's failure cancels b
+//	schedule.Step(ctx, EventReturn, "b") // b has observed the cancellation
+//
+// A Schedule must only be used by a single app run.
+type Schedule struct {
+	mu    sync.Mutex
+	gates map[schedulePoint]*scheduleGate
+}
+
+// schedulePoint is an event of a named runner.
+type schedulePoint struct {
+	event Event
+	name  string
+}
+
+// scheduleGate holds a runner at a point until it is released.
+type scheduleGate struct {
+	arrived chan struct{}
+	release chan struct{}
+}
+
+// NewSchedule returns a schedule that holds every runner at every event.
+func NewSchedule() *Schedule {
+	return &Schedule{gates: make(map[schedulePoint]*scheduleGate)}
+}
+
+// Step waits for the named runner to reach event and releases it. It returns
+// ctx's error if the runner does not reach event before ctx is done.
+func (s *Schedule) Step(ctx context.Context, event Event, name string) error {
+	gate := s.gate(event, name)
+	select {
+	case <-gate.arrived:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	close(gate.release)
+	return nil
+}
+
+// wait holds the named runner at event until it is released with Step. A nil
+// schedule holds no runner.
+func (s *Schedule) wait(event Event, name string) {
+	if s == nil {
+		return
+	}
+	gate := s.gate(event, name)
+	close(gate.arrived)
+	<-gate.release
+}
+
+// gate returns the gate of the named runner at event, creating it for
+// whichever of the runner and Step gets there first.
+func (s *Schedule) gate(event Event, name string) *scheduleGate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	point := schedulePoint{event: event, name: name}
+	gate, ok := s.gates[point]
+	if !ok {
+		gate = &scheduleGate{arrived: make(chan struct{}), release: make(chan struct{})}
+		s.gates[point] = gate
+	}
+	return gate
+}
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runScheduled runs an app of the named runners under schedule and returns a
+// channel receiving the result of Run
+func runScheduled(t *testing.T, names []string, runners []Runner, schedule *Schedule, options ...Option) <-chan error {
+	t.Helper()
+	logger, _ := createTestLogger()
+	options = append(options, WithRunnerNames(names), WithSchedule(schedule))
+	application := New(runners, logger, options...)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- application.Run()
+	}()
+	return done
+}
+
+// step releases the named runner at event, failing the test if it does not
+// get there within a second
+func step(t *testing.T, schedule *Schedule, event Event, name string) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, schedule.Step(ctx, event, name), "runner %s did not reach %s", name, event)
+}
+
+// TestScheduleStartOrder tests that runners start in the order they are
+// released
+func TestScheduleStartOrder(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) Runner {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return nil
+		}
+	}
+	schedule := NewSchedule()
+	done := runScheduled(t, []string{"a", "b", "c"}, []Runner{record("a"), record("b"), record("c")}, schedule)
+
+	for _, name := range []string{"c", "a", "b"} {
+		step(t, schedule, EventStart, name)
+		// Reaching its return guarantees the runner has run
+		step(t, schedule, EventReturn, name)
+	}
+
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"c", "a", "b"}, order)
+}
+
+// TestScheduleCancellationOrder tests the complete order of lifecycle events
+// when a failure cancels the remaining runners, including the runner events
+// that otherwise race with the transitions
+func TestScheduleCancellationOrder(t *testing.T) {
+	buffer := trace.New(0)
+	failing := func(ctx context.Context) error {
+		return errors.New("failed")
+	}
+	waiting := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	schedule := NewSchedule()
+	done := runScheduled(t, []string{"failing", "waiting"}, []Runner{failing, waiting}, schedule,
+		WithTrace(buffer),
+		WithPreShutdownHook(func(ctx context.Context) error { return nil }),
+	)
+
+	step(t, schedule, EventStart, "waiting")
+	step(t, schedule, EventStart, "failing")
+	step(t, schedule, EventReturn, "failing")
+	// The waiting runner only returns once the failure has cancelled it
+	step(t, schedule, EventReturn, "waiting")
+	require.ErrorContains(t, <-done, "failed")
+
+	events, _ := buffer.Events()
+	var recorded []string
+	for _, event := range events {
+		recorded = append(recorded, strings.Join(strings.Fields(event.Name+" "+event.Subject+" "+event.Detail), " "))
+	}
+	assert.Equal(t, []string{
+		"state changed created -> starting",
+		"runner started failing",
+		"runner started waiting",
+		"state changed starting -> running",
+		"runner returned failing failed",
+		"shutdown initiated",
+		"state changed running -> draining",
+		"pre-shutdown hook started hook-0",
+		"pre-shutdown hook finished hook-0",
+		"runners cancelled",
+		"runner returned waiting context canceled",
+		"all runners returned",
+		"state changed draining -> stopping",
+	}, recorded)
+}
+
+// TestScheduleStepTimeout tests that Step gives up on a runner that never
+// reaches the event
+func TestScheduleStepTimeout(t *testing.T) {
+	schedule := NewSchedule()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, schedule.Step(ctx, EventStart, "missing"), context.DeadlineExceeded)
+}
+
+// TestEventString tests the names of the schedule events
+func TestEventString(t *testing.T) {
+	assert.Equal(t, "start", EventStart.String())
+	assert.Equal(t, "return", EventReturn.String())
+	assert.Equal(t, "unknown", Event(99).String())
+}
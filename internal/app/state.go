@@ -0,0 +1,84 @@
+package app
+
+import "fmt"
+
+// State is a stage in the application lifecycle. States only ever move
+// forward in the order they are declared, ending in either StateStopped or
+// StateFailed.
+type State int32
+
+const (
+	// StateCreated is the state of an app that has not been run yet.
+	StateCreated State = iota
+
+	// StateStarting is entered when Run is called and runners are being launched.
+	StateStarting
+
+	// StateRunning is entered once all runners have been launched.
+	StateRunning
+
+	// StateDraining is entered when shutdown is initiated, either by a
+	// termination signal, a failing runner, or all runners returning.
+	StateDraining
+
+	// StateStopping is entered once all runners have returned and cleanup
+	// is in progress.
+	StateStopping
+
+	// StateStopped is the terminal state of an app that shut down cleanly.
+	StateStopped
+
+	// StateFailed is the terminal state of an app whose runners or cleanup failed.
+	StateFailed
+)
+
+// String returns the lower-case name of the state.
+func (s State) String() string {
+	switch s {
+	case StateCreated:
+		return "created"
+	case StateStarting:
+		return "starting"
+	case StateRunning:
+		return "running"
+	case StateDraining:
+		return "draining"
+	case StateStopping:
+		return "stopping"
+	case StateStopped:
+		return "stopped"
+	case StateFailed:
+		return "failed"
+	default:
+		return fmt.Sprintf("State(%d)", int32(s))
+	}
+}
+
+// transitionDetails holds the "from -> to" descriptions of every pair of
+// states, built once so that recording a transition does not allocate.
+var transitionDetails = func() (details [StateFailed + 1][StateFailed + 1]string) {
+	for from := range details {
+		for to := range details[from] {
+			details[from][to] = State(from).String() + " -> " + State(to).String()
+		}
+	}
+	return details
+}()
+
+// transitionDetail returns the "from -> to" description of a transition.
+func transitionDetail(from, to State) string {
+	if from < 0 || from > StateFailed || to < 0 || to > StateFailed {
+		return from.String() + " -> " + to.String()
+	}
+	return transitionDetails[from][to]
+}
+
+// Terminal reports whether the state is StateStopped or StateFailed.
+func (s State) Terminal() bool {
+	return s == StateStopped || s == StateFailed
+}
+
+// TransitionHook is called synchronously after every state transition.
+// Hooks must not block for long, as they delay the lifecycle. They may call
+// App.Shutdown; the transitions it causes are delivered once they return.
+type TransitionHook func(from, to State)
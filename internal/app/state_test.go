@@ -0,0 +1,156 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordTransitions returns an option recording every transition and a
+// function returning the recorded target states.
+func recordTransitions() (Option, func() []State) {
+	var mu sync.Mutex
+	var states []State
+	option := WithTransitionHook(func(from, to State) {
+		mu.Lock()
+		defer mu.Unlock()
+		states = append(states, to)
+	})
+	return option, func() []State {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]State{}, states...)
+	}
+}
+
+func TestStateString(t *testing.T) {
+	assert.Equal(t, "created", StateCreated.String())
+	assert.Equal(t, "running", StateRunning.String())
+	assert.Equal(t, "failed", StateFailed.String())
+	assert.Equal(t, "State(42)", State(42).String())
+}
+
+func TestStateTerminal(t *testing.T) {
+	assert.False(t, StateStopping.Terminal())
+	assert.True(t, StateStopped.Terminal())
+	assert.True(t, StateFailed.Terminal())
+}
+
+func TestTransitionDetail(t *testing.T) {
+	assert.Equal(t, "created -> starting", transitionDetail(StateCreated, StateStarting))
+	assert.Equal(t, "stopping -> failed", transitionDetail(StateStopping, StateFailed))
+	assert.Equal(t, "failed -> State(42)", transitionDetail(StateFailed, State(42)))
+}
+
+// BenchmarkAppTransition measures a transition with DEBUG logging disabled,
+// which should not allocate
+func BenchmarkAppTransition(b *testing.B) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	a := New(nil, logger, WithTrace(trace.New(trace.DefaultSize)))
+
+	b.ReportAllocs()
+	for b.Loop() {
+		a.state.Store(int32(StateCreated))
+		a.transition(StateStarting)
+		a.transition(StateRunning)
+	}
+}
+
+// TestAppStateTransitionsSuccess tests the lifecycle of a successful app
+// This test verifies that:
+// - A new app starts in StateCreated
+// - Run moves through every non-terminal state in order
+// - Finish without error ends in StateStopped
+func TestAppStateTransitionsSuccess(t *testing.T) {
+	logger, _ := createTestLogger()
+	option, transitions := recordTransitions()
+
+	app := New([]Runner{successfulRunner}, logger, option)
+	assert.Equal(t, StateCreated, app.State())
+
+	require.NoError(t, app.Run())
+	assert.Equal(t, StateStopping, app.State())
+
+	app.Finish(nil)
+
+	assert.Equal(t, StateStopped, app.State())
+	assert.Equal(t, []State{StateStarting, StateRunning, StateDraining, StateStopping, StateStopped}, transitions())
+}
+
+// TestAppStateTransitionsFailure tests the lifecycle of a failing app
+// This test verifies that:
+// - A failing runner still moves the app through draining and stopping
+// - Finish with an error ends in StateFailed
+// - Transitions after a terminal state are ignored
+func TestAppStateTransitionsFailure(t *testing.T) {
+	logger, _ := createTestLogger()
+	option, transitions := recordTransitions()
+
+	app := New([]Runner{failingRunner, longRunningRunner(nil)}, logger, option)
+
+	err := app.Run()
+	require.Error(t, err)
+	app.Finish(err)
+	app.Finish(nil)
+
+	assert.Equal(t, StateFailed, app.State())
+	assert.Equal(t, []State{StateStarting, StateRunning, StateDraining, StateStopping, StateFailed}, transitions())
+}
+
+// TestAppStateFinishWithoutRun tests Finish on an app that never ran
+// This test verifies that cleanup failures are still reflected in the state
+func TestAppStateFinishWithoutRun(t *testing.T) {
+	logger, _ := createTestLogger()
+	app := New(nil, logger)
+
+	app.Finish(errors.New("cleanup failed"))
+
+	assert.Equal(t, StateFailed, app.State())
+}
+
+// TestAppShutdownFromTransitionHook tests a hook that shuts the app down
+// This test verifies that:
+// - Calling Shutdown from a hook on entering StateRunning or StateDraining does not deadlock
+// - Transitions caused by a hook are delivered after it returns, in order
+func TestAppShutdownFromTransitionHook(t *testing.T) {
+	for _, trigger := range []State{StateRunning, StateDraining} {
+		t.Run(trigger.String(), func(t *testing.T) {
+			logger, _ := createTestLogger()
+			option, transitions := recordTransitions()
+
+			var app *App
+			runner := func(ctx context.Context) error {
+				// Entering StateDraining takes a shutdown to begin with
+				if trigger == StateDraining {
+					app.Shutdown()
+				}
+				<-ctx.Done()
+				return nil
+			}
+			app = New([]Runner{runner}, logger, option, WithTransitionHook(func(_, to State) {
+				if to == trigger {
+					app.Shutdown()
+				}
+			}))
+
+			done := make(chan error, 1)
+			go func() { done <- app.Run() }()
+			select {
+			case err := <-done:
+				require.NoError(t, err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("shutting down from a transition hook deadlocked")
+			}
+
+			assert.Equal(t, []State{StateStarting, StateRunning, StateDraining, StateStopping}, transitions())
+		})
+	}
+}
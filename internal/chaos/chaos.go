@@ -0,0 +1,155 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/runner"
+)
+
+// ErrInjectedFailure is returned by runners that were failed on purpose.
+var ErrInjectedFailure = errors.New("chaos: injected runner failure")
+
+// Config describes which failures to inject into the application lifecycle.
+// The zero value injects nothing.
+type Config struct {
+
+	// Enabled reports whether chaos injection was switched on via EZAPP_CHAOS.
+	Enabled bool
+
+	// RunnerFailureRate is the probability (0 to 1) that a runner is failed
+	// on purpose at a random point within MaxFailureDelay of starting.
+	RunnerFailureRate float64
+
+	// MaxFailureDelay bounds how long after starting an injected runner
+	// failure happens.
+	MaxFailureDelay time.Duration
+
+	// CleanupDelay is added before the cleanup function runs.
+	CleanupDelay time.Duration
+
+	// DroppedSignals is the number of SIGINT/SIGTERM signals to ignore before
+	// shutdown is honoured.
+	DroppedSignals int
+}
+
+// Load reads the chaos configuration from the environment. Nothing is read
+// unless EZAPP_CHAOS is set to a true value, so chaos can never be enabled
+// accidentally through one of the individual settings:
+//
+//   - EZAPP_CHAOS_RUNNER_FAILURE_RATE: probability (0 to 1) of failing each runner
+//   - EZAPP_CHAOS_MAX_FAILURE_DELAY: upper bound for when a failure happens (default 10s)
+//   - EZAPP_CHAOS_CLEANUP_DELAY: delay added before cleanup (e.g. 5s)
+//   - EZAPP_CHAOS_DROP_SIGNALS: number of termination signals to ignore
+//
+// Returns an error if any variable contains an invalid value.
+func Load() (Config, error) {
+	enabledStr := os.Getenv("EZAPP_CHAOS")
+	if enabledStr == "" {
+		return Config{}, nil
+	}
+
+	enabled, err := strconv.ParseBool(enabledStr)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid EZAPP_CHAOS value: %s - must be a boolean", enabledStr)
+	}
+	if !enabled {
+		return Config{}, nil
+	}
+
+	cfg := Config{
+		Enabled:         true,
+		MaxFailureDelay: 10 * time.Second,
+	}
+
+	if v := os.Getenv("EZAPP_CHAOS_RUNNER_FAILURE_RATE"); v != "" {
+		cfg.RunnerFailureRate, err = strconv.ParseFloat(v, 64)
+		if err != nil || cfg.RunnerFailureRate < 0 || cfg.RunnerFailureRate > 1 {
+			return Config{}, fmt.Errorf("invalid EZAPP_CHAOS_RUNNER_FAILURE_RATE value: %s - must be a number between 0 and 1", v)
+		}
+	}
+
+	if v := os.Getenv("EZAPP_CHAOS_MAX_FAILURE_DELAY"); v != "" {
+		cfg.MaxFailureDelay, err = time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid EZAPP_CHAOS_MAX_FAILURE_DELAY value: %s - must be a duration", v)
+		}
+	}
+
+	if v := os.Getenv("EZAPP_CHAOS_CLEANUP_DELAY"); v != "" {
+		cfg.CleanupDelay, err = time.ParseDuration(v)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid EZAPP_CHAOS_CLEANUP_DELAY value: %s - must be a duration", v)
+		}
+	}
+
+	if v := os.Getenv("EZAPP_CHAOS_DROP_SIGNALS"); v != "" {
+		cfg.DroppedSignals, err = strconv.Atoi(v)
+		if err != nil || cfg.DroppedSignals < 0 {
+			return Config{}, fmt.Errorf("invalid EZAPP_CHAOS_DROP_SIGNALS value: %s - must be a non-negative integer", v)
+		}
+	}
+
+	return cfg, nil
+}
+
+// WrapRunner returns r wrapped so that, with probability RunnerFailureRate,
+// it is cancelled at a random point within MaxFailureDelay and reports
+// ErrInjectedFailure. If the runner returns on its own first, its result is
+// returned unchanged.
+func (c Config) WrapRunner(r runner.Runner) runner.Runner {
+	if c.RunnerFailureRate <= 0 {
+		return r
+	}
+
+	return func(ctx context.Context) error {
+		if rand.Float64() >= c.RunnerFailureRate {
+			return r(ctx)
+		}
+
+		var delay time.Duration
+		if c.MaxFailureDelay > 0 {
+			delay = rand.N(c.MaxFailureDelay)
+		}
+
+		runCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- r(runCtx)
+		}()
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case err := <-errCh:
+			return err
+		case <-timer.C:
+			cancel()
+			<-errCh
+			return ErrInjectedFailure
+		}
+	}
+}
+
+// DelayCleanup blocks for CleanupDelay or until ctx is done.
+func (c Config) DelayCleanup(ctx context.Context) {
+	if c.CleanupDelay <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(c.CleanupDelay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
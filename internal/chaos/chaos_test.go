@@ -0,0 +1,120 @@
+package chaos
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setChaosEnv(t *testing.T, vars map[string]string) {
+	for key, value := range vars {
+		os.Setenv(key, value)
+	}
+	t.Cleanup(func() {
+		for key := range vars {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		setChaosEnv(t, map[string]string{"EZAPP_CHAOS_RUNNER_FAILURE_RATE": "1"})
+
+		cfg, err := Load()
+
+		require.NoError(t, err)
+		assert.Equal(t, Config{}, cfg, "settings should be ignored unless EZAPP_CHAOS is set")
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		setChaosEnv(t, map[string]string{
+			"EZAPP_CHAOS":                     "true",
+			"EZAPP_CHAOS_RUNNER_FAILURE_RATE": "0.25",
+			"EZAPP_CHAOS_MAX_FAILURE_DELAY":   "2s",
+			"EZAPP_CHAOS_CLEANUP_DELAY":       "500ms",
+			"EZAPP_CHAOS_DROP_SIGNALS":        "1",
+		})
+
+		cfg, err := Load()
+
+		require.NoError(t, err)
+		assert.True(t, cfg.Enabled)
+		assert.Equal(t, 0.25, cfg.RunnerFailureRate)
+		assert.Equal(t, 2*time.Second, cfg.MaxFailureDelay)
+		assert.Equal(t, 500*time.Millisecond, cfg.CleanupDelay)
+		assert.Equal(t, 1, cfg.DroppedSignals)
+	})
+
+	invalid := map[string]string{
+		"EZAPP_CHAOS":                     "maybe",
+		"EZAPP_CHAOS_RUNNER_FAILURE_RATE": "1.5",
+		"EZAPP_CHAOS_MAX_FAILURE_DELAY":   "soon",
+		"EZAPP_CHAOS_CLEANUP_DELAY":       "later",
+		"EZAPP_CHAOS_DROP_SIGNALS":        "-1",
+	}
+	for key, value := range invalid {
+		t.Run("invalid "+key, func(t *testing.T) {
+			setChaosEnv(t, map[string]string{"EZAPP_CHAOS": "true", key: value})
+
+			_, err := Load()
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), key)
+		})
+	}
+}
+
+func TestWrapRunner(t *testing.T) {
+	t.Run("always fails at rate one", func(t *testing.T) {
+		cfg := Config{RunnerFailureRate: 1, MaxFailureDelay: 10 * time.Millisecond}
+		var cancelled bool
+
+		err := cfg.WrapRunner(func(ctx context.Context) error {
+			<-ctx.Done()
+			cancelled = true
+			return ctx.Err()
+		})(context.Background())
+
+		assert.ErrorIs(t, err, ErrInjectedFailure)
+		assert.True(t, cancelled, "the wrapped runner should be cancelled before the failure is reported")
+	})
+
+	t.Run("runner result wins if it returns first", func(t *testing.T) {
+		cfg := Config{RunnerFailureRate: 1, MaxFailureDelay: time.Hour}
+
+		err := cfg.WrapRunner(func(ctx context.Context) error {
+			return nil
+		})(context.Background())
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("unchanged at rate zero", func(t *testing.T) {
+		var called bool
+
+		err := Config{}.WrapRunner(func(ctx context.Context) error {
+			called = true
+			return nil
+		})(context.Background())
+
+		assert.NoError(t, err)
+		assert.True(t, called)
+	})
+}
+
+func TestDelayCleanup(t *testing.T) {
+	start := time.Now()
+	Config{CleanupDelay: 20 * time.Millisecond}.DelayCleanup(context.Background())
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start = time.Now()
+	Config{CleanupDelay: time.Hour}.DelayCleanup(ctx)
+	assert.Less(t, time.Since(start), time.Second, "a done context should cut the delay short")
+}
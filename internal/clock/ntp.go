@@ -0,0 +1,100 @@
+// Package clock measures the offset of the local clock against an NTP
+// server using a single SNTP (RFC 4330) exchange.
+package clock
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// DefaultTimeout bounds an exchange when the context has no deadline.
+const DefaultTimeout = 5 * time.Second
+
+// ntpEpochOffset is the number of seconds between the NTP epoch (1900) and
+// the Unix epoch (1970).
+const ntpEpochOffset = 2208988800
+
+// Offset queries server, a host with an optional port (default 123), and
+// returns how far the local clock is behind it: a positive offset means the
+// local clock is slow, a negative one that it is fast.
+func Offset(ctx context.Context, server string) (time.Duration, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "123")
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", server)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach NTP server %s: %w", server, err)
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DefaultTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return 0, err
+	}
+
+	// Leap indicator 0, version 4, client mode, with the transmit timestamp
+	// echoed back by the server as the originate timestamp
+	request := make([]byte, 48)
+	request[0] = 0<<6 | 4<<3 | 3
+	sent := time.Now()
+	putTimestamp(request[40:48], sent)
+	if _, err := conn.Write(request); err != nil {
+		return 0, fmt.Errorf("failed to query NTP server %s: %w", server, err)
+	}
+
+	response := make([]byte, 48)
+	n, err := conn.Read(response)
+	received := time.Now()
+	if err != nil {
+		return 0, fmt.Errorf("failed to query NTP server %s: %w", server, err)
+	}
+	if err := validate(response[:n], request); err != nil {
+		return 0, fmt.Errorf("invalid response from NTP server %s: %w", server, err)
+	}
+
+	serverReceived := timestamp(response[32:40])
+	serverSent := timestamp(response[40:48])
+	return (serverReceived.Sub(sent) + serverSent.Sub(received)) / 2, nil
+}
+
+// validate checks that response is a server reply to request.
+func validate(response, request []byte) error {
+	switch {
+	case len(response) < 48:
+		return errors.New("short packet")
+	case response[0]&0x07 != 4:
+		return errors.New("not a server reply")
+	case response[1] == 0:
+		return errors.New("kiss-of-death reply")
+	case response[0]>>6 == 3:
+		return errors.New("server clock not synchronised")
+	case !bytes.Equal(response[24:32], request[40:48]):
+		return errors.New("reply does not match request")
+	}
+	return nil
+}
+
+// putTimestamp encodes t as an NTP timestamp into b.
+func putTimestamp(b []byte, t time.Time) {
+	seconds := uint64(t.Unix() + ntpEpochOffset)
+	fraction := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+	binary.BigEndian.PutUint32(b[0:4], uint32(seconds))
+	binary.BigEndian.PutUint32(b[4:8], uint32(fraction))
+}
+
+// timestamp decodes the NTP timestamp in b.
+func timestamp(b []byte) time.Time {
+	seconds := int64(binary.BigEndian.Uint32(b[0:4])) - ntpEpochOffset
+	fraction := uint64(binary.BigEndian.Uint32(b[4:8]))
+	return time.Unix(seconds, int64(fraction*uint64(time.Second)>>32))
+}
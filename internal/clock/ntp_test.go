@@ -0,0 +1,90 @@
+package clock
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeNTP starts an SNTP server whose clock runs offset ahead of the
+// local one. reply may alter each response before it is sent.
+func newFakeNTP(t *testing.T, offset time.Duration, reply func(response []byte)) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		request := make([]byte, 48)
+		for {
+			_, addr, err := conn.ReadFrom(request)
+			if err != nil {
+				return
+			}
+			response := make([]byte, 48)
+			response[0] = 4<<3 | 4
+			response[1] = 2
+			copy(response[24:32], request[40:48])
+			putTimestamp(response[32:40], time.Now().Add(offset))
+			putTimestamp(response[40:48], time.Now().Add(offset))
+			if reply != nil {
+				reply(response)
+			}
+			_, _ = conn.WriteTo(response, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestOffset(t *testing.T) {
+	for _, skew := range []time.Duration{0, 3 * time.Second, -90 * time.Second} {
+		server := newFakeNTP(t, skew, nil)
+
+		offset, err := Offset(context.Background(), server)
+
+		require.NoError(t, err)
+		assert.InDelta(t, skew, offset, float64(50*time.Millisecond), "offset for a server %s ahead", skew)
+	}
+}
+
+func TestOffsetInvalidReply(t *testing.T) {
+	tests := map[string]func(response []byte){
+		"client mode":     func(response []byte) { response[0] = 4<<3 | 3 },
+		"kiss-of-death":   func(response []byte) { response[1] = 0 },
+		"unsynchronised":  func(response []byte) { response[0] |= 3 << 6 },
+		"wrong originate": func(response []byte) { response[24]++ },
+	}
+	for name, reply := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := newFakeNTP(t, 0, reply)
+
+			_, err := Offset(context.Background(), server)
+
+			assert.ErrorContains(t, err, "invalid response")
+		})
+	}
+}
+
+func TestOffsetTimeout(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = Offset(ctx, conn.LocalAddr().String())
+
+	assert.Error(t, err)
+}
+
+func TestTimestampRoundTrip(t *testing.T) {
+	now := time.Now()
+	b := make([]byte, 8)
+
+	putTimestamp(b, now)
+
+	assert.WithinDuration(t, now, timestamp(b), time.Microsecond)
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"maps"
+	"reflect"
+	"time"
+
+	"github.com/Netflix/go-env"
+)
+
+// DefaultsProvider is implemented by configuration types that compute
+// defaults at runtime, such as IDs derived from the hostname. SetDefaults is
+// called on a zero value before the environment is applied.
+type DefaultsProvider interface {
+	SetDefaults()
+}
+
+// WithDefaults registers fn to set defaults on the configuration before the
+// environment is applied, after DefaultsProvider. fn receives a pointer to
+// the configuration struct.
+func WithDefaults(fn func(cfg any)) LoadOption {
+	return func(s *loadSettings) {
+		s.defaults = fn
+	}
+}
+
+// applyDefaults sets the runtime defaults on cfg, a pointer to the
+// configuration struct, and returns a copy of the resulting struct value.
+func applyDefaults(cfg any, settings loadSettings) reflect.Value {
+	if provider, ok := cfg.(DefaultsProvider); ok {
+		provider.SetDefaults()
+	}
+	if settings.defaults != nil {
+		settings.defaults(cfg)
+	}
+
+	defaults := reflect.New(reflect.TypeOf(cfg).Elem()).Elem()
+	defaults.Set(reflect.ValueOf(cfg).Elem())
+	return defaults
+}
+
+// defaultRequired adds a placeholder to envSet for every required variable of
+// the struct type of defaults that is not set and whose field has a non-zero
+// runtime default, so that the default satisfies the requirement. It returns
+// the variables present before placeholders were added.
+func defaultRequired(envSet env.EnvSet, defaults reflect.Value) env.EnvSet {
+	present := maps.Clone(envSet)
+
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		t := v.Type()
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+				walk(v.Field(i))
+			}
+
+			tag := field.Tag.Get("env")
+			if tag == "" {
+				continue
+			}
+			parsed := parseEnvTag(tag)
+			if !parsed.required || len(parsed.keys) == 0 || anyKeySet(envSet, parsed.keys) || v.Field(i).IsZero() {
+				continue
+			}
+			if placeholder, ok := zeroPlaceholder(field.Type); ok {
+				envSet[parsed.keys[0]] = placeholder
+			}
+		}
+	}
+	walk(defaults)
+
+	return present
+}
+
+// restoreDefaults sets the fields of the struct value v whose variables are
+// not in present back to their non-zero runtime defaults, which take
+// precedence over `default` tags but not over the environment.
+func restoreDefaults(v, defaults reflect.Value, present env.EnvSet) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+			restoreDefaults(v.Field(i), defaults.Field(i), present)
+		}
+
+		tag := field.Tag.Get("env")
+		if tag == "" || defaults.Field(i).IsZero() || anyKeySet(present, parseEnvTag(tag).keys) {
+			continue
+		}
+		v.Field(i).Set(defaults.Field(i))
+	}
+}
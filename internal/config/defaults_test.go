@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// defaultsConfig computes runtime defaults for some of its fields.
+type defaultsConfig struct {
+	ConsumerID string        `env:"CONSUMER_ID,required=true"`
+	Region     string        `env:"REGION,default=eu-west-1"`
+	Timeout    time.Duration `env:"TIMEOUT,default=5s"`
+	Nested     struct {
+		Queue string `env:"QUEUE"`
+	}
+}
+
+func (c *defaultsConfig) SetDefaults() {
+	c.ConsumerID = "orders-host-1"
+	c.Region = "us-east-1"
+	c.Nested.Queue = "orders"
+}
+
+func TestLoadVarDefaultsProvider(t *testing.T) {
+	t.Setenv("QUEUE", "orders-priority")
+
+	cfg, err := LoadVar[defaultsConfig]()
+
+	require.NoError(t, err, "a runtime default should satisfy a required variable")
+	assert.Equal(t, "orders-host-1", cfg.ConsumerID)
+	assert.Equal(t, "us-east-1", cfg.Region, "runtime defaults should take precedence over default tags")
+	assert.Equal(t, 5*time.Second, cfg.Timeout, "default tags should apply where no runtime default is set")
+	assert.Equal(t, "orders-priority", cfg.Nested.Queue, "the environment should take precedence over runtime defaults")
+}
+
+func TestLoadVarWithDefaults(t *testing.T) {
+	t.Setenv("CONSUMER_ID", "from-env")
+
+	cfg, err := LoadVar[defaultsConfig](WithDefaults(func(cfg any) {
+		c := cfg.(*defaultsConfig)
+		c.Region = "ap-south-1"
+		c.Timeout = time.Minute
+	}))
+
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", cfg.ConsumerID)
+	assert.Equal(t, "ap-south-1", cfg.Region, "WithDefaults should run after SetDefaults")
+	assert.Equal(t, time.Minute, cfg.Timeout)
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Netflix/go-env"
+)
+
+// DevMode reports whether local development mode is enabled through the
+// EZAPP_DEV environment variable. Any value accepted by strconv.ParseBool
+// that evaluates to true (e.g. "1", "true") enables it.
+func DevMode() bool {
+	enabled, err := strconv.ParseBool(os.Getenv("EZAPP_DEV"))
+	return err == nil && enabled
+}
+
+// LoadDotEnv reads KEY=VALUE pairs from the file at path into the process
+// environment. Variables that are already set are not overridden, blank lines
+// and lines starting with # are skipped, an optional "export " prefix is
+// accepted, and values may be wrapped in single or double quotes.
+// A missing file is not an error. Returns the names of the variables set.
+func LoadDotEnv(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var loaded []string
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return loaded, fmt.Errorf("invalid line %d in %s: expected KEY=VALUE", lineNo, path)
+		}
+		value = unquote(strings.TrimSpace(value))
+
+		if _, exists := os.LookupEnv(key); exists {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return loaded, fmt.Errorf("failed to set %s from %s: %w", key, path, err)
+		}
+		loaded = append(loaded, key)
+	}
+	if err := scanner.Err(); err != nil {
+		return loaded, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return loaded, nil
+}
+
+// unquote strips one pair of matching single or double quotes from value.
+func unquote(value string) string {
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if (first == '"' || first == '\'') && first == last {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
+
+// relaxRequired finds fields of the struct type t (including nested structs)
+// tagged as required that have neither a value in envSet nor a default. For
+// each such field whose type has a representable zero value, a placeholder is
+// added to envSet so that loading succeeds with the zero value. The names of
+// the relaxed variables are returned.
+func relaxRequired(envSet env.EnvSet, t reflect.Type) []string {
+	var relaxed []string
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+			relaxed = append(relaxed, relaxRequired(envSet, field.Type)...)
+		}
+
+		tag := field.Tag.Get("env")
+		if tag == "" {
+			continue
+		}
+
+		parsed := parseEnvTag(tag)
+		if !parsed.required || parsed.defaultValue != "" || len(parsed.keys) == 0 || anyKeySet(envSet, parsed.keys) {
+			continue
+		}
+
+		placeholder, ok := zeroPlaceholder(field.Type)
+		if !ok {
+			continue
+		}
+		envSet[parsed.keys[0]] = placeholder
+		relaxed = append(relaxed, parsed.keys[0])
+	}
+
+	return relaxed
+}
+
+// envTag holds the settings of an `env` struct tag.
+type envTag struct {
+	keys         []string
+	required     bool
+	defaultValue string
+}
+
+// parseEnvTag extracts the variable names and the required and default
+// settings from an `env` struct tag.
+func parseEnvTag(tag string) envTag {
+	var parsed envTag
+	for _, part := range strings.Split(tag, ",") {
+		name, value, isOption := strings.Cut(part, "=")
+		if !isOption {
+			parsed.keys = append(parsed.keys, part)
+			continue
+		}
+		switch strings.ToLower(name) {
+		case "required":
+			parsed.required = strings.ToLower(value) == "true"
+		case "default":
+			parsed.defaultValue = value
+		}
+	}
+	return parsed
+}
+
+// anyKeySet reports whether any of the keys is present in envSet.
+func anyKeySet(envSet env.EnvSet, keys []string) bool {
+	for _, key := range keys {
+		if _, ok := envSet[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// zeroPlaceholder returns a string that go-env parses into the zero value of
+// t, and whether such a string exists.
+func zeroPlaceholder(t reflect.Type) (string, bool) {
+	if t.Kind() == reflect.Ptr {
+		return "", false
+	}
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return "0s", true
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "", true
+	case reflect.Bool:
+		return "false", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "0", true
+	default:
+		return "", false
+	}
+}
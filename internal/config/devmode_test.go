@@ -0,0 +1,106 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevMode(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected bool
+	}{
+		{value: "", expected: false},
+		{value: "1", expected: true},
+		{value: "true", expected: true},
+		{value: "0", expected: false},
+		{value: "yes", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			t.Setenv("EZAPP_DEV", tc.value)
+			assert.Equal(t, tc.expected, DevMode())
+		})
+	}
+}
+
+func TestLoadDotEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := "# local settings\n" +
+		"\n" +
+		"DOTENV_PLAIN=plain\n" +
+		"export DOTENV_EXPORTED=exported\n" +
+		"DOTENV_DOUBLE=\"double quoted\"\n" +
+		"DOTENV_SINGLE='single quoted'\n" +
+		"DOTENV_EXISTING=from-file\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	for _, key := range []string{"DOTENV_PLAIN", "DOTENV_EXPORTED", "DOTENV_DOUBLE", "DOTENV_SINGLE"} {
+		t.Setenv(key, "")
+		require.NoError(t, os.Unsetenv(key))
+	}
+	t.Setenv("DOTENV_EXISTING", "from-env")
+
+	loaded, err := LoadDotEnv(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DOTENV_PLAIN", "DOTENV_EXPORTED", "DOTENV_DOUBLE", "DOTENV_SINGLE"}, loaded)
+
+	assert.Equal(t, "plain", os.Getenv("DOTENV_PLAIN"))
+	assert.Equal(t, "exported", os.Getenv("DOTENV_EXPORTED"))
+	assert.Equal(t, "double quoted", os.Getenv("DOTENV_DOUBLE"))
+	assert.Equal(t, "single quoted", os.Getenv("DOTENV_SINGLE"))
+	assert.Equal(t, "from-env", os.Getenv("DOTENV_EXISTING"), "existing variables must not be overridden")
+}
+
+func TestLoadDotEnvMissingFile(t *testing.T) {
+	loaded, err := LoadDotEnv(filepath.Join(t.TempDir(), ".env"))
+	assert.NoError(t, err)
+	assert.Empty(t, loaded)
+}
+
+func TestLoadDotEnvInvalidLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte("NOT A PAIR\n"), 0o600))
+
+	_, err := LoadDotEnv(path)
+	assert.ErrorContains(t, err, "line 1")
+}
+
+func TestLoadVarRelaxRequired(t *testing.T) {
+	type Nested struct {
+		Token string `env:"DEVMODE_TOKEN,required=true"`
+	}
+	type Config struct {
+		URL     string        `env:"DEVMODE_URL,required=true"`
+		Port    int           `env:"DEVMODE_PORT,required=true"`
+		Timeout time.Duration `env:"DEVMODE_TIMEOUT,required=true"`
+		Name    string        `env:"DEVMODE_NAME,required=true"`
+		Level   string        `env:"DEVMODE_LEVEL,required=true,default=info"`
+		Nested  Nested
+	}
+	t.Setenv("DEVMODE_NAME", "set")
+
+	// Without the option missing required variables are an error
+	_, err := LoadVar[Config]()
+	require.Error(t, err)
+
+	var relaxed []string
+	cfg, err := LoadVar[Config](RelaxRequired(func(key string) {
+		relaxed = append(relaxed, key)
+	}))
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"DEVMODE_URL", "DEVMODE_PORT", "DEVMODE_TIMEOUT", "DEVMODE_TOKEN"}, relaxed)
+	assert.Empty(t, cfg.URL)
+	assert.Zero(t, cfg.Port)
+	assert.Zero(t, cfg.Timeout)
+	assert.Equal(t, "set", cfg.Name)
+	assert.Equal(t, "info", cfg.Level)
+	assert.Empty(t, cfg.Nested.Token)
+}
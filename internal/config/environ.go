@@ -0,0 +1,61 @@
+package config
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/Netflix/go-env"
+)
+
+// environCache holds the most recently parsed environment, so that loading
+// several configuration structs from the same environment, as the
+// application and its companion packages do at startup, parses it once.
+var environCache struct {
+	mu      sync.Mutex
+	environ []string
+	envSet  env.EnvSet
+}
+
+// parseEnviron returns the variables of environ, in the "key=value" form of
+// os.Environ, as an EnvSet owned by the caller. With prefixes, only the
+// variables whose names start with one of them are returned.
+func parseEnviron(environ []string, prefixes []string) (env.EnvSet, error) {
+	environCache.mu.Lock()
+	defer environCache.mu.Unlock()
+
+	if environCache.envSet == nil || !slices.Equal(environCache.environ, environ) {
+		envSet, err := env.EnvironToEnvSet(environ)
+		if err != nil {
+			return nil, err
+		}
+		environCache.environ = slices.Clone(environ)
+		environCache.envSet = envSet
+	}
+
+	if len(prefixes) == 0 {
+		return maps.Clone(environCache.envSet), nil
+	}
+	envSet := make(env.EnvSet)
+	for key, value := range environCache.envSet {
+		if hasAnyPrefix(key, prefixes) {
+			envSet[key] = value
+		}
+	}
+	return envSet, nil
+}
+
+// overlayPrefixes returns prefixes together with their variants for the
+// overlay of environment, so that overlay variables survive filtering by
+// prefix.
+func overlayPrefixes(prefixes []string, environment string) []string {
+	if len(prefixes) == 0 || environment == "" {
+		return prefixes
+	}
+	overlay := OverlayPrefix(environment)
+	all := slices.Clone(prefixes)
+	for _, prefix := range prefixes {
+		all = append(all, overlay+prefix)
+	}
+	return all
+}
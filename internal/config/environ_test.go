@@ -0,0 +1,40 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/Netflix/go-env"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEnviron(t *testing.T) {
+	environ := []string{"PORT=8080", "DB_URL=postgres://db/orders"}
+
+	envSet, err := parseEnviron(environ, nil)
+	require.NoError(t, err)
+	assert.Equal(t, env.EnvSet{"PORT": "8080", "DB_URL": "postgres://db/orders"}, envSet)
+
+	envSet["PORT"] = "9090"
+	envSet, err = parseEnviron(environ, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "8080", envSet["PORT"], "changes to a returned set should not reach the cache")
+
+	envSet, err = parseEnviron([]string{"PORT=9090"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, env.EnvSet{"PORT": "9090"}, envSet, "a different environment should be parsed again")
+
+	envSet, err = parseEnviron(environ, []string{"DB_"})
+	require.NoError(t, err)
+	assert.Equal(t, env.EnvSet{"DB_URL": "postgres://db/orders"}, envSet)
+
+	_, err = parseEnviron([]string{"INVALID"}, nil)
+	assert.Error(t, err)
+}
+
+func TestOverlayPrefixes(t *testing.T) {
+	assert.Nil(t, overlayPrefixes(nil, "staging"))
+	assert.Equal(t, []string{"DB_"}, overlayPrefixes([]string{"DB_"}, ""))
+	assert.Equal(t, []string{"DB_", "CACHE_", "STAGING_DB_", "STAGING_CACHE_"},
+		overlayPrefixes([]string{"DB_", "CACHE_"}, "staging"))
+}
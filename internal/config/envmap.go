@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Netflix/go-env"
+)
+
+// EnvMapTag is the struct tag marking a field of type map[string]S, with S a
+// struct type, that is populated from indexed variables. A field tagged
+// `envmap:"TENANT"` collects the variables named TENANT_<ID>_<NAME>, where
+// NAME is a variable of S, into an S per ID. IDs are lower-cased to form the
+// map keys, so TENANT_ACME_DB_URL sets DB_URL of the entry "acme". Defaults
+// and required variables of S apply to every entry.
+const EnvMapTag = "envmap"
+
+// loadEnvMaps populates the map fields tagged with EnvMapTag in the struct
+// value v, and in its nested structs, from envSet.
+func loadEnvMaps(envSet env.EnvSet, v reflect.Value) error {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+			if err := loadEnvMaps(envSet, v.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		prefix := field.Tag.Get(EnvMapTag)
+		if prefix == "" {
+			continue
+		}
+		entries, err := loadEnvMap(envSet, prefix, field.Type)
+		if err != nil {
+			return err
+		}
+		v.Field(i).Set(entries)
+	}
+	return nil
+}
+
+// loadEnvMap builds the map of type t from the variables starting with
+// prefix and an underscore.
+func loadEnvMap(envSet env.EnvSet, prefix string, t reflect.Type) (reflect.Value, error) {
+	if t.Kind() != reflect.Map || t.Key().Kind() != reflect.String || t.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("field tagged %s:%q must be a map[string] of a struct type, got %s", EnvMapTag, prefix, t)
+	}
+
+	// Longer names are matched first, so that TENANT_ACME_DB_URL is read
+	// as DB_URL of ACME rather than URL of ACME_DB when both exist
+	names := variableNames(t.Elem())
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+
+	perID := make(map[string]env.EnvSet)
+	for key, value := range envSet {
+		rest, ok := strings.CutPrefix(key, prefix+"_")
+		if !ok {
+			continue
+		}
+		for _, name := range names {
+			id, ok := strings.CutSuffix(rest, "_"+name)
+			if !ok || id == "" {
+				continue
+			}
+			if perID[id] == nil {
+				perID[id] = make(env.EnvSet)
+			}
+			perID[id][name] = value
+			break
+		}
+	}
+
+	entries := reflect.MakeMapWithSize(t, len(perID))
+	for id, vars := range perID {
+		entry := reflect.New(t.Elem())
+		if err := env.Unmarshal(vars, entry.Interface()); err != nil {
+			return reflect.Value{}, fmt.Errorf("%s_%s: %w", prefix, id, err)
+		}
+		entries.SetMapIndex(reflect.ValueOf(strings.ToLower(id)).Convert(t.Key()), entry.Elem())
+	}
+	return entries, nil
+}
+
+// variableNames returns the names, including aliases, of the variables read
+// into the struct type t.
+func variableNames(t reflect.Type) []string {
+	var names []string
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+			names = append(names, variableNames(field.Type)...)
+		}
+		if tag := field.Tag.Get("env"); tag != "" {
+			names = append(names, parseEnvTag(tag).keys...)
+		}
+	}
+	return names
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tenantConfig is the per-tenant configuration of multiTenantConfig.
+type tenantConfig struct {
+	URL   string `env:"URL"`
+	DBURL string `env:"DB_URL,required=true" resolve:"true"`
+	Plan  string `env:"PLAN,default=free"`
+}
+
+// multiTenantConfig reads per-tenant settings from TENANT_<ID>_<NAME>.
+type multiTenantConfig struct {
+	Port    int                     `env:"PORT"`
+	Tenants map[string]tenantConfig `envmap:"TENANT"`
+}
+
+func TestLoadVarEnvMap(t *testing.T) {
+	t.Setenv("PORT", "8080")
+	t.Setenv("TENANT_ACME_DB_URL", "postgres://acme")
+	t.Setenv("TENANT_ACME_PLAN", "enterprise")
+	t.Setenv("TENANT_GLOBEX_EU_DB_URL", "postgres://globex")
+	t.Setenv("TENANT_GLOBEX_EU_URL", "https://globex.example")
+	t.Setenv("TENANT_UNRELATED", "ignored")
+
+	cfg, err := LoadVar[multiTenantConfig]()
+
+	require.NoError(t, err)
+	assert.Equal(t, 8080, cfg.Port)
+	assert.Equal(t, map[string]tenantConfig{
+		"acme":      {DBURL: "postgres://acme", Plan: "enterprise"},
+		"globex_eu": {URL: "https://globex.example", DBURL: "postgres://globex", Plan: "free"},
+	}, cfg.Tenants)
+}
+
+func TestLoadVarEnvMapRequired(t *testing.T) {
+	t.Setenv("TENANT_ACME_PLAN", "enterprise")
+
+	_, err := LoadVar[multiTenantConfig]()
+
+	assert.ErrorContains(t, err, "TENANT_ACME")
+	assert.ErrorContains(t, err, "DB_URL")
+}
+
+func TestLoadVarEnvMapInvalidType(t *testing.T) {
+	type invalidConfig struct {
+		Tenants map[string]string `envmap:"TENANT"`
+	}
+
+	_, err := LoadVar[invalidConfig]()
+
+	assert.ErrorContains(t, err, "must be a map[string] of a struct type")
+}
+
+func TestSchemaEnvMap(t *testing.T) {
+	vars := Schema[multiTenantConfig]()
+
+	names := make([]string, 0, len(vars))
+	for _, v := range vars {
+		names = append(names, v.Name)
+	}
+	assert.Equal(t, []string{"PORT", "TENANT_*_URL", "TENANT_*_DB_URL", "TENANT_*_PLAN"}, names)
+}
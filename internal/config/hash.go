@@ -0,0 +1,23 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// Hash computes a deterministic SHA-256 hash of the resolved configuration.
+// The configuration is serialized to JSON before hashing, which orders struct
+// fields by declaration and map keys lexically, so two processes that resolved
+// identical configuration always produce the same hash.
+// Returns an error if the configuration cannot be serialized.
+func Hash[CFG any](cfg CFG) (string, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize configuration for hashing: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
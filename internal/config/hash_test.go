@@ -0,0 +1,45 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type hashTestConfig struct {
+	Name   string
+	Port   int
+	Labels map[string]string
+}
+
+func TestHash(t *testing.T) {
+	t.Run("identical configuration hashes identically", func(t *testing.T) {
+		a := hashTestConfig{Name: "svc", Port: 8080, Labels: map[string]string{"a": "1", "b": "2", "c": "3"}}
+		b := hashTestConfig{Name: "svc", Port: 8080, Labels: map[string]string{"c": "3", "b": "2", "a": "1"}}
+
+		hashA, err := Hash(a)
+		require.NoError(t, err)
+		hashB, err := Hash(b)
+		require.NoError(t, err)
+
+		assert.Equal(t, hashA, hashB)
+		assert.Len(t, hashA, 64, "hash should be a hex encoded SHA-256 digest")
+	})
+
+	t.Run("different configuration hashes differently", func(t *testing.T) {
+		hashA, err := Hash(hashTestConfig{Name: "svc", Port: 8080})
+		require.NoError(t, err)
+		hashB, err := Hash(hashTestConfig{Name: "svc", Port: 8081})
+		require.NoError(t, err)
+
+		assert.NotEqual(t, hashA, hashB)
+	})
+
+	t.Run("unserializable configuration", func(t *testing.T) {
+		_, err := Hash(struct{ Ch chan int }{Ch: make(chan int)})
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to serialize configuration for hashing")
+	})
+}
@@ -0,0 +1,14 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// HoldMode reports whether hold mode is enabled through the EZAPP_HOLD
+// environment variable. Any value accepted by strconv.ParseBool that
+// evaluates to true (e.g. "1", "true") enables it.
+func HoldMode() bool {
+	enabled, err := strconv.ParseBool(os.Getenv("EZAPP_HOLD"))
+	return err == nil && enabled
+}
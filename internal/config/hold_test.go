@@ -0,0 +1,18 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHoldMode(t *testing.T) {
+	t.Setenv("EZAPP_HOLD", "")
+	assert.False(t, HoldMode())
+
+	t.Setenv("EZAPP_HOLD", "true")
+	assert.True(t, HoldMode())
+
+	t.Setenv("EZAPP_HOLD", "standby")
+	assert.False(t, HoldMode())
+}
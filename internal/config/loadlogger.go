@@ -3,41 +3,54 @@ package config
 import (
 	"log/slog"
 	"os"
-	"strings"
 )
 
 // LoadLogger creates a slog logger with the log level specified by the EZAPP_LOG_LEVEL
 // environment variable. If the variable is not set or invalid, the default log level is INFO.
+// EZAPP_LOG_LEVELS overrides the level per component, e.g. "kafka=debug,http=warn"
+// (see levelHandler).
+// In development mode (see DevMode) the logger writes human-readable text instead of
+// JSON and the default log level is DEBUG.
 func LoadLogger() *slog.Logger {
+	devMode := DevMode()
 
-	// Get log level from environment variable
+	// Get log level from environment variable, defaulting to INFO for
+	// empty or invalid values, or DEBUG in development mode if empty
 	logLevelStr := os.Getenv("EZAPP_LOG_LEVEL")
-	logLevelStr = strings.ToUpper(logLevelStr)
-
-	// Set default log level to INFO
-	var logLevel slog.Level
-
-	// Parse log level from environment variable
-	switch logLevelStr {
-	case "DEBUG":
-		logLevel = slog.LevelDebug
-	case "INFO":
-		logLevel = slog.LevelInfo
-	case "WARN":
-		logLevel = slog.LevelWarn
-	case "ERROR":
-		logLevel = slog.LevelError
-	default:
-		// Default to INFO for invalid or empty values
+	logLevel, ok := parseLevel(logLevelStr)
+	if !ok {
 		logLevel = slog.LevelInfo
+		if logLevelStr == "" && devMode {
+			logLevel = slog.LevelDebug
+		}
+	}
+
+	// Get per-component overrides, which the handler must not filter out
+	levels, invalid := parseLogLevels(os.Getenv("EZAPP_LOG_LEVELS"))
+	handlerLevel := logLevel
+	for _, level := range levels {
+		handlerLevel = min(handlerLevel, level)
 	}
 
-	// Create JSON handler with the configured level
+	// Create a JSON handler, or a text handler in development mode,
+	// with the configured level
 	opts := &slog.HandlerOptions{
-		Level: logLevel,
+		Level: handlerLevel,
+	}
+	var handler slog.Handler = slog.NewJSONHandler(os.Stdout, opts)
+	if devMode {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	}
+
+	// Filter records by component when overrides are set
+	if len(levels) > 0 {
+		handler = newLevelHandler(handler, logLevel, levels)
 	}
-	handler := slog.NewJSONHandler(os.Stdout, opts)
 
-	// Create and return logger
-	return slog.New(handler)
+	// Create the logger and report overrides that could not be parsed
+	logger := slog.New(handler)
+	for _, entry := range invalid {
+		logger.Warn("ignoring invalid log level override", "variable", "EZAPP_LOG_LEVELS", "entry", entry)
+	}
+	return logger
 }
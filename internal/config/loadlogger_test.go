@@ -1,6 +1,7 @@
 package config
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"testing"
@@ -76,3 +77,20 @@ func TestLoadLogger(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadLoggerDevMode(t *testing.T) {
+	t.Setenv("EZAPP_DEV", "1")
+
+	t.Run("defaults to debug", func(t *testing.T) {
+		t.Setenv("EZAPP_LOG_LEVEL", "")
+		logger := LoadLogger()
+		assert.True(t, logger.Enabled(context.Background(), slog.LevelDebug))
+		assert.IsType(t, &slog.TextHandler{}, logger.Handler())
+	})
+
+	t.Run("explicit level wins", func(t *testing.T) {
+		t.Setenv("EZAPP_LOG_LEVEL", "WARN")
+		logger := LoadLogger()
+		assert.False(t, logger.Enabled(context.Background(), slog.LevelInfo))
+	})
+}
@@ -2,32 +2,133 @@ package config
 
 import (
 	"fmt"
+	"os"
 	"reflect"
 
 	"github.com/Netflix/go-env"
 )
 
+// LoadOption represents a functional option for configuring LoadVar.
+type LoadOption func(*loadSettings)
+
+// loadSettings holds the settings applied by LoadOption values.
+type loadSettings struct {
+	onRelaxed func(key string)
+	defaults  func(cfg any)
+	environ   []string
+	prefixes  []string
+}
+
+// RelaxRequired makes LoadVar tolerate missing required variables: instead of
+// failing, the affected fields are left at their zero value and onRelaxed is
+// called with the name of each missing variable.
+func RelaxRequired(onRelaxed func(key string)) LoadOption {
+	return func(s *loadSettings) {
+		s.onRelaxed = onRelaxed
+	}
+}
+
+// WithEnviron makes LoadVar read the variables from environ, in the "key=value"
+// form of os.Environ, instead of the process environment.
+func WithEnviron(environ []string) LoadOption {
+	return func(s *loadSettings) {
+		s.environ = environ
+	}
+}
+
+// WithPrefix makes LoadVar read only the variables whose names start with one
+// of prefixes, e.g. "PYROSCOPE_", along with their overlay variants. Loading
+// a struct whose variables share a prefix then no longer depends on the size
+// of the environment. References in values (see resolveReferences) can only
+// name variables with one of the prefixes.
+func WithPrefix(prefixes ...string) LoadOption {
+	return func(s *loadSettings) {
+		s.prefixes = append(s.prefixes, prefixes...)
+	}
+}
+
 // LoadVar creates and populates a configuration struct of type CFG using environment variables.
 // It validates that CFG is a struct type, creates a new instance, and populates its fields
 // using the Netflix env var library based on struct tags.
+// The parsed environment is cached, so loading several structs from the same
+// environment parses it once; WithPrefix limits loading to the variables with
+// the given prefixes.
+// If an environment is selected via EZAPP_ENV, its overlay variables are merged
+// over the base variables before the struct is populated (see ApplyOverlay).
+// Fields tagged with EnvMapTag are populated from indexed variables.
+// Defaults computed at runtime by a DefaultsProvider or WithDefaults apply to
+// fields whose variables are not set, taking precedence over `default` tags.
+// Values of fields tagged with ResolveTag may reference other variables and
+// files (see resolveReferences).
 // Returns an error if CFG is not a struct type or if there's an error populating the struct.
-func LoadVar[CFG any]() (CFG, error) {
+func LoadVar[CFG any](options ...LoadOption) (CFG, error) {
+	var settings loadSettings
+	for _, opt := range options {
+		opt(&settings)
+	}
+
 	var config CFG
 	
-	// Validate that CFG is a struct
-	configType := reflect.TypeOf(config)
+	// Validate that CFG is a struct. The type is taken from a pointer so that
+	// interface type parameters are reported instead of yielding a nil type.
+	configType := reflect.TypeOf((*CFG)(nil)).Elem()
 	if configType.Kind() != reflect.Struct {
-		return config, fmt.Errorf("config type must be a struct, got %v", configType.Kind())
+		return config, fmt.Errorf("config type must be a struct, got %s (%v)%s", configType, configType.Kind(), configTypeHint(configType))
 	}
 	
 	// Create a new instance of CFG
 	// (Already done with var config CFG)
 	
+	// Read the environment and merge the selected environment overlay
+	environ := settings.environ
+	if environ == nil {
+		environ = os.Environ()
+	}
+	environment := Environment()
+	envSet, err := parseEnviron(environ, overlayPrefixes(settings.prefixes, environment))
+	if err != nil {
+		return config, fmt.Errorf("failed to read environment: %w", err)
+	}
+	ApplyOverlay(envSet, environment)
+
+	// Resolve references to other variables and to mounted secret files
+	if err := resolveReferences(envSet, resolvedNames(schemaOf(configType))); err != nil {
+		return config, fmt.Errorf("failed to load configuration from environment: %w", err)
+	}
+
+	// Apply the runtime defaults, which satisfy required variables
+	defaults := applyDefaults(&config, settings)
+	present := defaultRequired(envSet, defaults)
+
+	// Fill in placeholders for missing required variables if relaxed
+	if settings.onRelaxed != nil {
+		for _, key := range relaxRequired(envSet, configType) {
+			settings.onRelaxed(key)
+		}
+	}
+
 	// Use Netflix env var library to populate the struct
-	_, err := env.UnmarshalFromEnviron(&config)
+	err = env.Unmarshal(envSet, &config)
 	if err != nil {
 		return config, fmt.Errorf("failed to load configuration from environment: %w", err)
 	}
+	restoreDefaults(reflect.ValueOf(&config).Elem(), defaults, present)
+
+	// Populate the maps of indexed variables, such as per-tenant settings
+	if err := loadEnvMaps(envSet, reflect.ValueOf(&config).Elem()); err != nil {
+		return config, fmt.Errorf("failed to load configuration from environment: %w", err)
+	}
 	
 	return config, nil
-}
\ No newline at end of file
+}
+// configTypeHint suggests how to fix a config type that is not a struct.
+func configTypeHint(t reflect.Type) string {
+	switch {
+	case t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct:
+		return fmt.Sprintf(": use the struct type %s instead of a pointer to it", t.Elem())
+	case t.Kind() == reflect.Interface:
+		return ": use a concrete struct type with env tags"
+	default:
+		return ""
+	}
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -50,13 +52,123 @@ func TestLoadVar(t *testing.T) {
 		assert.Error(t, err)
 	})
 
-	// Test case 3: Non-struct type
+	// Test case 3: Environment overlay
+	t.Run("environment overlay", func(t *testing.T) {
+		os.Setenv("EZAPP_ENV", "staging")
+		os.Setenv("TEST_STRING", "base value")
+		os.Setenv("STAGING_TEST_STRING", "staging value")
+		defer func() {
+			os.Unsetenv("EZAPP_ENV")
+			os.Unsetenv("TEST_STRING")
+			os.Unsetenv("STAGING_TEST_STRING")
+		}()
+
+		config, err := LoadVar[TestConfig]()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "staging value", config.TestString)
+	})
+
+	// Test case 4: Non-struct type
 	t.Run("non-struct type", func(t *testing.T) {
 		// Call the function with a non-struct type
 		_, err := LoadVar[string]()
 
 		// Check results
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "config type must be a struct")
+		assert.Contains(t, err.Error(), "config type must be a struct, got string")
 	})
-}
\ No newline at end of file
+
+	// Test case 5: Pointer and interface types are named with a hint
+	t.Run("pointer and interface types", func(t *testing.T) {
+		_, err := LoadVar[*TestConfig]()
+		assert.ErrorContains(t, err, "got *config.TestConfig (ptr): use the struct type config.TestConfig instead of a pointer to it")
+
+		_, err = LoadVar[any]()
+		assert.ErrorContains(t, err, "got interface {} (interface): use a concrete struct type")
+	})
+}
+func TestLoadVarWithEnviron(t *testing.T) {
+	t.Setenv("TEST_STRING", "from process")
+	t.Setenv("TEST_INT", "1")
+
+	config, err := LoadVar[TestConfig](WithEnviron([]string{"TEST_STRING=from environ", "TEST_BOOL=true"}))
+
+	assert.NoError(t, err)
+	assert.Equal(t, TestConfig{TestString: "from environ", TestBool: true}, config)
+}
+
+// benchConfig is a configuration struct for the LoadVar benchmarks
+type benchConfig struct {
+	Host     string        `env:"BENCH_HOST,default=localhost"`
+	Port     int           `env:"BENCH_PORT,default=8080"`
+	Debug    bool          `env:"BENCH_DEBUG"`
+	Timeout  time.Duration `env:"BENCH_TIMEOUT,default=5s"`
+	Database struct {
+		URL      string `env:"BENCH_DB_URL,required=true"`
+		Password string `env:"BENCH_DB_PASSWORD" resolve:"true"`
+		PoolSize int    `env:"BENCH_DB_POOL_SIZE,default=10"`
+	}
+	Tenants map[string]struct {
+		URL string `env:"URL"`
+	} `envmap:"BENCH_TENANT"`
+}
+
+// benchEnviron returns an environment of the given size holding the
+// variables read into benchConfig among unrelated ones
+func benchEnviron(size int) []string {
+	environ := []string{
+		"BENCH_PORT=9090",
+		"BENCH_DB_URL=postgres://db/orders",
+		"BENCH_DB_PASSWORD=${BENCH_SECRET}",
+		"BENCH_SECRET=hunter2",
+		"BENCH_TENANT_ACME_URL=https://acme.example.com",
+	}
+	for i := len(environ); i < size; i++ {
+		environ = append(environ, fmt.Sprintf("UNRELATED_VARIABLE_%d=value-%d", i, i))
+	}
+	return environ
+}
+
+func BenchmarkLoadVar(b *testing.B) {
+	for _, size := range []int{100, 10000} {
+		environ := benchEnviron(size)
+
+		b.Run(fmt.Sprintf("vars=%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := LoadVar[benchConfig](WithEnviron(environ)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("vars=%d/prefix", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := LoadVar[benchConfig](WithEnviron(environ), WithPrefix("BENCH_")); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadVarWithPrefix(t *testing.T) {
+	environ := []string{
+		"BENCH_DB_URL=postgres://db/orders",
+		"BENCH_DB_PASSWORD=${OTHER_SECRET}",
+		"OTHER_SECRET=hunter2",
+		"BENCH_PORT=9090",
+		"STAGING_BENCH_PORT=9191",
+	}
+
+	_, err := LoadVar[benchConfig](WithEnviron(environ), WithPrefix("BENCH_"))
+	assert.ErrorContains(t, err, "OTHER_SECRET", "references should only see variables with the prefix")
+
+	environ[1] = "BENCH_DB_PASSWORD=hunter2"
+	t.Setenv("EZAPP_ENV", "staging")
+	cfg, err := LoadVar[benchConfig](WithEnviron(environ), WithPrefix("BENCH_"))
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres://db/orders", cfg.Database.URL)
+	assert.Equal(t, 9191, cfg.Port, "overlay variables should be read along with the prefix")
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/pgvanniekerk/ezapp/runner"
+)
+
+// ComponentKey is the log attribute naming the component a logger belongs
+// to, e.g. logger.With("component", "kafka"). Per-component levels set with
+// EZAPP_LOG_LEVELS apply to loggers carrying it.
+const ComponentKey = "component"
+
+// parseLevel parses a log level name such as "debug" or "WARN".
+func parseLevel(name string) (slog.Level, bool) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "DEBUG":
+		return slog.LevelDebug, true
+	case "INFO":
+		return slog.LevelInfo, true
+	case "WARN":
+		return slog.LevelWarn, true
+	case "ERROR":
+		return slog.LevelError, true
+	default:
+		return 0, false
+	}
+}
+
+// parseLogLevels parses per-component log levels in the EZAPP_LOG_LEVELS
+// format, e.g. "kafka=debug,http=warn". Invalid entries are returned
+// separately so that they can be reported once the logger exists.
+func parseLogLevels(list string) (map[string]slog.Level, []string) {
+	levels := make(map[string]slog.Level)
+	var invalid []string
+	for _, entry := range strings.Split(list, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		name, levelName, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		level, valid := parseLevel(levelName)
+		if !ok || name == "" || !valid {
+			invalid = append(invalid, strings.TrimSpace(entry))
+			continue
+		}
+		levels[name] = level
+	}
+	return levels, invalid
+}
+
+// levelHandler filters records by the level of the component that logs
+// them. The component is taken from a ComponentKey attribute added with
+// Logger.With or, failing that, from the name of the runner whose context is
+// passed to a *Context logging method. Dotted names form a tree: a level set
+// for "kafka" also applies to "kafka.consumer" unless it has its own.
+type levelHandler struct {
+	next      slog.Handler
+	level     slog.Level
+	levels    map[string]slog.Level
+	component string
+	grouped   bool
+}
+
+// newLevelHandler wraps next, which must accept every level in levels, so
+// that records are filtered by levels with level as the default.
+func newLevelHandler(next slog.Handler, level slog.Level, levels map[string]slog.Level) *levelHandler {
+	return &levelHandler{next: next, level: level, levels: levels}
+}
+
+// Enabled reports whether the component's level permits level.
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.levelFor(ctx)
+}
+
+// levelFor returns the level of the component logging with ctx.
+func (h *levelHandler) levelFor(ctx context.Context) slog.Level {
+	name := h.component
+	if name == "" && ctx != nil {
+		name, _ = runner.Name(ctx)
+	}
+	for name != "" {
+		if level, ok := h.levels[name]; ok {
+			return level
+		}
+		idx := strings.LastIndexByte(name, '.')
+		if idx < 0 {
+			break
+		}
+		name = name[:idx]
+	}
+	return h.level
+}
+
+// Handle passes the record on to the wrapped handler.
+func (h *levelHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.next.Handle(ctx, record)
+}
+
+// WithAttrs records the component named by a top-level ComponentKey attribute.
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	if !h.grouped {
+		for _, attr := range attrs {
+			if attr.Key == ComponentKey {
+				clone.component = attr.Value.String()
+			}
+		}
+	}
+	return &clone
+}
+
+// WithGroup opens a group; component attributes within it are ignored.
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	clone.grouped = true
+	return &clone
+}
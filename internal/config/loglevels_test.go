@@ -0,0 +1,63 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/pgvanniekerk/ezapp/runner"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLogLevels(t *testing.T) {
+	levels, invalid := parseLogLevels(" kafka=debug, http=WARN,,broken,=info,db=loud")
+	assert.Equal(t, map[string]slog.Level{"kafka": slog.LevelDebug, "http": slog.LevelWarn}, levels)
+	assert.Equal(t, []string{"broken", "=info", "db=loud"}, invalid)
+}
+
+func TestLevelHandler(t *testing.T) {
+	var buf bytes.Buffer
+	next := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	logger := slog.New(newLevelHandler(next, slog.LevelInfo, map[string]slog.Level{
+		"kafka":       slog.LevelDebug,
+		"kafka.noisy": slog.LevelError,
+		"http":        slog.LevelWarn,
+	}))
+	ctx := context.Background()
+
+	assert.False(t, logger.Enabled(ctx, slog.LevelDebug), "the default level applies without a component")
+	assert.True(t, logger.Enabled(ctx, slog.LevelInfo))
+
+	kafka := logger.With(ComponentKey, "kafka")
+	assert.True(t, kafka.Enabled(ctx, slog.LevelDebug))
+	assert.True(t, kafka.With(ComponentKey, "kafka.consumer").Enabled(ctx, slog.LevelDebug), "children inherit their parent's level")
+	assert.False(t, kafka.With(ComponentKey, "kafka.noisy").Enabled(ctx, slog.LevelWarn))
+
+	http := logger.With(ComponentKey, "http")
+	assert.False(t, http.Enabled(ctx, slog.LevelInfo))
+	assert.True(t, http.Enabled(ctx, slog.LevelWarn))
+
+	assert.False(t, logger.WithGroup("request").With(ComponentKey, "kafka").Enabled(ctx, slog.LevelDebug),
+		"component attributes within groups are ignored")
+
+	// Runner contexts select the runner's level
+	pprof.Do(ctx, pprof.Labels(runner.LabelKey, "kafka"), func(ctx context.Context) {
+		assert.True(t, logger.Enabled(ctx, slog.LevelDebug))
+		logger.DebugContext(ctx, "polled")
+	})
+	assert.Contains(t, buf.String(), "msg=polled")
+}
+
+func TestLoadLoggerLevels(t *testing.T) {
+	t.Setenv("EZAPP_DEV", "")
+	t.Setenv("EZAPP_LOG_LEVEL", "INFO")
+	t.Setenv("EZAPP_LOG_LEVELS", "kafka=debug,http=warn")
+
+	logger := LoadLogger()
+	ctx := context.Background()
+	assert.False(t, logger.Enabled(ctx, slog.LevelDebug))
+	assert.True(t, logger.With(ComponentKey, "kafka").Enabled(ctx, slog.LevelDebug))
+	assert.False(t, logger.With(ComponentKey, "http").Enabled(ctx, slog.LevelInfo))
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/Netflix/go-env"
+)
+
+// Environment returns the deployment environment selected by the EZAPP_ENV
+// environment variable (e.g. "staging"), or an empty string if none is set.
+func Environment() string {
+	return strings.TrimSpace(os.Getenv("EZAPP_ENV"))
+}
+
+// OverlayPrefix returns the variable name prefix used for overrides belonging
+// to the given environment. The environment name is upper-cased and any
+// character that is not a letter or digit is replaced with an underscore,
+// so "eu-staging" yields "EU_STAGING_".
+func OverlayPrefix(environment string) string {
+	prefix := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, environment)
+	return prefix + "_"
+}
+
+// ApplyOverlay merges the overlay for the given environment over the base
+// variables in envSet. For an environment of "staging", a variable named
+// STAGING_PORT overrides PORT. Overlay variables are left in place so they
+// remain visible to callers. If environment is empty, envSet is unchanged.
+func ApplyOverlay(envSet env.EnvSet, environment string) {
+	if environment == "" {
+		return
+	}
+
+	prefix := OverlayPrefix(environment)
+	overrides := make(env.EnvSet)
+	for key, value := range envSet {
+		if name, ok := strings.CutPrefix(key, prefix); ok && name != "" {
+			overrides[name] = value
+		}
+	}
+
+	for key, value := range overrides {
+		envSet[key] = value
+	}
+}
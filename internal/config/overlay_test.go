@@ -0,0 +1,49 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Netflix/go-env"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvironment(t *testing.T) {
+	os.Setenv("EZAPP_ENV", " staging ")
+	defer os.Unsetenv("EZAPP_ENV")
+
+	assert.Equal(t, "staging", Environment())
+}
+
+func TestOverlayPrefix(t *testing.T) {
+	assert.Equal(t, "STAGING_", OverlayPrefix("staging"))
+	assert.Equal(t, "EU_STAGING_", OverlayPrefix("eu-staging"))
+	assert.Equal(t, "PROD2_", OverlayPrefix("Prod2"))
+}
+
+func TestApplyOverlay(t *testing.T) {
+	t.Run("overrides base values", func(t *testing.T) {
+		envSet := env.EnvSet{
+			"PORT":          "8080",
+			"HOST":          "localhost",
+			"STAGING_PORT":  "9090",
+			"STAGING_DEBUG": "true",
+			"PROD_PORT":     "80",
+		}
+
+		ApplyOverlay(envSet, "staging")
+
+		assert.Equal(t, "9090", envSet["PORT"])
+		assert.Equal(t, "localhost", envSet["HOST"])
+		assert.Equal(t, "true", envSet["DEBUG"])
+		assert.Equal(t, "9090", envSet["STAGING_PORT"], "overlay variables should be kept")
+	})
+
+	t.Run("no environment", func(t *testing.T) {
+		envSet := env.EnvSet{"PORT": "8080", "STAGING_PORT": "9090"}
+
+		ApplyOverlay(envSet, "")
+
+		assert.Equal(t, "8080", envSet["PORT"])
+	})
+}
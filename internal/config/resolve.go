@@ -0,0 +1,156 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+
+	"github.com/Netflix/go-env"
+)
+
+// FileScheme prefixes a variable value naming a file whose content is the
+// value, e.g. "file:///var/run/secrets/db/password".
+const FileScheme = "file://"
+
+// ResolveTag is the struct tag opting a field into reference resolution: the
+// value of a field tagged `resolve:"true"` may name a file or reference other
+// variables (see resolveReferences). Values of other fields are used as is,
+// so a DSN such as "file:///data/app.db" or a password holding "$$" or "${"
+// is not rewritten.
+const ResolveTag = "resolve"
+
+// FileSuffix is appended to a variable name to give the name of a variable
+// holding the path of a file to read the value from: DB_PASSWORD_FILE sets
+// DB_PASSWORD unless DB_PASSWORD is set itself.
+const FileSuffix = "_FILE"
+
+// resolveReferences resolves the values of the variables in envSet whose
+// names match one of names, those of the fields tagged with ResolveTag as
+// reported by Schema, in place:
+//
+//   - ${NAME} is replaced by the value of the variable NAME, and $$ by $
+//   - a value starting with FileScheme, after substitution, is replaced by
+//     the content of the file, with trailing newlines trimmed
+//   - a variable that is not set is read from the file named by the variable
+//     with FileSuffix appended, if that is set
+//
+// This covers secrets mounted as files, as Kubernetes secret volumes and
+// Docker secrets provide them. References are resolved once, against the
+// unresolved values.
+func resolveReferences(envSet env.EnvSet, names []string) error {
+	// Resolved values are collected and applied at the end, so that every
+	// reference sees the unresolved values. Values without references are
+	// skipped before matching their names, which is the costly part in
+	// large environments.
+	resolved := make(map[string]string)
+	for key, value := range envSet {
+		if !strings.Contains(value, "$") && !strings.HasPrefix(value, FileScheme) {
+			continue
+		}
+		if !matchesAny(key, names) {
+			continue
+		}
+		value, err := resolveValue(value, envSet)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", key, err)
+		}
+		resolved[key] = value
+	}
+
+	for key, path := range envSet {
+		name, ok := strings.CutSuffix(key, FileSuffix)
+		if !ok || !matchesAny(name, names) {
+			continue
+		}
+		if _, set := envSet[name]; set {
+			continue
+		}
+		value, err := readValueFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s from %s: %w", name, key, err)
+		}
+		resolved[name] = value
+	}
+
+	maps.Copy(envSet, resolved)
+	return nil
+}
+
+// resolveValue substitutes the references in value and reads the file it
+// names, if any.
+func resolveValue(value string, vars map[string]string) (string, error) {
+	value, err := substitute(value, vars)
+	if err != nil {
+		return "", err
+	}
+	if path, ok := strings.CutPrefix(value, FileScheme); ok {
+		return readValueFile(path)
+	}
+	return value, nil
+}
+
+// substitute replaces ${NAME} in value by the value of NAME in vars and $$
+// by $. A reference to a variable that is not set is an error. Errors never
+// quote value, which may be a secret.
+func substitute(value string, vars map[string]string) (string, error) {
+	if !strings.Contains(value, "$") {
+		return value, nil
+	}
+
+	var b strings.Builder
+	for {
+		idx := strings.IndexByte(value, '$')
+		if idx < 0 || idx == len(value)-1 {
+			b.WriteString(value)
+			return b.String(), nil
+		}
+		b.WriteString(value[:idx])
+
+		switch value[idx+1] {
+		case '$':
+			b.WriteByte('$')
+			value = value[idx+2:]
+		case '{':
+			end := strings.IndexByte(value[idx:], '}')
+			if end < 0 {
+				return "", errors.New("unterminated reference")
+			}
+			name := value[idx+2 : idx+end]
+			referenced, ok := vars[name]
+			if !ok {
+				return "", fmt.Errorf("references %s, which is not set", name)
+			}
+			b.WriteString(referenced)
+			value = value[idx+end+1:]
+		default:
+			b.WriteByte('$')
+			value = value[idx+1:]
+		}
+	}
+}
+
+// readValueFile reads a value from the file at path, trimming trailing
+// newlines as editors and kubectl leave them.
+func readValueFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// resolvedNames returns the names and aliases of the vars tagged with
+// ResolveTag.
+func resolvedNames(vars []Var) []string {
+	var names []string
+	for _, v := range vars {
+		if !v.Resolve {
+			continue
+		}
+		names = append(names, v.Name)
+		names = append(names, v.Aliases...)
+	}
+	return names
+}
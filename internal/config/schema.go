@@ -0,0 +1,97 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Var describes an environment variable read into a configuration struct.
+type Var struct {
+	// Name is the variable name; Aliases are the alternative names listed
+	// after it in the `env` tag.
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+
+	// Type is the Go type of the field the variable is loaded into.
+	Type string `json:"type"`
+
+	// Default is the value used when the variable is not set.
+	Default string `json:"default,omitempty"`
+
+	// Required reports whether loading fails when the variable is not set
+	// and has no default.
+	Required bool `json:"required"`
+
+	// Secret reports whether the field is tagged `secret:"true"`, marking
+	// a value that should be provisioned from a secret store.
+	Secret bool `json:"secret"`
+
+	// Resolve reports whether the field is tagged `resolve:"true"`, so its
+	// value may name a file or reference other variables.
+	Resolve bool `json:"resolve"`
+}
+
+// Schema describes the environment variables read by LoadVar[CFG], including
+// those of nested structs, in field declaration order. Fields without an
+// `env` tag are omitted. The variables of a map tagged with EnvMapTag are
+// named with a "*" in place of the ID, e.g. TENANT_*_DB_URL. CFG must be a
+// struct type; other types yield nil.
+func Schema[CFG any]() []Var {
+	configType := reflect.TypeOf((*CFG)(nil)).Elem()
+	if configType.Kind() != reflect.Struct {
+		return nil
+	}
+	return schemaOf(configType)
+}
+
+// schemaOf describes the variables of the struct type t.
+func schemaOf(t reflect.Type) []Var {
+	var vars []Var
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+			vars = append(vars, schemaOf(field.Type)...)
+		}
+		if prefix := field.Tag.Get(EnvMapTag); prefix != "" && field.Type.Kind() == reflect.Map && field.Type.Elem().Kind() == reflect.Struct {
+			vars = append(vars, indexedSchema(prefix, schemaOf(field.Type.Elem()))...)
+		}
+
+		tag := field.Tag.Get("env")
+		if tag == "" {
+			continue
+		}
+		parsed := parseEnvTag(tag)
+		if len(parsed.keys) == 0 {
+			continue
+		}
+
+		v := Var{
+			Name:     parsed.keys[0],
+			Type:     field.Type.String(),
+			Default:  parsed.defaultValue,
+			Required: parsed.required,
+			Secret:   strings.ToLower(field.Tag.Get("secret")) == "true",
+			Resolve:  strings.ToLower(field.Tag.Get(ResolveTag)) == "true",
+		}
+		if len(parsed.keys) > 1 {
+			v.Aliases = parsed.keys[1:]
+		}
+		vars = append(vars, v)
+	}
+
+	return vars
+}
+
+// indexedSchema names vars, the variables of the entries of a map tagged
+// with EnvMapTag, with prefix and a "*" in place of the ID.
+func indexedSchema(prefix string, vars []Var) []Var {
+	for idx := range vars {
+		vars[idx].Name = prefix + "_*_" + vars[idx].Name
+		for a := range vars[idx].Aliases {
+			vars[idx].Aliases[a] = prefix + "_*_" + vars[idx].Aliases[a]
+		}
+	}
+	return vars
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type schemaDatabase struct {
+	URL      string `env:"DATABASE_URL,DB_URL,required=true"`
+	Password string `env:"DATABASE_PASSWORD,required=true" secret:"true" resolve:"true"`
+}
+
+type schemaConfig struct {
+	Port     int           `env:"PORT,default=8080"`
+	Timeout  time.Duration `env:"TIMEOUT,default=5s"`
+	Database schemaDatabase
+	Internal string
+}
+
+func TestSchema(t *testing.T) {
+	assert.Equal(t, []Var{
+		{Name: "PORT", Type: "int", Default: "8080"},
+		{Name: "TIMEOUT", Type: "time.Duration", Default: "5s"},
+		{Name: "DATABASE_URL", Aliases: []string{"DB_URL"}, Type: "string", Required: true},
+		{Name: "DATABASE_PASSWORD", Type: "string", Required: true, Secret: true, Resolve: true},
+	}, Schema[schemaConfig]())
+
+	assert.Nil(t, Schema[string]())
+}
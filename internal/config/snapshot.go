@@ -0,0 +1,144 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Snapshot records the resolved configuration of a run so that the next run
+// can report what changed.
+type Snapshot struct {
+	// Hash is the configuration hash computed by Hash.
+	Hash string `json:"hash"`
+
+	// Values maps each variable described by Schema to its resolved value.
+	// Secret values are replaced by a digest, so changes to them are
+	// detected without the values being stored.
+	Values map[string]string `json:"values"`
+}
+
+// Change describes a variable whose value differs between two snapshots.
+// From or To is empty if the variable did not exist in that snapshot.
+type Change struct {
+	Variable string
+	From     string
+	To       string
+}
+
+// NewSnapshot records the values of the variables of cfg, which must be a
+// struct, under hash.
+func NewSnapshot[CFG any](cfg CFG, hash string) Snapshot {
+	values := make(map[string]string)
+	value := reflect.ValueOf(cfg)
+	if value.Kind() == reflect.Struct {
+		snapshotOf(value, values)
+	}
+	return Snapshot{Hash: hash, Values: values}
+}
+
+// snapshotOf records the values of the variables of the struct value v,
+// walking nested structs like schemaOf.
+func snapshotOf(v reflect.Value, values map[string]string) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+			snapshotOf(v.Field(i), values)
+		}
+
+		tag := field.Tag.Get("env")
+		if tag == "" {
+			continue
+		}
+		parsed := parseEnvTag(tag)
+		if len(parsed.keys) == 0 {
+			continue
+		}
+
+		value := fmt.Sprint(v.Field(i).Interface())
+		if strings.ToLower(field.Tag.Get("secret")) == "true" {
+			value = redact(value)
+		}
+		values[parsed.keys[0]] = value
+	}
+}
+
+// redact replaces a secret value by a short digest.
+func redact(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "redacted:" + hex.EncodeToString(sum[:6])
+}
+
+// Diff returns the variables whose values differ from previous, in name
+// order.
+func (s Snapshot) Diff(previous Snapshot) []Change {
+	var changes []Change
+	for name, value := range s.Values {
+		if old, ok := previous.Values[name]; !ok || old != value {
+			changes = append(changes, Change{Variable: name, From: old, To: value})
+		}
+	}
+	for name, old := range previous.Values {
+		if _, ok := s.Values[name]; !ok {
+			changes = append(changes, Change{Variable: name, From: old})
+		}
+	}
+	slices.SortFunc(changes, func(a, b Change) int {
+		return strings.Compare(a.Variable, b.Variable)
+	})
+	return changes
+}
+
+// LoadSnapshot reads the snapshot stored at path. It returns a zero Snapshot
+// if the file does not exist.
+func LoadSnapshot(path string) (Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Snapshot{}, nil
+	}
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return Snapshot{}, fmt.Errorf("invalid configuration snapshot %s: %w", path, err)
+	}
+	return snapshot, nil
+}
+
+// Save writes the snapshot to path, replacing the file atomically so that
+// a crash cannot leave a partial snapshot behind.
+func (s Snapshot) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
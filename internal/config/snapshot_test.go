@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type snapshotConfig struct {
+	LogLevel string `env:"LOG_LEVEL"`
+	Password string `env:"DB_PASSWORD" secret:"true"`
+	Database struct {
+		Port int `env:"DB_PORT"`
+	}
+	Untagged string
+}
+
+func TestNewSnapshot(t *testing.T) {
+	cfg := snapshotConfig{LogLevel: "info", Password: "hunter2"}
+	cfg.Database.Port = 5432
+
+	snapshot := NewSnapshot(cfg, "hash")
+	assert.Equal(t, "hash", snapshot.Hash)
+	assert.Equal(t, "info", snapshot.Values["LOG_LEVEL"])
+	assert.Equal(t, "5432", snapshot.Values["DB_PORT"])
+	assert.NotContains(t, snapshot.Values["DB_PASSWORD"], "hunter2")
+	assert.Len(t, snapshot.Values, 3)
+
+	assert.Empty(t, NewSnapshot("not a struct", "hash").Values)
+}
+
+func TestSnapshotDiff(t *testing.T) {
+	previous := Snapshot{Values: map[string]string{"LOG_LEVEL": "info", "PORT": "8080", "OLD": "x"}}
+	current := Snapshot{Values: map[string]string{"LOG_LEVEL": "debug", "PORT": "8080", "NEW": "y"}}
+
+	assert.Equal(t, []Change{
+		{Variable: "LOG_LEVEL", From: "info", To: "debug"},
+		{Variable: "NEW", To: "y"},
+		{Variable: "OLD", From: "x"},
+	}, current.Diff(previous))
+	assert.Empty(t, current.Diff(current))
+}
+
+func TestSnapshotSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	snapshot, err := LoadSnapshot(path)
+	require.NoError(t, err, "a missing snapshot is not an error")
+	assert.Zero(t, snapshot)
+
+	saved := Snapshot{Hash: "abc", Values: map[string]string{"PORT": "8080"}}
+	require.NoError(t, saved.Save(path))
+	loaded, err := LoadSnapshot(path)
+	require.NoError(t, err)
+	assert.Equal(t, saved, loaded)
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files should be left behind")
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+	_, err = LoadSnapshot(path)
+	assert.ErrorContains(t, err, "invalid configuration snapshot")
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+// UnknownVars returns the names of the variables in environ, given as
+// "key=value" pairs, that start with one of prefixes but match none of known,
+// in name order. Known names may contain "*" to match indexed variables, as
+// Schema reports for maps tagged with EnvMapTag.
+func UnknownVars(environ, prefixes, known []string) []string {
+	var unknown []string
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		if !hasAnyPrefix(name, prefixes) || matchesAny(name, known) {
+			continue
+		}
+		unknown = append(unknown, name)
+	}
+	slices.Sort(unknown)
+	return slices.Compact(unknown)
+}
+
+// hasAnyPrefix reports whether name starts with one of prefixes.
+func hasAnyPrefix(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny reports whether name equals or matches one of patterns.
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if name == pattern {
+			return true
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Suggest returns the name in known closest to name, if it is close enough
+// to be a likely typo: within a third of the length of name.
+func Suggest(name string, known []string) (string, bool) {
+	best, bestDistance := "", len(name)/3+1
+	for _, candidate := range known {
+		if strings.Contains(candidate, "*") {
+			continue
+		}
+		if d := distance(name, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best, best != ""
+}
+
+// distance returns the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnknownVars(t *testing.T) {
+	environ := []string{
+		"PATH=/usr/bin",
+		"EZAPP_SHUTDOWN_TIMEOUT=30s",
+		"EZAPP_SHUTOWN_TIMEOUT=30s",
+		"ORDERS_PORT=8080",
+		"ORDERS_PROT=8080",
+		"ORDERS_TENANT_ACME_DB_URL=postgres://acme",
+	}
+	known := []string{"EZAPP_SHUTDOWN_TIMEOUT", "ORDERS_PORT", "ORDERS_TENANT_*_DB_URL"}
+
+	unknown := UnknownVars(environ, []string{"EZAPP_", "ORDERS_"}, known)
+
+	assert.Equal(t, []string{"EZAPP_SHUTOWN_TIMEOUT", "ORDERS_PROT"}, unknown)
+}
+
+func TestSuggest(t *testing.T) {
+	known := []string{"EZAPP_SHUTDOWN_TIMEOUT", "EZAPP_STARTUP_TIMEOUT", "EZAPP_LOG_LEVEL", "TENANT_*_URL"}
+
+	suggestion, ok := Suggest("EZAPP_SHUTOWN_TIMEOUT", known)
+	assert.True(t, ok)
+	assert.Equal(t, "EZAPP_SHUTDOWN_TIMEOUT", suggestion)
+
+	_, ok = Suggest("EZAPP_METRICS_ADDRESS", known)
+	assert.False(t, ok, "unrelated names should not be suggested")
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, distance("PORT", "PORT"))
+	assert.Equal(t, 2, distance("PORT", "PROT"))
+	assert.Equal(t, 1, distance("EZAPP_SHUTOWN_TIMEOUT", "EZAPP_SHUTDOWN_TIMEOUT"))
+	assert.Equal(t, 4, distance("", "PORT"))
+}
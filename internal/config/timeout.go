@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// DefaultShutdownTimeout is the shutdown timeout used when EZAPP_SHUTDOWN_TIMEOUT is not set.
+const DefaultShutdownTimeout = 15 * time.Second
+
+// DefaultStartupTimeout returns the startup timeout used when EZAPP_STARTUP_TIMEOUT
+// is not set: 15 seconds, or 60 seconds in development mode (see DevMode).
+func DefaultStartupTimeout() time.Duration {
+	if DevMode() {
+		return 60 * time.Second
+	}
+	return 15 * time.Second
+}
+
+// ParseTimeout reads a timeout from the environment variable name. The value may be
+// a duration string accepted by time.ParseDuration (e.g. "30s", "1m30s") or, for
+// backwards compatibility, a plain integer number of seconds. If the variable is not
+// set, fallback is returned. Invalid or negative values return an error.
+func ParseTimeout(name string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	// Accept legacy integer seconds
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, fmt.Errorf("invalid %s value: %s - must not be negative", name, value)
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value: %s - must be a duration (e.g. 30s) or an integer representing seconds", name, value)
+	}
+	if timeout < 0 {
+		return 0, fmt.Errorf("invalid %s value: %s - must not be negative", name, value)
+	}
+	return timeout, nil
+}
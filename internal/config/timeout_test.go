@@ -0,0 +1,48 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTimeout(t *testing.T) {
+	testCases := []struct {
+		name          string
+		value         string
+		expected      time.Duration
+		expectedError bool
+	}{
+		{name: "unset uses fallback", value: "", expected: 7 * time.Second},
+		{name: "legacy integer seconds", value: "30", expected: 30 * time.Second},
+		{name: "zero", value: "0", expected: 0},
+		{name: "duration string", value: "1m30s", expected: 90 * time.Second},
+		{name: "sub-second duration", value: "250ms", expected: 250 * time.Millisecond},
+		{name: "negative integer", value: "-1", expectedError: true},
+		{name: "negative duration", value: "-1s", expectedError: true},
+		{name: "invalid", value: "soon", expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("TEST_TIMEOUT", tc.value)
+
+			timeout, err := ParseTimeout("TEST_TIMEOUT", 7*time.Second)
+			if tc.expectedError {
+				assert.ErrorContains(t, err, "TEST_TIMEOUT")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, timeout)
+		})
+	}
+}
+
+func TestDefaultStartupTimeout(t *testing.T) {
+	t.Setenv("EZAPP_DEV", "")
+	assert.Equal(t, 15*time.Second, DefaultStartupTimeout())
+
+	t.Setenv("EZAPP_DEV", "1")
+	assert.Equal(t, 60*time.Second, DefaultStartupTimeout())
+}
@@ -0,0 +1,154 @@
+// Package lambda implements a client for the AWS Lambda Runtime API, which a
+// custom runtime uses to receive invocations and report their outcome. See
+// https://docs.aws.amazon.com/lambda/latest/dg/runtimes-api.html.
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Handler processes the payload of a single invocation. The returned value is
+// encoded as JSON and sent as the invocation's response.
+type Handler func(ctx context.Context, event json.RawMessage) (any, error)
+
+// requestIDKey is the context key under which the invocation's request ID is
+// stored.
+type requestIDKey struct{}
+
+// RequestID returns the AWS request ID of the invocation ctx belongs to, or
+// an empty string if ctx is not an invocation context.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Client polls the Runtime API for invocations.
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// NewClient returns a Client for the Runtime API at address, the host:port
+// given by the AWS_LAMBDA_RUNTIME_API environment variable.
+func NewClient(address string) *Client {
+	return &Client{
+		baseURL: "http://" + address + "/2018-06-01/runtime",
+		http:    &http.Client{},
+	}
+}
+
+// FromEnv returns a Client for the Runtime API named by AWS_LAMBDA_RUNTIME_API,
+// or an error if the variable is not set, i.e. when not running on Lambda.
+func FromEnv() (*Client, error) {
+	address := os.Getenv("AWS_LAMBDA_RUNTIME_API")
+	if address == "" {
+		return nil, errors.New("AWS_LAMBDA_RUNTIME_API is not set; not running in a Lambda environment")
+	}
+	return NewClient(address), nil
+}
+
+// Serve receives invocations one at a time and passes them to handler until
+// ctx is cancelled, which ends serving with a nil error. Handler failures are
+// reported to the Runtime API as invocation errors and do not end serving;
+// failures to talk to the Runtime API do.
+func (c *Client) Serve(ctx context.Context, handler Handler) error {
+	for {
+		err := c.serveNext(ctx, handler)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// serveNext receives and handles a single invocation.
+func (c *Client) serveNext(ctx context.Context, handler Handler) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/invocation/next", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to receive invocation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to receive invocation: unexpected status %s", resp.Status)
+	}
+	event, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read invocation: %w", err)
+	}
+
+	requestID := resp.Header.Get("Lambda-Runtime-Aws-Request-Id")
+	invokeCtx := context.WithValue(ctx, requestIDKey{}, requestID)
+	if ms, err := strconv.ParseInt(resp.Header.Get("Lambda-Runtime-Deadline-Ms"), 10, 64); err == nil {
+		var cancel context.CancelFunc
+		invokeCtx, cancel = context.WithDeadline(invokeCtx, time.UnixMilli(ms))
+		defer cancel()
+	}
+
+	result, err := handler(invokeCtx, event)
+	if err != nil {
+		return c.post(ctx, "/invocation/"+requestID+"/error", invocationError(err), "")
+	}
+	body, err := json.Marshal(result)
+	if err != nil {
+		return c.post(ctx, "/invocation/"+requestID+"/error", invocationError(fmt.Errorf("failed to encode response: %w", err)), "")
+	}
+	return c.post(ctx, "/invocation/"+requestID+"/response", body, "")
+}
+
+// InitError reports err to the Runtime API as the reason the function failed
+// to initialize, so that Lambda reports it instead of a generic timeout. The
+// process is expected to exit afterwards.
+func (c *Client) InitError(ctx context.Context, err error) error {
+	return c.post(ctx, "/init/error", invocationError(err), "Runtime.InitError")
+}
+
+// invocationError encodes the payload reporting a failed invocation or
+// initialization.
+func invocationError(err error) []byte {
+	body, _ := json.Marshal(map[string]string{
+		"errorMessage": err.Error(),
+		"errorType":    reflect.TypeOf(err).String(),
+	})
+	return body
+}
+
+// post sends the JSON body to the Runtime API path, with the
+// Lambda-Runtime-Function-Error-Type header set to errorType if not empty.
+func (c *Client) post(ctx context.Context, path string, body []byte, errorType string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if errorType != "" {
+		req.Header.Set("Lambda-Runtime-Function-Error-Type", errorType)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to report to the runtime API: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("failed to report to the runtime API: unexpected status %s", resp.Status)
+	}
+	return nil
+}
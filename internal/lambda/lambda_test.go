@@ -0,0 +1,146 @@
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRuntime emulates the Runtime API, serving the queued events in order
+// and recording the outcomes posted back.
+type fakeRuntime struct {
+	mu       sync.Mutex
+	events   []string
+	served   int
+	outcomes map[string]string
+	done     chan struct{}
+}
+
+func newFakeRuntime(events ...string) (*fakeRuntime, *httptest.Server) {
+	rt := &fakeRuntime{events: events, outcomes: make(map[string]string), done: make(chan struct{})}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /2018-06-01/runtime/invocation/next", func(w http.ResponseWriter, r *http.Request) {
+		rt.mu.Lock()
+		if len(rt.events) == 0 {
+			rt.mu.Unlock()
+			<-r.Context().Done()
+			return
+		}
+		event := rt.events[0]
+		rt.events = rt.events[1:]
+		rt.served++
+		id := strconv.Itoa(rt.served)
+		rt.mu.Unlock()
+
+		w.Header().Set("Lambda-Runtime-Aws-Request-Id", "req-"+id)
+		w.Header().Set("Lambda-Runtime-Deadline-Ms", strconv.FormatInt(time.Now().Add(time.Minute).UnixMilli(), 10))
+		_, _ = io.WriteString(w, event)
+	})
+	mux.HandleFunc("POST /2018-06-01/runtime/invocation/{id}/{outcome}", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rt.mu.Lock()
+		rt.outcomes[r.PathValue("id")] = r.PathValue("outcome") + ":" + string(body)
+		if len(rt.events) == 0 {
+			select {
+			case <-rt.done:
+			default:
+				close(rt.done)
+			}
+		}
+		rt.mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	})
+	return rt, httptest.NewServer(mux)
+}
+
+func TestClientServe(t *testing.T) {
+	rt, server := newFakeRuntime(`{"name":"a"}`, `{"name":"fail"}`)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- NewClient(strings.TrimPrefix(server.URL, "http://")).Serve(ctx, func(ctx context.Context, event json.RawMessage) (any, error) {
+			var payload struct{ Name string }
+			if err := json.Unmarshal(event, &payload); err != nil {
+				return nil, err
+			}
+			if _, ok := ctx.Deadline(); !ok {
+				return nil, errors.New("missing deadline")
+			}
+			if payload.Name == "fail" {
+				return nil, errors.New("bad name")
+			}
+			return map[string]string{"greeting": "hello " + payload.Name, "id": RequestID(ctx)}, nil
+		})
+	}()
+
+	select {
+	case <-rt.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("invocations were not handled")
+	}
+	cancel()
+	require.NoError(t, <-served)
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	assert.Equal(t, map[string]string{
+		"req-1": `response:{"greeting":"hello a","id":"req-1"}`,
+		"req-2": `error:{"errorMessage":"bad name","errorType":"*errors.errorString"}`,
+	}, rt.outcomes)
+}
+
+func TestClientServeRuntimeFailure(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	err := NewClient(strings.TrimPrefix(server.URL, "http://")).Serve(context.Background(), func(context.Context, json.RawMessage) (any, error) {
+		return nil, nil
+	})
+	assert.ErrorContains(t, err, "unexpected status 404")
+}
+
+func TestClientInitError(t *testing.T) {
+	var errorType, body string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /2018-06-01/runtime/init/error", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		errorType, body = r.Header.Get("Lambda-Runtime-Function-Error-Type"), string(data)
+		w.WriteHeader(http.StatusAccepted)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(strings.TrimPrefix(server.URL, "http://"))
+	require.NoError(t, client.InitError(context.Background(), errors.New("missing table")))
+	assert.Equal(t, "Runtime.InitError", errorType)
+	assert.Equal(t, `{"errorMessage":"missing table","errorType":"*errors.errorString"}`, body)
+
+	server.Config.Handler = http.NotFoundHandler()
+	assert.ErrorContains(t, client.InitError(context.Background(), errors.New("missing table")), "unexpected status 404")
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("AWS_LAMBDA_RUNTIME_API", "")
+	_, err := FromEnv()
+	assert.ErrorContains(t, err, "AWS_LAMBDA_RUNTIME_API is not set")
+
+	t.Setenv("AWS_LAMBDA_RUNTIME_API", "127.0.0.1:9001")
+	client, err := FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:9001/2018-06-01/runtime", client.baseURL)
+}
@@ -0,0 +1,24 @@
+// Package logctx carries a logger in a context. It backs
+// ezapp.ContextWithLogger and ezapp.LoggerFromContext, so that companion
+// packages that cannot import ezapp, such as httprunner, can attach loggers
+// that application code retrieves through ezapp.
+package logctx
+
+import (
+	"context"
+	"log/slog"
+)
+
+// key is the context key under which the logger is stored.
+type key struct{}
+
+// With returns a copy of ctx carrying logger.
+func With(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, key{}, logger)
+}
+
+// From returns the logger carried by ctx and whether it carries one.
+func From(ctx context.Context) (*slog.Logger, bool) {
+	logger, ok := ctx.Value(key{}).(*slog.Logger)
+	return logger, ok
+}
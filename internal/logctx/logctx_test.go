@@ -0,0 +1,20 @@
+package logctx
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger(t *testing.T) {
+	_, ok := From(context.Background())
+	assert.False(t, ok)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	got, ok := From(With(context.Background(), logger))
+	assert.True(t, ok)
+	assert.Same(t, logger, got)
+}
@@ -0,0 +1,49 @@
+// Package requestid carries the ID correlating a request across services in
+// a context. It backs httprunner.RequestIDFromContext, so that IDs assigned
+// by httprunner and grpcrunner are passed on by the clients of either.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// MaxLength bounds the length of request IDs accepted from clients.
+const MaxLength = 128
+
+// key is the context key under which the request ID is stored.
+type key struct{}
+
+// With returns a copy of ctx carrying id.
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, key{}, id)
+}
+
+// From returns the request ID carried by ctx, or an empty string if it
+// carries none.
+func From(ctx context.Context) string {
+	id, _ := ctx.Value(key{}).(string)
+	return id
+}
+
+// Valid reports whether id, received from a client, is short and made of
+// printable ASCII, so it is safe to echo and log.
+func Valid(id string) bool {
+	if id == "" || len(id) > MaxLength {
+		return false
+	}
+	for idx := range len(id) {
+		if id[idx] < 0x21 || id[idx] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// New returns a random 128-bit request ID in hex.
+func New() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,23 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	assert.Empty(t, From(context.Background()))
+	assert.Equal(t, "upstream-42", From(With(context.Background(), "upstream-42")))
+
+	id := New()
+	assert.Len(t, id, 32)
+	assert.True(t, Valid(id))
+	assert.NotEqual(t, id, New())
+
+	for _, invalid := range []string{"", "has space", "new\nline", strings.Repeat("x", MaxLength+1)} {
+		assert.False(t, Valid(invalid), "%q should be invalid", invalid)
+	}
+}
@@ -0,0 +1,127 @@
+// Package runopt holds the settings applied by ezapp.RunOption values. It
+// lives in an internal package so that both the ezapp package and its
+// companion packages (such as ezapptest) can construct run options, while
+// the settings themselves stay out of the public API.
+package runopt
+
+import (
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/checkpoint"
+	"github.com/pgvanniekerk/ezapp/sops"
+)
+
+// Settings holds the optional overrides for a single ezapp.Run or ezapp.RunE
+// invocation.
+type Settings struct {
+	// Config, if non-nil, is passed to the initializer instead of loading
+	// the configuration from environment variables. Its dynamic type must
+	// match the Config type parameter of the run.
+	Config any
+
+	// ConfigDefaults, if non-nil, is a func(*Config) setting runtime
+	// defaults on the configuration before the environment is applied. Its
+	// Config must match the Config type parameter of the run.
+	ConfigDefaults any
+
+	// Environ, if non-nil, holds the variables, in the "key=value" form of
+	// os.Environ, that Config is loaded from and that InitCtx.Environ
+	// returns, instead of the process environment.
+	Environ []string
+
+	// SOPSFile, if set, is the path of a dotenv file encrypted with SOPS
+	// whose variables are added to Environ, decrypted with SOPSKeys.
+	SOPSFile string
+
+	// SOPSKeys decrypt the data key of SOPSFile. If empty, the age keys
+	// configured in the environment are used.
+	SOPSKeys []sops.KeyService
+
+	// StrictEnvPrefixes, if non-empty, are the prefixes of the environment
+	// variables that must be read by the application.
+	StrictEnvPrefixes []string
+
+	// StartupTimeout, if positive, replaces the default startup timeout
+	// used when EZAPP_STARTUP_TIMEOUT is not set.
+	StartupTimeout time.Duration
+
+	// ShutdownTimeout, if positive, replaces the default shutdown timeout
+	// used when EZAPP_SHUTDOWN_TIMEOUT is not set.
+	ShutdownTimeout time.Duration
+
+	// WatchdogGrace, if positive, replaces the default grace period of the
+	// shutdown watchdog used when EZAPP_WATCHDOG_GRACE is not set; if
+	// negative, the watchdog is disabled.
+	WatchdogGrace time.Duration
+
+	// ShutdownDeadline, if positive, is the deadline apportioned across the
+	// phases of shutdown used when EZAPP_SHUTDOWN_DEADLINE is not set.
+	ShutdownDeadline time.Duration
+
+	// StartupBudget, if positive, is the soft startup budget used when
+	// EZAPP_STARTUP_BUDGET is not set.
+	StartupBudget time.Duration
+
+	// KubernetesMetadata enables reading the pod, node and namespace from
+	// the downward-API environment variables.
+	KubernetesMetadata bool
+
+	// CheckpointStore, if non-nil, is carried by the startup, runner and
+	// shutdown contexts for the checkpoint package.
+	CheckpointStore checkpoint.Store
+
+	// CrashMarker, if set, is the path of the marker file used to detect
+	// unclean shutdowns.
+	CrashMarker string
+
+	// ProfileDir, if set, is the directory heap and goroutine profiles are
+	// written to when the application exits abnormally.
+	ProfileDir string
+
+	// ConfigSnapshot, if set, is the path of the file the resolved
+	// configuration is compared with and stored to.
+	ConfigSnapshot string
+
+	// LogRedactor, if non-nil, is applied to every attribute logged through
+	// the application's logger.
+	LogRedactor func(attr slog.Attr) slog.Attr
+
+	// LoggerReady, if non-nil, receives the application's logger once it
+	// has been built, before the configuration is loaded.
+	LoggerReady func(logger *slog.Logger)
+
+	// ClockServer, if set, is the NTP server the local clock is checked
+	// against at startup.
+	ClockServer string
+
+	// ClockMaxSkew is the largest clock offset from ClockServer tolerated.
+	ClockMaxSkew time.Duration
+
+	// NoRuntimeMetrics leaves the Go runtime and process collectors out of
+	// the metrics registry.
+	NoRuntimeMetrics bool
+
+	// Hold starts the application with its runners held until released.
+	Hold bool
+
+	// ReleaseResources releases the resources still tracked after cleanup.
+	ReleaseResources bool
+
+	// SelfTest runs the application as a self-test, stopping it once the
+	// smoke checks have run.
+	SelfTest bool
+
+	// ValidateOnly stops the run after the initializer has returned and
+	// its AppCtx has been checked, running cleanup instead of the runners.
+	ValidateOnly bool
+
+	// Describe, if non-nil and ValidateOnly is set, receives the
+	// description of the validated AppCtx.
+	Describe io.Writer
+
+	// Manifest, if non-nil and ValidateOnly is set, receives the manifest
+	// of the validated application as JSON.
+	Manifest io.Writer
+}
@@ -0,0 +1,96 @@
+package testutil
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// TestHandler is a slog.Handler that records every log record it receives so
+// tests can assert on emitted log output.
+type TestHandler struct {
+	mu      *sync.Mutex
+	records *[]slog.Record
+	attrs   []slog.Attr
+	level   slog.Level
+}
+
+// NewTestLogger creates a logger backed by a TestHandler that records all
+// records at or above the given level.
+func NewTestLogger(level slog.Level) (*slog.Logger, *TestHandler) {
+	handler := &TestHandler{
+		mu:      &sync.Mutex{},
+		records: &[]slog.Record{},
+		level:   level,
+	}
+	return slog.New(handler), handler
+}
+
+// Enabled reports whether the handler records logs at the given level.
+func (h *TestHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+// Handle records the log record.
+func (h *TestHandler) Handle(_ context.Context, record slog.Record) error {
+	record = record.Clone()
+	record.AddAttrs(h.attrs...)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	*h.records = append(*h.records, record)
+	return nil
+}
+
+// WithAttrs returns a handler sharing the same record store that adds the
+// given attributes to every record.
+func (h *TestHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &clone
+}
+
+// WithGroup returns the handler unchanged; groups are not needed by tests.
+func (h *TestHandler) WithGroup(_ string) slog.Handler {
+	return h
+}
+
+// Records returns a copy of all recorded log records.
+func (h *TestHandler) Records() []slog.Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]slog.Record{}, *h.records...)
+}
+
+// Messages returns the messages of all recorded log records in order.
+func (h *TestHandler) Messages() []string {
+	records := h.Records()
+	messages := make([]string, 0, len(records))
+	for _, record := range records {
+		messages = append(messages, record.Message)
+	}
+	return messages
+}
+
+// Attr returns the value of the named attribute on the first record with the
+// given message, and whether it was found.
+func (h *TestHandler) Attr(message, key string) (slog.Value, bool) {
+	for _, record := range h.Records() {
+		if record.Message != message {
+			continue
+		}
+		var (
+			value slog.Value
+			found bool
+		)
+		record.Attrs(func(attr slog.Attr) bool {
+			if attr.Key == key {
+				value, found = attr.Value, true
+				return false
+			}
+			return true
+		})
+		return value, found
+	}
+	return slog.Value{}, false
+}
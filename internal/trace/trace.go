@@ -0,0 +1,83 @@
+// Package trace records the recent lifecycle events of an application in a
+// bounded in-memory ring buffer, so that intermittent shutdown hangs can be
+// diagnosed after the fact from the order and timing of what happened.
+package trace
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultSize is the number of events a Buffer created with a non-positive
+// size retains.
+const DefaultSize = 256
+
+// Event is a single recorded lifecycle event, such as a state transition or
+// a runner returning.
+type Event struct {
+	Time time.Time `json:"time"`
+
+	// Name describes what happened, e.g. "runner returned".
+	Name string `json:"event"`
+
+	// Subject names the runner, hook or cleanup step the event concerns,
+	// if any.
+	Subject string `json:"subject,omitempty"`
+
+	// Detail holds additional information such as the new state or an
+	// error message.
+	Detail string `json:"detail,omitempty"`
+}
+
+// Buffer retains the most recent events, dropping the oldest once full. A
+// nil Buffer records nothing. It is safe for concurrent use.
+type Buffer struct {
+	mu      sync.Mutex
+	events  []Event
+	next    int
+	dropped int
+}
+
+// New creates a Buffer retaining the given number of events, or DefaultSize
+// if size is not positive.
+func New(size int) *Buffer {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return &Buffer{events: make([]Event, 0, size)}
+}
+
+// Record adds an event at the current time.
+func (b *Buffer) Record(name, subject, detail string) {
+	if b == nil {
+		return
+	}
+	event := Event{Time: time.Now(), Name: name, Subject: subject, Detail: detail}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.events) < cap(b.events) {
+		b.events = append(b.events, event)
+		return
+	}
+	b.events[b.next] = event
+	b.next = (b.next + 1) % len(b.events)
+	b.dropped++
+}
+
+// Events returns the retained events, oldest first, and the number of older
+// events that were dropped to make room for them.
+func (b *Buffer) Events() ([]Event, int) {
+	if b == nil {
+		return nil, 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events := make([]Event, 0, len(b.events))
+	events = append(events, b.events[b.next:]...)
+	events = append(events, b.events[:b.next]...)
+	return events, b.dropped
+}
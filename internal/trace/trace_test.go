@@ -0,0 +1,106 @@
+package trace
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// names returns the names of events.
+func names(events []Event) []string {
+	result := make([]string, len(events))
+	for idx, event := range events {
+		result[idx] = event.Name
+	}
+	return result
+}
+
+func TestBufferRecord(t *testing.T) {
+	buffer := New(3)
+	buffer.Record("state changed", "", "running")
+	buffer.Record("runner returned", "http", "listener closed")
+
+	events, dropped := buffer.Events()
+
+	require.Len(t, events, 2)
+	assert.Zero(t, dropped)
+	assert.Equal(t, Event{Time: events[1].Time, Name: "runner returned", Subject: "http", Detail: "listener closed"}, events[1])
+	assert.False(t, events[1].Time.Before(events[0].Time))
+}
+
+func TestBufferDropsOldest(t *testing.T) {
+	buffer := New(3)
+	for idx := range 5 {
+		buffer.Record(fmt.Sprint(idx), "", "")
+	}
+
+	events, dropped := buffer.Events()
+
+	assert.Equal(t, []string{"2", "3", "4"}, names(events))
+	assert.Equal(t, 2, dropped)
+}
+
+func TestBufferDefaultSize(t *testing.T) {
+	buffer := New(0)
+	for idx := range DefaultSize + 1 {
+		buffer.Record(fmt.Sprint(idx), "", "")
+	}
+
+	events, dropped := buffer.Events()
+
+	assert.Len(t, events, DefaultSize)
+	assert.Equal(t, 1, dropped)
+}
+
+func TestNilBuffer(t *testing.T) {
+	var buffer *Buffer
+	buffer.Record("ignored", "", "")
+
+	events, dropped := buffer.Events()
+
+	assert.Empty(t, events)
+	assert.Zero(t, dropped)
+}
+
+func TestBufferConcurrentRecord(t *testing.T) {
+	buffer := New(10)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				buffer.Record("event", "", "")
+			}
+		}()
+	}
+	wg.Wait()
+
+	events, dropped := buffer.Events()
+	assert.Len(t, events, 10)
+	assert.Equal(t, 790, dropped)
+}
+
+// BenchmarkRecord measures recording into a nil buffer and into a full one,
+// neither of which should allocate
+func BenchmarkRecord(b *testing.B) {
+	buffers := []struct {
+		name   string
+		buffer *Buffer
+	}{
+		{name: "nil", buffer: nil},
+		{name: "full", buffer: New(DefaultSize)},
+	}
+
+	for _, bb := range buffers {
+		b.Run(bb.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				bb.buffer.Record("runner returned", "http", "")
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package ezapp
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+)
+
+// KubernetesMetadata identifies the pod an application runs in, as exposed
+// through the Kubernetes downward API. Fields are empty when the
+// corresponding variable is not set.
+type KubernetesMetadata struct {
+	// Pod is read from POD_NAME.
+	Pod string
+
+	// Node is read from NODE_NAME.
+	Node string
+
+	// Namespace is read from POD_NAMESPACE, or NAMESPACE if that is unset.
+	Namespace string
+}
+
+// WithKubernetesMetadata reads the pod, node and namespace from the
+// downward-API environment variables POD_NAME, NODE_NAME and POD_NAMESPACE
+// (or NAMESPACE) and attaches them to the application: every log record
+// carries "pod", "node" and "namespace" attributes, the health report lists
+// them under Info, and they are available as InitCtx.Kubernetes, e.g. for
+// metrics labels. Unset variables are omitted.
+//
+// The variables are populated by the pod spec:
+//
+//	env:
+//	  - name: POD_NAME
+//	    valueFrom: {fieldRef: {fieldPath: metadata.name}}
+//	  - name: POD_NAMESPACE
+//	    valueFrom: {fieldRef: {fieldPath: metadata.namespace}}
+//	  - name: NODE_NAME
+//	    valueFrom: {fieldRef: {fieldPath: spec.nodeName}}
+//
+// Example:
+//
+//	ezapp.Run(initializer, ezapp.WithKubernetesMetadata())
+func WithKubernetesMetadata() RunOption {
+	return func(settings *runopt.Settings) {
+		settings.KubernetesMetadata = true
+	}
+}
+
+// loadKubernetesMetadata reads the downward-API environment variables.
+func loadKubernetesMetadata() KubernetesMetadata {
+	namespace := os.Getenv("POD_NAMESPACE")
+	if namespace == "" {
+		namespace = os.Getenv("NAMESPACE")
+	}
+	return KubernetesMetadata{
+		Pod:       os.Getenv("POD_NAME"),
+		Node:      os.Getenv("NODE_NAME"),
+		Namespace: namespace,
+	}
+}
+
+// fields returns the non-empty metadata as key-value pairs.
+func (m KubernetesMetadata) fields() [][2]string {
+	var fields [][2]string
+	for _, field := range [][2]string{{"pod", m.Pod}, {"node", m.Node}, {"namespace", m.Namespace}} {
+		if field[1] != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// logAttrs returns the non-empty metadata as logger attributes.
+func (m KubernetesMetadata) logAttrs() []any {
+	var attrs []any
+	for _, field := range m.fields() {
+		attrs = append(attrs, slog.String(field[0], field[1]))
+	}
+	return attrs
+}
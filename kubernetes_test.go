@@ -0,0 +1,55 @@
+package ezapp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithKubernetesMetadata(t *testing.T) {
+	t.Setenv("POD_NAME", "api-7d9f-abcde")
+	t.Setenv("NODE_NAME", "node-1")
+	t.Setenv("POD_NAMESPACE", "")
+	t.Setenv("NAMESPACE", "payments")
+
+	var metadata KubernetesMetadata
+	var info map[string]string
+	var instanceID, configHash string
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		metadata = ctx.Kubernetes
+		instanceID = ctx.InstanceID
+		configHash = ctx.ConfigHash
+		info = ctx.Health.Check(context.Background()).Info
+		return Construct()
+	}, WithKubernetesMetadata())
+	require.NoError(t, err)
+
+	assert.Equal(t, KubernetesMetadata{Pod: "api-7d9f-abcde", Node: "node-1", Namespace: "payments"}, metadata)
+	assert.Equal(t, map[string]string{"pod": "api-7d9f-abcde", "node": "node-1", "namespace": "payments", InstanceIDKey: instanceID, ConfigHashKey: configHash}, info)
+}
+
+func TestKubernetesMetadataDisabled(t *testing.T) {
+	t.Setenv("POD_NAME", "api-7d9f-abcde")
+
+	var metadata KubernetesMetadata
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		metadata = ctx.Kubernetes
+		return Construct()
+	})
+	require.NoError(t, err)
+
+	assert.Zero(t, metadata)
+}
+
+func TestKubernetesMetadataLogAttrs(t *testing.T) {
+	t.Setenv("POD_NAME", "api-7d9f-abcde")
+	t.Setenv("NODE_NAME", "")
+	t.Setenv("POD_NAMESPACE", "payments")
+
+	metadata := loadKubernetesMetadata()
+	assert.Len(t, metadata.logAttrs(), 2, "unset variables should be omitted")
+	assert.Empty(t, KubernetesMetadata{}.logAttrs())
+}
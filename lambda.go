@@ -0,0 +1,154 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/lambda"
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+)
+
+// lambdaRunnerName is the name of the runner added by WithLambdaHandler.
+const lambdaRunnerName = "lambda"
+
+// lambdaInitErrorTimeout bounds reporting an init error to the Runtime API.
+const lambdaInitErrorTimeout = 5 * time.Second
+
+// LambdaHandler processes the JSON payload of a single AWS Lambda invocation.
+// The returned value is encoded as JSON and sent as the invocation's response;
+// a returned error is reported as a failed invocation. The context carries
+// the invocation's deadline and request ID (see LambdaRequestID).
+type LambdaHandler = lambda.Handler
+
+// WithLambdaHandler is a functional option that serves AWS Lambda invocations
+// with handler. It adds a runner named "lambda" that receives invocations
+// from the Lambda Runtime API one at a time until the application shuts
+// down. The runner fails if AWS_LAMBDA_RUNTIME_API is not set, i.e. when not
+// running on Lambda. A failing invocation does not stop the runner.
+//
+// Example:
+//
+//	appCtx, err := Construct(
+//	    WithLambdaHandler(func(ctx context.Context, event json.RawMessage) (any, error) {
+//	        var order Order
+//	        if err := json.Unmarshal(event, &order); err != nil {
+//	            return nil, err
+//	        }
+//	        return service.Place(ctx, order)
+//	    }),
+//	    WithCleanup(metrics.Flush),
+//	)
+func WithLambdaHandler(handler LambdaHandler) option {
+	return func(appCtx *AppCtx) error {
+		if handler == nil {
+			return errors.New("lambda handler cannot be nil")
+		}
+		appCtx.addRunner(lambdaRunnerName, func(ctx context.Context) error {
+			client, err := lambda.FromEnv()
+			if err != nil {
+				return err
+			}
+			return client.Serve(ctx, handler)
+		})
+		return nil
+	}
+}
+
+// LambdaRequestID returns the AWS request ID of the Lambda invocation ctx
+// belongs to, or an empty string if ctx is not an invocation context.
+func LambdaRequestID(ctx context.Context) string {
+	return lambda.RequestID(ctx)
+}
+
+// RunLambda runs an application deployed as an AWS Lambda function, mapping
+// the lifecycle onto the Lambda execution environment: the initializer runs
+// once per environment during the init phase, the handler registered with
+// WithLambdaHandler serves each invocation, and on SIGTERM at environment
+// shutdown the pre-shutdown hooks and cleanup run, e.g. to flush buffered
+// metrics. It otherwise behaves like Run, and fails if the initializer does
+// not register a handler with WithLambdaHandler.
+//
+// A failure before the application is running, such as missing configuration,
+// a failing initializer or preflight check, is reported to the Runtime API
+// as an init error before the process exits, so Lambda reports the cause
+// instead of a generic init timeout.
+//
+// Container platforms that scale to zero, such as Cloud Run, need no adapter:
+// use Run and keep EZAPP_SHUTDOWN_TIMEOUT below the platform's grace period.
+//
+// Example:
+//
+//	func main() {
+//	    ezapp.RunLambda(Initialize)
+//	}
+func RunLambda[Config any](initializer Initializer[Config], options ...RunOption) {
+	run, options := runMode[Config](os.Args[1:], options)
+	if err := runLambda(run, initializer, options...); err != nil {
+		os.Exit(1)
+	}
+}
+
+// runLambda runs the application with run like RunLambda and reports a
+// failure before the application is running as an init error.
+func runLambda[Config any](run func(Initializer[Config], ...RunOption) error, initializer Initializer[Config], options ...RunOption) error {
+	var running atomic.Bool
+	logger := slog.Default()
+	options = append(slices.Clip(options), func(settings *runopt.Settings) {
+		settings.LoggerReady = func(l *slog.Logger) {
+			logger = l
+		}
+	})
+	initializer = requireLambdaHandler(initializer)
+	err := run(func(initCtx InitCtx[Config]) (AppCtx, error) {
+		appCtx, err := initializer(initCtx)
+		if err != nil {
+			return appCtx, err
+		}
+		appCtx.stateHooks = append(appCtx.stateHooks, func(_, to State) {
+			if to == StateRunning {
+				running.Store(true)
+			}
+		})
+		return appCtx, nil
+	}, options...)
+
+	if err != nil && !running.Load() {
+		reportLambdaInitError(logger, err)
+	}
+	return err
+}
+
+// reportLambdaInitError reports err to the Runtime API as an init error,
+// logging a failure to report it through logger. It does nothing when not
+// running on Lambda.
+func reportLambdaInitError(logger *slog.Logger, err error) {
+	client, clientErr := lambda.FromEnv()
+	if clientErr != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), lambdaInitErrorTimeout)
+	defer cancel()
+	if reportErr := client.InitError(ctx, err); reportErr != nil {
+		logger.Error("failed to report init error to the lambda runtime API", "error", reportErr)
+	}
+}
+
+// requireLambdaHandler wraps initializer so that it fails if the AppCtx it
+// returns has no handler registered with WithLambdaHandler.
+func requireLambdaHandler[Config any](initializer Initializer[Config]) Initializer[Config] {
+	return func(initCtx InitCtx[Config]) (AppCtx, error) {
+		appCtx, err := initializer(initCtx)
+		if err != nil {
+			return appCtx, err
+		}
+		if !slices.Contains(appCtx.runnerNames, lambdaRunnerName) {
+			return AppCtx{}, errors.New("RunLambda requires a handler registered with WithLambdaHandler")
+		}
+		return appCtx, nil
+	}
+}
@@ -0,0 +1,139 @@
+package ezapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func echoHandler(ctx context.Context, event json.RawMessage) (any, error) {
+	return event, nil
+}
+
+func TestWithLambdaHandler(t *testing.T) {
+	appCtx, err := Construct(WithLambdaHandler(echoHandler))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lambda"}, appCtx.RunnerNames())
+
+	_, err = Construct(WithLambdaHandler(nil))
+	assert.ErrorContains(t, err, "lambda handler cannot be nil")
+}
+
+func TestWithLambdaHandlerOutsideLambda(t *testing.T) {
+	t.Setenv("AWS_LAMBDA_RUNTIME_API", "")
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(WithLambdaHandler(echoHandler))
+	})
+
+	var runnerErr *RunnerError
+	require.ErrorAs(t, err, &runnerErr)
+	assert.Equal(t, "lambda", runnerErr.Name)
+	assert.ErrorContains(t, err, "AWS_LAMBDA_RUNTIME_API is not set")
+}
+
+func TestRequireLambdaHandler(t *testing.T) {
+	err := RunE(requireLambdaHandler(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(WithRunners(successfulRunner))
+	}))
+	assert.ErrorContains(t, err, "RunLambda requires a handler registered with WithLambdaHandler")
+
+	_, err = requireLambdaHandler(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(WithLambdaHandler(echoHandler))
+	})(InitCtx[TestConfig]{})
+	assert.NoError(t, err)
+
+	assert.Empty(t, LambdaRequestID(context.Background()))
+}
+
+// lambdaInitErrors serves a Runtime API that records the init errors posted
+// to it and fails every request for an invocation.
+func lambdaInitErrors(t *testing.T) func() []string {
+	var (
+		mu       sync.Mutex
+		reported []string
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /2018-06-01/runtime/init/error", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		reported = append(reported, string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	t.Setenv("AWS_LAMBDA_RUNTIME_API", strings.TrimPrefix(server.URL, "http://"))
+
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return reported
+	}
+}
+
+func TestRunLambdaInitError(t *testing.T) {
+	initErrors := lambdaInitErrors(t)
+
+	err := runLambda(RunE[TestConfig], func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return AppCtx{}, errors.New("missing table")
+	})
+	require.ErrorContains(t, err, "missing table")
+
+	reported := initErrors()
+	require.Len(t, reported, 1, "the init failure should be reported to the runtime API")
+	assert.Contains(t, reported[0], `"errorMessage":"initialization failed: missing table"`)
+}
+
+func TestRunLambdaRunnerFailure(t *testing.T) {
+	initErrors := lambdaInitErrors(t)
+
+	// The Runtime API does not serve invocations, so the lambda runner
+	// fails once the application is running
+	err := runLambda(RunE[TestConfig], func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(WithLambdaHandler(echoHandler))
+	})
+	var runnerErr *RunnerError
+	require.ErrorAs(t, err, &runnerErr)
+
+	assert.Empty(t, initErrors(), "a failure after init should not be reported as an init error")
+}
+
+func TestRunLambdaInitErrorReportFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("AWS_LAMBDA_RUNTIME_API", strings.TrimPrefix(server.URL, "http://"))
+
+	// The failure to report is logged through the app's logger, so its
+	// redactor sees it
+	var (
+		mu     sync.Mutex
+		logged []string
+	)
+	redactor := WithLogRedactor(func(attr slog.Attr) slog.Attr {
+		mu.Lock()
+		defer mu.Unlock()
+		logged = append(logged, attr.Value.String())
+		return attr
+	})
+	err := runLambda(RunE[TestConfig], func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return AppCtx{}, errors.New("missing table")
+	}, redactor)
+	require.ErrorContains(t, err, "missing table")
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Contains(t, logged, "failed to report to the runtime API: unexpected status 500 Internal Server Error")
+}
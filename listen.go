@@ -0,0 +1,215 @@
+package ezapp
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// listenFdsStart is the first file descriptor passed by systemd socket
+// activation (SD_LISTEN_FDS_START).
+const listenFdsStart = 3
+
+// Listen returns a ready listener for address, to be passed to a server
+// runner, e.g. http.Server.Serve. The address selects the kind of listener:
+//   - "host:port" or "tcp://host:port" listens on TCP
+//   - "unix:/path/to.sock" or "unix:///path/to.sock" listens on a Unix
+//     socket, replacing a stale socket file left by a previous run
+//   - "systemd:name" uses the socket passed by systemd socket activation
+//     under that name (FileDescriptorName=, or the socket unit's name)
+//   - "systemd:" or "systemd:N" uses the first or N-th (from 0) passed socket
+//
+// Sockets passed by systemd can each be taken once; the LISTEN_* variables
+// are unset and the sockets marked close-on-exec when first read so child
+// processes do not inherit them.
+//
+// Example:
+//
+//	listener, err := ezapp.Listen(ctx.Config.ListenAddress) // e.g. "systemd:http"
+//	if err != nil {
+//	    return ezapp.AppCtx{}, err
+//	}
+//	server := &http.Server{Handler: mux}
+//	return ezapp.Construct(
+//	    ezapp.WithNamedRunner("http", func(ctx context.Context) error {
+//	        go func() {
+//	            <-ctx.Done()
+//	            server.Shutdown(context.Background())
+//	        }()
+//	        if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+//	            return err
+//	        }
+//	        return nil
+//	    }),
+//	)
+func Listen(address string) (net.Listener, error) {
+	switch {
+	case strings.HasPrefix(address, "systemd:"):
+		return systemdListener(strings.TrimPrefix(address, "systemd:"))
+	case strings.HasPrefix(address, "unix:"):
+		return listenUnix(strings.TrimPrefix(strings.TrimPrefix(address, "unix:"), "//"))
+	default:
+		return net.Listen("tcp", strings.TrimPrefix(address, "tcp://"))
+	}
+}
+
+// ListenAll calls Listen for every address as an early preflight, before
+// any runner starts, and reports every address that cannot be bound in one
+// joined error instead of failing on the first port conflict. On failure,
+// the listeners that were bound are closed. Listeners are returned in the
+// order of addresses.
+//
+// Example:
+//
+//	listeners, err := ezapp.ListenAll(ctx.Config.HTTPAddress, ctx.Config.MetricsAddress)
+//	if err != nil {
+//	    return ezapp.AppCtx{}, err // e.g. both ports in use, reported together
+//	}
+func ListenAll(addresses ...string) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(addresses))
+	var errs []error
+	for _, address := range addresses {
+		listener, err := Listen(address)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot listen on %s: %w", address, err))
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+
+	if len(errs) > 0 {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+		return nil, errors.Join(errs...)
+	}
+	return listeners, nil
+}
+
+// WithListener is a functional option that records the address listener is
+// bound to as an endpoint named name, so that ports assigned dynamically by
+// listening on ":0" are reported in the manifest, the description of the
+// AppCtx and the development mode banner.
+//
+// Example:
+//
+//	listener, err := ezapp.Listen(":0")
+//	...
+//	appCtx, err := Construct(
+//	    WithNamedRunner("http", serve(listener)),
+//	    WithListener("http", listener),
+//	)
+func WithListener(name string, listener net.Listener) option {
+	return func(appCtx *AppCtx) error {
+		if listener == nil {
+			return fmt.Errorf("listener %q cannot be nil", name)
+		}
+		return WithEndpoint(name, listener.Addr().String())(appCtx)
+	}
+}
+
+// listenUnix listens on the Unix socket at path, removing a socket file left
+// behind by a previous run first. Other files at path are not removed.
+func listenUnix(path string) (net.Listener, error) {
+	if path == "" {
+		return nil, errors.New("unix socket path must not be empty")
+	}
+	if info, err := os.Lstat(path); err == nil && info.Mode().Type() == fs.ModeSocket {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// activation holds the sockets passed by systemd socket activation.
+var activation struct {
+	mu     sync.Mutex
+	loaded bool
+	err    error
+	files  []*os.File
+	names  []string
+}
+
+// systemdListener returns a listener for the activated socket selected by
+// name or index. An empty selector selects the first socket.
+func systemdListener(selector string) (net.Listener, error) {
+	activation.mu.Lock()
+	defer activation.mu.Unlock()
+
+	if !activation.loaded {
+		activation.files, activation.names, activation.err = loadActivation(os.Getpid(), listenFdsStart)
+		activation.loaded = true
+	}
+	if activation.err != nil {
+		return nil, activation.err
+	}
+
+	idx := -1
+	if selector == "" {
+		idx = 0
+	} else if n, err := strconv.Atoi(selector); err == nil {
+		idx = n
+	} else {
+		idx = slices.Index(activation.names, selector)
+	}
+	if idx < 0 || idx >= len(activation.files) {
+		return nil, fmt.Errorf("no socket %q passed by systemd (have %d: %s)", selector, len(activation.files), strings.Join(activation.names, ", "))
+	}
+
+	// Each passed socket is handed out once
+	file := activation.files[idx]
+	if file == nil {
+		return nil, fmt.Errorf("systemd socket %q is already in use", selector)
+	}
+	activation.files[idx] = nil
+
+	listener, err := net.FileListener(file)
+	file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to use systemd socket %q: %w", selector, err)
+	}
+	return listener, nil
+}
+
+// loadActivation reads the sockets passed by systemd to the process pid,
+// numbered from the file descriptor start, from the LISTEN_PID, LISTEN_FDS
+// and LISTEN_FDNAMES variables, and unsets them. The sockets are marked
+// close-on-exec, so those that are never taken do not leak into child
+// processes such as those started with runner.Exec.
+func loadActivation(pid int, start int) ([]*os.File, []string, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	listenPid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || listenPid != pid {
+		return nil, nil, errors.New("no sockets passed by systemd socket activation")
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil, errors.New("no sockets passed by systemd socket activation")
+	}
+
+	var files []*os.File
+	var names []string
+	fdNames := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	for i := range count {
+		name := "LISTEN_FD_" + strconv.Itoa(start+i)
+		if i < len(fdNames) && fdNames[i] != "" {
+			name = fdNames[i]
+		}
+		closeOnExec(start + i)
+		names = append(names, name)
+		files = append(files, os.NewFile(uintptr(start+i), name))
+	}
+	return files, names, nil
+}
@@ -0,0 +1,6 @@
+//go:build !unix
+
+package ezapp
+
+// closeOnExec is a no-op on platforms without systemd socket activation.
+func closeOnExec(fd int) {}
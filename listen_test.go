@@ -0,0 +1,100 @@
+package ezapp
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pgvanniekerk/ezapp/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenTCP(t *testing.T) {
+	for _, address := range []string{"127.0.0.1:0", "tcp://127.0.0.1:0"} {
+		listener, err := Listen(address)
+		require.NoError(t, err, address)
+		assert.Equal(t, "tcp", listener.Addr().Network())
+		listener.Close()
+	}
+}
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+
+	for _, address := range []string{"unix:" + path, "unix://" + path} {
+		listener, err := Listen(address)
+		require.NoError(t, err, address)
+		assert.Equal(t, path, listener.Addr().String())
+
+		conn, err := net.Dial("unix", path)
+		require.NoError(t, err)
+		conn.Close()
+
+		// Leave the socket file behind as a crashed process would
+		listener.(*net.UnixListener).SetUnlinkOnClose(false)
+		listener.Close()
+	}
+
+	_, err := Listen("unix:")
+	assert.ErrorContains(t, err, "unix socket path must not be empty")
+}
+
+func TestListenUnixKeepsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.txt")
+	require.NoError(t, os.WriteFile(path, []byte("keep"), 0o600))
+
+	_, err := Listen("unix:" + path)
+	assert.Error(t, err)
+	assert.FileExists(t, path)
+}
+
+func TestLoadActivationNotActivated(t *testing.T) {
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "1")
+
+	_, _, err := loadActivation(os.Getpid(), listenFdsStart)
+	assert.ErrorContains(t, err, "no sockets passed by systemd socket activation")
+	assert.Empty(t, os.Getenv("LISTEN_FDS"), "the variables should be unset")
+}
+
+func TestListenAll(t *testing.T) {
+	listeners, err := ListenAll("127.0.0.1:0", "tcp://127.0.0.1:0")
+	require.NoError(t, err)
+	require.Len(t, listeners, 2)
+	for _, listener := range listeners {
+		listener.Close()
+	}
+}
+
+func TestListenAllReportsEveryConflict(t *testing.T) {
+	busy1, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer busy1.Close()
+	busy2, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer busy2.Close()
+
+	_, err = ListenAll(busy1.Addr().String(), "127.0.0.1:0", busy2.Addr().String())
+	assert.ErrorContains(t, err, "cannot listen on "+busy1.Addr().String())
+	assert.ErrorContains(t, err, "cannot listen on "+busy2.Addr().String())
+}
+
+func TestWithListener(t *testing.T) {
+	listener, err := Listen("127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	appCtx, err := Construct(WithListener("http", listener))
+	require.NoError(t, err)
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	manifest := newManifest(nil, appCtx, health.NewRegistry())
+	require.Len(t, manifest.Endpoints, 1)
+	assert.Equal(t, "http", manifest.Endpoints[0].Name)
+	assert.Equal(t, port, manifest.Endpoints[0].Port)
+
+	_, err = Construct(WithListener("http", nil))
+	assert.ErrorContains(t, err, `listener "http" cannot be nil`)
+}
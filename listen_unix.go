@@ -0,0 +1,11 @@
+//go:build unix
+
+package ezapp
+
+import "syscall"
+
+// closeOnExec marks fd close-on-exec, so a socket passed by systemd is not
+// inherited by child processes.
+func closeOnExec(fd int) {
+	syscall.CloseOnExec(fd)
+}
@@ -0,0 +1,84 @@
+//go:build unix
+
+package ezapp
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// activateSockets passes TCP listeners to the process as systemd would,
+// starting at a high file descriptor, and resets the activation state.
+func activateSockets(t *testing.T, names string, count int) ([]string, int) {
+	const start = 200
+	var addresses []string
+	for i := range count {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		file, err := listener.(*net.TCPListener).File()
+		require.NoError(t, err)
+		require.NoError(t, syscall.Dup2(int(file.Fd()), start+i))
+		addresses = append(addresses, listener.Addr().String())
+		file.Close()
+		listener.Close()
+	}
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", strconv.Itoa(count))
+	t.Setenv("LISTEN_FDNAMES", names)
+
+	files, fdNames, err := loadActivation(os.Getpid(), start)
+	activation.files, activation.names, activation.err, activation.loaded = files, fdNames, err, true
+	t.Cleanup(func() {
+		activation.files, activation.names, activation.err, activation.loaded = nil, nil, nil, false
+	})
+	return addresses, start
+}
+
+func TestListenSystemd(t *testing.T) {
+	addresses, _ := activateSockets(t, "http:metrics", 2)
+	assert.Empty(t, os.Getenv("LISTEN_FDS"), "the variables should be unset")
+
+	listener, err := Listen("systemd:metrics")
+	require.NoError(t, err)
+	assert.Equal(t, addresses[1], listener.Addr().String())
+	listener.Close()
+
+	listener, err = Listen("systemd:")
+	require.NoError(t, err)
+	assert.Equal(t, addresses[0], listener.Addr().String())
+	listener.Close()
+
+	_, err = Listen("systemd:0")
+	assert.ErrorContains(t, err, `systemd socket "0" is already in use`)
+
+	_, err = Listen("systemd:grpc")
+	assert.ErrorContains(t, err, `no socket "grpc" passed by systemd (have 2: http, metrics)`)
+}
+
+func TestListenSystemdUnnamed(t *testing.T) {
+	_, start := activateSockets(t, "", 1)
+
+	_, err := Listen("systemd:1")
+	assert.ErrorContains(t, err, "have 1: LISTEN_FD_"+strconv.Itoa(start))
+
+	listener, err := Listen("systemd:0")
+	require.NoError(t, err)
+	listener.Close()
+}
+
+func TestLoadActivationCloseOnExec(t *testing.T) {
+	_, start := activateSockets(t, "", 2)
+
+	for fd := start; fd < start+2; fd++ {
+		flags, _, errno := syscall.Syscall(syscall.SYS_FCNTL, uintptr(fd), syscall.F_GETFD, 0)
+		require.Zero(t, errno)
+		assert.NotZero(t, flags&syscall.FD_CLOEXEC, "fd %d should not be inherited by child processes", fd)
+	}
+}
@@ -0,0 +1,59 @@
+package loadshed
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// retryPushback is the trailer telling gRPC clients retrying a rejected call
+// how long to back off, in milliseconds, the counterpart of Retry-After.
+const retryPushback = "grpc-retry-pushback-ms"
+
+// UnaryServerInterceptor admits unary calls through s, the gRPC counterpart
+// of Middleware. A call over the limit is rejected with ResourceExhausted
+// while s is in ModeNormal, and with Unavailable while it is degraded or
+// shedding, so that clients with a retry policy try another instance. The
+// rejection asks clients to back off for a second.
+//
+// Example:
+//
+//	server := grpc.NewServer(
+//	    grpc.ChainUnaryInterceptor(loadshed.UnaryServerInterceptor(shedder)),
+//	    grpc.ChainStreamInterceptor(loadshed.StreamServerInterceptor(shedder)),
+//	)
+func UnaryServerInterceptor(s *Shedder) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		release, ok := s.Acquire()
+		if !ok {
+			return nil, s.reject(ctx)
+		}
+		defer release()
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of
+// UnaryServerInterceptor. A stream counts against the limit until it ends.
+func StreamServerInterceptor(s *Shedder) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		release, ok := s.Acquire()
+		if !ok {
+			return s.reject(stream.Context())
+		}
+		defer release()
+		return handler(srv, stream)
+	}
+}
+
+// reject returns the status error rejecting a call over the limit of s.
+func (s *Shedder) reject(ctx context.Context) error {
+	_ = grpc.SetTrailer(ctx, metadata.Pairs(retryPushback, "1000"))
+	if s.Stats().Mode == ModeNormal {
+		return status.Error(codes.ResourceExhausted, "server is overloaded")
+	}
+	return status.Error(codes.Unavailable, "server is overloaded")
+}
@@ -0,0 +1,246 @@
+// Package loadshed rejects work an application cannot take on without
+// slowing down everything else in flight. A Shedder admits a bounded number of
+// concurrent requests and adapts the bound to the latency it observes; its
+// mode, raised by the health checks reporting the application as degraded or
+// by memory pressure, tightens the bound further. Middleware applies a Shedder
+// to an http.Handler, and httprunner.WithLoadShedder to an httprunner.Server;
+// UnaryServerInterceptor and StreamServerInterceptor apply it to a
+// grpc.Server, and grpcrunner.WithLoadShedder to a grpcrunner.Server.
+package loadshed
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Default settings of a Shedder.
+const (
+	DefaultMaxConcurrency = 1000
+	DefaultMinConcurrency = 1
+)
+
+// Mode is the level of pressure a Shedder is under. Modes are ordered from
+// the least to the most restrictive.
+type Mode int
+
+const (
+	// ModeNormal admits up to the adaptive concurrency limit.
+	ModeNormal Mode = iota
+
+	// ModeDegraded admits half of the adaptive concurrency limit.
+	ModeDegraded
+
+	// ModeShedding admits only the minimum concurrency.
+	ModeShedding
+)
+
+// String returns the lower-case name of the mode.
+func (m Mode) String() string {
+	switch m {
+	case ModeNormal:
+		return "normal"
+	case ModeDegraded:
+		return "degraded"
+	case ModeShedding:
+		return "shedding"
+	default:
+		return "unknown"
+	}
+}
+
+// Shedder limits the number of requests handled concurrently. Without a
+// latency target the limit is the maximum concurrency; with one, the limit is
+// cut by a tenth whenever a request takes longer than the target and grows
+// back by one for every limit's worth of requests that do not, so the
+// application keeps its latency while shedding the excess. The zero value is
+// not usable; create Shedders with New.
+type Shedder struct {
+	maxConcurrency int
+	minConcurrency int
+	latencyTarget  time.Duration
+	now            func() time.Time
+
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+	pressure map[string]Mode
+	mode     Mode
+	admitted uint64
+	rejected uint64
+}
+
+// shedderOption represents a functional option for configuring a Shedder.
+// This type is not exported to ensure only predefined options can be used.
+type shedderOption func(*Shedder)
+
+// WithMaxConcurrency bounds the number of requests handled concurrently,
+// instead of DefaultMaxConcurrency. It is also the initial adaptive limit.
+func WithMaxConcurrency(n int) shedderOption {
+	return func(s *Shedder) {
+		s.maxConcurrency = max(n, 1)
+	}
+}
+
+// WithMinConcurrency sets the number of concurrent requests admitted whatever
+// the latency and mode, instead of DefaultMinConcurrency.
+func WithMinConcurrency(n int) shedderOption {
+	return func(s *Shedder) {
+		s.minConcurrency = max(n, 1)
+	}
+}
+
+// WithLatencyTarget adapts the concurrency limit to keep requests faster than
+// target. Without it the limit stays at the maximum concurrency.
+func WithLatencyTarget(target time.Duration) shedderOption {
+	return func(s *Shedder) {
+		s.latencyTarget = target
+	}
+}
+
+// New returns a Shedder in ModeNormal.
+//
+// Example:
+//
+//	shedder := loadshed.New(
+//	    loadshed.WithMaxConcurrency(200),
+//	    loadshed.WithLatencyTarget(250*time.Millisecond),
+//	)
+func New(options ...shedderOption) *Shedder {
+	s := &Shedder{
+		maxConcurrency: DefaultMaxConcurrency,
+		minConcurrency: DefaultMinConcurrency,
+		now:            time.Now,
+		pressure:       make(map[string]Mode),
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	s.minConcurrency = min(s.minConcurrency, s.maxConcurrency)
+	s.limit = float64(s.maxConcurrency)
+	return s
+}
+
+// Acquire admits a request if the Shedder is below its limit. The returned
+// function must be called once the request has been handled; it is nil if the
+// request was rejected.
+func (s *Shedder) Acquire() (release func(), ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.inFlight >= s.limitLocked() {
+		s.rejected++
+		return nil, false
+	}
+	s.inFlight++
+	s.admitted++
+
+	startedAt := s.now()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.finish(s.now().Sub(startedAt))
+		})
+	}, true
+}
+
+// finish records a request that took latency to handle.
+func (s *Shedder) finish(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inFlight--
+	if s.latencyTarget <= 0 {
+		return
+	}
+	if latency > s.latencyTarget {
+		s.limit = max(s.limit*0.9, float64(s.minConcurrency))
+		return
+	}
+	s.limit = min(s.limit+1/s.limit, float64(s.maxConcurrency))
+}
+
+// limitLocked returns the number of requests admitted concurrently in the
+// current mode. The caller must hold mu.
+func (s *Shedder) limitLocked() int {
+	switch s.mode {
+	case ModeDegraded:
+		return max(int(s.limit)/2, s.minConcurrency)
+	case ModeShedding:
+		return s.minConcurrency
+	default:
+		return max(int(s.limit), s.minConcurrency)
+	}
+}
+
+// SetPressure sets the mode that source, such as "health" or "memory", puts
+// the Shedder under. The Shedder is in the most restrictive mode of all its
+// sources.
+func (s *Shedder) SetPressure(source string, mode Mode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if mode == ModeNormal {
+		delete(s.pressure, source)
+	} else {
+		s.pressure[source] = mode
+	}
+	s.mode = ModeNormal
+	for _, m := range s.pressure {
+		s.mode = max(s.mode, m)
+	}
+}
+
+// Stats describes the state of a Shedder.
+type Stats struct {
+	Mode     Mode
+	Limit    int
+	InFlight int
+	Admitted uint64
+	Rejected uint64
+}
+
+// Stats returns the current state of the Shedder.
+func (s *Shedder) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return Stats{
+		Mode:     s.mode,
+		Limit:    s.limitLocked(),
+		InFlight: s.inFlight,
+		Admitted: s.admitted,
+		Rejected: s.rejected,
+	}
+}
+
+// Middleware admits requests to next through s, rejecting those over its
+// limit with 503 Service Unavailable and a Retry-After header so that clients
+// back off and load balancers try other instances.
+func Middleware(s *Shedder, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		release, ok := s.Acquire()
+		if !ok {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "server is overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		defer release()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// poll calls fn every interval until ctx is done, returning nil then.
+func poll(ctx context.Context, interval time.Duration, fn func()) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		fn()
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package loadshed
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestShedderConcurrencyLimit(t *testing.T) {
+	s := New(WithMaxConcurrency(2))
+
+	first, ok := s.Acquire()
+	require.True(t, ok)
+	_, ok = s.Acquire()
+	require.True(t, ok)
+	_, ok = s.Acquire()
+	assert.False(t, ok, "a third request should be shed")
+
+	first()
+	first() // releasing twice has no further effect
+	_, ok = s.Acquire()
+	assert.True(t, ok)
+
+	stats := s.Stats()
+	assert.Equal(t, Stats{Mode: ModeNormal, Limit: 2, InFlight: 2, Admitted: 3, Rejected: 1}, stats)
+}
+
+func TestShedderLatencyTarget(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := New(WithMaxConcurrency(100), WithMinConcurrency(5), WithLatencyTarget(100*time.Millisecond))
+	s.now = func() time.Time { return now }
+
+	slow := func() {
+		release, ok := s.Acquire()
+		require.True(t, ok)
+		now = now.Add(time.Second)
+		release()
+	}
+	slow()
+	assert.Equal(t, 90, s.Stats().Limit, "a slow request should cut the limit by a tenth")
+	for range 100 {
+		slow()
+	}
+	assert.Equal(t, 5, s.Stats().Limit, "the limit should not drop below the minimum")
+
+	// Fast requests grow the limit back by about one per limit's worth
+	for range 6 {
+		release, _ := s.Acquire()
+		release()
+	}
+	assert.Equal(t, 6, s.Stats().Limit)
+}
+
+func TestShedderPressure(t *testing.T) {
+	s := New(WithMaxConcurrency(10), WithMinConcurrency(2))
+
+	s.SetPressure(SourceHealth, ModeDegraded)
+	assert.Equal(t, Stats{Mode: ModeDegraded, Limit: 5}, s.Stats())
+
+	s.SetPressure(SourceMemory, ModeShedding)
+	assert.Equal(t, Stats{Mode: ModeShedding, Limit: 2}, s.Stats())
+
+	// The most restrictive source wins until it is lifted
+	s.SetPressure(SourceHealth, ModeNormal)
+	assert.Equal(t, ModeShedding, s.Stats().Mode)
+	s.SetPressure(SourceMemory, ModeNormal)
+	assert.Equal(t, Stats{Mode: ModeNormal, Limit: 10}, s.Stats())
+}
+
+func TestMiddleware(t *testing.T) {
+	s := New(WithMaxConcurrency(1))
+	handler := Middleware(s, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	s.SetPressure(SourceMemory, ModeShedding)
+	_, ok := s.Acquire()
+	require.True(t, ok)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+}
+
+func TestWatchHealth(t *testing.T) {
+	registry := health.NewRegistry()
+	require.NoError(t, registry.Register("cache", func(ctx context.Context) error {
+		return context.DeadlineExceeded
+	}, health.NonCritical()))
+	s := New()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.WatchHealth(registry, time.Millisecond)(ctx) }()
+
+	assert.Eventually(t, func() bool {
+		return s.Stats().Mode == ModeDegraded
+	}, time.Second, time.Millisecond)
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestHealthMode(t *testing.T) {
+	assert.Equal(t, ModeNormal, healthMode(health.StatusUp))
+	assert.Equal(t, ModeNormal, healthMode(health.StatusStarting))
+	assert.Equal(t, ModeDegraded, healthMode(health.StatusDegraded))
+	assert.Equal(t, ModeShedding, healthMode(health.StatusDown))
+}
+
+func TestWatchMemory(t *testing.T) {
+	s := New()
+
+	// A limit of one byte is always exceeded
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.WatchMemory(1, time.Millisecond)(ctx) }()
+	assert.Eventually(t, func() bool {
+		return s.Stats().Mode == ModeShedding
+	}, time.Second, time.Millisecond)
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestMemoryMode(t *testing.T) {
+	assert.Equal(t, ModeNormal, memoryMode(50, 100))
+	assert.Equal(t, ModeDegraded, memoryMode(85, 100))
+	assert.Equal(t, ModeShedding, memoryMode(101, 100))
+	assert.Equal(t, ModeNormal, memoryMode(1<<40, math.MaxInt64))
+}
+
+func TestModeString(t *testing.T) {
+	assert.Equal(t, "normal", ModeNormal.String())
+	assert.Equal(t, "degraded", ModeDegraded.String())
+	assert.Equal(t, "shedding", ModeShedding.String())
+	assert.Equal(t, "unknown", Mode(9).String())
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	s := New(WithMaxConcurrency(1))
+	interceptor := UnaryServerInterceptor(s)
+	handler := func(context.Context, any) (any, error) {
+		return "ok", nil
+	}
+
+	resp, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+
+	release, ok := s.Acquire()
+	require.True(t, ok)
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "calls over the limit should be rejected")
+
+	s.SetPressure(SourceMemory, ModeShedding)
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+	assert.Equal(t, codes.Unavailable, status.Code(err), "calls shed under pressure should be retried elsewhere")
+
+	release()
+	assert.Equal(t, uint64(2), s.Stats().Rejected)
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	s := New(WithMaxConcurrency(1))
+	interceptor := StreamServerInterceptor(s)
+	var inFlight int
+	handler := func(any, grpc.ServerStream) error {
+		inFlight = s.Stats().InFlight
+		return nil
+	}
+
+	require.NoError(t, interceptor(nil, &testStream{}, &grpc.StreamServerInfo{}, handler))
+	assert.Equal(t, 1, inFlight, "a stream should count against the limit while it runs")
+
+	_, ok := s.Acquire()
+	require.True(t, ok)
+	err := interceptor(nil, &testStream{}, &grpc.StreamServerInfo{}, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+// testStream is a grpc.ServerStream with a background context.
+type testStream struct {
+	grpc.ServerStream
+}
+
+func (*testStream) Context() context.Context {
+	return context.Background()
+}
@@ -0,0 +1,85 @@
+package loadshed
+
+import (
+	"context"
+	"math"
+	"runtime/debug"
+	rtmetrics "runtime/metrics"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/health"
+)
+
+// Pressure sources set by the watchers.
+const (
+	SourceHealth = "health"
+	SourceMemory = "memory"
+)
+
+// WatchHealth returns a runner that checks registry every interval and puts s
+// under pressure from SourceHealth: ModeDegraded while the application is
+// degraded, because a non-critical dependency is failing, and ModeShedding
+// while it is down. The pressure is lifted when the application is up again.
+//
+// Example:
+//
+//	ezapp.WithNamedRunner("loadshed-health", shedder.WatchHealth(ctx.Health, 5*time.Second))
+func (s *Shedder) WatchHealth(registry *health.Registry, interval time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return poll(ctx, interval, func() {
+			s.SetPressure(SourceHealth, healthMode(registry.Check(ctx).Status))
+		})
+	}
+}
+
+// healthMode returns the mode the health status puts a Shedder in.
+func healthMode(status health.Status) Mode {
+	switch status {
+	case health.StatusDegraded:
+		return ModeDegraded
+	case health.StatusDown:
+		return ModeShedding
+	default:
+		return ModeNormal
+	}
+}
+
+// WatchMemory returns a runner that reads the bytes of allocated heap objects
+// every interval and puts s under pressure from SourceMemory: ModeDegraded
+// above 80% of limit and ModeShedding above limit, so that the application
+// sheds requests rather than being killed for running out of memory. A
+// non-positive limit uses the runtime's soft memory limit, set with
+// GOMEMLIMIT; without one the runner only waits for ctx to be done.
+//
+// Example:
+//
+//	ezapp.WithNamedRunner("loadshed-memory", shedder.WatchMemory(0, time.Second))
+func (s *Shedder) WatchMemory(limit int64, interval time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if limit <= 0 {
+			limit = debug.SetMemoryLimit(-1)
+		}
+		if limit == math.MaxInt64 {
+			<-ctx.Done()
+			return nil
+		}
+
+		sample := []rtmetrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+		return poll(ctx, interval, func() {
+			rtmetrics.Read(sample)
+			s.SetPressure(SourceMemory, memoryMode(sample[0].Value.Uint64(), limit))
+		})
+	}
+}
+
+// memoryMode returns the mode heap bytes in use put a Shedder in, given limit.
+func memoryMode(heap uint64, limit int64) Mode {
+	switch {
+	case heap > uint64(limit):
+		return ModeShedding
+	case heap > uint64(limit)/10*8:
+		return ModeDegraded
+	default:
+		return ModeNormal
+	}
+}
@@ -0,0 +1,91 @@
+package ezapp
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/pgvanniekerk/ezapp/internal/logctx"
+)
+
+// AppMetadata identifies the running application. It is carried by
+// InitCtx.StartupCtx, the runner contexts, the pre-shutdown hooks and the
+// cleanup context, and returned by MetadataFromContext.
+type AppMetadata struct {
+	// InstanceID is the ID of this run, as in InitCtx.InstanceID.
+	InstanceID string
+
+	// Environment is the deployment environment selected by EZAPP_ENV, or
+	// empty if none was selected.
+	Environment string
+
+	// ConfigHash is the hash of the resolved configuration, as in
+	// InitCtx.ConfigHash.
+	ConfigHash string
+
+	// Kubernetes identifies the pod the application runs in when
+	// WithKubernetesMetadata is used, and is empty otherwise.
+	Kubernetes KubernetesMetadata
+}
+
+// metadataKey is the context key under which the AppMetadata is stored.
+type metadataKey struct{}
+
+// ContextWithLogger returns a copy of ctx carrying logger, which
+// LoggerFromContext returns. Use it to add request-scoped attributes for the
+// code called with the returned context.
+//
+// Example:
+//
+//	logger := ezapp.LoggerFromContext(ctx).With("request_id", id)
+//	ctx = ezapp.ContextWithLogger(ctx, logger)
+func ContextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return logctx.With(ctx, logger)
+}
+
+// LoggerFromContext returns the logger carried by ctx, so that code deep in
+// a call stack can log without a logger being passed down to it. The logger
+// of InitCtx.StartupCtx, the pre-shutdown hooks and the cleanup context is
+// InitCtx.Logger; the logger of a runner's context also records the runner's
+// name under "runner", and the logger of a request served by an httprunner
+// Server with WithRequestID also records its ID under "request_id". If ctx
+// carries no logger, slog.Default() is returned.
+//
+// Example:
+//
+//	func (r *Repository) Save(ctx context.Context, order Order) error {
+//	    ezapp.LoggerFromContext(ctx).Debug("saving order", "order_id", order.ID)
+//	    ...
+//	}
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := logctx.From(ctx); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// MetadataFromContext returns the AppMetadata carried by ctx and whether it
+// carries any.
+func MetadataFromContext(ctx context.Context) (AppMetadata, bool) {
+	metadata, ok := ctx.Value(metadataKey{}).(AppMetadata)
+	return metadata, ok
+}
+
+// contextWithApp returns a copy of ctx carrying logger and metadata.
+func contextWithApp(ctx context.Context, logger *slog.Logger, metadata AppMetadata) context.Context {
+	return context.WithValue(ContextWithLogger(ctx, logger), metadataKey{}, metadata)
+}
+
+// withLogger wraps fn so that its context carries logger.
+func withLogger(logger *slog.Logger, fn func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return fn(ContextWithLogger(ctx, logger))
+	}
+}
+
+// withAppContext wraps fn so that its context carries logger and metadata.
+// It applies to runners and pre-shutdown hooks alike.
+func withAppContext(logger *slog.Logger, metadata AppMetadata, fn func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return fn(contextWithApp(ctx, logger, metadata))
+	}
+}
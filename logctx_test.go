@@ -0,0 +1,84 @@
+package ezapp
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerFromContext(t *testing.T) {
+	logger, _ := testutil.NewTestLogger(slog.LevelDebug)
+
+	assert.Same(t, slog.Default(), LoggerFromContext(context.Background()), "a context without a logger should yield the default logger")
+	assert.Same(t, logger, LoggerFromContext(ContextWithLogger(context.Background(), logger)))
+}
+
+func TestMetadataFromContext(t *testing.T) {
+	_, ok := MetadataFromContext(context.Background())
+	assert.False(t, ok)
+
+	logger, _ := testutil.NewTestLogger(slog.LevelDebug)
+	metadata := AppMetadata{InstanceID: "host-1-abcdef01", Environment: "staging"}
+	ctx := contextWithApp(context.Background(), logger, metadata)
+
+	got, ok := MetadataFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, metadata, got)
+
+	got, ok = MetadataFromContext(ContextWithLogger(ctx, slog.Default()))
+	assert.True(t, ok, "replacing the logger should keep the metadata")
+	assert.Equal(t, metadata, got)
+}
+
+func TestWithAppContext(t *testing.T) {
+	logger, handler := testutil.NewTestLogger(slog.LevelDebug)
+
+	r := withAppContext(logger.With("runner", "worker"), AppMetadata{InstanceID: "host-1-abcdef01"}, func(ctx context.Context) error {
+		LoggerFromContext(ctx).Info("processing")
+		return nil
+	})
+
+	require.NoError(t, r(context.Background()))
+	value, ok := handler.Attr("processing", "runner")
+	require.True(t, ok)
+	assert.Equal(t, "worker", value.String())
+}
+
+func TestRunELoggerInContext(t *testing.T) {
+	var initLogger, startupLogger, cleanupLogger *slog.Logger
+	var initCtx InitCtx[TestConfig]
+	var runnerMetadata, cleanupMetadata AppMetadata
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		initCtx = ctx
+		initLogger = ctx.Logger
+		startupLogger = LoggerFromContext(ctx.StartupCtx)
+		return Construct(
+			WithNamedRunner("worker", func(ctx context.Context) error {
+				assert.NotSame(t, slog.Default(), LoggerFromContext(ctx))
+				runnerMetadata, _ = MetadataFromContext(ctx)
+				return nil
+			}),
+			WithCleanup(func(ctx context.Context) error {
+				cleanupLogger = LoggerFromContext(ctx)
+				cleanupMetadata, _ = MetadataFromContext(ctx)
+				return nil
+			}),
+		)
+	})
+
+	require.NoError(t, err)
+	assert.Same(t, initLogger, startupLogger)
+	assert.Same(t, initLogger, cleanupLogger)
+	want := AppMetadata{
+		InstanceID:  initCtx.InstanceID,
+		Environment: initCtx.Environment,
+		ConfigHash:  initCtx.ConfigHash,
+	}
+	assert.Equal(t, want, runnerMetadata)
+	assert.Equal(t, want, cleanupMetadata)
+}
@@ -0,0 +1,134 @@
+package ezapp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/pgvanniekerk/ezapp/health"
+	"github.com/pgvanniekerk/ezapp/internal/config"
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+)
+
+// ManifestFlag makes Run validate the wiring like ValidateFlag and print
+// the application's manifest as JSON to standard output.
+const ManifestFlag = "--manifest"
+
+// ConfigVar describes an environment variable read into the configuration
+// struct: its name and aliases, Go type, default, and whether it is required
+// or a secret (tagged `secret:"true"`).
+type ConfigVar = config.Var
+
+// AppManifest is a machine-readable description of an application for
+// deployment tooling, such as Helm chart generators. It is produced by
+// Manifest.
+type AppManifest struct {
+	// Config lists the environment variables the application reads.
+	Config []ConfigVar `json:"config"`
+
+	// Secrets lists the names of the variables marked as secrets.
+	Secrets []string `json:"secrets"`
+
+	// Runners lists the names of the configured runners.
+	Runners []string `json:"runners"`
+
+	// Endpoints lists the endpoints registered with WithEndpoint.
+	Endpoints []ManifestEndpoint `json:"endpoints"`
+
+	// HealthChecks lists the checks registered with InitCtx.Health.
+	HealthChecks []health.CheckInfo `json:"healthChecks"`
+
+	// Components lists the components registered with WithComponent.
+	Components []ComponentInfo `json:"components"`
+}
+
+// ManifestEndpoint describes an endpoint in an AppManifest. Port is parsed
+// from the address, or zero if it has none.
+type ManifestEndpoint struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Port    int    `json:"port,omitempty"`
+}
+
+// Manifest validates the wiring like ValidateWiring and returns the
+// application's manifest as indented JSON, decodable into an AppManifest.
+// The manifest is also printed by starting a binary built with Run with the
+// --manifest flag (see ManifestFlag).
+//
+// Example:
+//
+//	manifest, err := ezapp.Manifest(Initialize, ezapptest.WithConfig(Config{}))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	os.WriteFile("manifest.json", manifest, 0o644)
+func Manifest[Config any](initializer Initializer[Config], options ...RunOption) ([]byte, error) {
+	var out bytes.Buffer
+	options = append(slices.Clip(options), func(settings *runopt.Settings) {
+		settings.Manifest = &out
+	})
+	if err := ValidateWiring(initializer, options...); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// newManifest builds the manifest of an application from its configuration
+// schema, AppCtx and health registry.
+func newManifest(schema []ConfigVar, appCtx AppCtx, registry *health.Registry) AppManifest {
+	manifest := AppManifest{
+		Config:       schema,
+		Secrets:      []string{},
+		Runners:      appCtx.RunnerNames(),
+		Endpoints:    make([]ManifestEndpoint, 0, len(appCtx.endpoints)),
+		HealthChecks: registry.Registered(),
+		Components:   appCtx.Components(),
+	}
+	if manifest.Config == nil {
+		manifest.Config = []ConfigVar{}
+	}
+
+	for _, v := range schema {
+		if v.Secret {
+			manifest.Secrets = append(manifest.Secrets, v.Name)
+		}
+	}
+	for _, endpoint := range appCtx.endpoints {
+		manifest.Endpoints = append(manifest.Endpoints, ManifestEndpoint{
+			Name:    endpoint.Name,
+			Address: endpoint.Address,
+			Port:    endpointPort(endpoint.Address),
+		})
+	}
+
+	return manifest
+}
+
+// encode returns the manifest as indented JSON.
+func (manifest AppManifest) encode() ([]byte, error) {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// endpointPort returns the port of an endpoint address given as a URL, such
+// as "http://localhost:8080", or as host:port, or zero if it has none.
+func endpointPort(address string) int {
+	if u, err := url.Parse(address); err == nil && u.Host != "" {
+		address = u.Host
+	}
+	_, portText, err := net.SplitHostPort(address)
+	if err != nil {
+		return 0
+	}
+	port, err := strconv.Atoi(portText)
+	if err != nil {
+		return 0
+	}
+	return port
+}
@@ -0,0 +1,70 @@
+package ezapp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/pgvanniekerk/ezapp/health"
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type manifestConfig struct {
+	Port        int    `env:"PORT,default=8080"`
+	DatabaseURL string `env:"DATABASE_URL,required=true" secret:"true"`
+}
+
+func TestManifest(t *testing.T) {
+	var runnerStarted bool
+
+	data, err := Manifest(func(ctx InitCtx[manifestConfig]) (AppCtx, error) {
+		return Construct(
+			WithNamedRunner("http", func(context.Context) error {
+				runnerStarted = true
+				return nil
+			}),
+			WithEndpoint("http", "http://localhost:8080"),
+			WithEndpoint("socket", "unix:///tmp/app.sock"),
+			WithComponent("database", &testPool{}),
+		)
+	}, func(settings *runopt.Settings) {
+		settings.Config = manifestConfig{}
+	})
+	require.NoError(t, err)
+	assert.False(t, runnerStarted, "Runners should not be started")
+
+	var manifest AppManifest
+	require.NoError(t, json.Unmarshal(data, &manifest))
+
+	assert.Equal(t, AppManifest{
+		Config: []ConfigVar{
+			{Name: "PORT", Type: "int", Default: "8080"},
+			{Name: "DATABASE_URL", Type: "string", Required: true, Secret: true},
+		},
+		Secrets: []string{"DATABASE_URL"},
+		Runners: []string{"http"},
+		Endpoints: []ManifestEndpoint{
+			{Name: "http", Address: "http://localhost:8080", Port: 8080},
+			{Name: "socket", Address: "unix:///tmp/app.sock"},
+		},
+		HealthChecks: []health.CheckInfo{{Name: "database", Critical: true}},
+		Components:   []ComponentInfo{{Name: "database", Type: "*ezapp.testPool", Healthy: true}},
+	}, manifest)
+}
+
+func TestManifestFailure(t *testing.T) {
+	_, err := Manifest(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(WithNamedRunner("http", successfulRunner), WithNamedRunner("http", successfulRunner))
+	})
+	assert.ErrorContains(t, err, `duplicate runner name "http"`)
+}
+
+func TestEndpointPort(t *testing.T) {
+	assert.Equal(t, 8080, endpointPort("http://localhost:8080"))
+	assert.Equal(t, 9090, endpointPort("localhost:9090"))
+	assert.Equal(t, 50051, endpointPort(":50051"))
+	assert.Zero(t, endpointPort("https://example.com"))
+	assert.Zero(t, endpointPort("unix:///tmp/app.sock"))
+}
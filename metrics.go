@@ -0,0 +1,153 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/app"
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+	"github.com/pgvanniekerk/ezapp/metrics"
+)
+
+// WithoutRuntimeMetrics leaves the Go runtime and process collectors out of
+// InitCtx.Metrics, which otherwise reports goroutines, heap usage, GC cycles
+// and pauses, CPU time, resident memory and open file descriptors alongside
+// the lifecycle metrics.
+//
+// Example:
+//
+//	ezapp.Run(initializer, ezapp.WithoutRuntimeMetrics())
+func WithoutRuntimeMetrics() RunOption {
+	return func(settings *runopt.Settings) {
+		settings.NoRuntimeMetrics = true
+	}
+}
+
+// newMetricsRegistry returns the registry passed to the initializer, with
+// the runtime collectors registered unless they are disabled.
+func newMetricsRegistry(settings runopt.Settings) *metrics.Registry {
+	registry := metrics.NewRegistry()
+	if !settings.NoRuntimeMetrics {
+		registry.Register(metrics.GoCollector())
+		registry.Register(metrics.ProcessCollector())
+	}
+	return registry
+}
+
+// lifecycleCollector reports the instance ID, the lifecycle state of
+// application and the number of runners it is running.
+func lifecycleCollector(application *app.App, instanceID string) metrics.Collector {
+	return func() []metrics.Sample {
+		return []metrics.Sample{
+			{
+				Name:   "ezapp_instance_info",
+				Help:   "Identifies the running instance of the application.",
+				Type:   metrics.Gauge,
+				Labels: map[string]string{InstanceIDKey: instanceID},
+				Value:  1,
+			},
+			{
+				Name:   "ezapp_state",
+				Help:   "Current lifecycle state of the application.",
+				Type:   metrics.Gauge,
+				Labels: map[string]string{"state": application.State().String()},
+				Value:  1,
+			},
+			{
+				Name:  "ezapp_runners",
+				Help:  "Number of runners currently running.",
+				Type:  metrics.Gauge,
+				Value: float64(len(application.Runners())),
+			},
+		}
+	}
+}
+
+// metricsExporter is an exporter registered with WithMetricsExporter.
+type metricsExporter struct {
+	exporter metrics.Exporter
+	interval time.Duration
+}
+
+// WithMetricsExporter is a functional option that pushes the samples of
+// InitCtx.Metrics to exporter every interval while the application runs, for
+// backends that do not scrape Metrics.Handler(). A non-positive interval
+// uses metrics.DefaultExportInterval. The samples are exported a final time
+// once the runners have returned, before cleanup, and the exporter is then
+// closed if it implements io.Closer. Export failures are logged.
+//
+// Example:
+//
+//	exporter, err := metrics.DogStatsDFromEnv()
+//	if err != nil {
+//	    return ezapp.AppCtx{}, err
+//	}
+//	return ezapp.Construct(
+//	    ezapp.WithRunners(server.Run),
+//	    ezapp.WithMetricsExporter(exporter, 10*time.Second),
+//	)
+func WithMetricsExporter(exporter metrics.Exporter, interval time.Duration) option {
+	return func(appCtx *AppCtx) error {
+		if exporter == nil {
+			return errors.New("metrics exporter must not be nil")
+		}
+		if interval <= 0 {
+			interval = metrics.DefaultExportInterval
+		}
+		appCtx.metricsExporters = append(appCtx.metricsExporters, metricsExporter{exporter: exporter, interval: interval})
+		return nil
+	}
+}
+
+// startMetricsExport pushes the samples of registry to each exporter until
+// the returned function is called, which exports them a final time within
+// timeout, closes the exporters and waits for them to finish.
+func startMetricsExport(logger *slog.Logger, registry *metrics.Registry, exporters []metricsExporter, timeout time.Duration) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for _, e := range exporters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.run(ctx, logger, registry, timeout)
+		}()
+	}
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// run exports the samples of registry every interval until ctx is done,
+// then exports them a final time within timeout and closes the exporter.
+func (e metricsExporter) run(ctx context.Context, logger *slog.Logger, registry *metrics.Registry, timeout time.Duration) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			e.export(ctx, logger, registry)
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			e.export(flushCtx, logger, registry)
+			if closer, ok := e.exporter.(io.Closer); ok {
+				if err := closer.Close(); err != nil {
+					logger.Warn("failed to close metrics exporter", "error", err)
+				}
+			}
+			return
+		}
+	}
+}
+
+// export pushes the samples currently gathered from registry.
+func (e metricsExporter) export(ctx context.Context, logger *slog.Logger, registry *metrics.Registry) {
+	if err := e.exporter.Export(ctx, registry.Gather()); err != nil {
+		logger.Warn("failed to export metrics", "error", err)
+	}
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultDogStatsDPort is the port of the Datadog agent's DogStatsD server
+// used when DD_DOGSTATSD_PORT is not set.
+const DefaultDogStatsDPort = "8125"
+
+// maxDatagramSize keeps DogStatsD datagrams within a typical network MTU.
+const maxDatagramSize = 1432
+
+// DogStatsD exports samples to a Datadog agent, or any StatsD server that
+// understands DogStatsD tags, over UDP. Gauges are sent as gauges; counters
+// are sent as the increase since the previous export. Labels are sent as
+// tags, together with the exporter's global tags.
+type DogStatsD struct {
+	conn net.Conn
+	tags []string
+
+	mu   sync.Mutex
+	last map[string]float64
+}
+
+// NewDogStatsD returns an exporter sending to the DogStatsD server at
+// address, e.g. "localhost:8125", that adds tags such as "service:orders" to
+// every sample.
+func NewDogStatsD(address string, tags ...string) (*DogStatsD, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	return &DogStatsD{conn: conn, tags: tags, last: make(map[string]float64)}, nil
+}
+
+// DogStatsDFromEnv returns an exporter configured from the standard Datadog
+// environment variables: the agent is reached at DD_AGENT_HOST (default
+// localhost) and DD_DOGSTATSD_PORT (default 8125), and DD_SERVICE, DD_ENV,
+// DD_VERSION and DD_TAGS are added as tags.
+func DogStatsDFromEnv() (*DogStatsD, error) {
+	host := cmp.Or(os.Getenv("DD_AGENT_HOST"), "localhost")
+	port := cmp.Or(os.Getenv("DD_DOGSTATSD_PORT"), DefaultDogStatsDPort)
+
+	var tags []string
+	for _, tag := range []struct{ key, variable string }{
+		{"service", "DD_SERVICE"},
+		{"env", "DD_ENV"},
+		{"version", "DD_VERSION"},
+	} {
+		if value := os.Getenv(tag.variable); value != "" {
+			tags = append(tags, tag.key+":"+value)
+		}
+	}
+	tags = append(tags, strings.FieldsFunc(os.Getenv("DD_TAGS"), func(r rune) bool {
+		return r == ',' || r == ' '
+	})...)
+
+	return NewDogStatsD(net.JoinHostPort(host, port), tags...)
+}
+
+// Export sends samples, packing as many as fit into each datagram.
+func (d *DogStatsD) Export(ctx context.Context, samples []Sample) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var errs []error
+	var datagram []byte
+	flush := func() {
+		if len(datagram) == 0 {
+			return
+		}
+		if _, err := d.conn.Write(datagram); err != nil {
+			errs = append(errs, err)
+		}
+		datagram = datagram[:0]
+	}
+
+	for _, s := range samples {
+		line := d.line(s)
+		if len(datagram) > 0 && len(datagram)+1+len(line) > maxDatagramSize {
+			flush()
+		}
+		if len(datagram) > 0 {
+			datagram = append(datagram, '\n')
+		}
+		datagram = append(datagram, line...)
+	}
+	flush()
+	return errors.Join(errs...)
+}
+
+// line formats s as a DogStatsD metric, turning counters into increments.
+// The caller must hold mu.
+func (d *DogStatsD) line(s Sample) string {
+	value, kind := s.Value, "g"
+	if s.Type == Counter {
+		key := seriesKey(s)
+		if last, ok := d.last[key]; ok && value >= last {
+			value -= last
+		}
+		d.last[key] = s.Value
+		kind = "c"
+	}
+
+	var b strings.Builder
+	b.WriteString(s.Name + ":" + strconv.FormatFloat(value, 'f', -1, 64) + "|" + kind)
+	tags := slices.Clone(d.tags)
+	for _, key := range slices.Sorted(maps.Keys(s.Labels)) {
+		tags = append(tags, key+":"+tagEscaper.Replace(s.Labels[key]))
+	}
+	if len(tags) > 0 {
+		b.WriteString("|#" + strings.Join(tags, ","))
+	}
+	return b.String()
+}
+
+// Close closes the connection to the DogStatsD server.
+func (d *DogStatsD) Close() error {
+	return d.conn.Close()
+}
+
+// tagEscaper replaces the characters DogStatsD uses as separators.
+var tagEscaper = strings.NewReplacer(",", "_", "|", "_", "\n", "_")
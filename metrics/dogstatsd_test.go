@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listenStatsD returns a UDP socket standing in for the DogStatsD server.
+func listenStatsD(t *testing.T) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readDatagram returns the next datagram received on conn.
+func readDatagram(t *testing.T, conn net.PacketConn) string {
+	buf := make([]byte, 65536)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	return string(buf[:n])
+}
+
+func TestDogStatsDExport(t *testing.T) {
+	server := listenStatsD(t)
+	exporter, err := NewDogStatsD(server.LocalAddr().String(), "service:orders")
+	require.NoError(t, err)
+	defer exporter.Close()
+
+	samples := []Sample{
+		{Name: "queue_depth", Type: Gauge, Value: 3, Labels: map[string]string{"queue": "a,b"}},
+		{Name: "jobs_total", Type: Counter, Value: 10},
+	}
+	require.NoError(t, exporter.Export(context.Background(), samples))
+	assert.Equal(t, "queue_depth:3|g|#service:orders,queue:a_b\njobs_total:10|c|#service:orders", readDatagram(t, server))
+
+	// Counters are sent as the increase since the previous export
+	samples[1].Value = 14
+	require.NoError(t, exporter.Export(context.Background(), samples[1:]))
+	assert.Equal(t, "jobs_total:4|c|#service:orders", readDatagram(t, server))
+}
+
+func TestDogStatsDDatagramSize(t *testing.T) {
+	server := listenStatsD(t)
+	exporter, err := NewDogStatsD(server.LocalAddr().String())
+	require.NoError(t, err)
+	defer exporter.Close()
+
+	samples := make([]Sample, 200)
+	for idx := range samples {
+		samples[idx] = Sample{Name: "some_reasonably_long_metric_name", Value: float64(idx)}
+	}
+	require.NoError(t, exporter.Export(context.Background(), samples))
+
+	var lines int
+	for lines < len(samples) {
+		datagram := readDatagram(t, server)
+		assert.LessOrEqual(t, len(datagram), maxDatagramSize)
+		lines += len(strings.Split(datagram, "\n"))
+	}
+	assert.Equal(t, len(samples), lines)
+}
+
+func TestDogStatsDFromEnv(t *testing.T) {
+	server := listenStatsD(t)
+	_, port, err := net.SplitHostPort(server.LocalAddr().String())
+	require.NoError(t, err)
+
+	t.Setenv("DD_AGENT_HOST", "127.0.0.1")
+	t.Setenv("DD_DOGSTATSD_PORT", port)
+	t.Setenv("DD_SERVICE", "orders")
+	t.Setenv("DD_ENV", "prod")
+	t.Setenv("DD_VERSION", "")
+	t.Setenv("DD_TAGS", "team:payments region:eu")
+
+	exporter, err := DogStatsDFromEnv()
+	require.NoError(t, err)
+	defer exporter.Close()
+
+	require.NoError(t, exporter.Export(context.Background(), []Sample{{Name: "up", Value: 1}}))
+	assert.Equal(t, "up:1|g|#service:orders,env:prod,team:payments,region:eu", readDatagram(t, server))
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultExportInterval is how often samples are pushed to an exporter when
+// no interval is given.
+const DefaultExportInterval = 10 * time.Second
+
+// Exporter pushes gathered samples to a metrics backend, for deployments
+// that do not scrape Registry.Handler. Exporters that implement io.Closer
+// are closed once the final samples have been exported.
+type Exporter interface {
+	Export(ctx context.Context, samples []Sample) error
+}
+
+// seriesKey identifies the series of a sample by its name and labels.
+func seriesKey(s Sample) string {
+	var b strings.Builder
+	b.WriteString(s.Name)
+	for _, key := range slices.Sorted(maps.Keys(s.Labels)) {
+		b.WriteString("\x00" + key + "=" + s.Labels[key])
+	}
+	return b.String()
+}
@@ -0,0 +1,117 @@
+// Package metrics collects lifecycle, Go runtime and process metrics and
+// serves them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Type is the kind of value a Sample reports.
+type Type string
+
+const (
+	// Gauge is a value that can go up and down, such as a goroutine count.
+	Gauge Type = "gauge"
+
+	// Counter is a value that only increases, such as completed GC cycles.
+	Counter Type = "counter"
+)
+
+// Sample is a single metric value at the time it was collected.
+type Sample struct {
+	Name   string
+	Help   string
+	Type   Type
+	Labels map[string]string
+	Value  float64
+}
+
+// Collector returns the current samples of a group of metrics. It is called
+// each time the registry is gathered and must be safe for concurrent use.
+type Collector func() []Sample
+
+// Registry holds collectors and gathers their samples.
+// It is safe for concurrent use.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []Collector
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds collector to the registry.
+func (r *Registry) Register(collector Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, collector)
+}
+
+// Gather calls every collector and returns their samples ordered by name,
+// keeping the order of samples that share a name.
+func (r *Registry) Gather() []Sample {
+	r.mu.Lock()
+	collectors := slices.Clone(r.collectors)
+	r.mu.Unlock()
+
+	var samples []Sample
+	for _, collect := range collectors {
+		samples = append(samples, collect()...)
+	}
+	slices.SortStableFunc(samples, func(a, b Sample) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return samples
+}
+
+// Handler returns an http.Handler that serves the gathered samples in the
+// Prometheus text exposition format, for scraping at e.g. /metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = WriteText(w, r.Gather())
+	})
+}
+
+// WriteText writes samples in the Prometheus text exposition format. Samples
+// sharing a name must be adjacent, as returned by Registry.Gather.
+func WriteText(w io.Writer, samples []Sample) error {
+	var b strings.Builder
+	for idx, s := range samples {
+		if idx == 0 || samples[idx-1].Name != s.Name {
+			if s.Help != "" {
+				fmt.Fprintf(&b, "# HELP %s %s\n", s.Name, helpEscaper.Replace(s.Help))
+			}
+			fmt.Fprintf(&b, "# TYPE %s %s\n", s.Name, cmp.Or(s.Type, Gauge))
+		}
+		b.WriteString(s.Name)
+		if len(s.Labels) > 0 {
+			b.WriteByte('{')
+			for i, key := range slices.Sorted(maps.Keys(s.Labels)) {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				fmt.Fprintf(&b, "%s=\"%s\"", key, labelEscaper.Replace(s.Labels[key]))
+			}
+			b.WriteByte('}')
+		}
+		fmt.Fprintf(&b, " %s\n", strconv.FormatFloat(s.Value, 'g', -1, 64))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryGather(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(func() []Sample {
+		return []Sample{{Name: "b_total", Type: Counter, Value: 2}}
+	})
+	registry.Register(func() []Sample {
+		return []Sample{
+			{Name: "a", Value: 1, Labels: map[string]string{"x": "1"}},
+			{Name: "a", Value: 3, Labels: map[string]string{"x": "2"}},
+		}
+	})
+
+	samples := registry.Gather()
+	require.Len(t, samples, 3)
+	assert.Equal(t, []string{"a", "a", "b_total"}, []string{samples[0].Name, samples[1].Name, samples[2].Name})
+	assert.Equal(t, "1", samples[0].Labels["x"], "samples sharing a name should keep their order")
+}
+
+func TestRegistryHandler(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(func() []Sample {
+		return []Sample{
+			{Name: "requests_total", Help: "Requests served.", Type: Counter, Value: 42,
+				Labels: map[string]string{"path": `/a"b`, "code": "200"}},
+			{Name: "requests_total", Type: Counter, Value: 1.5,
+				Labels: map[string]string{"path": "/", "code": "500"}},
+			{Name: "up", Value: 1},
+		}
+	})
+
+	recorder := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Header().Get("Content-Type"), "text/plain; version=0.0.4")
+	body, err := io.ReadAll(recorder.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{code="200",path="/a\"b"} 42
+requests_total{code="500",path="/"} 1.5
+# TYPE up gauge
+up 1
+`, string(body))
+}
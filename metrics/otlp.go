@@ -0,0 +1,268 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultOTLPEndpoint is the OTLP/HTTP metrics endpoint of a local
+// OpenTelemetry collector, used when no endpoint is configured.
+const DefaultOTLPEndpoint = "http://localhost:4318/v1/metrics"
+
+// scopeName identifies ezapp as the instrumentation scope of exported metrics.
+const scopeName = "github.com/pgvanniekerk/ezapp"
+
+// OTLP exports samples to an OpenTelemetry collector using OTLP over HTTP
+// with JSON encoding. Gauges are exported as gauges and counters as
+// cumulative monotonic sums; labels become data point attributes.
+type OTLP struct {
+	endpoint string
+	headers  http.Header
+	resource map[string]string
+	client   *http.Client
+}
+
+// otlpOption represents a functional option for configuring an OTLP exporter.
+// This type is not exported to ensure only predefined options can be used.
+type otlpOption func(*OTLP)
+
+// WithHeader adds a header, such as an API key, to every export request.
+func WithHeader(key, value string) otlpOption {
+	return func(o *OTLP) {
+		o.headers.Add(key, value)
+	}
+}
+
+// WithResourceAttribute adds an attribute, such as "service.name", to the
+// resource the exported metrics describe.
+func WithResourceAttribute(key, value string) otlpOption {
+	return func(o *OTLP) {
+		o.resource[key] = value
+	}
+}
+
+// WithHTTPClient sends export requests with client instead of a client
+// with a 10 second timeout.
+func WithHTTPClient(client *http.Client) otlpOption {
+	return func(o *OTLP) {
+		o.client = client
+	}
+}
+
+// NewOTLP returns an exporter posting to the OTLP/HTTP metrics endpoint,
+// e.g. DefaultOTLPEndpoint.
+func NewOTLP(endpoint string, options ...otlpOption) *OTLP {
+	o := &OTLP{
+		endpoint: endpoint,
+		headers:  make(http.Header),
+		resource: make(map[string]string),
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range options {
+		opt(o)
+	}
+	return o
+}
+
+// OTLPFromEnv returns an exporter configured from the standard OpenTelemetry
+// environment variables: the endpoint is read from
+// OTEL_EXPORTER_OTLP_METRICS_ENDPOINT, or OTEL_EXPORTER_OTLP_ENDPOINT with
+// "/v1/metrics" appended, headers from OTEL_EXPORTER_OTLP_HEADERS and
+// OTEL_EXPORTER_OTLP_METRICS_HEADERS, and resource attributes from
+// OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME.
+func OTLPFromEnv() (*OTLP, error) {
+	endpoint := DefaultOTLPEndpoint
+	if base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+		endpoint = strings.TrimSuffix(base, "/") + "/v1/metrics"
+	}
+	if metricsEndpoint := os.Getenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT"); metricsEndpoint != "" {
+		endpoint = metricsEndpoint
+	}
+
+	var options []otlpOption
+	for _, variable := range []string{"OTEL_EXPORTER_OTLP_HEADERS", "OTEL_EXPORTER_OTLP_METRICS_HEADERS"} {
+		headers, err := parseKeyValues(os.Getenv(variable))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", variable, err)
+		}
+		for _, kv := range headers {
+			options = append(options, WithHeader(kv[0], kv[1]))
+		}
+	}
+	attributes, err := parseKeyValues(os.Getenv("OTEL_RESOURCE_ATTRIBUTES"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTEL_RESOURCE_ATTRIBUTES: %w", err)
+	}
+	for _, kv := range attributes {
+		options = append(options, WithResourceAttribute(kv[0], kv[1]))
+	}
+	if service := os.Getenv("OTEL_SERVICE_NAME"); service != "" {
+		options = append(options, WithResourceAttribute("service.name", service))
+	}
+
+	return NewOTLP(endpoint, options...), nil
+}
+
+// parseKeyValues parses a comma-separated list of URL-encoded key=value
+// pairs, as used by the OpenTelemetry environment variables.
+func parseKeyValues(list string) ([][2]string, error) {
+	var pairs [][2]string
+	for _, item := range strings.Split(list, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not a key=value pair", item)
+		}
+		key, err := url.QueryUnescape(strings.TrimSpace(key))
+		if err != nil {
+			return nil, err
+		}
+		value, err = url.QueryUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, [2]string{key, value})
+	}
+	return pairs, nil
+}
+
+// Export posts samples to the endpoint in a single request.
+func (o *OTLP) Export(ctx context.Context, samples []Sample) error {
+	body, err := json.Marshal(o.request(samples, time.Now()))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = o.headers.Clone()
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("otlp export failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("otlp export failed with status %s", resp.Status)
+	}
+	return nil
+}
+
+// otlpRequest mirrors the JSON encoding of ExportMetricsServiceRequest.
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetric struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Gauge       *otlpData `json:"gauge,omitempty"`
+	Sum         *otlpData `json:"sum,omitempty"`
+}
+
+type otlpData struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+
+	// AggregationTemporality and IsMonotonic are only set for sums.
+	AggregationTemporality int  `json:"aggregationTemporality,omitempty"`
+	IsMonotonic            bool `json:"isMonotonic,omitempty"`
+}
+
+type otlpDataPoint struct {
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	AsDouble          float64         `json:"asDouble"`
+}
+
+type otlpAttribute struct {
+	Key   string             `json:"key"`
+	Value otlpAttributeValue `json:"value"`
+}
+
+type otlpAttributeValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// aggregationCumulative is AGGREGATION_TEMPORALITY_CUMULATIVE.
+const aggregationCumulative = 2
+
+// request builds the export request for samples collected at now. Samples
+// sharing a name become data points of a single metric.
+func (o *OTLP) request(samples []Sample, now time.Time) otlpRequest {
+	var metrics []otlpMetric
+	for idx, s := range samples {
+		if idx == 0 || samples[idx-1].Name != s.Name {
+			metric := otlpMetric{Name: s.Name, Description: s.Help}
+			if s.Type == Counter {
+				metric.Sum = &otlpData{AggregationTemporality: aggregationCumulative, IsMonotonic: true}
+			} else {
+				metric.Gauge = &otlpData{}
+			}
+			metrics = append(metrics, metric)
+		}
+
+		point := otlpDataPoint{
+			Attributes:   attributes(s.Labels),
+			TimeUnixNano: strconv.FormatInt(now.UnixNano(), 10),
+			AsDouble:     s.Value,
+		}
+		metric := &metrics[len(metrics)-1]
+		data := metric.Gauge
+		if metric.Sum != nil {
+			data = metric.Sum
+			point.StartTimeUnixNano = strconv.FormatInt(startTime.UnixNano(), 10)
+		}
+		data.DataPoints = append(data.DataPoints, point)
+	}
+
+	return otlpRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource: otlpResource{Attributes: attributes(o.resource)},
+		ScopeMetrics: []otlpScopeMetrics{{
+			Scope:   otlpScope{Name: scopeName},
+			Metrics: metrics,
+		}},
+	}}}
+}
+
+// attributes converts labels to OTLP attributes in key order.
+func attributes(labels map[string]string) []otlpAttribute {
+	attrs := make([]otlpAttribute, 0, len(labels))
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		attrs = append(attrs, otlpAttribute{Key: key, Value: otlpAttributeValue{StringValue: labels[key]}})
+	}
+	return attrs
+}
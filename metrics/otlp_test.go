@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTLPExport(t *testing.T) {
+	var received map[string]any
+	var header http.Header
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/v1/metrics", r.URL.Path)
+		header = r.Header
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &received))
+	}))
+	defer collector.Close()
+
+	exporter := NewOTLP(collector.URL+"/v1/metrics",
+		WithHeader("X-Api-Key", "secret"),
+		WithResourceAttribute("service.name", "orders"),
+	)
+	err := exporter.Export(context.Background(), []Sample{
+		{Name: "jobs_total", Help: "Jobs run.", Type: Counter, Value: 7, Labels: map[string]string{"queue": "a"}},
+		{Name: "jobs_total", Type: Counter, Value: 2, Labels: map[string]string{"queue": "b"}},
+		{Name: "up", Value: 1},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.Equal(t, "secret", header.Get("X-Api-Key"))
+
+	resource := received["resourceMetrics"].([]any)[0].(map[string]any)
+	assert.Equal(t, []any{map[string]any{"key": "service.name", "value": map[string]any{"stringValue": "orders"}}},
+		resource["resource"].(map[string]any)["attributes"])
+
+	scope := resource["scopeMetrics"].([]any)[0].(map[string]any)
+	assert.Equal(t, scopeName, scope["scope"].(map[string]any)["name"])
+	metrics := scope["metrics"].([]any)
+	require.Len(t, metrics, 2)
+
+	jobs := metrics[0].(map[string]any)
+	assert.Equal(t, "jobs_total", jobs["name"])
+	assert.Equal(t, "Jobs run.", jobs["description"])
+	sum := jobs["sum"].(map[string]any)
+	assert.Equal(t, float64(aggregationCumulative), sum["aggregationTemporality"])
+	assert.Equal(t, true, sum["isMonotonic"])
+	points := sum["dataPoints"].([]any)
+	require.Len(t, points, 2)
+	assert.Equal(t, 7.0, points[0].(map[string]any)["asDouble"])
+	assert.NotEmpty(t, points[0].(map[string]any)["startTimeUnixNano"])
+
+	up := metrics[1].(map[string]any)
+	assert.Contains(t, up, "gauge")
+	assert.NotContains(t, up, "sum")
+}
+
+func TestOTLPExportFailure(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer collector.Close()
+
+	err := NewOTLP(collector.URL).Export(context.Background(), []Sample{{Name: "up", Value: 1}})
+	assert.ErrorContains(t, err, "otlp export failed with status 400")
+}
+
+func TestOTLPRequestMixedTypes(t *testing.T) {
+	request := NewOTLP(DefaultOTLPEndpoint).request([]Sample{
+		{Name: "x", Type: Gauge, Value: 1},
+		{Name: "x", Type: Counter, Value: 2},
+	}, time.Now())
+
+	metrics := request.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	require.Len(t, metrics, 1)
+	assert.Len(t, metrics[0].Gauge.DataPoints, 2, "samples sharing a name are reported with the first sample's type")
+}
+
+func TestOTLPFromEnv(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318/")
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "api-key=a%20b, tenant=acme")
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_HEADERS", "")
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "deployment.environment=prod")
+	t.Setenv("OTEL_SERVICE_NAME", "orders")
+
+	exporter, err := OTLPFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "http://collector:4318/v1/metrics", exporter.endpoint)
+	assert.Equal(t, "a b", exporter.headers.Get("Api-Key"))
+	assert.Equal(t, "acme", exporter.headers.Get("Tenant"))
+	assert.Equal(t, map[string]string{"deployment.environment": "prod", "service.name": "orders"}, exporter.resource)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", "https://otlp.example.com/metrics")
+	exporter, err = OTLPFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "https://otlp.example.com/metrics", exporter.endpoint)
+
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "invalid")
+	_, err = OTLPFromEnv()
+	assert.ErrorContains(t, err, "invalid OTEL_RESOURCE_ATTRIBUTES")
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// startTime approximates the process start time for process_start_time_seconds.
+var startTime = time.Now()
+
+// ProcessCollector returns a Collector reporting process metrics: the start
+// time, CPU time, resident memory and open file descriptors. Metrics that
+// cannot be read on the current platform are omitted.
+func ProcessCollector() Collector {
+	return func() []Sample {
+		samples := []Sample{{
+			Name:  "process_start_time_seconds",
+			Help:  "Start time of the process since the Unix epoch in seconds.",
+			Type:  Gauge,
+			Value: float64(startTime.UnixNano()) / float64(time.Second),
+		}}
+		if cpu, ok := cpuSeconds(); ok {
+			samples = append(samples, Sample{
+				Name:  "process_cpu_seconds_total",
+				Help:  "Total user and system CPU time spent in seconds.",
+				Type:  Counter,
+				Value: cpu,
+			})
+		}
+		if rss, ok := residentMemory(); ok {
+			samples = append(samples, Sample{
+				Name:  "process_resident_memory_bytes",
+				Help:  "Resident memory size in bytes.",
+				Type:  Gauge,
+				Value: rss,
+			})
+		}
+		if fds, err := os.ReadDir("/proc/self/fd"); err == nil {
+			samples = append(samples, Sample{
+				Name:  "process_open_fds",
+				Help:  "Number of open file descriptors.",
+				Type:  Gauge,
+				Value: float64(len(fds)),
+			})
+		}
+		return samples
+	}
+}
+
+// residentMemory reads the resident set size from /proc/self/statm, which
+// is only available on Linux.
+func residentMemory() (float64, bool) {
+	statm, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0, false
+	}
+	fields := strings.Fields(string(statm))
+	if len(fields) < 2 {
+		return 0, false
+	}
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return float64(pages * uint64(os.Getpagesize())), true
+}
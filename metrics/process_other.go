@@ -0,0 +1,8 @@
+//go:build !unix
+
+package metrics
+
+// cpuSeconds is not supported on this platform.
+func cpuSeconds() (float64, bool) {
+	return 0, false
+}
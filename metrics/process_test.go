@@ -0,0 +1,19 @@
+package metrics
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessCollector(t *testing.T) {
+	values := sampleValues(ProcessCollector()())
+
+	assert.Positive(t, values["process_start_time_seconds"])
+	if runtime.GOOS == "linux" {
+		assert.Positive(t, values["process_cpu_seconds_total"])
+		assert.Positive(t, values["process_resident_memory_bytes"])
+		assert.Positive(t, values["process_open_fds"])
+	}
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package metrics
+
+import "syscall"
+
+// cpuSeconds returns the user and system CPU time of the process.
+func cpuSeconds() (float64, bool) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, false
+	}
+	seconds := func(tv syscall.Timeval) float64 {
+		return float64(tv.Sec) + float64(tv.Usec)/1e6
+	}
+	return seconds(usage.Utime) + seconds(usage.Stime), true
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"runtime"
+	"runtime/debug"
+	rtmetrics "runtime/metrics"
+	"time"
+)
+
+// goMetrics maps runtime/metrics names to the samples reported for them.
+var goMetrics = []struct {
+	source string
+	name   string
+	help   string
+	typ    Type
+}{
+	{"/sched/goroutines:goroutines", "go_goroutines", "Number of goroutines that currently exist.", Gauge},
+	{"/sched/gomaxprocs:threads", "go_gomaxprocs", "Current GOMAXPROCS setting.", Gauge},
+	{"/memory/classes/heap/objects:bytes", "go_heap_alloc_bytes", "Bytes of allocated heap objects.", Gauge},
+	{"/gc/heap/objects:objects", "go_heap_objects", "Number of allocated heap objects.", Gauge},
+	{"/memory/classes/total:bytes", "go_memory_total_bytes", "Bytes of memory mapped by the Go runtime.", Gauge},
+	{"/gc/cycles/total:gc-cycles", "go_gc_cycles_total", "Number of completed GC cycles.", Counter},
+}
+
+// GoCollector returns a Collector reporting Go runtime metrics: goroutines,
+// GOMAXPROCS, heap usage, GC cycles and GC pause time.
+func GoCollector() Collector {
+	return func() []Sample {
+		descs := make([]rtmetrics.Sample, len(goMetrics))
+		for idx, m := range goMetrics {
+			descs[idx].Name = m.source
+		}
+		rtmetrics.Read(descs)
+
+		samples := make([]Sample, 0, len(goMetrics)+3)
+		for idx, m := range goMetrics {
+			var value float64
+			switch descs[idx].Value.Kind() {
+			case rtmetrics.KindUint64:
+				value = float64(descs[idx].Value.Uint64())
+			case rtmetrics.KindFloat64:
+				value = descs[idx].Value.Float64()
+			default:
+				// Not supported by this Go version
+				continue
+			}
+			samples = append(samples, Sample{Name: m.name, Help: m.help, Type: m.typ, Value: value})
+		}
+
+		stats := debug.GCStats{Pause: make([]time.Duration, 0, 1)}
+		debug.ReadGCStats(&stats)
+		samples = append(samples, Sample{
+			Name:  "go_gc_pause_seconds_total",
+			Help:  "Total time spent in GC stop-the-world pauses.",
+			Type:  Counter,
+			Value: stats.PauseTotal.Seconds(),
+		})
+		if len(stats.Pause) > 0 {
+			samples = append(samples, Sample{
+				Name:  "go_gc_last_pause_seconds",
+				Help:  "Duration of the most recent GC stop-the-world pause.",
+				Type:  Gauge,
+				Value: stats.Pause[0].Seconds(),
+			})
+		}
+		samples = append(samples, Sample{
+			Name:   "go_info",
+			Help:   "Information about the Go environment.",
+			Type:   Gauge,
+			Labels: map[string]string{"version": runtime.Version()},
+			Value:  1,
+		})
+		return samples
+	}
+}
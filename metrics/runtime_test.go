@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sampleValues indexes samples by name.
+func sampleValues(samples []Sample) map[string]float64 {
+	values := make(map[string]float64, len(samples))
+	for _, s := range samples {
+		values[s.Name] = s.Value
+	}
+	return values
+}
+
+func TestGoCollector(t *testing.T) {
+	runtime.GC()
+	values := sampleValues(GoCollector()())
+
+	assert.Positive(t, values["go_goroutines"])
+	assert.Positive(t, values["go_gomaxprocs"])
+	assert.Positive(t, values["go_heap_alloc_bytes"])
+	assert.Positive(t, values["go_gc_cycles_total"])
+	assert.Contains(t, values, "go_gc_pause_seconds_total")
+	assert.Contains(t, values, "go_gc_last_pause_seconds")
+	assert.Equal(t, 1.0, values["go_info"])
+}
@@ -0,0 +1,107 @@
+package ezapp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+	"github.com/pgvanniekerk/ezapp/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gatheredNames returns the names of the samples gathered from registry.
+func gatheredNames(registry *metrics.Registry) []string {
+	var names []string
+	for _, s := range registry.Gather() {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func TestRunEMetrics(t *testing.T) {
+	var gathered []metrics.Sample
+	var body string
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		require.NotNil(t, ctx.Metrics)
+		return Construct(WithNamedRunner("probe", func(context.Context) error {
+			gathered = ctx.Metrics.Gather()
+			recorder := httptest.NewRecorder()
+			ctx.Metrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			body = recorder.Body.String()
+			return nil
+		}))
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, body, `ezapp_state{state="running"} 1`)
+	assert.Contains(t, body, "ezapp_runners 1")
+	assert.Contains(t, body, "go_goroutines ")
+	assert.Contains(t, body, "process_start_time_seconds ")
+	assert.NotEmpty(t, gathered)
+}
+
+func TestWithoutRuntimeMetrics(t *testing.T) {
+	settings := runopt.Settings{}
+	WithoutRuntimeMetrics()(&settings)
+	assert.Empty(t, gatheredNames(newMetricsRegistry(settings)))
+
+	names := gatheredNames(newMetricsRegistry(runopt.Settings{}))
+	assert.Contains(t, names, "go_goroutines")
+	assert.Contains(t, names, "process_start_time_seconds")
+}
+
+// recordingExporter records the samples it is asked to export.
+type recordingExporter struct {
+	mu      sync.Mutex
+	exports [][]metrics.Sample
+	closed  bool
+}
+
+func (e *recordingExporter) Export(ctx context.Context, samples []metrics.Sample) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.exports = append(e.exports, samples)
+	return nil
+}
+
+func (e *recordingExporter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.closed = true
+	return nil
+}
+
+func TestWithMetricsExporter(t *testing.T) {
+	exporter := &recordingExporter{}
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithNamedRunner("worker", func(context.Context) error {
+				time.Sleep(50 * time.Millisecond)
+				return nil
+			}),
+			WithMetricsExporter(exporter, 10*time.Millisecond),
+		)
+	}, WithoutRuntimeMetrics())
+	require.NoError(t, err)
+
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+	assert.GreaterOrEqual(t, len(exporter.exports), 2, "samples should be exported periodically and on shutdown")
+	assert.True(t, exporter.closed, "the exporter should be closed after the final export")
+
+	final := exporter.exports[len(exporter.exports)-1]
+	idx := slices.IndexFunc(final, func(s metrics.Sample) bool { return s.Name == "ezapp_runners" })
+	require.GreaterOrEqual(t, idx, 0)
+	assert.Zero(t, final[idx].Value, "the final export should happen after the runners returned")
+
+	_, err = Construct(WithMetricsExporter(nil, time.Second))
+	assert.ErrorContains(t, err, "must not be nil")
+}
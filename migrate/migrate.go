@@ -0,0 +1,35 @@
+// Package migrate defines the interfaces through which runner.Migrations
+// applies database schema migrations, so that migration tools such as goose
+// or golang-migrate can be driven under the application lifecycle.
+//
+// Neither tool is a dependency of this module: each bundles every database
+// driver it supports into a single module. A Migrator for either is a small
+// adapter written against the tool's API, as shown in the README.
+package migrate
+
+import "context"
+
+// Migrator applies the pending migrations of a database schema, typically
+// read from an embedded file system.
+type Migrator interface {
+
+	// Pending returns the versions of the migrations that Up would apply, in
+	// the order it would apply them.
+	Pending(ctx context.Context) ([]int64, error)
+
+	// Up applies the pending migrations in order and returns the versions it
+	// applied, including those applied before it failed.
+	Up(ctx context.Context) ([]int64, error)
+}
+
+// Locker is a lock shared by every instance of a fleet, held while
+// migrations are applied so that instances starting at the same time do not
+// migrate the same database concurrently.
+type Locker interface {
+
+	// Lock blocks until the lock is held or ctx is done.
+	Lock(ctx context.Context) error
+
+	// Unlock releases the lock.
+	Unlock(ctx context.Context) error
+}
@@ -0,0 +1,63 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// PostgresLock is a Locker holding a session-level Postgres advisory lock.
+// The lock is held on a dedicated connection taken from the pool, so
+// Postgres releases it if the instance dies while holding it.
+type PostgresLock struct {
+	db  *sql.DB
+	key int64
+
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+// NewPostgresLock creates a PostgresLock on the advisory lock identified by
+// key, which must be the same for every instance of the fleet and should not
+// be used for anything else.
+func NewPostgresLock(db *sql.DB, key int64) *PostgresLock {
+	return &PostgresLock{db: db, key: key}
+}
+
+// Lock takes a connection from the pool and blocks on pg_advisory_lock until
+// the lock is held or ctx is done.
+func (l *PostgresLock) Lock(ctx context.Context) error {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get a connection for advisory lock %d: %w", l.key, err)
+	}
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", l.key); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to acquire advisory lock %d: %w", l.key, err)
+	}
+
+	l.mu.Lock()
+	l.conn = conn
+	l.mu.Unlock()
+	return nil
+}
+
+// Unlock releases the advisory lock and returns its connection to the pool.
+// It does nothing if the lock is not held.
+func (l *PostgresLock) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	conn := l.conn
+	l.conn = nil
+	l.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+
+	var err error
+	if _, execErr := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key); execErr != nil {
+		err = fmt.Errorf("failed to release advisory lock %d: %w", l.key, execErr)
+	}
+	return errors.Join(err, conn.Close())
+}
@@ -0,0 +1,100 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lockConnector opens connections recording the statements executed on
+// them, failing those containing fail.
+type lockConnector struct {
+	fail string
+
+	mu    sync.Mutex
+	execs []lockExec
+}
+
+// lockExec is a statement executed on the connection conn.
+type lockExec struct {
+	conn  *lockConn
+	query string
+	args  []driver.NamedValue
+}
+
+func (c *lockConnector) Connect(context.Context) (driver.Conn, error) {
+	return &lockConn{connector: c}, nil
+}
+
+func (c *lockConnector) Driver() driver.Driver {
+	return nil
+}
+
+type lockConn struct {
+	connector *lockConnector
+}
+
+func (c *lockConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.connector.mu.Lock()
+	defer c.connector.mu.Unlock()
+	c.connector.execs = append(c.connector.execs, lockExec{conn: c, query: query, args: args})
+	if c.connector.fail != "" && strings.Contains(query, c.connector.fail) {
+		return nil, errors.New("canceling statement due to user request")
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (*lockConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (*lockConn) Close() error {
+	return nil
+}
+
+func (*lockConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestPostgresLock(t *testing.T) {
+	connector := &lockConnector{}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	lock := NewPostgresLock(db, 42)
+
+	require.NoError(t, lock.Lock(context.Background()))
+	assert.Equal(t, 1, db.Stats().InUse, "the lock should hold a dedicated connection")
+	require.NoError(t, lock.Unlock(context.Background()))
+	assert.Equal(t, 0, db.Stats().InUse, "unlocking should return the connection to the pool")
+
+	require.Len(t, connector.execs, 2)
+	assert.Equal(t, "SELECT pg_advisory_lock($1)", connector.execs[0].query)
+	assert.Equal(t, "SELECT pg_advisory_unlock($1)", connector.execs[1].query)
+	assert.Same(t, connector.execs[0].conn, connector.execs[1].conn, "the lock must be released on the session holding it")
+	for _, exec := range connector.execs {
+		require.Len(t, exec.args, 1)
+		assert.Equal(t, int64(42), exec.args[0].Value)
+	}
+
+	assert.NoError(t, lock.Unlock(context.Background()), "unlocking a lock that is not held should do nothing")
+	assert.Len(t, connector.execs, 2)
+}
+
+func TestPostgresLockFailure(t *testing.T) {
+	connector := &lockConnector{fail: "pg_advisory_lock"}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	lock := NewPostgresLock(db, 42)
+
+	err := lock.Lock(context.Background())
+	assert.ErrorContains(t, err, "failed to acquire advisory lock 42")
+	assert.Equal(t, 0, db.Stats().InUse, "a failed lock should return its connection to the pool")
+	assert.NoError(t, lock.Unlock(context.Background()))
+}
@@ -0,0 +1,64 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/pgvanniekerk/ezapp/preflight"
+)
+
+// ErrPreflight is returned by RunE when a preflight check registered with
+// WithPreflightChecks fails.
+var ErrPreflight = errors.New("preflight checks failed")
+
+// WithPreflightChecks is a functional option that registers checks run under
+// the startup context after the initializer returns and before the runners
+// start, such as resolving the hosts of critical dependencies or connecting
+// to the database and broker. The checks run concurrently; each result is
+// logged and any failure, other than of checks marked preflight.WarnOnly,
+// fails startup with ErrPreflight.
+//
+// Example:
+//
+//	appCtx, err := Construct(
+//	    WithRunners(server.Run),
+//	    WithPreflightChecks(
+//	        preflight.DNS("db.internal"),
+//	        preflight.TCP("db.internal:5432"),
+//	        preflight.TCP("cache.internal:6379", preflight.WarnOnly()),
+//	    ),
+//	)
+func WithPreflightChecks(checks ...preflight.Check) option {
+	return func(appCtx *AppCtx) error {
+		appCtx.preflightChecks = append(appCtx.preflightChecks, checks...)
+		return nil
+	}
+}
+
+// runPreflightChecks runs the preflight checks of appCtx and logs their
+// report, returning an error if startup must not proceed.
+func runPreflightChecks(ctx context.Context, logger *slog.Logger, appCtx AppCtx) error {
+	if len(appCtx.preflightChecks) == 0 {
+		return nil
+	}
+
+	report := preflight.Run(ctx, appCtx.preflightChecks...)
+	for _, result := range report.Results {
+		switch {
+		case result.Err == nil:
+			logger.Debug("preflight check passed", "check", result.Name, "duration", result.Duration)
+		case result.WarnOnly:
+			logger.Warn("preflight check failed", "check", result.Name, "duration", result.Duration, "error", result.Err)
+		default:
+			logger.Error("preflight check failed", "check", result.Name, "duration", result.Duration, "error", result.Err)
+		}
+	}
+
+	if err := report.Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrPreflight, err)
+	}
+	logger.Info("preflight checks passed", "checks", len(report.Results))
+	return nil
+}
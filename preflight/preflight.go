@@ -0,0 +1,156 @@
+// Package preflight verifies at startup that the dependencies an application
+// cannot work without, such as the hosts of its database and broker, can be
+// resolved and reached, before any runner starts.
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is the timeout applied to a check that does not configure
+// its own timeout with WithTimeout.
+const DefaultTimeout = 5 * time.Second
+
+// checkOption represents a functional option for configuring a check.
+// This type is not exported to ensure only predefined options can be used.
+type checkOption func(*Check)
+
+// WithTimeout sets the maximum duration the check may take before it is
+// considered failed. Defaults to DefaultTimeout.
+func WithTimeout(timeout time.Duration) checkOption {
+	return func(c *Check) {
+		c.timeout = timeout
+	}
+}
+
+// WarnOnly makes a failure of the check a warning in the report instead of
+// failing startup, for dependencies the application can start without.
+func WarnOnly() checkOption {
+	return func(c *Check) {
+		c.warnOnly = true
+	}
+}
+
+// Check is a single preflight check. Create checks with New or with one of
+// the predefined checks such as DNS and TCP.
+type Check struct {
+	name     string
+	fn       func(ctx context.Context) error
+	timeout  time.Duration
+	warnOnly bool
+}
+
+// New creates a check named name that fails if fn returns an error. fn must
+// respect ctx, which carries the check's timeout.
+//
+// Example:
+//
+//	preflight.New("vault", func(ctx context.Context) error {
+//	    _, err := vaultClient.Sys().HealthWithContext(ctx)
+//	    return err
+//	})
+func New(name string, fn func(ctx context.Context) error, options ...checkOption) Check {
+	c := Check{name: name, fn: fn, timeout: DefaultTimeout}
+	for _, opt := range options {
+		opt(&c)
+	}
+	return c
+}
+
+// DNS creates a check that host resolves to at least one address.
+func DNS(host string, options ...checkOption) Check {
+	return New("dns:"+host, func(ctx context.Context) error {
+		addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+		if err != nil {
+			return err
+		}
+		if len(addrs) == 0 {
+			return fmt.Errorf("no addresses found for %s", host)
+		}
+		return nil
+	}, options...)
+}
+
+// TCP creates a check that a TCP connection to address, a "host:port" pair,
+// can be established. The connection is closed straight away.
+func TCP(address string, options ...checkOption) Check {
+	return New("tcp:"+address, func(ctx context.Context) error {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}, options...)
+}
+
+// Name returns the name of the check.
+func (c Check) Name() string {
+	return c.name
+}
+
+// Result is the outcome of a single check.
+type Result struct {
+	Name     string
+	Err      error
+	WarnOnly bool
+	Duration time.Duration
+}
+
+// Report is the aggregated outcome of a preflight run, with a Result for
+// every check in the order the checks were given.
+type Report struct {
+	Results []Result
+}
+
+// Err returns the combined errors of the failed checks that are not
+// WarnOnly, or nil if startup may proceed.
+func (r Report) Err() error {
+	var errs []error
+	for _, result := range r.Results {
+		if result.Err != nil && !result.WarnOnly {
+			errs = append(errs, fmt.Errorf("%s: %w", result.Name, result.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run runs checks concurrently, each bounded by its timeout and by ctx, and
+// returns their report once all have finished.
+func Run(ctx context.Context, checks ...Check) Report {
+	results := make([]Result, len(checks))
+
+	var wg sync.WaitGroup
+	for idx, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[idx] = c.run(ctx)
+		}()
+	}
+	wg.Wait()
+
+	return Report{Results: results}
+}
+
+// run runs the check once.
+func (c Check) run(ctx context.Context) Result {
+	result := Result{Name: c.name, WarnOnly: c.warnOnly}
+	if c.fn == nil {
+		result.Err = errors.New("check function must not be nil")
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	startedAt := time.Now()
+	result.Err = c.fn(ctx)
+	result.Duration = time.Since(startedAt)
+	return result
+}
@@ -0,0 +1,62 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	report := Run(context.Background(),
+		New("ok", func(context.Context) error { return nil }),
+		New("broker", func(context.Context) error { return errors.New("unreachable") }),
+		New("cache", func(context.Context) error { return errors.New("unreachable") }, WarnOnly()),
+	)
+
+	require.Len(t, report.Results, 3)
+	assert.Equal(t, "ok", report.Results[0].Name)
+	assert.NoError(t, report.Results[0].Err)
+	assert.True(t, report.Results[2].WarnOnly)
+
+	err := report.Err()
+	assert.ErrorContains(t, err, "broker: unreachable")
+	assert.NotContains(t, err.Error(), "cache", "warn-only failures should not fail startup")
+}
+
+func TestRunTimeout(t *testing.T) {
+	report := Run(context.Background(), New("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithTimeout(20*time.Millisecond)))
+
+	assert.ErrorIs(t, report.Err(), context.DeadlineExceeded)
+}
+
+func TestRunNilCheck(t *testing.T) {
+	report := Run(context.Background(), New("nil", nil))
+
+	assert.ErrorContains(t, report.Err(), "must not be nil")
+}
+
+func TestTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+
+	check := TCP(address)
+	assert.Equal(t, "tcp:"+address, check.Name())
+	assert.NoError(t, Run(context.Background(), check).Err())
+
+	listener.Close()
+	assert.Error(t, Run(context.Background(), TCP(address)).Err())
+}
+
+func TestDNS(t *testing.T) {
+	assert.NoError(t, Run(context.Background(), DNS("localhost")).Err())
+	assert.Error(t, Run(context.Background(), DNS("does-not-exist.invalid")).Err())
+}
@@ -0,0 +1,48 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/pgvanniekerk/ezapp/preflight"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPreflightChecks(t *testing.T) {
+	logger, handler := testutil.NewTestLogger(slog.LevelDebug)
+	appCtx, err := Construct(WithPreflightChecks(
+		preflight.New("db", func(context.Context) error { return nil }),
+		preflight.New("cache", func(context.Context) error { return errors.New("unreachable") }, preflight.WarnOnly()),
+	))
+	require.NoError(t, err)
+
+	require.NoError(t, runPreflightChecks(context.Background(), logger, appCtx))
+	check, ok := handler.Attr("preflight check failed", "check")
+	require.True(t, ok)
+	assert.Equal(t, "cache", check.String())
+	assert.Contains(t, handler.Messages(), "preflight checks passed")
+}
+
+func TestRunEPreflightFailure(t *testing.T) {
+	var started bool
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithRunners(func(context.Context) error {
+				started = true
+				return nil
+			}),
+			WithPreflightChecks(preflight.New("broker", func(context.Context) error {
+				return errors.New("connection refused")
+			})),
+		)
+	})
+
+	assert.ErrorIs(t, err, ErrPreflight)
+	assert.ErrorContains(t, err, "broker: connection refused")
+	assert.False(t, started, "runners should not start after a failed preflight check")
+}
@@ -0,0 +1,106 @@
+package ezapp
+
+import (
+	"cmp"
+	"context"
+	"slices"
+)
+
+// WithCleanupStepPriority is a functional option that registers a named
+// cleanup step with a priority. Steps with a higher priority run before
+// steps with a lower one, regardless of the order they were registered in;
+// steps with the same priority run in reverse registration order. Steps
+// registered with WithCleanupStep, WithManagedComponent and the function set
+// with WithCleanup have priority 0.
+//
+// Priorities make teardown deterministic in large applications whose cleanup
+// is registered from many places, e.g. to flush metrics only after every
+// producer that reports them has been closed.
+//
+// Example:
+//
+//	appCtx, err := Construct(
+//	    WithRunners(server.Run),
+//	    WithCleanupStepPriority("metrics", -10, func(ctx context.Context) error { return metrics.Flush(ctx) }),
+//	    WithCleanupStep("producer", func(ctx context.Context) error { return producer.Close() }),
+//	)
+func WithCleanupStepPriority(step string, priority int, fn func(shutdownCtx context.Context) error) option {
+	return func(appCtx *AppCtx) error {
+		appCtx.cleanupSteps = append(appCtx.cleanupSteps, cleanupStep{name: step, fn: fn, priority: priority})
+		return nil
+	}
+}
+
+// WithPreShutdownHookPriority is a functional option that registers a
+// pre-shutdown hook with a priority. Hooks with a higher priority run before
+// hooks with a lower one, regardless of the order they were registered in;
+// hooks with the same priority run in reverse registration order, like
+// cleanup steps. Hooks registered with WithPreShutdownHook, and by options
+// such as WithServiceRegistration, have priority 0.
+//
+// Example:
+//
+//	appCtx, err := Construct(
+//	    WithRunners(server.Run),
+//	    WithServiceRegistration(registrar),
+//	    // Stop accepting jobs before the instance is deregistered
+//	    WithPreShutdownHookPriority(10, func(ctx context.Context) error {
+//	        return scheduler.Pause(ctx)
+//	    }),
+//	)
+func WithPreShutdownHookPriority(priority int, hook func(ctx context.Context) error) option {
+	return func(appCtx *AppCtx) error {
+		appCtx.addPreShutdownHook(priority, hook)
+		return nil
+	}
+}
+
+// addPreShutdownHook adds a pre-shutdown hook with the given priority.
+func (appCtx *AppCtx) addPreShutdownHook(priority int, hook func(ctx context.Context) error) {
+	appCtx.preShutdownHooks = append(appCtx.preShutdownHooks, hook)
+	appCtx.preShutdownPriorities = append(appCtx.preShutdownPriorities, priority)
+}
+
+// orderedPreShutdownHooks returns the pre-shutdown hooks in the order they
+// run: by descending priority, then in reverse registration order.
+func (appCtx *AppCtx) orderedPreShutdownHooks() []func(ctx context.Context) error {
+	order := make([]int, len(appCtx.preShutdownHooks))
+	for idx := range order {
+		order[idx] = len(order) - 1 - idx
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(appCtx.preShutdownPriority(b), appCtx.preShutdownPriority(a))
+	})
+
+	hooks := make([]func(ctx context.Context) error, 0, len(order))
+	for _, idx := range order {
+		hooks = append(hooks, appCtx.preShutdownHooks[idx])
+	}
+	return hooks
+}
+
+// preShutdownPriority returns the priority of the pre-shutdown hook at
+// position idx.
+func (appCtx *AppCtx) preShutdownPriority(idx int) int {
+	if idx < len(appCtx.preShutdownPriorities) {
+		return appCtx.preShutdownPriorities[idx]
+	}
+	return 0
+}
+
+// orderedCleanupSteps returns the cleanup function and the cleanup steps in
+// the order they run: by descending priority, then in reverse registration
+// order with the cleanup function first.
+func (appCtx *AppCtx) orderedCleanupSteps() []cleanupStep {
+	steps := make([]cleanupStep, 0, len(appCtx.cleanupSteps)+1)
+	if appCtx.cleanupFunc != nil {
+		steps = append(steps, cleanupStep{name: "cleanup", fn: appCtx.cleanupFunc})
+	}
+	for i := len(appCtx.cleanupSteps) - 1; i >= 0; i-- {
+		steps = append(steps, appCtx.cleanupSteps[i])
+	}
+	slices.SortStableFunc(steps, func(a, b cleanupStep) int {
+		return cmp.Compare(b.priority, a.priority)
+	})
+	return steps
+}
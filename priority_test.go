@@ -0,0 +1,80 @@
+package ezapp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCleanupStepPriority(t *testing.T) {
+	var order []string
+	step := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	appCtx, err := Construct(
+		WithCleanupStepPriority("metrics", -10, step("metrics")),
+		WithCleanupStep("database", step("database")),
+		WithCleanupStep("producer", step("producer")),
+		WithCleanupStepPriority("listener", 10, step("listener")),
+		WithCleanup(step("cleanup")),
+	)
+	require.NoError(t, err)
+
+	expected := []string{"listener", "cleanup", "producer", "database", "metrics"}
+	assert.Equal(t, expected, appCtx.CleanupSteps())
+	require.NoError(t, appCtx.cleanup(context.Background(), nil))
+	assert.Equal(t, expected, order)
+}
+
+func TestWithPreShutdownHookPriority(t *testing.T) {
+	var order []string
+	hook := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	appCtx, err := Construct(
+		WithPreShutdownHookPriority(-1, hook("last")),
+		WithPreShutdownHook(hook("first")),
+		WithPreShutdownHook(hook("second")),
+		When("scheduler", true, func() (AppCtx, error) {
+			return Construct(WithPreShutdownHookPriority(5, hook("sub")))
+		}),
+	)
+	require.NoError(t, err)
+
+	for _, h := range appCtx.orderedPreShutdownHooks() {
+		require.NoError(t, h(context.Background()))
+	}
+	assert.Equal(t, []string{"sub", "second", "first", "last"}, order,
+		"ties should run in reverse registration order, like cleanup steps")
+}
+
+func TestRunEPreShutdownHookPriority(t *testing.T) {
+	var order []string
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithRunners(successfulRunner),
+			WithPreShutdownHook(func(context.Context) error {
+				order = append(order, "deregister")
+				return nil
+			}),
+			WithPreShutdownHookPriority(1, func(context.Context) error {
+				order = append(order, "pause")
+				return nil
+			}),
+		)
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pause", "deregister"}, order)
+}
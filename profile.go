@@ -0,0 +1,91 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+)
+
+// WithProfileCapture writes heap and goroutine profiles to dir when the
+// application exits abnormally: because a runner failed, including a runner
+// that overran its shutdown timeout, or because cleanup ran out of time. The
+// profiles are taken after cleanup, so goroutines that are still stuck show up
+// in the goroutine profile. Files are named after the instance ID and the time
+// of capture, e.g. "orders-api-4121-3fa85f64-20260114T093000Z-heap.pb.gz",
+// and are listed in ShutdownReport.Profiles. Failures to write them are
+// logged.
+//
+// The heap profile can be inspected with go tool pprof; the goroutine
+// profile is a plain-text dump of every goroutine's stack.
+//
+// Example:
+//
+//	ezapp.Run(initializer, ezapp.WithProfileCapture("/var/lib/orders/profiles"))
+func WithProfileCapture(dir string) RunOption {
+	return func(settings *runopt.Settings) {
+		settings.ProfileDir = dir
+	}
+}
+
+// abnormalExit reports whether the run and cleanup errors warrant capturing
+// profiles.
+func abnormalExit(runErr, cleanupErr error) bool {
+	return runErr != nil || errors.Is(cleanupErr, context.DeadlineExceeded)
+}
+
+// captureProfiles writes heap and goroutine profiles to dir and returns the
+// paths of the files written.
+func captureProfiles(logger *slog.Logger, dir, instanceID string) []string {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		logger.Error("failed to capture profiles", "dir", dir, "error", err)
+		return nil
+	}
+
+	// Collect garbage first so the heap profile reflects live memory
+	runtime.GC()
+
+	prefix := fmt.Sprintf("%s-%s", instanceID, time.Now().UTC().Format("20060102T150405Z"))
+	profiles := []struct {
+		name  string
+		debug int
+		ext   string
+	}{
+		{name: "heap", debug: 0, ext: "pb.gz"},
+		{name: "goroutine", debug: 2, ext: "txt"},
+	}
+
+	var paths []string
+	for _, p := range profiles {
+		path := filepath.Join(dir, fmt.Sprintf("%s-%s.%s", prefix, p.name, p.ext))
+		if err := writeProfile(path, p.name, p.debug); err != nil {
+			logger.Error("failed to capture profile", "profile", p.name, "path", path, "error", err)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	if len(paths) > 0 {
+		logger.Info("captured profiles after abnormal exit", "paths", paths)
+	}
+	return paths
+}
+
+// writeProfile writes the named runtime profile to path.
+func writeProfile(path, name string, debug int) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := pprof.Lookup(name).WriteTo(file, debug); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
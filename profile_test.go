@@ -0,0 +1,65 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbnormalExit(t *testing.T) {
+	assert.False(t, abnormalExit(nil, nil))
+	assert.False(t, abnormalExit(nil, errors.New("cleanup failed")), "an ordinary cleanup failure is not abnormal")
+	assert.True(t, abnormalExit(errors.New("runner failed"), nil))
+	assert.True(t, abnormalExit(nil, fmt.Errorf("close db: %w", context.DeadlineExceeded)))
+}
+
+func TestCaptureProfiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "profiles")
+	logger, handler := testutil.NewTestLogger(slog.LevelDebug)
+
+	paths := captureProfiles(logger, dir, "orders-1")
+
+	require.Len(t, paths, 2)
+	assert.Regexp(t, `orders-1-\d{8}T\d{6}Z-heap\.pb\.gz$`, paths[0])
+	goroutines, err := os.ReadFile(paths[1])
+	require.NoError(t, err)
+	assert.Contains(t, string(goroutines), "TestCaptureProfiles")
+	assert.Contains(t, handler.Messages(), "captured profiles after abnormal exit")
+}
+
+func TestRunEProfileCapture(t *testing.T) {
+	dir := t.TempDir()
+	var report ShutdownReport
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithRunners(failingRunner),
+			WithPostRunHook(func(r ShutdownReport) {
+				report = r
+			}),
+		)
+	}, WithProfileCapture(dir))
+	require.Error(t, err)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Len(t, report.Profiles, 2)
+
+	// A clean exit captures nothing
+	clean := t.TempDir()
+	require.NoError(t, RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(WithRunners(successfulRunner))
+	}, WithProfileCapture(clean)))
+	entries, err = os.ReadDir(clean)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
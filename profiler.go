@@ -0,0 +1,50 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/pgvanniekerk/ezapp/profiling"
+)
+
+// WithProfiler is a functional option that runs a continuous profiler as a
+// runner named "profiler". The profiler is started when the runners start and
+// stopped once the runner's context is cancelled, with
+// profiling.DefaultFlushTimeout to upload the profiles it has not yet sent. A
+// profiler that fails to start, or to flush, fails the runner like any other.
+//
+// Example:
+//
+//	pyroscopeCfg, err := profiling.LoadPyroscopeConfig()
+//	if err != nil {
+//	    return AppCtx{}, err
+//	}
+//	pyroscopeCfg.Tags = map[string]string{"instance_id": ctx.InstanceID}
+//	appCtx, err := Construct(
+//	    WithRunners(server.Run),
+//	    WithProfiler(profiling.NewPyroscope(pyroscopeCfg)),
+//	)
+func WithProfiler(profiler profiling.Profiler) option {
+	run := func(ctx context.Context) error {
+		if err := profiler.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start profiler: %w", err)
+		}
+		<-ctx.Done()
+
+		flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), profiling.DefaultFlushTimeout)
+		defer cancel()
+		if err := profiler.Stop(flushCtx); err != nil {
+			return fmt.Errorf("failed to flush profiles: %w", err)
+		}
+		return nil
+	}
+
+	return func(appCtx *AppCtx) error {
+		if profiler == nil {
+			return errors.New("profiler must not be nil")
+		}
+		appCtx.addRunner("profiler", run)
+		return nil
+	}
+}
@@ -0,0 +1,58 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingProfiler records its lifecycle in events.
+type recordingProfiler struct {
+	events   []string
+	startErr error
+	stopErr  error
+}
+
+func (p *recordingProfiler) Start(ctx context.Context) error {
+	p.events = append(p.events, "start")
+	return p.startErr
+}
+
+func (p *recordingProfiler) Stop(ctx context.Context) error {
+	_, hasDeadline := ctx.Deadline()
+	if ctx.Err() != nil || !hasDeadline {
+		return errors.New("flush context should be live and bounded")
+	}
+	p.events = append(p.events, "stop")
+	return p.stopErr
+}
+
+func TestRunEWithProfiler(t *testing.T) {
+	profiler := &recordingProfiler{}
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(WithRunners(failingRunner), WithProfiler(profiler))
+	})
+
+	assert.ErrorContains(t, err, "runner failed")
+	assert.Equal(t, []string{"start", "stop"}, profiler.events, "the profiler should be flushed on shutdown")
+}
+
+func TestWithProfilerFailures(t *testing.T) {
+	appCtx, err := Construct(WithProfiler(&recordingProfiler{startErr: errors.New("profile already running")}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"profiler"}, appCtx.RunnerNames())
+	assert.ErrorContains(t, appCtx.runnerList[0](context.Background()), "failed to start profiler")
+
+	appCtx, err = Construct(WithProfiler(&recordingProfiler{stopErr: errors.New("upload failed")}))
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorContains(t, appCtx.runnerList[0](ctx), "failed to flush profiles")
+
+	_, err = Construct(WithProfiler(nil))
+	assert.Error(t, err)
+}
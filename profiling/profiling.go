@@ -0,0 +1,25 @@
+// Package profiling integrates continuous profilers, which sample the running
+// application and upload the profiles to a backend such as Pyroscope, with
+// the application lifecycle.
+package profiling
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultFlushTimeout bounds the final upload when a profiler is stopped.
+const DefaultFlushTimeout = 5 * time.Second
+
+// Profiler is a continuous profiler. Adapters for profiling backends
+// implement it; Pyroscope is included.
+type Profiler interface {
+
+	// Start begins profiling in the background and returns once profiling
+	// has started.
+	Start(ctx context.Context) error
+
+	// Stop ends profiling and flushes the profiles not yet uploaded,
+	// returning once they are uploaded or ctx is done.
+	Stop(ctx context.Context) error
+}
@@ -0,0 +1,243 @@
+package profiling
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/config"
+)
+
+// PyroscopeConfig configures a Pyroscope profiler. It is typically loaded
+// from the environment with LoadPyroscopeConfig, using the variables of the
+// Pyroscope agent.
+type PyroscopeConfig struct {
+
+	// ServerAddress is the URL of the Pyroscope server.
+	ServerAddress string `env:"PYROSCOPE_SERVER_ADDRESS,required=true"`
+
+	// ApplicationName names the application in Pyroscope.
+	ApplicationName string `env:"PYROSCOPE_APPLICATION_NAME,required=true"`
+
+	// AuthToken, if set, is sent as a bearer token.
+	AuthToken string `env:"PYROSCOPE_AUTH_TOKEN" resolve:"true"`
+
+	// BasicAuthUser and BasicAuthPassword, if set, are sent as basic
+	// authentication credentials, as Grafana Cloud expects.
+	BasicAuthUser     string `env:"PYROSCOPE_BASIC_AUTH_USER"`
+	BasicAuthPassword string `env:"PYROSCOPE_BASIC_AUTH_PASSWORD" resolve:"true"`
+
+	// TenantID, if set, selects the tenant of a multi-tenant server.
+	TenantID string `env:"PYROSCOPE_TENANT_ID"`
+
+	// UploadInterval is how often profiles are uploaded.
+	UploadInterval time.Duration `env:"PYROSCOPE_UPLOAD_INTERVAL,default=10s"`
+
+	// Tags are attached to every profile, e.g. the instance ID or region.
+	Tags map[string]string
+}
+
+// LoadPyroscopeConfig loads a PyroscopeConfig from the environment:
+//
+//   - PYROSCOPE_SERVER_ADDRESS: server URL (required)
+//   - PYROSCOPE_APPLICATION_NAME: application name (required)
+//   - PYROSCOPE_AUTH_TOKEN: bearer token
+//   - PYROSCOPE_BASIC_AUTH_USER, PYROSCOPE_BASIC_AUTH_PASSWORD: basic auth
+//   - PYROSCOPE_TENANT_ID: tenant of a multi-tenant server
+//   - PYROSCOPE_UPLOAD_INTERVAL: upload interval (default: 10s)
+func LoadPyroscopeConfig() (PyroscopeConfig, error) {
+	cfg, err := config.LoadVar[PyroscopeConfig](config.WithPrefix("PYROSCOPE_"))
+	if err != nil {
+		return PyroscopeConfig{}, fmt.Errorf("failed to load pyroscope configuration from environment: %w", err)
+	}
+	if cfg.UploadInterval <= 0 {
+		return PyroscopeConfig{}, fmt.Errorf("PYROSCOPE_UPLOAD_INTERVAL must be positive, got %s", cfg.UploadInterval)
+	}
+	return cfg, nil
+}
+
+// Pyroscope is a Profiler that records CPU profiles and snapshots the heap,
+// uploading both to a Pyroscope server's ingest API every UploadInterval.
+// Only one CPU profile can be recorded per process at a time, so Start fails
+// if another CPU profile is running.
+type Pyroscope struct {
+	cfg    PyroscopeConfig
+	client *http.Client
+
+	mu      sync.Mutex
+	cpu     bytes.Buffer
+	from    time.Time
+	stop    chan struct{}
+	done    chan struct{}
+	lastErr error
+}
+
+// NewPyroscope creates a Pyroscope profiler from the given configuration.
+func NewPyroscope(cfg PyroscopeConfig) *Pyroscope {
+	return &Pyroscope{cfg: cfg, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Start starts recording and the periodic uploads.
+func (p *Pyroscope) Start(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		return errors.New("pyroscope profiler already started")
+	}
+	if err := p.startCPU(); err != nil {
+		return err
+	}
+
+	p.stop, p.done = make(chan struct{}), make(chan struct{})
+	go p.loop(context.WithoutCancel(ctx), p.stop, p.done)
+	return nil
+}
+
+// Stop stops recording and uploads the profiles recorded since the last
+// upload. It returns the error of the final upload, or of the last periodic
+// upload if that failed.
+func (p *Pyroscope) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	stop, done := p.stop, p.done
+	p.mu.Unlock()
+	if stop == nil {
+		return nil
+	}
+
+	close(stop)
+	<-done
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stop = nil
+	flushErr := p.flush(ctx)
+	return errors.Join(flushErr, p.lastErr)
+}
+
+// loop uploads the recorded profiles every interval until stopped, leaving
+// the final upload to Stop.
+func (p *Pyroscope) loop(ctx context.Context, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(p.cfg.UploadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.mu.Lock()
+			p.lastErr = p.flush(ctx)
+			if err := p.startCPU(); err != nil {
+				p.lastErr = errors.Join(p.lastErr, err)
+			}
+			p.mu.Unlock()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// startCPU starts recording a CPU profile. It must be called with the lock
+// held.
+func (p *Pyroscope) startCPU() error {
+	p.cpu.Reset()
+	p.from = time.Now()
+	if err := pprof.StartCPUProfile(&p.cpu); err != nil {
+		return fmt.Errorf("failed to start CPU profile: %w", err)
+	}
+	return nil
+}
+
+// flush stops the CPU profile and uploads it with a heap snapshot. It must
+// be called with the lock held.
+func (p *Pyroscope) flush(ctx context.Context) error {
+	pprof.StopCPUProfile()
+	until := time.Now()
+
+	var heap bytes.Buffer
+	if err := pprof.Lookup("heap").WriteTo(&heap, 0); err != nil {
+		return fmt.Errorf("failed to write heap profile: %w", err)
+	}
+
+	return errors.Join(
+		p.upload(ctx, "cpu", p.cpu.Bytes(), p.from, until),
+		p.upload(ctx, "memory", heap.Bytes(), p.from, until),
+	)
+}
+
+// upload sends a pprof-encoded profile to the ingest API.
+func (p *Pyroscope) upload(ctx context.Context, kind string, profile []byte, from, until time.Time) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("profile", "profile.pprof")
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(profile); err != nil {
+		return err
+	}
+	if err := form.Close(); err != nil {
+		return err
+	}
+
+	query := url.Values{
+		"name":       {p.name(kind)},
+		"from":       {fmt.Sprint(from.Unix())},
+		"until":      {fmt.Sprint(until.Unix())},
+		"format":     {"pprof"},
+		"spyName":    {"gospy"},
+		"units":      {"samples"},
+		"sampleRate": {"100"},
+	}
+	endpoint := strings.TrimSuffix(p.cfg.ServerAddress, "/") + "/ingest?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	switch {
+	case p.cfg.AuthToken != "":
+		req.Header.Set("Authorization", "Bearer "+p.cfg.AuthToken)
+	case p.cfg.BasicAuthUser != "":
+		req.SetBasicAuth(p.cfg.BasicAuthUser, p.cfg.BasicAuthPassword)
+	}
+	if p.cfg.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", p.cfg.TenantID)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s profile: %w", kind, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to upload %s profile: unexpected status %s", kind, resp.Status)
+	}
+	return nil
+}
+
+// name returns the application name with the profile kind and the tags, in
+// Pyroscope's "app.kind{key=value,...}" form.
+func (p *Pyroscope) name(kind string) string {
+	keys := make([]string, 0, len(p.cfg.Tags))
+	for key := range p.cfg.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tags := make([]string, 0, len(keys))
+	for _, key := range keys {
+		tags = append(tags, key+"="+p.cfg.Tags[key])
+	}
+	return fmt.Sprintf("%s.%s{%s}", p.cfg.ApplicationName, kind, strings.Join(tags, ","))
+}
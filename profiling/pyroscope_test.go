@@ -0,0 +1,115 @@
+package profiling
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ingestRequest is an upload recorded by the fake Pyroscope server.
+type ingestRequest struct {
+	Name          string
+	Authorization string
+	Profile       []byte
+}
+
+// newFakePyroscope starts an HTTP server recording ingest requests.
+func newFakePyroscope(t *testing.T, status int) (*httptest.Server, func() []ingestRequest) {
+	var mu sync.Mutex
+	var requests []ingestRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/ingest", r.URL.Path)
+		assert.Equal(t, "pprof", r.URL.Query().Get("format"))
+		file, _, err := r.FormFile("profile")
+		require.NoError(t, err)
+		profile, _ := io.ReadAll(file)
+
+		mu.Lock()
+		requests = append(requests, ingestRequest{
+			Name:          r.URL.Query().Get("name"),
+			Authorization: r.Header.Get("Authorization"),
+			Profile:       profile,
+		})
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []ingestRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]ingestRequest{}, requests...)
+	}
+}
+
+func TestLoadPyroscopeConfig(t *testing.T) {
+	t.Run("from environment", func(t *testing.T) {
+		t.Setenv("PYROSCOPE_SERVER_ADDRESS", "http://pyroscope:4040")
+		t.Setenv("PYROSCOPE_APPLICATION_NAME", "orders")
+
+		cfg, err := LoadPyroscopeConfig()
+
+		require.NoError(t, err)
+		assert.Equal(t, "http://pyroscope:4040", cfg.ServerAddress)
+		assert.Equal(t, "orders", cfg.ApplicationName)
+		assert.Equal(t, 10*time.Second, cfg.UploadInterval)
+	})
+
+	t.Run("server address required", func(t *testing.T) {
+		t.Setenv("PYROSCOPE_SERVER_ADDRESS", "")
+		os.Unsetenv("PYROSCOPE_SERVER_ADDRESS")
+		t.Setenv("PYROSCOPE_APPLICATION_NAME", "orders")
+
+		_, err := LoadPyroscopeConfig()
+
+		assert.ErrorContains(t, err, "PYROSCOPE_SERVER_ADDRESS")
+	})
+}
+
+func TestPyroscopeUploads(t *testing.T) {
+	server, requests := newFakePyroscope(t, http.StatusOK)
+	profiler := NewPyroscope(PyroscopeConfig{
+		ServerAddress:   server.URL,
+		ApplicationName: "orders",
+		AuthToken:       "secret",
+		UploadInterval:  50 * time.Millisecond,
+		Tags:            map[string]string{"region": "eu", "instance_id": "orders-1"},
+	})
+
+	require.NoError(t, profiler.Start(context.Background()))
+	assert.Error(t, NewPyroscope(PyroscopeConfig{UploadInterval: time.Second}).Start(context.Background()),
+		"a second CPU profile should not start")
+	time.Sleep(120 * time.Millisecond)
+	require.NoError(t, profiler.Stop(context.Background()))
+
+	recorded := requests()
+	require.GreaterOrEqual(t, len(recorded), 4, "periodic uploads and the final flush should be sent")
+	assert.Equal(t, "orders.cpu{instance_id=orders-1,region=eu}", recorded[0].Name)
+	assert.Equal(t, "orders.memory{instance_id=orders-1,region=eu}", recorded[1].Name)
+	assert.Equal(t, "Bearer secret", recorded[0].Authorization)
+	assert.NotEmpty(t, recorded[0].Profile)
+
+	// Stopping again is a no-op, and the CPU profiler is free for reuse
+	assert.NoError(t, profiler.Stop(context.Background()))
+	require.NoError(t, profiler.Start(context.Background()))
+	require.NoError(t, profiler.Stop(context.Background()))
+}
+
+func TestPyroscopeUploadFailure(t *testing.T) {
+	server, _ := newFakePyroscope(t, http.StatusUnauthorized)
+	profiler := NewPyroscope(PyroscopeConfig{ServerAddress: server.URL, ApplicationName: "orders", UploadInterval: time.Minute})
+
+	require.NoError(t, profiler.Start(context.Background()))
+	err := profiler.Stop(context.Background())
+
+	assert.ErrorContains(t, err, "unexpected status 401")
+}
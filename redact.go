@@ -0,0 +1,95 @@
+package ezapp
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+)
+
+// RedactedValue replaces the parts of log attribute values masked by
+// RedactMatches.
+const RedactedValue = "[REDACTED]"
+
+// WithLogRedactor applies redact to every attribute logged through the
+// application's logger, including the lifecycle logs and the loggers derived
+// from InitCtx.Logger, before records leave the process. Attributes within
+// groups are passed to redact individually. Log messages are not redacted, so
+// keep personal data in attributes.
+//
+// Example:
+//
+//	email := regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`)
+//	ezapp.Run(initializer, ezapp.WithLogRedactor(ezapp.RedactMatches(email)))
+func WithLogRedactor(redact func(attr slog.Attr) slog.Attr) RunOption {
+	return func(settings *runopt.Settings) {
+		settings.LogRedactor = redact
+	}
+}
+
+// RedactMatches returns a redactor for WithLogRedactor that replaces the
+// parts of string attribute values matching any of patterns with
+// RedactedValue.
+func RedactMatches(patterns ...*regexp.Regexp) func(attr slog.Attr) slog.Attr {
+	return func(attr slog.Attr) slog.Attr {
+		if attr.Value.Kind() != slog.KindString {
+			return attr
+		}
+		value := attr.Value.String()
+		for _, pattern := range patterns {
+			value = pattern.ReplaceAllString(value, RedactedValue)
+		}
+		return slog.String(attr.Key, value)
+	}
+}
+
+// redactHandler applies a redactor to the attributes of every record before
+// passing it on.
+type redactHandler struct {
+	next   slog.Handler
+	redact func(attr slog.Attr) slog.Attr
+}
+
+// Enabled reports whether the wrapped handler handles level.
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle passes a copy of record with redacted attributes on.
+func (h *redactHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.apply(attr))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+// WithAttrs redacts attrs before adding them to the wrapped handler.
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for idx, attr := range attrs {
+		redacted[idx] = h.apply(attr)
+	}
+	return &redactHandler{next: h.next.WithAttrs(redacted), redact: h.redact}
+}
+
+// WithGroup opens a group in the wrapped handler.
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name), redact: h.redact}
+}
+
+// apply redacts attr, or each attribute of a group.
+func (h *redactHandler) apply(attr slog.Attr) slog.Attr {
+	attr.Value = attr.Value.Resolve()
+	if attr.Value.Kind() != slog.KindGroup {
+		return h.redact(attr)
+	}
+	members := attr.Value.Group()
+	redacted := make([]slog.Attr, len(members))
+	for idx, member := range members {
+		redacted[idx] = h.apply(member)
+	}
+	return slog.Attr{Key: attr.Key, Value: slog.GroupValue(redacted...)}
+}
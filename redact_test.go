@@ -0,0 +1,49 @@
+package ezapp
+
+import (
+	"bytes"
+	"log/slog"
+	"regexp"
+	"testing"
+
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactHandler(t *testing.T) {
+	var buf bytes.Buffer
+	email := regexp.MustCompile(`[\w.+-]+@[\w-]+\.[\w.]+`)
+	token := regexp.MustCompile(`Bearer \S+`)
+	logger := slog.New(&redactHandler{
+		next:   slog.NewTextHandler(&buf, nil),
+		redact: RedactMatches(email, token),
+	})
+
+	logger.With("user", "jane@example.com").
+		WithGroup("request").
+		Info("signed in",
+			"auth", "Bearer abc.def",
+			"attempts", 2,
+			slog.Group("client", "contact", "ops@example.com"),
+		)
+
+	out := buf.String()
+	assert.NotContains(t, out, "example.com")
+	assert.NotContains(t, out, "abc.def")
+	assert.Contains(t, out, "user=[REDACTED]")
+	assert.Contains(t, out, `request.auth=[REDACTED]`)
+	assert.Contains(t, out, "request.attempts=2")
+	assert.Contains(t, out, "request.client.contact=[REDACTED]")
+	assert.Contains(t, out, `msg="signed in"`)
+}
+
+func TestWithLogRedactor(t *testing.T) {
+	var settings runopt.Settings
+	WithLogRedactor(func(attr slog.Attr) slog.Attr {
+		return slog.String(attr.Key, "masked")
+	})(&settings)
+
+	require.NotNil(t, settings.LogRedactor)
+	assert.Equal(t, "masked", settings.LogRedactor(slog.String("token", "secret")).Value.String())
+}
@@ -0,0 +1,112 @@
+package ezapp
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/resources"
+)
+
+// PostRunHookTimeout is the total time post-run hooks are given to complete.
+// Once it elapses the application exits regardless, so a hanging hook can
+// never prevent the process from terminating.
+const PostRunHookTimeout = 5 * time.Second
+
+// ShutdownReport summarizes how the application run ended. It is passed to
+// post-run hooks after cleanup has completed.
+type ShutdownReport struct {
+
+	// InstanceID identifies the run that shut down, as InitCtx.InstanceID.
+	InstanceID string
+
+	// State is the terminal lifecycle state: StateStopped or StateFailed.
+	State State
+
+	// StartedAt is the time the runners were started.
+	StartedAt time.Time
+
+	// StoppedAt is the time cleanup completed.
+	StoppedAt time.Time
+
+	// RunErr is the error returned by the runners, if any.
+	RunErr error
+
+	// CleanupErr is the error returned by the cleanup function, if any.
+	CleanupErr error
+
+	// Results holds the values produced by runners added with
+	// WithResultRunner that completed successfully.
+	Results *Results
+
+	// Profiles lists the profiles captured with WithProfileCapture, if any.
+	Profiles []string
+
+	// Trace holds the most recent lifecycle events, as Runtime.Trace.
+	Trace LifecycleTrace
+
+	// Unreleased lists the resources in InitCtx.Resources that were still
+	// open once cleanup had completed.
+	Unreleased []resources.Resource
+}
+
+// Duration returns how long the application ran, including cleanup.
+func (r ShutdownReport) Duration() time.Duration {
+	return r.StoppedAt.Sub(r.StartedAt)
+}
+
+// Err returns the combined run and cleanup error, or nil if both succeeded.
+func (r ShutdownReport) Err() error {
+	return errors.Join(r.RunErr, r.CleanupErr)
+}
+
+// WithPostRunHook is a functional option that registers a hook executed after
+// cleanup has completed and immediately before the process exits, whether
+// the run succeeded or failed. It is the last chance to flush metrics or
+// report crashes.
+//
+// Hooks run sequentially in registration order and share a strict budget of
+// PostRunHookTimeout; hooks still running when it elapses are abandoned.
+//
+// Example:
+//
+//	appCtx, err := Construct(
+//	    WithRunners(job.Run),
+//	    WithPostRunHook(func(report ShutdownReport) {
+//	        metrics.Flush()
+//	        if err := report.Err(); err != nil {
+//	            crashReporter.Capture(err)
+//	        }
+//	    }),
+//	)
+func WithPostRunHook(hook func(report ShutdownReport)) option {
+	return func(appCtx *AppCtx) error {
+		appCtx.postRunHooks = append(appCtx.postRunHooks, hook)
+		return nil
+	}
+}
+
+// runPostRunHooks invokes each hook with the report, abandoning the hooks
+// once timeout elapses.
+func runPostRunHooks(logger *slog.Logger, hooks []func(report ShutdownReport), report ShutdownReport, timeout time.Duration) {
+	if len(hooks) == 0 {
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, hook := range hooks {
+			hook(report)
+		}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+		logger.Warn("post-run hooks did not complete in time", "timeout", timeout)
+	}
+}
@@ -0,0 +1,104 @@
+package ezapp
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestShutdownReport tests the derived values of a ShutdownReport
+func TestShutdownReport(t *testing.T) {
+	startedAt := time.Now()
+	report := ShutdownReport{
+		State:     StateStopped,
+		StartedAt: startedAt,
+		StoppedAt: startedAt.Add(3 * time.Second),
+	}
+
+	assert.Equal(t, 3*time.Second, report.Duration())
+	assert.NoError(t, report.Err())
+
+	runErr := errors.New("runner failed")
+	cleanupErr := errors.New("cleanup failed")
+	report.RunErr = runErr
+	report.CleanupErr = cleanupErr
+
+	assert.ErrorIs(t, report.Err(), runErr)
+	assert.ErrorIs(t, report.Err(), cleanupErr)
+}
+
+// TestRunPostRunHooks tests post-run hook execution
+// This test verifies that:
+// - Hooks run in registration order with the report
+// - Hooks exceeding the timeout are abandoned with a warning
+func TestRunPostRunHooks(t *testing.T) {
+	t.Run("runs hooks in order", func(t *testing.T) {
+		logger, _ := testutil.NewTestLogger(slog.LevelDebug)
+		report := ShutdownReport{State: StateFailed}
+
+		var order []int
+		hooks := []func(ShutdownReport){
+			func(r ShutdownReport) {
+				assert.Equal(t, StateFailed, r.State)
+				order = append(order, 1)
+			},
+			func(r ShutdownReport) { order = append(order, 2) },
+		}
+
+		runPostRunHooks(logger, hooks, report, time.Second)
+
+		assert.Equal(t, []int{1, 2}, order)
+	})
+
+	t.Run("abandons hanging hooks", func(t *testing.T) {
+		logger, logs := testutil.NewTestLogger(slog.LevelDebug)
+		release := make(chan struct{})
+		defer close(release)
+		hooks := []func(ShutdownReport){
+			func(r ShutdownReport) { <-release },
+		}
+
+		start := time.Now()
+		runPostRunHooks(logger, hooks, ShutdownReport{}, 20*time.Millisecond)
+
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Contains(t, logs.Messages(), "post-run hooks did not complete in time")
+	})
+}
+
+// TestRunWithPostRunHook tests that post-run hooks receive the final report
+func TestRunWithPostRunHook(t *testing.T) {
+	var report ShutdownReport
+	var cleanupCalled bool
+
+	initializer := func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithRunners(successfulRunner),
+			WithCleanup(createRecorderCleanup(&cleanupCalled)),
+			WithPostRunHook(func(r ShutdownReport) {
+				assert.True(t, cleanupCalled, "Post-run hooks should run after cleanup")
+				report = r
+			}),
+		)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Run(initializer)
+	}()
+
+	select {
+	case <-done:
+		assert.Equal(t, StateStopped, report.State)
+		assert.NoError(t, report.Err())
+		assert.False(t, report.StartedAt.IsZero())
+		assert.GreaterOrEqual(t, report.Duration(), time.Duration(0))
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not complete within timeout")
+	}
+}
@@ -0,0 +1,41 @@
+package ezapp
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+	"github.com/pgvanniekerk/ezapp/resources"
+)
+
+// WithResourceRelease releases the resources still tracked in
+// InitCtx.Resources once cleanup has completed, after logging them as
+// leaked. Without it, leaked resources are only logged.
+//
+// Example:
+//
+//	ezapp.Run(initializer, ezapp.WithResourceRelease())
+func WithResourceRelease() RunOption {
+	return func(settings *runopt.Settings) {
+		settings.ReleaseResources = true
+	}
+}
+
+// reportResources logs every resource in registry that is still open after
+// cleanup, releasing them if release is set, and returns them.
+func reportResources(logger *slog.Logger, registry *resources.Registry, release bool) []resources.Resource {
+	open := registry.Open()
+	for _, resource := range open {
+		logger.Warn("resource not released",
+			"kind", resource.Kind,
+			"name", resource.Name,
+			"open_for", time.Since(resource.OpenedAt).Round(time.Millisecond).String(),
+		)
+	}
+	if release && len(open) > 0 {
+		if err := registry.ReleaseAll(); err != nil {
+			logger.Error("failed to release resources", "error", err)
+		}
+	}
+	return open
+}
@@ -0,0 +1,200 @@
+// Package resources provides a registry of open handles, such as files,
+// sockets and temporary directories, that components and runners record
+// while they hold them. ezapp creates one registry per application, exposes
+// it as InitCtx.Resources and logs every resource still open when the
+// application exits, catching leaks that only matter across many restarts.
+package resources
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Kind classifies a tracked resource.
+type Kind string
+
+const (
+	// KindFile is an open file.
+	KindFile Kind = "file"
+
+	// KindSocket is an open network connection or listener.
+	KindSocket Kind = "socket"
+
+	// KindTempDir is a temporary directory that should be removed.
+	KindTempDir Kind = "tempdir"
+)
+
+// Resource describes a tracked resource.
+type Resource struct {
+	Kind     Kind      `json:"kind"`
+	Name     string    `json:"name"`
+	OpenedAt time.Time `json:"opened_at"`
+}
+
+// Handle is returned when a resource is tracked and is used to record that
+// it has been released.
+type Handle struct {
+	registry *Registry
+	id       uint64
+	release  func() error
+	once     sync.Once
+	err      error
+}
+
+// Release releases the resource and stops tracking it. Subsequent calls
+// return the result of the first one.
+func (h *Handle) Release() error {
+	h.once.Do(func() {
+		h.registry.untrack(h.id)
+		if h.release != nil {
+			h.err = h.release()
+		}
+	})
+	return h.err
+}
+
+// Released stops tracking the resource without releasing it, for resources
+// closed by their owner directly.
+func (h *Handle) Released() {
+	h.once.Do(func() {
+		h.registry.untrack(h.id)
+	})
+}
+
+// entry is a tracked resource together with its handle.
+type entry struct {
+	resource Resource
+	handle   *Handle
+}
+
+// Registry records the resources currently held by an application. It is
+// safe for concurrent use. The zero value is not usable; create registries
+// with New.
+type Registry struct {
+	mu      sync.Mutex
+	entries map[uint64]entry
+	nextID  uint64
+}
+
+// New creates an empty Registry.
+func New() *Registry {
+	return &Registry{entries: make(map[uint64]entry)}
+}
+
+// Track records an open resource of the given kind. release, which may be
+// nil, releases the resource; it is called by Handle.Release and by
+// ReleaseAll.
+//
+// Example:
+//
+//	lock, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL, 0o600)
+//	if err != nil {
+//	    return ezapp.AppCtx{}, err
+//	}
+//	handle := ctx.Resources.Track(resources.KindFile, path, func() error {
+//	    lock.Close()
+//	    return os.Remove(path)
+//	})
+//	defer handle.Release()
+func (r *Registry) Track(kind Kind, name string, release func() error) *Handle {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	handle := &Handle{registry: r, id: r.nextID, release: release}
+	r.entries[handle.id] = entry{
+		resource: Resource{Kind: kind, Name: name, OpenedAt: time.Now()},
+		handle:   handle,
+	}
+	return handle
+}
+
+// TrackFile records an open file, released by closing it.
+func (r *Registry) TrackFile(file *os.File) *Handle {
+	return r.Track(KindFile, file.Name(), file.Close)
+}
+
+// TrackConn records an open network connection, named after its remote
+// address and released by closing it.
+func (r *Registry) TrackConn(conn net.Conn) *Handle {
+	return r.Track(KindSocket, conn.RemoteAddr().String(), conn.Close)
+}
+
+// TrackListener records an open network listener, named after its address
+// and released by closing it.
+func (r *Registry) TrackListener(listener net.Listener) *Handle {
+	return r.Track(KindSocket, listener.Addr().String(), listener.Close)
+}
+
+// TempDir creates a temporary directory with os.MkdirTemp and records it,
+// released by removing it and its contents.
+//
+// Example:
+//
+//	dir, handle, err := ctx.Resources.TempDir("", "orders-")
+//	if err != nil {
+//	    return ezapp.AppCtx{}, err
+//	}
+//	return ezapp.Construct(
+//	    ezapp.WithRunners(spool.Run(dir)),
+//	    ezapp.WithCleanupStep("spool", func(context.Context) error { return handle.Release() }),
+//	)
+func (r *Registry) TempDir(dir, pattern string) (string, *Handle, error) {
+	path, err := os.MkdirTemp(dir, pattern)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	return path, r.Track(KindTempDir, path, func() error { return os.RemoveAll(path) }), nil
+}
+
+// Open returns the resources that have not been released, oldest first.
+func (r *Registry) Open() []Resource {
+	entries := r.open()
+	open := make([]Resource, 0, len(entries))
+	for _, e := range entries {
+		open = append(open, e.resource)
+	}
+	return open
+}
+
+// ReleaseAll releases every resource that has not been released, newest
+// first, and returns the joined errors of the failing releases.
+func (r *Registry) ReleaseAll() error {
+	entries := r.open()
+	var errs []error
+	for i := len(entries) - 1; i >= 0; i-- {
+		if err := entries[i].handle.Release(); err != nil {
+			e := entries[i].resource
+			errs = append(errs, fmt.Errorf("failed to release %s %s: %w", e.Kind, e.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// open returns the tracked entries in the order they were tracked.
+func (r *Registry) open() []entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		entries = append(entries, e)
+	}
+	slices.SortFunc(entries, func(a, b entry) int {
+		return cmp.Compare(a.handle.id, b.handle.id)
+	})
+	return entries
+}
+
+// untrack stops tracking the resource with the given ID.
+func (r *Registry) untrack(id uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.entries, id)
+}
@@ -0,0 +1,118 @@
+package resources
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryTrack(t *testing.T) {
+	registry := New()
+	released := 0
+
+	first := registry.Track(KindFile, "first", func() error {
+		released++
+		return nil
+	})
+	registry.Track(KindSocket, "second", nil)
+
+	open := registry.Open()
+	require.Len(t, open, 2)
+	assert.Equal(t, Resource{Kind: KindFile, Name: "first", OpenedAt: open[0].OpenedAt}, open[0])
+	assert.Equal(t, "second", open[1].Name)
+	assert.False(t, open[0].OpenedAt.IsZero())
+
+	require.NoError(t, first.Release())
+	require.NoError(t, first.Release())
+	assert.Equal(t, 1, released, "a resource should only be released once")
+
+	open = registry.Open()
+	require.Len(t, open, 1)
+	assert.Equal(t, "second", open[0].Name)
+}
+
+func TestHandleReleased(t *testing.T) {
+	registry := New()
+	handle := registry.Track(KindFile, "report.csv", func() error {
+		t.Fatal("Released should not release the resource")
+		return nil
+	})
+
+	handle.Released()
+
+	assert.Empty(t, registry.Open())
+	assert.NoError(t, handle.Release())
+}
+
+func TestRegistryReleaseAll(t *testing.T) {
+	registry := New()
+	var order []string
+	track := func(name string, err error) {
+		registry.Track(KindFile, name, func() error {
+			order = append(order, name)
+			return err
+		})
+	}
+	track("first", nil)
+	track("second", errors.New("busy"))
+	track("third", nil)
+
+	err := registry.ReleaseAll()
+
+	assert.EqualError(t, err, "failed to release file second: busy")
+	assert.Equal(t, []string{"third", "second", "first"}, order)
+	assert.Empty(t, registry.Open())
+}
+
+func TestRegistryTrackFile(t *testing.T) {
+	registry := New()
+	file, err := os.Create(filepath.Join(t.TempDir(), "data"))
+	require.NoError(t, err)
+
+	handle := registry.TrackFile(file)
+	assert.Equal(t, []Resource{{Kind: KindFile, Name: file.Name(), OpenedAt: registry.Open()[0].OpenedAt}}, registry.Open())
+
+	require.NoError(t, handle.Release())
+	assert.ErrorIs(t, file.Close(), os.ErrClosed)
+}
+
+func TestRegistryTrackSockets(t *testing.T) {
+	registry := New()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+
+	registry.TrackListener(listener)
+	registry.TrackConn(conn)
+
+	open := registry.Open()
+	require.Len(t, open, 2)
+	assert.Equal(t, KindSocket, open[0].Kind)
+	assert.Equal(t, listener.Addr().String(), open[0].Name)
+	assert.Equal(t, conn.RemoteAddr().String(), open[1].Name)
+
+	require.NoError(t, registry.ReleaseAll())
+	_, err = listener.Accept()
+	assert.ErrorIs(t, err, net.ErrClosed)
+}
+
+func TestRegistryTempDir(t *testing.T) {
+	registry := New()
+
+	dir, handle, err := registry.TempDir(t.TempDir(), "spool-")
+	require.NoError(t, err)
+	assert.DirExists(t, dir)
+	assert.Equal(t, KindTempDir, registry.Open()[0].Kind)
+
+	require.NoError(t, handle.Release())
+	assert.NoDirExists(t, dir)
+
+	_, _, err = registry.TempDir(filepath.Join(t.TempDir(), "missing"), "spool-")
+	assert.ErrorContains(t, err, "failed to create temporary directory")
+}
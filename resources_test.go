@@ -0,0 +1,66 @@
+package ezapp
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/pgvanniekerk/ezapp/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportResources(t *testing.T) {
+	logger, handler := testutil.NewTestLogger(slog.LevelDebug)
+	registry := resources.New()
+	released := false
+	registry.Track(resources.KindFile, "/tmp/orders.lock", func() error {
+		released = true
+		return nil
+	})
+	registry.Track(resources.KindSocket, "closed", nil).Released()
+
+	unreleased := reportResources(logger, registry, false)
+
+	require.Len(t, unreleased, 1)
+	assert.Equal(t, "/tmp/orders.lock", unreleased[0].Name)
+	value, ok := handler.Attr("resource not released", "name")
+	require.True(t, ok)
+	assert.Equal(t, "/tmp/orders.lock", value.String())
+	assert.False(t, released, "resources should only be released with WithResourceRelease")
+
+	reportResources(logger, registry, true)
+	assert.True(t, released)
+	assert.Empty(t, registry.Open())
+}
+
+func TestRunEResourceRelease(t *testing.T) {
+	var report ShutdownReport
+	var released []string
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		require.NotNil(t, ctx.Resources)
+		track := func(name string) *resources.Handle {
+			return ctx.Resources.Track(resources.KindSocket, name, func() error {
+				released = append(released, name)
+				return nil
+			})
+		}
+		pool := track("pool")
+		track("leaked")
+
+		return Construct(
+			WithRunners(successfulRunner),
+			WithCleanupStep("pool", func(context.Context) error { return pool.Release() }),
+			WithPostRunHook(func(r ShutdownReport) {
+				report = r
+			}),
+		)
+	}, WithResourceRelease())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pool", "leaked"}, released)
+	require.Len(t, report.Unreleased, 1)
+	assert.Equal(t, "leaked", report.Unreleased[0].Name)
+}
@@ -0,0 +1,135 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/pgvanniekerk/ezapp/internal/app"
+)
+
+// RunnerWithResult is a runner that produces a value when it completes, such
+// as the summary of an ETL step. Register it with WithResultRunner.
+type RunnerWithResult[T any] func(ctx context.Context) (T, error)
+
+// Results collects the values produced by result runners, keyed by runner
+// name. It is available to cleanup through ResultsFromContext and to post-run
+// hooks as ShutdownReport.Results. A nil *Results holds no values.
+type Results struct {
+	mu     sync.Mutex
+	values map[string]any
+}
+
+// set records the value produced by the named runner. Values set on a nil
+// *Results are discarded.
+func (r *Results) set(name string, value any) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.values == nil {
+		r.values = make(map[string]any)
+	}
+	r.values[name] = value
+}
+
+// Get returns the value produced by the named runner and whether the runner
+// completed successfully.
+func (r *Results) Get(name string) (any, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	value, ok := r.values[name]
+	return value, ok
+}
+
+// Names returns the names of the runners that produced a value, in lexical
+// order.
+func (r *Results) Names() []string {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.values))
+	for name := range r.values {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ResultOf returns the value produced by the named runner as a T. The boolean
+// is false if the runner has not produced a value or its value is not a T.
+//
+// Example:
+//
+//	summary, ok := ezapp.ResultOf[LoadSummary](report.Results, "load")
+func ResultOf[T any](results *Results, name string) (T, bool) {
+	value, ok := results.Get(name)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	typed, ok := value.(T)
+	return typed, ok
+}
+
+// WithResultRunner is a functional option that adds a named runner producing
+// a result. When the runner returns without error, its value is recorded in
+// the application's Results under the runner's name; failures are reported
+// like those of any other runner and record no value. An empty name is
+// rejected by Construct, since the value could not be looked up.
+//
+// This suits batch-mode applications whose steps pass summary data to a
+// final reporting step in cleanup or a post-run hook.
+//
+// Example:
+//
+//	appCtx, err := Construct(
+//	    WithResultRunner("extract", func(ctx context.Context) (int, error) {
+//	        return extractor.Run(ctx)
+//	    }),
+//	    WithPostRunHook(func(report ShutdownReport) {
+//	        rows, _ := ResultOf[int](report.Results, "extract")
+//	        logger.Info("extract finished", "rows", rows)
+//	    }),
+//	)
+func WithResultRunner[T any](name string, r RunnerWithResult[T]) option {
+	return func(appCtx *AppCtx) error {
+		if name == "" {
+			return errors.New("result runner name cannot be empty")
+		}
+		appCtx.addRunner(name, app.Runner(func(ctx context.Context) error {
+			value, err := r(ctx)
+			if err != nil {
+				return err
+			}
+			ResultsFromContext(ctx).set(name, value)
+			return nil
+		}))
+		return nil
+	}
+}
+
+// resultsKey is the context key under which Results are stored.
+type resultsKey struct{}
+
+// ResultsFromContext returns the Results of the application from the context
+// passed to runners, cleanup functions and cleanup steps, or nil if ctx
+// carries none.
+//
+// Example:
+//
+//	WithCleanup(func(ctx context.Context) error {
+//	    rows, _ := ResultOf[int](ResultsFromContext(ctx), "extract")
+//	    return reporter.Send(ctx, rows)
+//	})
+func ResultsFromContext(ctx context.Context) *Results {
+	results, _ := ctx.Value(resultsKey{}).(*Results)
+	return results
+}
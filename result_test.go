@@ -0,0 +1,87 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type loadSummary struct {
+	Rows int
+}
+
+func TestWithResultRunner(t *testing.T) {
+	var cleanupRows int
+	var report ShutdownReport
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithResultRunner("extract", func(ctx context.Context) (int, error) {
+				return 42, nil
+			}),
+			WithResultRunner("load", func(ctx context.Context) (loadSummary, error) {
+				return loadSummary{Rows: 40}, nil
+			}),
+			WithCleanup(func(ctx context.Context) error {
+				cleanupRows, _ = ResultOf[int](ResultsFromContext(ctx), "extract")
+				return nil
+			}),
+			WithPostRunHook(func(r ShutdownReport) {
+				report = r
+			}),
+		)
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 42, cleanupRows)
+	assert.Equal(t, []string{"extract", "load"}, report.Results.Names())
+
+	summary, ok := ResultOf[loadSummary](report.Results, "load")
+	assert.True(t, ok)
+	assert.Equal(t, loadSummary{Rows: 40}, summary)
+
+	_, ok = ResultOf[string](report.Results, "load")
+	assert.False(t, ok, "a value of a different type should not be returned")
+}
+
+func TestWithResultRunnerFailure(t *testing.T) {
+	stepErr := errors.New("extract failed")
+	var report ShutdownReport
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithResultRunner("extract", func(ctx context.Context) (int, error) {
+				return 0, stepErr
+			}),
+			WithPostRunHook(func(r ShutdownReport) {
+				report = r
+			}),
+		)
+	})
+
+	var runnerErr *RunnerError
+	require.ErrorAs(t, err, &runnerErr)
+	assert.Equal(t, "extract", runnerErr.Name)
+
+	_, ok := report.Results.Get("extract")
+	assert.False(t, ok, "a failed runner should not record a value")
+}
+
+func TestWithResultRunnerEmptyName(t *testing.T) {
+	_, err := Construct(WithResultRunner("", func(context.Context) (int, error) {
+		return 1, nil
+	}))
+	assert.ErrorContains(t, err, "result runner name cannot be empty")
+}
+
+func TestResultsNil(t *testing.T) {
+	var results *Results
+
+	_, ok := results.Get("missing")
+	assert.False(t, ok)
+	assert.Empty(t, results.Names())
+	assert.Nil(t, ResultsFromContext(context.Background()))
+}
@@ -0,0 +1,196 @@
+// Package retry retries operations with exponential backoff and jitter,
+// honouring context cancellation. It is used by runner.Supervise and can be
+// used directly inside runner bodies and initializers.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Policy controls how Do retries a failing operation.
+type Policy struct {
+
+	// MaxAttempts limits the total number of attempts, including the first.
+	// Zero or a negative value retries until the operation succeeds or the
+	// context is done.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between attempts. If zero, the delay is not capped.
+	MaxBackoff time.Duration
+
+	// Multiplier is the factor the delay grows by after each retry. Values
+	// of 1 or less default to 2.
+	Multiplier float64
+
+	// Jitter randomizes each delay by up to the given fraction in either
+	// direction, e.g. 0.2 spreads a 1s delay over 800ms to 1.2s, so that
+	// many instances retrying at once do not synchronize. Zero disables
+	// jitter; values are clamped to [0, 1].
+	Jitter float64
+
+	// Retryable optionally decides whether an error is worth retrying.
+	// If nil, every error is retried unless wrapped with Permanent.
+	Retryable func(err error) bool
+
+	// OnRetry is optionally called before waiting for each retry with the
+	// number of the attempt that failed, its error and the delay before the
+	// next attempt. Use it to log or emit metrics for retries.
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// Default is a policy suited to most network calls: up to 5 attempts with
+// delays growing from 100ms to at most 10s and 20% jitter.
+var Default = Policy{
+	MaxAttempts:    5,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+	Jitter:         0.2,
+}
+
+// Do calls fn until it succeeds, returns a permanent or non-retryable error,
+// the attempts are exhausted, or ctx is done. fn receives ctx unchanged.
+//
+// Errors wrapped with Permanent are returned unwrapped without retrying.
+// When the attempts are exhausted or ctx is done while waiting, the last
+// error is returned wrapped; errors.Is can still match it.
+//
+// Example:
+//
+//	err := retry.Do(ctx, retry.Default, func(ctx context.Context) error {
+//	    return db.PingContext(ctx)
+//	})
+func Do(ctx context.Context, policy Policy, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if policy.Retryable != nil && !policy.Retryable(err) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+		}
+
+		delay := policy.Backoff(attempt)
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt, err, delay)
+		}
+		if sleepErr := Sleep(ctx, delay); sleepErr != nil {
+			return fmt.Errorf("retry interrupted after %d attempts: %w", attempt, errors.Join(err, sleepErr))
+		}
+	}
+}
+
+// DoValue is like Do for operations that produce a value, such as opening a
+// connection pool during initialization. The value of the successful attempt
+// is returned; on failure the zero value is returned with the error.
+//
+// Passing InitCtx.StartupCtx bounds the retries by the startup deadline, so
+// a dependency that is not yet accepting connections during a rolling deploy
+// is retried instead of failing the application immediately:
+//
+//	db, err := retry.DoValue(ctx.StartupCtx, retry.Default, func(c context.Context) (*sql.DB, error) {
+//	    return openDB(c, ctx.Config.DatabaseURL)
+//	})
+func DoValue[T any](ctx context.Context, policy Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	var value T
+	err := Do(ctx, policy, func(ctx context.Context) error {
+		var err error
+		value, err = fn(ctx)
+		return err
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return value, nil
+}
+
+// Backoff returns the delay before retry number n, where n is 1 for the
+// first retry: InitialBackoff grown by Multiplier for each further retry,
+// capped at MaxBackoff and randomized by Jitter.
+func (p Policy) Backoff(n int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < n; i++ {
+		delay *= multiplier
+		if p.MaxBackoff > 0 && delay >= float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+
+	jitter := min(max(p.Jitter, 0), 1)
+	if jitter > 0 {
+		delay *= 1 + jitter*(2*rand.Float64()-1)
+	}
+
+	if delay >= math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
+
+// Sleep waits for d or until ctx is done, returning ctx.Err() in the latter case.
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Permanent wraps err so that Do returns it immediately without retrying.
+// A nil err returns nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// permanentError marks an error as not worth retrying.
+type permanentError struct {
+	err error
+}
+
+// Error implements the error interface.
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
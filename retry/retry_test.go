@@ -0,0 +1,163 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTransient = errors.New("transient failure")
+
+func TestDoSucceedsAfterRetries(t *testing.T) {
+	attempts := 0
+	var retried []int
+
+	err := Do(context.Background(), Policy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Millisecond,
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			retried = append(retried, attempt)
+			assert.ErrorIs(t, err, errTransient)
+		},
+	}, func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errTransient
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, []int{1, 2}, retried)
+}
+
+func TestDoGivesUp(t *testing.T) {
+	attempts := 0
+
+	err := Do(context.Background(), Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond}, func(ctx context.Context) error {
+		attempts++
+		return errTransient
+	})
+
+	assert.ErrorIs(t, err, errTransient)
+	assert.ErrorContains(t, err, "gave up after 3 attempts")
+	assert.Equal(t, 3, attempts)
+}
+
+func TestDoPermanent(t *testing.T) {
+	attempts := 0
+	permanentErr := errors.New("invalid credentials")
+
+	err := Do(context.Background(), Policy{InitialBackoff: time.Millisecond}, func(ctx context.Context) error {
+		attempts++
+		return Permanent(permanentErr)
+	})
+
+	assert.Equal(t, permanentErr, err)
+	assert.Equal(t, 1, attempts)
+	assert.NoError(t, Permanent(nil))
+}
+
+func TestDoRetryable(t *testing.T) {
+	attempts := 0
+	otherErr := errors.New("not retryable")
+
+	err := Do(context.Background(), Policy{
+		InitialBackoff: time.Millisecond,
+		Retryable:      func(err error) bool { return errors.Is(err, errTransient) },
+	}, func(ctx context.Context) error {
+		attempts++
+		if attempts == 1 {
+			return errTransient
+		}
+		return otherErr
+	})
+
+	assert.Equal(t, otherErr, err)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestDoContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := Do(ctx, Policy{InitialBackoff: time.Hour}, func(ctx context.Context) error {
+		return errTransient
+	})
+
+	assert.ErrorIs(t, err, errTransient)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "retry interrupted after 1 attempts")
+}
+
+func TestBackoff(t *testing.T) {
+	policy := Policy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+
+	assert.Equal(t, 10*time.Millisecond, policy.Backoff(1))
+	assert.Equal(t, 20*time.Millisecond, policy.Backoff(2))
+	assert.Equal(t, 40*time.Millisecond, policy.Backoff(3))
+	assert.Equal(t, 50*time.Millisecond, policy.Backoff(4))
+	assert.Equal(t, 50*time.Millisecond, policy.Backoff(1000))
+
+	policy.Multiplier = 3
+	assert.Equal(t, 30*time.Millisecond, policy.Backoff(2))
+}
+
+func TestBackoffJitter(t *testing.T) {
+	policy := Policy{InitialBackoff: time.Second, Jitter: 0.2}
+
+	for range 100 {
+		delay := policy.Backoff(1)
+		assert.GreaterOrEqual(t, delay, 800*time.Millisecond)
+		assert.LessOrEqual(t, delay, 1200*time.Millisecond)
+	}
+}
+
+func TestSleep(t *testing.T) {
+	assert.NoError(t, Sleep(context.Background(), time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, Sleep(ctx, time.Hour), context.Canceled)
+	assert.ErrorIs(t, Sleep(ctx, 0), context.Canceled)
+}
+
+func TestBackoffUncappedOverflow(t *testing.T) {
+	policy := Policy{InitialBackoff: time.Second}
+
+	assert.Positive(t, policy.Backoff(200))
+}
+
+func TestDoValue(t *testing.T) {
+	attempts := 0
+
+	value, err := DoValue(context.Background(), Policy{InitialBackoff: time.Millisecond}, func(ctx context.Context) (string, error) {
+		attempts++
+		if attempts < 2 {
+			return "partial", errTransient
+		}
+		return "connected", nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "connected", value)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestDoValueDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	value, err := DoValue(ctx, Policy{InitialBackoff: 5 * time.Millisecond}, func(ctx context.Context) (int, error) {
+		return 1, errTransient
+	})
+
+	assert.ErrorIs(t, err, errTransient)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Zero(t, value, "the zero value should be returned on failure")
+}
@@ -0,0 +1,220 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBreakerOpen is returned by Breaker.Check while the breaker is open.
+var ErrBreakerOpen = errors.New("circuit breaker is open")
+
+// BreakerState describes the state of a Breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed allows restarts; failures are being counted.
+	BreakerClosed BreakerState = iota
+
+	// BreakerOpen blocks restarts until the cool-down has elapsed.
+	BreakerOpen
+
+	// BreakerHalfOpen allows a single probing restart after the cool-down.
+	BreakerHalfOpen
+)
+
+// String returns the lower-case name of the state.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("BreakerState(%d)", int(s))
+	}
+}
+
+// breakerOption represents a functional option for configuring a Breaker.
+// This type is not exported to ensure only predefined options can be used.
+type breakerOption func(*Breaker)
+
+// WithProbe sets a probe that must succeed before a half-open breaker allows
+// a restart. The probe is typically the same function registered as the
+// dependency's health check, so the breaker and the readiness endpoint share
+// one view of the dependency's state.
+func WithProbe(probe func(ctx context.Context) error) breakerOption {
+	return func(b *Breaker) {
+		b.probe = probe
+	}
+}
+
+// Breaker is a circuit breaker guarding restarts of a supervised runner.
+// After threshold consecutive failures it opens and blocks restarts for the
+// cool-down duration, after which it half-opens and allows a single probing
+// restart. A successful probe closes the breaker; a failed one reopens it.
+// Without a probe configured, the probing restart is the probe: other
+// restarts wait until its outcome is recorded with RecordSuccess, as
+// Supervise does once RestartPolicy.StableAfter has elapsed, or
+// RecordFailure.
+//
+// A Breaker may be shared by several supervised runners depending on the same
+// resource, and its Check method can be registered as a health check.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	probe     func(ctx context.Context) error
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+
+	// probing is set while a caller admitted by Wait probes a half-open
+	// breaker; settled is closed when its outcome is recorded.
+	probing bool
+	settled chan struct{}
+}
+
+// NewBreaker creates a closed Breaker that opens after threshold consecutive
+// failures and stays open for cooldown before half-opening.
+func NewBreaker(threshold int, cooldown time.Duration, options ...breakerOption) *Breaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	b := &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     BreakerClosed,
+	}
+	for _, opt := range options {
+		opt(b)
+	}
+
+	return b
+}
+
+// State returns the current state of the breaker, moving an open breaker to
+// half-open if its cool-down has elapsed.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh()
+	return b.state
+}
+
+// RecordFailure records a failure. A failure while half-open, or reaching
+// the failure threshold while closed, opens the breaker.
+func (b *Breaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh()
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+	b.settle()
+}
+
+// RecordSuccess records a success, closing the breaker and resetting the
+// failure count.
+func (b *Breaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.settle()
+}
+
+// Wait blocks until the breaker permits a restart or ctx is done. While the
+// breaker is open it waits for the cool-down to elapse. Once half-open it
+// admits a single caller, which runs the probe, if configured, closing the
+// breaker if the probe succeeds and reopening it if it fails. Other callers
+// wait for the outcome of the probe or of the probing restart.
+func (b *Breaker) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		b.refresh()
+		state := b.state
+		remaining := b.cooldown - time.Since(b.openedAt)
+		probing, settled := b.probing, b.settled
+		if state == BreakerHalfOpen && !probing {
+			b.probing = true
+			b.settled = make(chan struct{})
+		}
+		b.mu.Unlock()
+
+		switch state {
+		case BreakerClosed:
+			return nil
+		case BreakerHalfOpen:
+			if probing {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-settled:
+				}
+				continue
+			}
+			if b.probe == nil {
+				return nil
+			}
+			if err := b.probe(ctx); err == nil {
+				b.RecordSuccess()
+				return nil
+			}
+			if ctx.Err() != nil {
+				// An interrupted probe says nothing about the dependency
+				b.mu.Lock()
+				b.settle()
+				b.mu.Unlock()
+				return ctx.Err()
+			}
+			b.RecordFailure()
+		case BreakerOpen:
+			timer := time.NewTimer(remaining)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// Check reports ErrBreakerOpen while the breaker is open and nil otherwise.
+// Its signature matches health.CheckFunc so a breaker can be registered with
+// the health registry.
+func (b *Breaker) Check(_ context.Context) error {
+	if b.State() == BreakerOpen {
+		return ErrBreakerOpen
+	}
+	return nil
+}
+
+// refresh moves an open breaker to half-open once its cool-down elapsed.
+// The caller must hold b.mu.
+func (b *Breaker) refresh() {
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cooldown {
+		b.state = BreakerHalfOpen
+	}
+}
+
+// settle ends the probe of a half-open breaker, if any, waking the callers
+// of Wait waiting for its outcome. The caller must hold b.mu.
+func (b *Breaker) settle() {
+	if b.probing {
+		b.probing = false
+		close(b.settled)
+	}
+}
@@ -0,0 +1,140 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestBreakerTransitions(t *testing.T) {
+	breaker := NewBreaker(2, 30*time.Millisecond)
+	assert.Equal(t, BreakerClosed, breaker.State())
+
+	breaker.RecordFailure()
+	assert.Equal(t, BreakerClosed, breaker.State(), "breaker should stay closed below the threshold")
+
+	breaker.RecordFailure()
+	assert.Equal(t, BreakerOpen, breaker.State(), "breaker should open at the threshold")
+	assert.ErrorIs(t, breaker.Check(context.Background()), ErrBreakerOpen)
+
+	time.Sleep(40 * time.Millisecond)
+	assert.Equal(t, BreakerHalfOpen, breaker.State(), "breaker should half-open after the cool-down")
+	assert.NoError(t, breaker.Check(context.Background()))
+
+	breaker.RecordFailure()
+	assert.Equal(t, BreakerOpen, breaker.State(), "a failure while half-open should reopen the breaker")
+
+	breaker.RecordSuccess()
+	assert.Equal(t, BreakerClosed, breaker.State())
+}
+
+func TestBreakerWait(t *testing.T) {
+	t.Run("waits for cool-down", func(t *testing.T) {
+		breaker := NewBreaker(1, 30*time.Millisecond)
+		breaker.RecordFailure()
+
+		start := time.Now()
+		require.NoError(t, breaker.Wait(context.Background()))
+
+		assert.GreaterOrEqual(t, time.Since(start), 25*time.Millisecond)
+	})
+
+	t.Run("probe must succeed", func(t *testing.T) {
+		var probes atomic.Int32
+		breaker := NewBreaker(1, 10*time.Millisecond, WithProbe(func(ctx context.Context) error {
+			if probes.Add(1) < 3 {
+				return errors.New("still down")
+			}
+			return nil
+		}))
+		breaker.RecordFailure()
+
+		require.NoError(t, breaker.Wait(context.Background()))
+
+		assert.Equal(t, int32(3), probes.Load())
+		assert.Equal(t, BreakerClosed, breaker.State(), "a successful probe should close the breaker")
+	})
+
+	t.Run("single probe while half-open", func(t *testing.T) {
+		var probes atomic.Int32
+		release := make(chan struct{})
+		breaker := NewBreaker(1, 10*time.Millisecond, WithProbe(func(ctx context.Context) error {
+			probes.Add(1)
+			<-release
+			return nil
+		}))
+		breaker.RecordFailure()
+
+		var grp errgroup.Group
+		for range 3 {
+			grp.Go(func() error {
+				return breaker.Wait(context.Background())
+			})
+		}
+		time.Sleep(30 * time.Millisecond)
+		close(release)
+		require.NoError(t, grp.Wait())
+
+		assert.Equal(t, int32(1), probes.Load(), "only one caller should probe a half-open breaker")
+	})
+
+	t.Run("single restart without probe", func(t *testing.T) {
+		breaker := NewBreaker(1, 10*time.Millisecond)
+		breaker.RecordFailure()
+
+		admitted := make(chan error, 3)
+		for range 3 {
+			go func() {
+				admitted <- breaker.Wait(context.Background())
+			}()
+		}
+
+		require.NoError(t, <-admitted)
+		select {
+		case <-admitted:
+			t.Fatal("only one restart should be admitted while half-open")
+		case <-time.After(30 * time.Millisecond):
+		}
+		assert.Equal(t, BreakerHalfOpen, breaker.State())
+
+		breaker.RecordSuccess()
+		require.NoError(t, <-admitted)
+		require.NoError(t, <-admitted)
+	})
+
+	t.Run("failed probing restart reopens", func(t *testing.T) {
+		breaker := NewBreaker(1, 10*time.Millisecond)
+		breaker.RecordFailure()
+		require.NoError(t, breaker.Wait(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, breaker.Wait(ctx), context.DeadlineExceeded, "the next restart should wait for the probing one")
+
+		breaker.RecordFailure()
+		assert.Equal(t, BreakerOpen, breaker.State())
+		require.NoError(t, breaker.Wait(context.Background()), "the next half-open period should admit a restart")
+	})
+
+	t.Run("context cancelled", func(t *testing.T) {
+		breaker := NewBreaker(1, time.Minute)
+		breaker.RecordFailure()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		assert.ErrorIs(t, breaker.Wait(ctx), context.DeadlineExceeded)
+	})
+}
+
+func TestBreakerStateString(t *testing.T) {
+	assert.Equal(t, "closed", BreakerClosed.String())
+	assert.Equal(t, "open", BreakerOpen.String())
+	assert.Equal(t, "half-open", BreakerHalfOpen.String())
+}
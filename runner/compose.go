@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Sequence returns a runner that runs stages one after another, each
+// receiving the same context. It stops at the first stage that returns an
+// error and returns that error wrapped with the stage's position. If ctx is
+// done before a stage starts, the remaining stages are skipped and ctx.Err()
+// is returned. A Sequence without stages returns nil.
+//
+// Example:
+//
+//	etl := runner.Sequence(extract, transform, load)
+func Sequence(stages ...Runner) Runner {
+	return func(ctx context.Context) error {
+		for idx, stage := range stages {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := stage(ctx); err != nil {
+				return fmt.Errorf("stage %d failed: %w", idx, err)
+			}
+		}
+		return nil
+	}
+}
+
+// Parallel returns a runner that runs all runners concurrently and waits for
+// every one of them to return. The runners share a context derived from ctx
+// that is cancelled as soon as one of them returns an error; the first error
+// is returned wrapped with the failing runner's position. Runners returning
+// nil do not affect the others. A Parallel without runners returns nil.
+//
+// Example:
+//
+//	fanOut := runner.Parallel(loadUsers, loadOrders, loadProducts)
+//	etl := runner.Sequence(extract, fanOut, report)
+func Parallel(runners ...Runner) Runner {
+	return func(ctx context.Context) error {
+		grp, grpCtx := errgroup.WithContext(ctx)
+		for idx, r := range runners {
+			grp.Go(func() error {
+				if err := r(grpCtx); err != nil {
+					return fmt.Errorf("parallel runner %d failed: %w", idx, err)
+				}
+				return nil
+			})
+		}
+		return grp.Wait()
+	}
+}
@@ -0,0 +1,106 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequence(t *testing.T) {
+	var order []int
+	stage := func(id int) Runner {
+		return func(ctx context.Context) error {
+			order = append(order, id)
+			return nil
+		}
+	}
+
+	err := Sequence(stage(1), stage(2), stage(3))(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, order)
+}
+
+func TestSequenceStopsOnError(t *testing.T) {
+	stageErr := errors.New("transform failed")
+	var ranLast bool
+
+	err := Sequence(
+		func(ctx context.Context) error { return nil },
+		func(ctx context.Context) error { return stageErr },
+		func(ctx context.Context) error { ranLast = true; return nil },
+	)(context.Background())
+
+	assert.ErrorIs(t, err, stageErr)
+	assert.ErrorContains(t, err, "stage 1 failed")
+	assert.False(t, ranLast, "stages after a failure should not run")
+}
+
+func TestSequenceStopsOnCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var ranSecond bool
+
+	err := Sequence(
+		func(ctx context.Context) error { cancel(); return nil },
+		func(ctx context.Context) error { ranSecond = true; return nil },
+	)(ctx)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, ranSecond, "stages after cancellation should not run")
+}
+
+func TestSequenceEmpty(t *testing.T) {
+	assert.NoError(t, Sequence()(context.Background()))
+}
+
+func TestParallel(t *testing.T) {
+	var running, maxRunning atomic.Int32
+	branch := func(ctx context.Context) error {
+		current := running.Add(1)
+		for {
+			observed := maxRunning.Load()
+			if current <= observed || maxRunning.CompareAndSwap(observed, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		running.Add(-1)
+		return nil
+	}
+
+	err := Parallel(branch, branch, branch)(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), maxRunning.Load(), "runners should run concurrently")
+}
+
+func TestParallelCancelsOnError(t *testing.T) {
+	branchErr := errors.New("load failed")
+	var mu sync.Mutex
+	var siblingErr error
+
+	err := Parallel(
+		func(ctx context.Context) error {
+			<-ctx.Done()
+			mu.Lock()
+			siblingErr = ctx.Err()
+			mu.Unlock()
+			return nil
+		},
+		func(ctx context.Context) error { return branchErr },
+	)(context.Background())
+
+	assert.ErrorIs(t, err, branchErr)
+	assert.ErrorContains(t, err, "parallel runner 1 failed")
+	assert.ErrorIs(t, siblingErr, context.Canceled, "siblings should be cancelled")
+}
+
+func TestParallelEmpty(t *testing.T) {
+	assert.NoError(t, Parallel()(context.Background()))
+}
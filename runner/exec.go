@@ -0,0 +1,203 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// TerminationGrace is how long a child process is given by default to exit
+// after SIGTERM on shutdown before it is killed.
+const TerminationGrace = 10 * time.Second
+
+// execSettings holds the settings applied by Exec options.
+type execSettings struct {
+	logger  *slog.Logger
+	env     []string
+	restart *RestartPolicy
+	grace   time.Duration
+}
+
+// execOption represents a functional option for configuring Exec.
+// This type is not exported to ensure only predefined options can be used.
+type execOption func(*execSettings)
+
+// WithLogger sets the logger that the process's output is written to,
+// instead of slog.Default.
+func WithLogger(logger *slog.Logger) execOption {
+	return func(settings *execSettings) {
+		settings.logger = logger
+	}
+}
+
+// WithEnv adds KEY=VALUE variables to the environment of the process, which
+// is otherwise cmd.Env, or the application's environment if cmd.Env is nil.
+// Later values override earlier ones for the same key.
+func WithEnv(vars ...string) execOption {
+	return func(settings *execSettings) {
+		settings.env = append(settings.env, vars...)
+	}
+}
+
+// WithRestartPolicy restarts the process according to policy when it fails,
+// as Supervise does for other runners.
+func WithRestartPolicy(policy RestartPolicy) execOption {
+	return func(settings *execSettings) {
+		settings.restart = &policy
+	}
+}
+
+// WithGracePeriod sets how long the process is given to exit after SIGTERM
+// on shutdown before it is killed, instead of TerminationGrace.
+func WithGracePeriod(grace time.Duration) execOption {
+	return func(settings *execSettings) {
+		settings.grace = grace
+	}
+}
+
+// Exec returns a runner that manages a child process under the application
+// lifecycle, such as a sidecar exporter or a migration tool.
+//
+// cmd is a template: each run starts a fresh process with cmd's Path, Args,
+// Env and Dir, so the process can be restarted. Its stdout and stderr are
+// logged line by line at INFO and WARN level with a "process" attribute. On
+// shutdown the process receives SIGTERM and is killed if it has not exited
+// within the grace period.
+//
+// The runner returns nil if the process exits successfully or is stopped by
+// shutdown, and an error describing its exit status otherwise.
+//
+// Example:
+//
+//	exporter := runner.Exec(exec.Command("/usr/local/bin/node_exporter"),
+//	    runner.WithLogger(ctx.Logger),
+//	    runner.WithEnv("EXPORTER_PORT=9100"),
+//	    runner.WithRestartPolicy(runner.RestartPolicy{InitialBackoff: time.Second}),
+//	    runner.WithGracePeriod(5*time.Second),
+//	)
+func Exec(cmd *exec.Cmd, options ...execOption) Runner {
+	settings := execSettings{grace: TerminationGrace}
+	for _, opt := range options {
+		opt(&settings)
+	}
+	if settings.logger == nil {
+		settings.logger = slog.Default()
+	}
+
+	r := Runner(func(ctx context.Context) error {
+		return runProcess(ctx, cmd, settings)
+	})
+	if settings.restart != nil {
+		r = Supervise(r, *settings.restart)
+	}
+	return r
+}
+
+// Isolated returns a runner that runs a workload as a child process, as a
+// bulkhead for risky native code or runners that leak memory: a crash or leak
+// takes down the child instead of the application. It is Exec with the given
+// logger, and can be restarted by wrapping it with Supervise.
+//
+// Example:
+//
+//	transcoder := runner.Supervise(
+//	    runner.Isolated(exec.Command("/usr/local/bin/transcoder", "--queue", "videos"), ctx.Logger),
+//	    runner.RestartPolicy{InitialBackoff: time.Second, MaxBackoff: time.Minute},
+//	)
+func Isolated(cmd *exec.Cmd, logger *slog.Logger) Runner {
+	return Exec(cmd, WithLogger(logger))
+}
+
+// runProcess starts a fresh process from the template cmd and waits for it,
+// terminating it when ctx is cancelled.
+func runProcess(ctx context.Context, cmd *exec.Cmd, settings execSettings) error {
+	if cmd.Err != nil {
+		return cmd.Err
+	}
+	name := filepath.Base(cmd.Path)
+	logger := settings.logger.With("process", name)
+
+	proc := exec.CommandContext(ctx, cmd.Path)
+	proc.Args = cmd.Args
+	proc.Env = cmd.Env
+	if len(settings.env) > 0 {
+		if proc.Env == nil {
+			proc.Env = os.Environ()
+		}
+		proc.Env = append(slices.Clip(proc.Env), settings.env...)
+	}
+	proc.Dir = cmd.Dir
+	proc.Cancel = func() error {
+		return proc.Process.Signal(syscall.SIGTERM)
+	}
+	proc.WaitDelay = settings.grace
+
+	stdout := newLineLogger(logger, slog.LevelInfo)
+	stderr := newLineLogger(logger, slog.LevelWarn)
+	proc.Stdout = stdout
+	proc.Stderr = stderr
+
+	if err := proc.Start(); err != nil {
+		stdout.Close()
+		stderr.Close()
+		return fmt.Errorf("failed to start process %s: %w", name, err)
+	}
+	logger.Debug("started process", "pid", proc.Process.Pid)
+
+	err := proc.Wait()
+	stdout.Close()
+	stderr.Close()
+
+	if ctx.Err() != nil {
+		logger.Debug("stopped process", "pid", proc.Process.Pid)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("process %s failed: %w", name, err)
+	}
+	return nil
+}
+
+// lineLogger is an io.WriteCloser that logs each line written to it.
+type lineLogger struct {
+	pw   *io.PipeWriter
+	done chan struct{}
+}
+
+// newLineLogger returns a lineLogger logging lines to logger at level.
+func newLineLogger(logger *slog.Logger, level slog.Level) *lineLogger {
+	pr, pw := io.Pipe()
+	l := &lineLogger{pw: pw, done: make(chan struct{})}
+
+	go func() {
+		defer close(l.done)
+		scanner := bufio.NewScanner(pr)
+		for scanner.Scan() {
+			logger.Log(context.Background(), level, scanner.Text())
+		}
+		// Drain the pipe if a line was too long to scan
+		_, _ = io.Copy(io.Discard, pr)
+	}()
+
+	return l
+}
+
+// Write passes p on to be split into lines and logged.
+func (l *lineLogger) Write(p []byte) (int, error) {
+	return l.pw.Write(p)
+}
+
+// Close logs any final unterminated line and waits for logging to finish.
+func (l *lineLogger) Close() error {
+	err := l.pw.Close()
+	<-l.done
+	return err
+}
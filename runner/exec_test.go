@@ -0,0 +1,129 @@
+//go:build unix
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use by log handlers.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func newTestLogger() (*slog.Logger, *syncBuffer) {
+	buf := &syncBuffer{}
+	return slog.New(slog.NewTextHandler(buf, nil)), buf
+}
+
+func TestIsolatedLogsOutput(t *testing.T) {
+	logger, logs := newTestLogger()
+	cmd := exec.Command("/bin/sh", "-c", "echo hello; printf 'oops' >&2")
+
+	r := Isolated(cmd, logger)
+	require.NoError(t, r(context.Background()))
+	require.NoError(t, r(context.Background()), "the template should be reusable")
+
+	assert.Contains(t, logs.String(), `level=INFO msg=hello process=sh`)
+	assert.Contains(t, logs.String(), `level=WARN msg=oops process=sh`)
+}
+
+func TestIsolatedFailure(t *testing.T) {
+	logger, _ := newTestLogger()
+
+	err := Isolated(exec.Command("/bin/sh", "-c", "exit 3"), logger)(context.Background())
+	assert.ErrorContains(t, err, "process sh failed: exit status 3")
+
+	err = Isolated(exec.Command("ezapp-no-such-binary"), logger)(context.Background())
+	assert.ErrorContains(t, err, "executable file not found")
+}
+
+func TestIsolatedForwardsSIGTERM(t *testing.T) {
+	logger, logs := newTestLogger()
+	cmd := exec.Command("/bin/sh", "-c", `trap 'echo terminating; exit 0' TERM; echo ready; while true; do sleep 0.01; done`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Isolated(cmd, logger)(ctx) }()
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(logs.String(), "msg=ready")
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("process was not terminated")
+	}
+	assert.Contains(t, logs.String(), "msg=terminating")
+}
+
+func TestExecEnv(t *testing.T) {
+	t.Setenv("EZAPP_EXEC_INHERITED", "inherited")
+	logger, logs := newTestLogger()
+	cmd := exec.Command("/bin/sh", "-c", `echo "$EZAPP_EXEC_INHERITED $EZAPP_EXEC_INJECTED"`)
+
+	err := Exec(cmd, WithLogger(logger), WithEnv("EZAPP_EXEC_INJECTED=injected"))(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, logs.String(), `msg="inherited injected"`)
+
+	cmd.Env = []string{"EZAPP_EXEC_INJECTED=template"}
+	err = Exec(cmd, WithLogger(logger), WithEnv("EZAPP_EXEC_INJECTED=override"))(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, logs.String(), `msg=" override"`, "an explicit cmd.Env should not inherit the environment")
+}
+
+func TestExecRestartPolicy(t *testing.T) {
+	logger, logs := newTestLogger()
+	cmd := exec.Command("/bin/sh", "-c", "echo attempt; exit 1")
+
+	err := Exec(cmd, WithLogger(logger), WithRestartPolicy(RestartPolicy{MaxRestarts: 2, InitialBackoff: time.Millisecond}))(context.Background())
+	assert.ErrorContains(t, err, "runner failed after 2 restarts")
+	assert.Equal(t, 3, strings.Count(logs.String(), "msg=attempt"))
+}
+
+func TestExecGracePeriod(t *testing.T) {
+	logger, logs := newTestLogger()
+	cmd := exec.Command("/bin/sh", "-c", `trap '' TERM; echo ready; while true; do sleep 0.01; done`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Exec(cmd, WithLogger(logger), WithGracePeriod(50*time.Millisecond))(ctx) }()
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(logs.String(), "msg=ready")
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("a process ignoring SIGTERM should be killed after the grace period")
+	}
+}
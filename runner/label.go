@@ -0,0 +1,19 @@
+package runner
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// LabelKey is the pprof label key under which ezapp records the name of the
+// runner executing a goroutine. ezapp invokes every runner through pprof.Do
+// with this label, so CPU and goroutine profiles are segmented by runner and
+// goroutines started by a runner inherit its name.
+const LabelKey = "runner"
+
+// Name returns the name of the runner that ctx was passed to, as recorded in
+// its pprof labels, and whether one was found. It is useful for tagging logs
+// or metrics emitted from shared code with the runner that invoked it.
+func Name(ctx context.Context) (string, bool) {
+	return pprof.Label(ctx, LabelKey)
+}
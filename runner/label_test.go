@@ -0,0 +1,20 @@
+package runner
+
+import (
+	"context"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestName(t *testing.T) {
+	_, ok := Name(context.Background())
+	assert.False(t, ok)
+
+	pprof.Do(context.Background(), pprof.Labels(LabelKey, "http"), func(ctx context.Context) {
+		name, ok := Name(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "http", name)
+	})
+}
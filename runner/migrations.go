@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/migrate"
+)
+
+// migrationsSettings holds the settings applied by Migrations options.
+type migrationsSettings struct {
+	lock   migrate.Locker
+	dryRun bool
+}
+
+// migrationsOption represents a functional option for configuring Migrations.
+// This type is not exported to ensure only predefined options can be used.
+type migrationsOption func(*migrationsSettings)
+
+// WithLock holds lock while the pending migrations are listed and applied,
+// so that instances starting at the same time migrate the database once.
+func WithLock(lock migrate.Locker) migrationsOption {
+	return func(settings *migrationsSettings) {
+		settings.lock = lock
+	}
+}
+
+// WithDryRun makes Migrations only log the pending migrations instead of
+// applying them, when dryRun is true.
+func WithDryRun(dryRun bool) migrationsOption {
+	return func(settings *migrationsSettings) {
+		settings.dryRun = dryRun
+	}
+}
+
+// Migrations returns a runner that applies the pending migrations of m,
+// logging each applied version to logger with a "version" attribute. It
+// returns nil once the schema is up to date, so it is usually the first
+// stage of a Sequence whose later stages need the migrated schema.
+//
+// Example:
+//
+//	//go:embed migrations/*.sql
+//	var migrations embed.FS
+//
+//	provider, err := goose.NewProvider(goose.DialectPostgres, db, migrations)
+//	...
+//	app := runner.Sequence(
+//	    runner.Migrations(gooseMigrator{provider}, ctx.Logger,
+//	        runner.WithLock(migrate.NewPostgresLock(db, migrationLockKey)),
+//	        runner.WithDryRun(ctx.Config.MigrateDryRun),
+//	    ),
+//	    server.Run,
+//	)
+func Migrations(m migrate.Migrator, logger *slog.Logger, options ...migrationsOption) Runner {
+	var settings migrationsSettings
+	for _, opt := range options {
+		opt(&settings)
+	}
+
+	return func(ctx context.Context) error {
+		if settings.lock != nil {
+			if err := settings.lock.Lock(ctx); err != nil {
+				return fmt.Errorf("failed to acquire migration lock: %w", err)
+			}
+			defer func() {
+				// Release the lock even when shutdown cancelled the migrations
+				if unlockErr := settings.lock.Unlock(context.WithoutCancel(ctx)); unlockErr != nil {
+					logger.Warn("failed to release migration lock", "error", unlockErr)
+				}
+			}()
+		}
+
+		pending, err := m.Pending(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list pending migrations: %w", err)
+		}
+		if len(pending) == 0 {
+			logger.Info("database schema is up to date")
+			return nil
+		}
+		if settings.dryRun {
+			for _, version := range pending {
+				logger.Info("migration pending", "version", version)
+			}
+			logger.Info("dry run, no migrations applied", "pending", len(pending))
+			return nil
+		}
+
+		started := time.Now()
+		applied, err := m.Up(ctx)
+		for _, version := range applied {
+			logger.Info("migration applied", "version", version)
+		}
+		if err != nil {
+			return fmt.Errorf("migrations failed after applying %d of %d: %w", len(applied), len(pending), err)
+		}
+		logger.Info("migrations applied", "count", len(applied), "duration", time.Since(started))
+		return nil
+	}
+}
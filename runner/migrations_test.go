@@ -0,0 +1,145 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMigrator applies the versions in pending, failing at failAt if set.
+type fakeMigrator struct {
+	pending []int64
+	failAt  int64
+	calls   *[]string
+}
+
+func (m *fakeMigrator) Pending(context.Context) ([]int64, error) {
+	*m.calls = append(*m.calls, "pending")
+	return m.pending, nil
+}
+
+func (m *fakeMigrator) Up(context.Context) ([]int64, error) {
+	*m.calls = append(*m.calls, "up")
+	var applied []int64
+	for _, version := range m.pending {
+		if version == m.failAt {
+			return applied, errors.New("syntax error at or near \"TABEL\"")
+		}
+		applied = append(applied, version)
+	}
+	m.pending = nil
+	return applied, nil
+}
+
+// fakeLocker records when it is locked and unlocked.
+type fakeLocker struct {
+	calls   *[]string
+	lockErr error
+}
+
+func (l *fakeLocker) Lock(context.Context) error {
+	*l.calls = append(*l.calls, "lock")
+	return l.lockErr
+}
+
+func (l *fakeLocker) Unlock(ctx context.Context) error {
+	*l.calls = append(*l.calls, "unlock")
+	return ctx.Err()
+}
+
+func appliedVersions(handler *testutil.TestHandler) []int64 {
+	var versions []int64
+	for _, record := range handler.Records() {
+		if record.Message != "migration applied" {
+			continue
+		}
+		record.Attrs(func(attr slog.Attr) bool {
+			if attr.Key == "version" {
+				versions = append(versions, attr.Value.Int64())
+			}
+			return true
+		})
+	}
+	return versions
+}
+
+func TestMigrations(t *testing.T) {
+	var calls []string
+	logger, handler := testutil.NewTestLogger(slog.LevelInfo)
+	m := &fakeMigrator{pending: []int64{1, 2, 3}, calls: &calls}
+
+	r := Migrations(m, logger, WithLock(&fakeLocker{calls: &calls}))
+	require.NoError(t, r(context.Background()))
+
+	assert.Equal(t, []string{"lock", "pending", "up", "unlock"}, calls)
+	assert.Equal(t, []int64{1, 2, 3}, appliedVersions(handler))
+	count, ok := handler.Attr("migrations applied", "count")
+	require.True(t, ok)
+	assert.Equal(t, int64(3), count.Int64())
+
+	require.NoError(t, r(context.Background()))
+	assert.Contains(t, handler.Messages(), "database schema is up to date")
+}
+
+func TestMigrationsDryRun(t *testing.T) {
+	var calls []string
+	logger, handler := testutil.NewTestLogger(slog.LevelInfo)
+	m := &fakeMigrator{pending: []int64{20240101, 20240102}, calls: &calls}
+
+	require.NoError(t, Migrations(m, logger, WithDryRun(true))(context.Background()))
+
+	assert.Equal(t, []string{"pending"}, calls, "a dry run must not apply migrations")
+	assert.Empty(t, appliedVersions(handler))
+	version, ok := handler.Attr("migration pending", "version")
+	require.True(t, ok)
+	assert.Equal(t, int64(20240101), version.Int64())
+	pending, ok := handler.Attr("dry run, no migrations applied", "pending")
+	require.True(t, ok)
+	assert.Equal(t, int64(2), pending.Int64())
+}
+
+func TestMigrationsFailure(t *testing.T) {
+	var calls []string
+	logger, handler := testutil.NewTestLogger(slog.LevelInfo)
+	m := &fakeMigrator{pending: []int64{1, 2, 3}, failAt: 3, calls: &calls}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := Migrations(m, logger, WithLock(&fakeLocker{calls: &calls}))
+	err := r(ctx)
+	cancel()
+
+	assert.EqualError(t, err, `migrations failed after applying 2 of 3: syntax error at or near "TABEL"`)
+	assert.Equal(t, []int64{1, 2}, appliedVersions(handler), "versions applied before the failure should be logged")
+	assert.Equal(t, "unlock", calls[len(calls)-1], "the lock should be released after a failure")
+}
+
+func TestMigrationsLockFailure(t *testing.T) {
+	var calls []string
+	logger, _ := testutil.NewTestLogger(slog.LevelInfo)
+	m := &fakeMigrator{pending: []int64{1}, calls: &calls}
+	lockErr := errors.New("context deadline exceeded")
+
+	err := Migrations(m, logger, WithLock(&fakeLocker{calls: &calls, lockErr: lockErr}))(context.Background())
+
+	assert.ErrorIs(t, err, lockErr)
+	assert.Equal(t, []string{"lock"}, calls, "migrations must not run without the lock")
+}
+
+func TestMigrationsUnlockAfterShutdown(t *testing.T) {
+	var calls []string
+	logger, handler := testutil.NewTestLogger(slog.LevelInfo)
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &fakeMigrator{pending: []int64{1}, calls: &calls}
+	r := Migrations(m, logger, WithLock(&fakeLocker{calls: &calls}))
+
+	cancel()
+	require.NoError(t, r(ctx))
+
+	assert.Equal(t, "unlock", calls[len(calls)-1])
+	assert.NotContains(t, handler.Messages(), "failed to release migration lock", "the lock should be released with an uncancelled context")
+}
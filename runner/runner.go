@@ -0,0 +1,10 @@
+// Package runner provides the Runner type executed by ezapp along with
+// helpers for composing and supervising runners.
+package runner
+
+import "context"
+
+// Runner is a function implementing a unit of the application's business
+// logic. The context is cancelled when the application shuts down and the
+// runner is expected to return promptly once that happens.
+type Runner func(context.Context) error
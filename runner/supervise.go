@@ -0,0 +1,99 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/retry"
+)
+
+// RestartPolicy controls how Supervise restarts a failing runner.
+type RestartPolicy struct {
+
+	// MaxRestarts limits the number of consecutive restarts before the
+	// supervised runner gives up and returns the last error. Zero or a
+	// negative value allows unlimited restarts.
+	MaxRestarts int
+
+	// InitialBackoff is the delay before the first restart. Each subsequent
+	// consecutive restart doubles the delay up to MaxBackoff.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the restart delay. If zero, the delay is not capped.
+	MaxBackoff time.Duration
+
+	// StableAfter is how long a restarted runner must keep running before
+	// the failure streak is considered over: the backoff and restart count
+	// are reset and the breaker, if any, records a success. If zero, a run
+	// is never considered stable.
+	StableAfter time.Duration
+
+	// Breaker optionally guards restarts with a circuit breaker so that a
+	// runner whose dependency is down does not restart in a tight loop.
+	Breaker *Breaker
+}
+
+// Supervise wraps r so that it is restarted according to policy whenever it
+// returns an error while ctx is still active. A nil return or a cancelled
+// context ends supervision and the result is returned as-is. If the restart
+// limit is reached, the last error is returned wrapped.
+func Supervise(r Runner, policy RestartPolicy) Runner {
+	return func(ctx context.Context) error {
+		backoff := retry.Policy{InitialBackoff: policy.InitialBackoff, MaxBackoff: policy.MaxBackoff}
+		restarts := 0
+
+		for {
+			stable, err := runOnce(ctx, r, policy)
+			if err == nil || ctx.Err() != nil {
+				return err
+			}
+
+			if stable {
+				restarts = 0
+			}
+
+			if policy.Breaker != nil {
+				policy.Breaker.RecordFailure()
+			}
+
+			if policy.MaxRestarts > 0 && restarts >= policy.MaxRestarts {
+				return fmt.Errorf("runner failed after %d restarts: %w", restarts, err)
+			}
+			restarts++
+
+			if err := retry.Sleep(ctx, backoff.Backoff(restarts)); err != nil {
+				return nil
+			}
+
+			if policy.Breaker != nil {
+				if err := policy.Breaker.Wait(ctx); err != nil {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// runOnce invokes r and reports whether it ran long enough to be considered
+// stable, recording a breaker success if so, along with its error.
+func runOnce(ctx context.Context, r Runner, policy RestartPolicy) (bool, error) {
+	if policy.StableAfter <= 0 {
+		return false, r(ctx)
+	}
+
+	stableCh := make(chan struct{})
+	timer := time.AfterFunc(policy.StableAfter, func() {
+		if policy.Breaker != nil {
+			policy.Breaker.RecordSuccess()
+		}
+		close(stableCh)
+	})
+
+	err := r(ctx)
+	if timer.Stop() {
+		return false, err
+	}
+	<-stableCh
+	return true, err
+}
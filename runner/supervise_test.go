@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuperviseRestartsUntilSuccess(t *testing.T) {
+	var attempts atomic.Int32
+	r := func(ctx context.Context) error {
+		if attempts.Add(1) < 3 {
+			return errors.New("transient failure")
+		}
+		return nil
+	}
+
+	err := Supervise(r, RestartPolicy{InitialBackoff: time.Millisecond})(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestSuperviseMaxRestarts(t *testing.T) {
+	var attempts atomic.Int32
+	r := func(ctx context.Context) error {
+		attempts.Add(1)
+		return errors.New("permanent failure")
+	}
+
+	err := Supervise(r, RestartPolicy{MaxRestarts: 2, InitialBackoff: time.Millisecond})(context.Background())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "runner failed after 2 restarts")
+	assert.Contains(t, err.Error(), "permanent failure")
+	assert.Equal(t, int32(3), attempts.Load(), "runner should run once plus two restarts")
+}
+
+func TestSuperviseStopsOnCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := func(ctx context.Context) error {
+		cancel()
+		return errors.New("failed during shutdown")
+	}
+
+	err := Supervise(r, RestartPolicy{InitialBackoff: time.Hour})(ctx)
+
+	assert.EqualError(t, err, "failed during shutdown", "errors after cancellation should not trigger restarts")
+}
+
+func TestSuperviseBreakerBlocksRestarts(t *testing.T) {
+	breaker := NewBreaker(2, 50*time.Millisecond)
+
+	var attempts atomic.Int32
+	r := func(ctx context.Context) error {
+		attempts.Add(1)
+		return errors.New("dependency down")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	err := Supervise(r, RestartPolicy{Breaker: breaker})(ctx)
+
+	assert.NoError(t, err, "supervision should end quietly when the context is cancelled")
+	assert.Equal(t, int32(2), attempts.Load(), "breaker should block restarts once open")
+	assert.Equal(t, BreakerOpen, breaker.State())
+}
+
+func TestSuperviseStableRunResetsBreaker(t *testing.T) {
+	breaker := NewBreaker(1, 10*time.Millisecond)
+
+	var attempts atomic.Int32
+	r := func(ctx context.Context) error {
+		if attempts.Add(1) == 1 {
+			return errors.New("dependency down")
+		}
+		time.Sleep(30 * time.Millisecond)
+		return nil
+	}
+
+	err := Supervise(r, RestartPolicy{StableAfter: 10 * time.Millisecond, Breaker: breaker})(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, BreakerClosed, breaker.State(), "a stable run should close the breaker")
+}
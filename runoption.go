@@ -0,0 +1,98 @@
+package ezapp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/config"
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+)
+
+// RunOption customises a single Run or RunE invocation. Run options are
+// provided by companion packages such as ezapptest.
+type RunOption func(*runopt.Settings)
+
+// WithStartupTimeout sets the startup timeout used when the EZAPP_STARTUP_TIMEOUT
+// environment variable is not set, replacing the 15 second default. The
+// environment variable still takes precedence so operators can adjust the
+// timeout without a rebuild. Non-positive values are ignored.
+//
+// Example:
+//
+//	ezapp.Run(initializer, ezapp.WithStartupTimeout(time.Minute))
+func WithStartupTimeout(timeout time.Duration) RunOption {
+	return func(settings *runopt.Settings) {
+		settings.StartupTimeout = max(timeout, 0)
+	}
+}
+
+// WithShutdownTimeout sets the shutdown timeout used when the EZAPP_SHUTDOWN_TIMEOUT
+// environment variable is not set, replacing the 15 second default. The timeout
+// bounds both the pre-shutdown hooks and the cleanup function. The environment
+// variable still takes precedence. Non-positive values are ignored.
+//
+// Example:
+//
+//	ezapp.Run(initializer, ezapp.WithShutdownTimeout(30*time.Second))
+func WithShutdownTimeout(timeout time.Duration) RunOption {
+	return func(settings *runopt.Settings) {
+		settings.ShutdownTimeout = max(timeout, 0)
+	}
+}
+
+// DefaultsProvider can be implemented by a Config type, with a pointer
+// receiver, to compute defaults at runtime, such as IDs derived from the
+// hostname. SetDefaults is called on a zero Config before the environment is
+// applied; a field it sets is kept unless one of its variables is set, and
+// takes precedence over a `default` in the env tag. A runtime default also
+// satisfies a required variable.
+//
+// Example:
+//
+//	func (c *Config) SetDefaults() {
+//	    hostname, _ := os.Hostname()
+//	    c.ConsumerID = "orders-" + hostname
+//	}
+type DefaultsProvider = config.DefaultsProvider
+
+// WithConfigDefaults registers fn to compute configuration defaults at
+// runtime, like DefaultsProvider but supplied by the caller of Run or RunE,
+// e.g. from values only known in main. fn is called after SetDefaults, if
+// Config implements DefaultsProvider. Config must match the Config type
+// parameter of the run.
+//
+// Example:
+//
+//	ezapp.Run(initializer, ezapp.WithConfigDefaults(func(cfg *Config) {
+//	    cfg.Version = buildVersion
+//	}))
+func WithConfigDefaults[Config any](fn func(cfg *Config)) RunOption {
+	return func(settings *runopt.Settings) {
+		settings.ConfigDefaults = fn
+	}
+}
+
+// loadConfig returns the configuration supplied through the run settings, or
+// loads it from environment variables if none was supplied.
+func loadConfig[Config any](settings runopt.Settings, options ...config.LoadOption) (Config, error) {
+	if settings.Config == nil {
+		if settings.ConfigDefaults != nil {
+			fn, ok := settings.ConfigDefaults.(func(*Config))
+			if !ok {
+				var zero Config
+				return zero, fmt.Errorf("configuration defaults are for %T, expected func(*%T)", settings.ConfigDefaults, zero)
+			}
+			options = append(options, config.WithDefaults(func(cfg any) {
+				fn(cfg.(*Config))
+			}))
+		}
+		return config.LoadVar[Config](options...)
+	}
+
+	cfg, ok := settings.Config.(Config)
+	if !ok {
+		var zero Config
+		return zero, fmt.Errorf("supplied configuration has type %T, expected %T", settings.Config, zero)
+	}
+	return cfg, nil
+}
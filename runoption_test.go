@@ -0,0 +1,88 @@
+package ezapp
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureTimeouts runs an app that records the remaining startup and cleanup
+// time budgets.
+func captureTimeouts(t *testing.T, options ...RunOption) (startup, shutdown time.Duration) {
+	t.Helper()
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		deadline, ok := ctx.StartupCtx.Deadline()
+		require.True(t, ok, "StartupCtx should have a deadline")
+		startup = time.Until(deadline)
+
+		return Construct(
+			WithRunners(successfulRunner),
+			WithCleanup(func(ctx context.Context) error {
+				deadline, ok := ctx.Deadline()
+				require.True(t, ok, "shutdown context should have a deadline")
+				shutdown = time.Until(deadline)
+				return nil
+			}),
+		)
+	}, options...)
+	require.NoError(t, err)
+
+	return startup, shutdown
+}
+
+func TestWithTimeouts(t *testing.T) {
+	t.Setenv("EZAPP_STARTUP_TIMEOUT", "")
+	t.Setenv("EZAPP_SHUTDOWN_TIMEOUT", "")
+
+	startup, shutdown := captureTimeouts(t, WithStartupTimeout(time.Minute), WithShutdownTimeout(2*time.Minute))
+
+	assert.InDelta(t, time.Minute, startup, float64(time.Second))
+	assert.InDelta(t, 2*time.Minute, shutdown, float64(time.Second))
+}
+
+func TestWithTimeoutsEnvironmentTakesPrecedence(t *testing.T) {
+	t.Setenv("EZAPP_STARTUP_TIMEOUT", "30s")
+	t.Setenv("EZAPP_SHUTDOWN_TIMEOUT", "45")
+
+	startup, shutdown := captureTimeouts(t, WithStartupTimeout(time.Minute), WithShutdownTimeout(2*time.Minute))
+
+	assert.InDelta(t, 30*time.Second, startup, float64(time.Second))
+	assert.InDelta(t, 45*time.Second, shutdown, float64(time.Second))
+}
+
+func TestWithTimeoutsIgnoresNonPositive(t *testing.T) {
+	t.Setenv("EZAPP_STARTUP_TIMEOUT", "")
+	t.Setenv("EZAPP_SHUTDOWN_TIMEOUT", "")
+	t.Setenv("EZAPP_DEV", "")
+
+	startup, shutdown := captureTimeouts(t, WithStartupTimeout(-time.Second), WithShutdownTimeout(0))
+
+	assert.InDelta(t, 15*time.Second, startup, float64(time.Second))
+	assert.InDelta(t, 15*time.Second, shutdown, float64(time.Second))
+}
+
+func TestWithConfigDefaults(t *testing.T) {
+	t.Setenv("TEST_VALUE", "")
+	os.Unsetenv("TEST_VALUE")
+	var cfg TestConfig
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		cfg = ctx.Config
+		return Construct()
+	}, WithConfigDefaults(func(cfg *TestConfig) {
+		cfg.TestValue = "computed"
+	}))
+
+	require.NoError(t, err)
+	assert.Equal(t, "computed", cfg.TestValue)
+
+	err = RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct()
+	}, WithConfigDefaults(func(cfg *struct{ Other string }) {}))
+	assert.ErrorIs(t, err, ErrConfigLoad)
+}
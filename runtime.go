@@ -0,0 +1,227 @@
+package ezapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/accounting"
+	"github.com/pgvanniekerk/ezapp/internal/app"
+	"github.com/pgvanniekerk/ezapp/internal/trace"
+)
+
+// ErrNotRunning is returned by Runtime.AddRunner before the application has
+// started running and once shutdown has been initiated.
+var ErrNotRunning = app.ErrNotRunning
+
+// ErrDuplicateRunner is returned by Runtime.AddRunner when a runner with the
+// same name is already running.
+var ErrDuplicateRunner = app.ErrDuplicateRunner
+
+// ErrRunnerNotFound is returned by Runtime.StopRunner when no runner with the
+// given name is running.
+var ErrRunnerNotFound = app.ErrRunnerNotFound
+
+// Runtime is a handle to the running application, available to initializers
+// as InitCtx.Runtime. It allows runners to be managed after startup, e.g. to
+// start a tenant-specific consumer when a tenant is provisioned, or to stop a
+// feature module from an admin endpoint.
+//
+// The handle can be retained by the initializer and used from any goroutine;
+// its methods are safe for concurrent use.
+type Runtime struct {
+	mu    sync.Mutex
+	app   *app.App
+	wrap  func(name string, r app.Runner) app.Runner
+	hold  *holdGate
+	trace *trace.Buffer
+}
+
+// attach connects the runtime to the application once it has been created.
+func (rt *Runtime) attach(application *app.App, wrap func(name string, r app.Runner) app.Runner) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.app = application
+	rt.wrap = wrap
+}
+
+// running returns the attached application, or nil if it has not been
+// created yet.
+func (rt *Runtime) running() (*app.App, func(name string, r app.Runner) app.Runner) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.app, rt.wrap
+}
+
+// AddRunner starts r under the given name while the application is running.
+// The runner is treated like those configured through WithRunners: it is
+// cancelled on shutdown, a failure shuts the application down and is reported
+// as a RunnerError, and the application waits for it to return before
+// running cleanup.
+//
+// ErrNotRunning is returned if the application has not started running yet
+// (e.g. when called from within the initializer) or is shutting down.
+// ErrDuplicateRunner is returned if a runner with the same name is running.
+//
+// Example:
+//
+//	func (s *TenantService) Provision(tenant string) error {
+//	    return s.runtime.AddRunner("consumer-"+tenant, s.newConsumer(tenant).Run)
+//	}
+func (rt *Runtime) AddRunner(name string, r app.Runner) error {
+	application, wrap := rt.running()
+	if application == nil {
+		return ErrNotRunning
+	}
+	return application.AddRunner(name, wrap(name, r))
+}
+
+// StopRunner cancels the context of the named runner, leaving the other
+// runners running, and waits for it to return or for ctx to be done. This
+// allows a feature module to be disabled without restarting the process.
+//
+// A runner stopped this way does not shut the application down: its error,
+// if it returns one other than context.Canceled, is returned from StopRunner
+// instead. Stopping the last running runner finishes the application as if
+// every runner had returned. ErrRunnerNotFound is returned if no runner with
+// the given name is running.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+//	defer cancel()
+//	if err := runtime.StopRunner(ctx, "recommendations"); err != nil {
+//	    http.Error(w, err.Error(), http.StatusInternalServerError)
+//	}
+func (rt *Runtime) StopRunner(ctx context.Context, name string) error {
+	application, _ := rt.running()
+	if application == nil {
+		return fmt.Errorf("%w: %s", ErrRunnerNotFound, name)
+	}
+	return application.StopRunner(ctx, name)
+}
+
+// Runners returns the names of the runners that are currently running, in
+// lexical order. Runners added through WithRunners are named by position,
+// e.g. "runner-0".
+func (rt *Runtime) Runners() []string {
+	application, _ := rt.running()
+	if application == nil {
+		return nil
+	}
+	return application.Runners()
+}
+
+// RunnerResources describes the resources attributed to a single runner.
+type RunnerResources struct {
+	// Goroutines is the number of live goroutines started by the runner,
+	// including the runner's own goroutine.
+	Goroutines int `json:"goroutines"`
+
+	// CPUTime is the CPU time the runner's goroutines used during the
+	// sampling window of SampleResources. It is zero in the report of
+	// Resources, which does not sample CPU time.
+	CPUTime time.Duration `json:"cpu_time,omitempty"`
+}
+
+// ResourceReport breaks the process's resource usage down by runner.
+type ResourceReport struct {
+	// Runners holds the resources of each running runner, keyed by name.
+	Runners map[string]RunnerResources `json:"runners"`
+
+	// Unattributed holds the resources not owned by any running runner,
+	// such as framework, runtime and initializer goroutines.
+	Unattributed RunnerResources `json:"unattributed"`
+}
+
+// Resources returns the current resource usage per running runner.
+//
+// Every runner is invoked with the pprof label runner=<name>, which is
+// inherited by the goroutines it starts. Goroutines are counted by that label,
+// so a goroutine leak can be traced back to the runner that caused it. The
+// same label segments CPU profiles, e.g.
+// `go tool pprof -tagfocus runner=http http://host/debug/pprof/profile`
+// attributes CPU time to the http runner.
+func (rt *Runtime) Resources() (ResourceReport, error) {
+	counts, err := accounting.GoroutinesByRunner()
+	if err != nil {
+		return ResourceReport{}, err
+	}
+
+	report := ResourceReport{Runners: make(map[string]RunnerResources)}
+	for _, name := range rt.Runners() {
+		report.Runners[name] = RunnerResources{Goroutines: counts[name]}
+		delete(counts, name)
+	}
+	for _, count := range counts {
+		report.Unattributed.Goroutines += count
+	}
+
+	return report, nil
+}
+
+// SampleResources returns the current resource usage per running runner like
+// Resources, together with the CPU time each runner used while a CPU profile
+// was sampled for window, or until ctx is done. The sample is attributed by
+// the same pprof label as the goroutines. Only one CPU profile can be taken
+// at a time, so it fails while another is in progress, e.g. a request to
+// /debug/pprof/profile or a continuous profiler (see WithProfiler).
+func (rt *Runtime) SampleResources(ctx context.Context, window time.Duration) (ResourceReport, error) {
+	cpu, err := accounting.CPUByRunner(ctx, window)
+	if err != nil {
+		return ResourceReport{}, err
+	}
+	report, err := rt.Resources()
+	if err != nil {
+		return ResourceReport{}, err
+	}
+
+	for name, resources := range report.Runners {
+		resources.CPUTime = cpu[name]
+		report.Runners[name] = resources
+		delete(cpu, name)
+	}
+	for _, used := range cpu {
+		report.Unattributed.CPUTime += used
+	}
+
+	return report, nil
+}
+
+// ResourceHandler returns an http.Handler that serves the report returned by
+// Resources as JSON. Mount it on a debug server, next to net/http/pprof. With
+// a cpu query parameter holding a duration, e.g. ?cpu=10s, it serves the
+// report of SampleResources for that window instead.
+//
+// Example:
+//
+//	debugMux := http.NewServeMux()
+//	debugMux.Handle("/debug/runners", ctx.Runtime.ResourceHandler())
+func (rt *Runtime) ResourceHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			report ResourceReport
+			err    error
+		)
+		if cpu := r.URL.Query().Get("cpu"); cpu != "" {
+			window, parseErr := time.ParseDuration(cpu)
+			if parseErr != nil || window <= 0 {
+				http.Error(w, fmt.Sprintf("invalid cpu sampling window %q", cpu), http.StatusBadRequest)
+				return
+			}
+			report, err = rt.SampleResources(r.Context(), window)
+		} else {
+			report, err = rt.Resources()
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
@@ -0,0 +1,192 @@
+package ezapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/runner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuntimeAddRunner(t *testing.T) {
+	addErr := errors.New("tenant consumer failed")
+	var duringInit error
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		duringInit = ctx.Runtime.AddRunner("too-early", successfulRunner)
+
+		return Construct(WithNamedRunner("main", func(runCtx context.Context) error {
+			if err := ctx.Runtime.AddRunner("tenant-a", func(context.Context) error {
+				return addErr
+			}); err != nil {
+				return err
+			}
+			<-runCtx.Done()
+			return nil
+		}))
+	})
+
+	assert.ErrorIs(t, duringInit, ErrNotRunning)
+
+	var runnerErr *RunnerError
+	require.ErrorAs(t, err, &runnerErr)
+	assert.Equal(t, "tenant-a", runnerErr.Name)
+	assert.ErrorIs(t, err, addErr)
+}
+
+func TestRuntimeRunners(t *testing.T) {
+	var names []string
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithRunners(successfulRunner),
+			WithNamedRunner("main", func(runCtx context.Context) error {
+				recorded := make(chan struct{})
+				if err := ctx.Runtime.AddRunner("added", func(context.Context) error {
+					<-recorded
+					return nil
+				}); err != nil {
+					return err
+				}
+				names = ctx.Runtime.Runners()
+				close(recorded)
+				return nil
+			}),
+		)
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, names, "main")
+	assert.Contains(t, names, "added")
+}
+
+func TestConstructDuplicateRunnerNames(t *testing.T) {
+	_, err := Construct(
+		WithRunners(successfulRunner),
+		WithNamedRunner("runner-0", successfulRunner),
+	)
+	assert.ErrorContains(t, err, `duplicate runner name "runner-0"`)
+
+	_, err = Construct(WithNamedRunner("a", successfulRunner), WithNamedRunner("b", successfulRunner))
+	assert.NoError(t, err)
+}
+
+func TestRuntimeStopRunner(t *testing.T) {
+	var stopErr, missingErr error
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithNamedRunner("feature", func(runCtx context.Context) error {
+				<-runCtx.Done()
+				return runCtx.Err()
+			}),
+			WithNamedRunner("admin", func(runCtx context.Context) error {
+				stopErr = ctx.Runtime.StopRunner(runCtx, "feature")
+				missingErr = ctx.Runtime.StopRunner(runCtx, "missing")
+				return nil
+			}),
+		)
+	})
+	require.NoError(t, err)
+
+	assert.NoError(t, stopErr)
+	assert.ErrorIs(t, missingErr, ErrRunnerNotFound)
+}
+
+func TestRuntimeResources(t *testing.T) {
+	var report ResourceReport
+	var reportErr error
+	var body string
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithNamedRunner("leaky", func(runCtx context.Context) error {
+				// Start helper goroutines that inherit the runner label
+				done := make(chan struct{})
+				for range 3 {
+					go func() { <-done }()
+				}
+				<-runCtx.Done()
+				close(done)
+				return nil
+			}),
+			WithNamedRunner("inspector", func(runCtx context.Context) error {
+				require.Eventually(t, func() bool {
+					report, reportErr = ctx.Runtime.Resources()
+					return reportErr == nil && report.Runners["leaky"].Goroutines == 4
+				}, time.Second, 10*time.Millisecond)
+
+				recorder := httptest.NewRecorder()
+				ctx.Runtime.ResourceHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/runners", nil))
+				body = recorder.Body.String()
+
+				return ctx.Runtime.StopRunner(runCtx, "leaky")
+			}),
+		)
+	})
+	require.NoError(t, err)
+
+	assert.Positive(t, report.Runners["inspector"].Goroutines)
+	assert.Positive(t, report.Unattributed.Goroutines)
+	assert.Contains(t, body, `"leaky":{"goroutines":4}`)
+}
+
+func TestRuntimeSampleResources(t *testing.T) {
+	var body, invalid string
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithNamedRunner("busy", func(runCtx context.Context) error {
+				for runCtx.Err() == nil {
+					// Spin until stopped to use CPU time
+				}
+				return nil
+			}),
+			WithNamedRunner("inspector", func(runCtx context.Context) error {
+				recorder := httptest.NewRecorder()
+				ctx.Runtime.ResourceHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/runners?cpu=300ms", nil))
+				body = recorder.Body.String()
+
+				recorder = httptest.NewRecorder()
+				ctx.Runtime.ResourceHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/runners?cpu=soon", nil))
+				invalid = recorder.Body.String()
+
+				return ctx.Runtime.StopRunner(runCtx, "busy")
+			}),
+		)
+	})
+	require.NoError(t, err)
+
+	var report ResourceReport
+	require.NoError(t, json.Unmarshal([]byte(body), &report), body)
+	assert.Positive(t, report.Runners["busy"].CPUTime, "the busy runner should be attributed CPU time")
+	assert.Positive(t, report.Runners["busy"].Goroutines)
+	assert.Contains(t, invalid, `invalid cpu sampling window "soon"`)
+}
+
+func TestRunnerPprofLabels(t *testing.T) {
+	var names []string
+	var mu sync.Mutex
+	record := func(ctx context.Context) error {
+		name, _ := runner.Name(ctx)
+		mu.Lock()
+		names = append(names, name)
+		mu.Unlock()
+		return nil
+	}
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(WithRunners(record), WithNamedRunner("http", record))
+	})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"runner-0", "http"}, names)
+}
@@ -0,0 +1,189 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/health"
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+)
+
+// SelfTestFlag makes Run run the self-test with SelfTest instead of running
+// the application.
+const SelfTestFlag = "--self-test"
+
+// ErrSelfTest is returned by SelfTest when a component self-test, the
+// readiness check or a smoke check fails.
+var ErrSelfTest = errors.New("self-test failed")
+
+// selfTestPollInterval is the interval at which the health report is polled
+// while components are starting during a self-test.
+const selfTestPollInterval = 100 * time.Millisecond
+
+// SelfTester is implemented by components that can verify themselves without
+// external dependencies, such as a template set parsing its templates. The
+// SelfTest method of components registered with WithComponent is run by
+// SelfTest before the runners start.
+type SelfTester interface {
+	SelfTest(ctx context.Context) error
+}
+
+// smokeCheck is a check registered with WithSmokeCheck.
+type smokeCheck struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// WithSmokeCheck is a functional option that registers a check run by
+// SelfTest once the runners have started, such as requesting an endpoint of
+// the application's own HTTP server. Smoke checks run in registration order
+// under the startup timeout and are ignored outside a self-test.
+// An empty name or a nil check is rejected by Construct.
+//
+// Example:
+//
+//	appCtx, err := Construct(
+//	    WithRunners(server.Run),
+//	    WithSmokeCheck("orders-api", func(ctx context.Context) error {
+//	        return getOK(ctx, "http://localhost:8080/orders")
+//	    }),
+//	)
+func WithSmokeCheck(name string, check func(ctx context.Context) error) option {
+	return func(appCtx *AppCtx) error {
+		if name == "" {
+			return errors.New("smoke check name cannot be empty")
+		}
+		if check == nil {
+			return fmt.Errorf("smoke check %s must not be nil", name)
+		}
+		appCtx.smokeChecks = append(appCtx.smokeChecks, smokeCheck{name: name, fn: check})
+		return nil
+	}
+}
+
+// SelfTest checks that the application boots, as a gate in CI: it runs the
+// application like RunE with InitCtx.SelfTest set, so the initializer can
+// wire stub or ephemeral dependencies instead of real ones. Before the
+// runners start, the wiring is checked as by ValidateWiring and the SelfTest
+// method of every component implementing SelfTester is run. Once the runners
+// have started, SelfTest waits for the components reported with
+// Health.Starting, requires the health report to be ready, runs the smoke
+// checks registered with WithSmokeCheck and shuts the application down.
+// Failures wrap ErrSelfTest.
+//
+// Starting the binary with the --self-test flag (see SelfTestFlag) runs the
+// self-test, so that a built image can be checked with e.g.
+// "docker run orders --self-test".
+//
+// Example:
+//
+//	func Initialize(ctx ezapp.InitCtx[Config]) (ezapp.AppCtx, error) {
+//	    store := NewPostgresStore(ctx.Config.DatabaseURL)
+//	    if ctx.SelfTest {
+//	        store = NewMemoryStore()
+//	    }
+//	    ...
+//	}
+func SelfTest[Config any](initializer Initializer[Config], options ...RunOption) error {
+	options = append(slices.Clip(options), func(settings *runopt.Settings) {
+		settings.SelfTest = true
+	})
+	return RunE(initializer, options...)
+}
+
+// runComponentSelfTests runs the SelfTest method of the components of appCtx
+// implementing SelfTester and returns their failures.
+func runComponentSelfTests(ctx context.Context, logger *slog.Logger, appCtx AppCtx) error {
+	var errs []error
+	for _, c := range appCtx.components {
+		tester, ok := c.value.(SelfTester)
+		if !ok {
+			continue
+		}
+		if err := tester.SelfTest(ctx); err != nil {
+			logger.Error("component self-test failed", "component", c.name, "error", err)
+			errs = append(errs, fmt.Errorf("component %s: %w", c.name, err))
+			continue
+		}
+		logger.Debug("component self-test passed", "component", c.name)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%w: %w", ErrSelfTest, err)
+	}
+	return nil
+}
+
+// selfTest runs the readiness check and the smoke checks of a self-test in
+// the background once the runners have started.
+type selfTest struct {
+	done chan struct{}
+	err  error
+}
+
+// newSelfTest returns a selfTest that has not started.
+func newSelfTest() *selfTest {
+	return &selfTest{done: make(chan struct{})}
+}
+
+// start runs the self-test under ctx and then calls shutdown. It must be
+// called once.
+func (st *selfTest) start(ctx context.Context, logger *slog.Logger, registry *health.Registry, checks []smokeCheck, shutdown func()) {
+	go func() {
+		defer close(st.done)
+		defer shutdown()
+
+		st.err = runSmokeChecks(ctx, logger, registry, checks)
+		if st.err != nil {
+			st.err = fmt.Errorf("%w: %w", ErrSelfTest, st.err)
+			return
+		}
+		logger.Info("self-test passed", "smoke_checks", len(checks))
+	}()
+}
+
+// wait waits for a started self-test and returns its failure, if any.
+func (st *selfTest) wait() error {
+	<-st.done
+	return st.err
+}
+
+// runSmokeChecks waits for registry to be ready and runs checks, returning
+// their failures.
+func runSmokeChecks(ctx context.Context, logger *slog.Logger, registry *health.Registry, checks []smokeCheck) error {
+	report := registry.Check(ctx)
+	for report.Status == health.StatusStarting {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("components still starting: %w", ctx.Err())
+		case <-time.After(selfTestPollInterval):
+		}
+		report = registry.Check(ctx)
+	}
+	if !report.Ready() {
+		var failing []string
+		for _, result := range report.Checks {
+			if result.Status != health.StatusUp && result.Critical {
+				failing = append(failing, result.Name)
+			}
+		}
+		logger.Error("self-test health check failed", "status", report.Status, "checks", failing)
+		return fmt.Errorf("application not ready, failing health checks: %v", failing)
+	}
+
+	var errs []error
+	for _, check := range checks {
+		start := time.Now()
+		if err := check.fn(ctx); err != nil {
+			logger.Error("smoke check failed", "check", check.name, "duration", time.Since(start), "error", err)
+			errs = append(errs, fmt.Errorf("smoke check %s: %w", check.name, err))
+			continue
+		}
+		logger.Debug("smoke check passed", "check", check.name, "duration", time.Since(start))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,130 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// selfTestingComponent is a component implementing SelfTester.
+type selfTestingComponent struct {
+	err    error
+	tested bool
+}
+
+func (c *selfTestingComponent) SelfTest(ctx context.Context) error {
+	c.tested = true
+	return c.err
+}
+
+// untilStopped is a runner that serves until its context is cancelled.
+func untilStopped(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func TestSelfTest(t *testing.T) {
+	component := &selfTestingComponent{}
+	var selfTesting, smokeChecked bool
+
+	err := SelfTest(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		selfTesting = ctx.SelfTest
+		done := ctx.Health.Starting("cache")
+		return Construct(
+			WithComponent("templates", component),
+			WithRunners(untilStopped, func(ctx context.Context) error {
+				time.Sleep(2 * selfTestPollInterval)
+				done()
+				return untilStopped(ctx)
+			}),
+			WithSmokeCheck("api", func(context.Context) error {
+				smokeChecked = true
+				return nil
+			}),
+		)
+	})
+
+	require.NoError(t, err, "the self-test should stop the application once its smoke checks pass")
+	assert.True(t, selfTesting)
+	assert.True(t, component.tested)
+	assert.True(t, smokeChecked)
+}
+
+func TestSelfTestComponentFailure(t *testing.T) {
+	component := &selfTestingComponent{err: errors.New("template parse error")}
+
+	err := SelfTest(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithComponent("templates", component),
+			WithRunners(func(context.Context) error {
+				t.Error("runners should not start when a component self-test fails")
+				return nil
+			}),
+		)
+	})
+
+	require.ErrorIs(t, err, ErrSelfTest)
+	assert.ErrorContains(t, err, "component templates: template parse error")
+}
+
+func TestSelfTestSmokeCheckFailure(t *testing.T) {
+	var secondChecked bool
+
+	err := SelfTest(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithRunners(untilStopped),
+			WithSmokeCheck("api", func(context.Context) error {
+				return errors.New("status 500")
+			}),
+			WithSmokeCheck("metrics", func(context.Context) error {
+				secondChecked = true
+				return nil
+			}),
+		)
+	})
+
+	require.ErrorIs(t, err, ErrSelfTest)
+	assert.ErrorContains(t, err, "smoke check api: status 500")
+	assert.True(t, secondChecked, "every smoke check should run")
+}
+
+func TestSelfTestNotReady(t *testing.T) {
+	err := SelfTest(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		if err := ctx.Health.Register("database", func(context.Context) error {
+			return errors.New("connection refused")
+		}); err != nil {
+			return AppCtx{}, err
+		}
+		return Construct(WithRunners(untilStopped))
+	})
+
+	require.ErrorIs(t, err, ErrSelfTest)
+	assert.ErrorContains(t, err, "failing health checks: [database]")
+}
+
+func TestRunEIgnoresSmokeChecks(t *testing.T) {
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		assert.False(t, ctx.SelfTest)
+		return Construct(
+			WithRunners(successfulRunner),
+			WithSmokeCheck("api", func(context.Context) error {
+				t.Error("smoke checks should only run in a self-test")
+				return nil
+			}),
+		)
+	})
+
+	assert.NoError(t, err)
+}
+
+func TestWithSmokeCheckValidation(t *testing.T) {
+	_, err := Construct(WithSmokeCheck("", successfulCleanup))
+	assert.ErrorContains(t, err, "smoke check name cannot be empty")
+
+	_, err = Construct(WithSmokeCheck("api", nil))
+	assert.ErrorContains(t, err, "smoke check api must not be nil")
+}
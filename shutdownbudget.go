@@ -0,0 +1,154 @@
+package ezapp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+)
+
+// WithShutdownDeadline bounds the whole of shutdown, used when the
+// EZAPP_SHUTDOWN_DEADLINE environment variable is not set.
+//
+// By default the pre-shutdown hooks, the runners with a shutdown timeout and
+// the cleanup each have up to the shutdown timeout, so a shutdown can take
+// several times EZAPP_SHUTDOWN_TIMEOUT and overrun a pod's termination grace
+// period. With a deadline, the time left when each phase starts is split
+// evenly between it and the phases still to come: with hooks, runners and
+// cleanup and a deadline of 30 seconds, the hooks get up to 10 seconds, the
+// runners half of what the hooks left and the cleanup whatever remains. Each
+// phase receives a context with its derived deadline, runners that have not
+// returned by theirs fail with ErrRunnerShutdownTimeout, and the time a phase
+// does not use passes to the phases after it. Every phase stays bounded by the
+// shutdown timeout and runner budgets by WithRunnerShutdownTimeout.
+// Non-positive values disable the deadline.
+//
+// Example:
+//
+//	ezapp.Run(initializer, ezapp.WithShutdownDeadline(25*time.Second))
+func WithShutdownDeadline(deadline time.Duration) RunOption {
+	return func(settings *runopt.Settings) {
+		settings.ShutdownDeadline = max(deadline, 0)
+	}
+}
+
+// shutdownPhase is a phase of shutdown that shares the shutdown deadline.
+type shutdownPhase int
+
+const (
+	phaseHooks shutdownPhase = iota
+	phaseRunners
+	phaseCleanup
+)
+
+// shutdownBudget apportions the shutdown deadline across the phases of
+// shutdown. A nil budget leaves the phases unbounded.
+type shutdownBudget struct {
+	total  time.Duration
+	phases []shutdownPhase
+	now    func() time.Time
+
+	mu        sync.Mutex
+	deadline  time.Time
+	deadlines map[shutdownPhase]time.Time
+}
+
+// newShutdownBudget returns a budget of total shared by the phases the
+// application takes part in: the pre-shutdown hooks if it has any, the
+// runners and the cleanup if it has any.
+func newShutdownBudget(total time.Duration, hooks, cleanup bool) *shutdownBudget {
+	var phases []shutdownPhase
+	if hooks {
+		phases = append(phases, phaseHooks)
+	}
+	phases = append(phases, phaseRunners)
+	if cleanup {
+		phases = append(phases, phaseCleanup)
+	}
+	return &shutdownBudget{
+		total:     total,
+		phases:    phases,
+		now:       time.Now,
+		deadlines: make(map[shutdownPhase]time.Time),
+	}
+}
+
+// start starts the shutdown deadline, unless it has already started.
+func (b *shutdownBudget) start() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.startLocked()
+}
+
+func (b *shutdownBudget) startLocked() {
+	if b.deadline.IsZero() {
+		b.deadline = b.now().Add(b.total)
+	}
+}
+
+// phaseDeadline returns the deadline of phase, derived the first time it is
+// asked for: the phase gets an even share of the time left with the phases
+// after it, and the last phase, or one the application does not take part
+// in, gets the rest of the shutdown deadline.
+func (b *shutdownBudget) phaseDeadline(phase shutdownPhase) time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if deadline, ok := b.deadlines[phase]; ok {
+		return deadline
+	}
+	b.startLocked()
+
+	remaining := 1
+	for idx, p := range b.phases {
+		if p == phase {
+			remaining = len(b.phases) - idx
+			break
+		}
+	}
+	now := b.now()
+	deadline := b.deadline
+	if left := b.deadline.Sub(now); remaining > 1 && left > 0 {
+		deadline = now.Add(left / time.Duration(remaining))
+	}
+	b.deadlines[phase] = deadline
+	return deadline
+}
+
+// remaining returns the time left before the deadline of phase.
+func (b *shutdownBudget) remaining(phase shutdownPhase) time.Duration {
+	return max(b.phaseDeadline(phase).Sub(b.now()), 0)
+}
+
+// context derives a context from ctx that expires at the deadline of phase.
+func (b *shutdownBudget) context(ctx context.Context, phase shutdownPhase) (context.Context, context.CancelFunc) {
+	if b == nil {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, b.phaseDeadline(phase))
+}
+
+// hook wraps a pre-shutdown hook so that it runs with the deadline of the
+// hooks phase.
+func (b *shutdownBudget) hook(hook func(ctx context.Context) error) func(ctx context.Context) error {
+	if b == nil {
+		return hook
+	}
+	return func(ctx context.Context) error {
+		ctx, cancel := b.context(ctx, phaseHooks)
+		defer cancel()
+		return hook(ctx)
+	}
+}
+
+// watchdogBudget returns the time the watchdog allows for the pre-shutdown
+// hooks and the runners, given the budget they have without a deadline.
+func (b *shutdownBudget) watchdogBudget(budget time.Duration) time.Duration {
+	if b == nil {
+		return budget
+	}
+	return min(budget, b.total)
+}
@@ -0,0 +1,128 @@
+package ezapp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithShutdownDeadline(t *testing.T) {
+	var settings runopt.Settings
+	WithShutdownDeadline(30 * time.Second)(&settings)
+	assert.Equal(t, 30*time.Second, settings.ShutdownDeadline)
+
+	WithShutdownDeadline(-time.Second)(&settings)
+	assert.Zero(t, settings.ShutdownDeadline)
+}
+
+func TestShutdownBudgetPhaseDeadline(t *testing.T) {
+	now := time.Unix(0, 0)
+	budget := newShutdownBudget(30*time.Second, true, true)
+	budget.now = func() time.Time { return now }
+	budget.start()
+
+	// The hooks get a third of the deadline
+	assert.Equal(t, now.Add(10*time.Second), budget.phaseDeadline(phaseHooks))
+
+	// The hooks finish early, leaving the runners half of the 26 seconds left
+	now = now.Add(4 * time.Second)
+	assert.Equal(t, 13*time.Second, budget.remaining(phaseRunners))
+
+	// The phase deadline is fixed once derived
+	now = now.Add(time.Second)
+	assert.Equal(t, 12*time.Second, budget.remaining(phaseRunners))
+
+	// The cleanup gets the rest of the deadline
+	now = now.Add(12 * time.Second)
+	assert.Equal(t, 13*time.Second, budget.remaining(phaseCleanup))
+
+	// An expired deadline leaves no time
+	now = now.Add(time.Minute)
+	assert.Zero(t, budget.remaining(phaseCleanup))
+}
+
+func TestShutdownBudgetPhases(t *testing.T) {
+	now := time.Unix(0, 0)
+	budget := newShutdownBudget(30*time.Second, false, false)
+	budget.now = func() time.Time { return now }
+
+	// The runners alone get the whole deadline, which starts when first asked
+	assert.Equal(t, 30*time.Second, budget.remaining(phaseRunners))
+
+	budget = newShutdownBudget(30*time.Second, false, true)
+	budget.now = func() time.Time { return now }
+	assert.Equal(t, 15*time.Second, budget.remaining(phaseRunners))
+}
+
+func TestShutdownBudgetNil(t *testing.T) {
+	var budget *shutdownBudget
+	budget.start()
+
+	ctx, cancel := budget.context(context.Background(), phaseCleanup)
+	defer cancel()
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+
+	called := false
+	hook := budget.hook(func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	require.NoError(t, hook(context.Background()))
+	assert.True(t, called)
+	assert.Equal(t, time.Minute, budget.watchdogBudget(time.Minute))
+}
+
+func TestRunEShutdownDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	var hookLeft, cleanupLeft time.Duration
+	done := make(chan error, 1)
+	go func() {
+		done <- RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+			return Construct(
+				WithNamedRunner("failing", failingRunner),
+				WithNamedRunner("stubborn", func(ctx context.Context) error {
+					<-release
+					return nil
+				}),
+				WithPreShutdownHook(func(ctx context.Context) error {
+					deadline, _ := ctx.Deadline()
+					hookLeft = time.Until(deadline)
+					return nil
+				}),
+				WithCleanup(func(ctx context.Context) error {
+					deadline, _ := ctx.Deadline()
+					cleanupLeft = time.Until(deadline)
+					return nil
+				}),
+			)
+		}, WithShutdownDeadline(300*time.Millisecond))
+	}()
+
+	// Without the deadline the app would wait for the stubborn runner forever
+	select {
+	case err := <-done:
+		assert.ErrorContains(t, err, "runner failed")
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunE did not complete within timeout")
+	}
+	assert.LessOrEqual(t, hookLeft, 100*time.Millisecond, "the hook should get a third of the deadline")
+	assert.Positive(t, cleanupLeft)
+	assert.LessOrEqual(t, cleanupLeft, 150*time.Millisecond, "the cleanup should get what the runners left")
+}
+
+func TestRunEShutdownDeadlineInvalid(t *testing.T) {
+	t.Setenv("EZAPP_SHUTDOWN_DEADLINE", "soon")
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		t.Fatal("initializer should not be invoked")
+		return AppCtx{}, nil
+	})
+	assert.ErrorContains(t, err, "failed to load shutdown deadline")
+}
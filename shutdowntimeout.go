@@ -0,0 +1,89 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/app"
+)
+
+// ErrRunnerShutdownTimeout is reported, wrapped in a RunnerError, for a
+// runner that has not returned within its shutdown timeout once its context
+// was cancelled.
+var ErrRunnerShutdownTimeout = errors.New("runner did not stop within its shutdown timeout")
+
+// WithRunnerShutdownTimeout is a functional option that gives the named runner
+// its own shutdown budget: once its context is cancelled, it has timeout to
+// return. A runner that overruns its budget fails with ErrRunnerShutdownTimeout
+// and the application moves on to cleanup without waiting for it further.
+//
+// Budgets are capped by the overall shutdown timeout (EZAPP_SHUTDOWN_TIMEOUT,
+// default 15 seconds). Runners without a budget are waited on until they
+// return.
+//
+// Example:
+//
+//	appCtx, err := Construct(
+//	    WithNamedRunner("http", server.Run),
+//	    WithNamedRunner("kafka", consumer.Run),
+//	    WithRunnerShutdownTimeout("http", 10*time.Second),
+//	    WithRunnerShutdownTimeout("kafka", 60*time.Second), // commit offsets
+//	)
+func WithRunnerShutdownTimeout(name string, timeout time.Duration) option {
+	return func(appCtx *AppCtx) error {
+		if name == "" {
+			return errors.New("runner shutdown timeout requires a runner name")
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("shutdown timeout of runner %s must be positive, got %s", name, timeout)
+		}
+		if appCtx.shutdownTimeouts == nil {
+			appCtx.shutdownTimeouts = make(map[string]time.Duration)
+		}
+		appCtx.shutdownTimeouts[name] = timeout
+		return nil
+	}
+}
+
+// runnerShutdownTimeout returns the shutdown budget of the named runner,
+// capped by limit, and whether the runner has one.
+func (appCtx *AppCtx) runnerShutdownTimeout(name string, limit time.Duration) (time.Duration, bool) {
+	timeout, ok := appCtx.shutdownTimeouts[name]
+	if !ok {
+		return 0, false
+	}
+	return min(timeout, limit), true
+}
+
+// stopWithin wraps r so that it fails with ErrRunnerShutdownTimeout if it has
+// not returned within the timeout returned by timeout when its context is
+// cancelled. The overrun is logged, as only the first runner failure is
+// returned from the app, and the runner's goroutine is abandoned.
+func stopWithin(logger *slog.Logger, name string, timeout func() time.Duration, r app.Runner) app.Runner {
+	return func(ctx context.Context) error {
+		done := make(chan error, 1)
+		go func() {
+			done <- r(ctx)
+		}()
+
+		select {
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+		}
+
+		limit := timeout()
+		timer := time.NewTimer(limit)
+		defer timer.Stop()
+		select {
+		case err := <-done:
+			return err
+		case <-timer.C:
+			logger.Error("runner did not stop within its shutdown timeout", "runner", name, "timeout", limit)
+			return fmt.Errorf("%w of %s", ErrRunnerShutdownTimeout, limit)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package ezapp
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRunnerShutdownTimeout(t *testing.T) {
+	appCtx, err := Construct(
+		WithRunnerShutdownTimeout("kafka", time.Minute),
+		When("worker", true, func() (AppCtx, error) {
+			return Construct(WithRunnerShutdownTimeout("http", 10*time.Second))
+		}),
+	)
+	require.NoError(t, err)
+
+	timeout, ok := appCtx.runnerShutdownTimeout("kafka", 30*time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, timeout, "budgets should be capped by the overall shutdown timeout")
+
+	timeout, ok = appCtx.runnerShutdownTimeout("http", 30*time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, 10*time.Second, timeout)
+
+	_, ok = appCtx.runnerShutdownTimeout("other", 30*time.Second)
+	assert.False(t, ok)
+
+	_, err = Construct(WithRunnerShutdownTimeout("", time.Second))
+	assert.ErrorContains(t, err, "requires a runner name")
+	_, err = Construct(WithRunnerShutdownTimeout("http", 0))
+	assert.ErrorContains(t, err, "must be positive")
+}
+
+func TestStopWithin(t *testing.T) {
+	stubborn := func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(time.Second)
+		return nil
+	}
+	prompt := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	start := time.Now()
+	err := stopWithin(logger, "stubborn", func() time.Duration { return 20 * time.Millisecond }, stubborn)(ctx)
+	assert.ErrorIs(t, err, ErrRunnerShutdownTimeout)
+	assert.Less(t, time.Since(start), time.Second)
+
+	assert.ErrorIs(t, stopWithin(logger, "prompt", func() time.Duration { return time.Second }, prompt)(ctx), context.Canceled)
+}
+
+func TestRunERunnerShutdownTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	// Without its shutdown timeout the app would wait for kafka forever
+	done := make(chan error, 1)
+	go func() {
+		done <- RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+			return Construct(
+				WithNamedRunner("failing", failingRunner),
+				WithNamedRunner("kafka", func(ctx context.Context) error {
+					<-release
+					return nil
+				}),
+				WithRunnerShutdownTimeout("kafka", 20*time.Millisecond),
+			)
+		})
+	}()
+
+	select {
+	case err := <-done:
+		assert.ErrorContains(t, err, "runner failed")
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunE did not complete within timeout")
+	}
+}
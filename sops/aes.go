@@ -0,0 +1,54 @@
+package sops
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// encryptedValue matches a value encrypted by SOPS.
+var encryptedValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.+),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+// decryptValue decrypts value, encrypted by SOPS with AES-256-GCM under
+// dataKey and authenticated with additionalData. SOPS leaves empty values
+// unencrypted, so an empty value decrypts to itself.
+func decryptValue(value string, dataKey []byte, additionalData string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	match := encryptedValue.FindStringSubmatch(value)
+	if match == nil {
+		return "", errors.New("value is not encrypted by SOPS")
+	}
+	parts := make([][]byte, 3)
+	for idx, name := range []string{"data", "iv", "tag"} {
+		decoded, err := base64.StdEncoding.DecodeString(match[idx+1])
+		if err != nil {
+			return "", fmt.Errorf("invalid %s: %w", name, err)
+		}
+		parts[idx] = decoded
+	}
+	data, iv, tag := parts[0], parts[1], parts[2]
+	switch match[4] {
+	case "str", "bytes":
+	default:
+		return "", fmt.Errorf("unsupported value type %q", match[4])
+	}
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid data key: %w", err)
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return "", fmt.Errorf("invalid iv: %w", err)
+	}
+	plaintext, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
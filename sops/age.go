@@ -0,0 +1,84 @@
+package sops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// Age is a KeyService decrypting age master keys with age identities.
+type Age struct {
+	identities []age.Identity
+}
+
+// NewAge returns an Age decrypting with identities.
+func NewAge(identities ...age.Identity) *Age {
+	return &Age{identities: identities}
+}
+
+// LoadAgeKeys loads age identities from the environment, as the sops CLI
+// does:
+//
+//   - SOPS_AGE_KEY: identities, one AGE-SECRET-KEY-1... per line
+//   - SOPS_AGE_KEY_FILE: path of a file of identities
+//
+// If neither is set, the identities are read from sops/age/keys.txt in the
+// user's configuration directory, e.g. ~/.config/sops/age/keys.txt.
+func LoadAgeKeys() (*Age, error) {
+	var sources []string
+	if keys := os.Getenv("SOPS_AGE_KEY"); keys != "" {
+		sources = append(sources, keys)
+	}
+	path := os.Getenv("SOPS_AGE_KEY_FILE")
+	if path == "" && len(sources) == 0 {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, fmt.Errorf("no age keys configured: set SOPS_AGE_KEY or SOPS_AGE_KEY_FILE: %w", err)
+		}
+		path = filepath.Join(dir, "sops", "age", "keys.txt")
+	}
+	if path != "" {
+		keys, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read age keys: %w", err)
+		}
+		sources = append(sources, string(keys))
+	}
+
+	var identities []age.Identity
+	for _, source := range sources {
+		parsed, err := age.ParseIdentities(strings.NewReader(source))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse age keys: %w", err)
+		}
+		identities = append(identities, parsed...)
+	}
+	return NewAge(identities...), nil
+}
+
+// Decrypt decrypts the data key of an age master key, held armored in its
+// "enc" field.
+func (a *Age) Decrypt(_ context.Context, key MasterKey) ([]byte, error) {
+	if key.Type != "age" {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedKey, key.Type)
+	}
+	if len(a.identities) == 0 {
+		return nil, errors.New("no age identities")
+	}
+	reader, err := age.Decrypt(armor.NewReader(strings.NewReader(key.Fields["enc"])), a.identities...)
+	if err != nil {
+		return nil, fmt.Errorf("recipient %s: %w", key.Fields["recipient"], err)
+	}
+	dataKey, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("recipient %s: %w", key.Fields["recipient"], err)
+	}
+	return dataKey, nil
+}
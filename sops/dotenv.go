@@ -0,0 +1,234 @@
+package sops
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// metadataPrefix starts the names of the variables holding the metadata of
+// a dotenv file encrypted by SOPS.
+const metadataPrefix = "sops_"
+
+// defaultUnencryptedSuffix is the suffix of the names of variables that SOPS
+// leaves unencrypted when the metadata selects no variables.
+const defaultUnencryptedSuffix = "_unencrypted"
+
+// masterKeyName matches the flattened name of a master key field, e.g.
+// "age__list_0__map_enc".
+var masterKeyName = regexp.MustCompile(`^([a-z_]+?)__list_(\d+)__map_(.+)$`)
+
+// variable is a variable of a dotenv file.
+type variable struct {
+	name  string
+	value string
+}
+
+// metadata is the metadata of a file encrypted by SOPS.
+type metadata struct {
+	lastModified      string
+	mac               string
+	unencryptedSuffix string
+	encryptedSuffix   string
+	unencryptedRegex  *regexp.Regexp
+	encryptedRegex    *regexp.Regexp
+	macOnlyEncrypted  bool
+	masterKeys        []MasterKey
+}
+
+// encrypted reports whether SOPS encrypted the variable name.
+func (m metadata) encrypted(name string) bool {
+	switch {
+	case m.unencryptedSuffix != "":
+		return !strings.HasSuffix(name, m.unencryptedSuffix)
+	case m.encryptedSuffix != "":
+		return strings.HasSuffix(name, m.encryptedSuffix)
+	case m.unencryptedRegex != nil:
+		return !m.unencryptedRegex.MatchString(name)
+	case m.encryptedRegex != nil:
+		return m.encryptedRegex.MatchString(name)
+	}
+	return true
+}
+
+// DecryptDotEnvFile reads the dotenv file at path, encrypted by SOPS, and
+// decrypts it with DecryptDotEnv.
+func DecryptDotEnvFile(ctx context.Context, path string, keys ...KeyService) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	vars, err := DecryptDotEnv(ctx, data, keys...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", path, err)
+	}
+	return vars, nil
+}
+
+// DecryptDotEnv decrypts data, a dotenv file encrypted by SOPS, and returns
+// its variables in the "key=value" form of os.Environ, in file order. The
+// data key is recovered from the first master key of the file that one of
+// keys can decrypt, and the file's MAC is verified, so a file whose values
+// were altered, removed or reordered is rejected.
+func DecryptDotEnv(ctx context.Context, data []byte, keys ...KeyService) ([]string, error) {
+	vars, meta, err := parseDotEnv(data)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := recoverDataKey(ctx, meta.masterKeys, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha512.New()
+	environ := make([]string, 0, len(vars))
+	for _, v := range vars {
+		value := v.value
+		encrypted := meta.encrypted(v.name)
+		if encrypted {
+			if value, err = decryptValue(v.value, dataKey, v.name+":"); err != nil {
+				return nil, fmt.Errorf("%s: %w", v.name, err)
+			}
+		}
+		if encrypted || !meta.macOnlyEncrypted {
+			hash.Write([]byte(value))
+		}
+		environ = append(environ, v.name+"="+value)
+	}
+
+	lastModified, err := time.Parse(time.RFC3339, meta.lastModified)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lastmodified: %w", err)
+	}
+	mac, err := decryptValue(meta.mac, dataKey, lastModified.Format(time.RFC3339))
+	if err != nil {
+		return nil, fmt.Errorf("mac: %w", err)
+	}
+	if mac != fmt.Sprintf("%X", hash.Sum(nil)) {
+		return nil, errors.New("MAC mismatch: the file was modified after it was encrypted")
+	}
+	return environ, nil
+}
+
+// parseDotEnv splits data, a dotenv file encrypted by SOPS, into its
+// variables and its metadata, following the dotenv format written by SOPS:
+// one KEY=VALUE per line with newlines in values escaped as \n, and comment
+// lines starting with #.
+func parseDotEnv(data []byte) ([]variable, metadata, error) {
+	var (
+		vars []variable
+		raw  = make(map[string]string)
+	)
+	for lineNo, line := range bytes.Split(data, []byte("\n")) {
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		name, value, ok := strings.Cut(string(line), "=")
+		if !ok {
+			return nil, metadata{}, fmt.Errorf("invalid line %d: expected KEY=VALUE", lineNo+1)
+		}
+		value = strings.ReplaceAll(value, `\n`, "\n")
+		if field, isMetadata := strings.CutPrefix(name, metadataPrefix); isMetadata {
+			raw[field] = value
+			continue
+		}
+		vars = append(vars, variable{name: name, value: value})
+	}
+	if raw["mac"] == "" || raw["lastmodified"] == "" {
+		return nil, metadata{}, errors.New("file is not encrypted by SOPS: metadata not found")
+	}
+
+	meta, err := parseMetadata(raw)
+	return vars, meta, err
+}
+
+// parseMetadata parses the flattened metadata of a file encrypted by SOPS.
+func parseMetadata(raw map[string]string) (metadata, error) {
+	meta := metadata{
+		lastModified:      raw["lastmodified"],
+		mac:               raw["mac"],
+		unencryptedSuffix: raw["unencrypted_suffix"],
+		encryptedSuffix:   raw["encrypted_suffix"],
+	}
+	for field, target := range map[string]**regexp.Regexp{
+		"unencrypted_regex": &meta.unencryptedRegex,
+		"encrypted_regex":   &meta.encryptedRegex,
+	} {
+		if raw[field] == "" {
+			continue
+		}
+		re, err := regexp.Compile(raw[field])
+		if err != nil {
+			return metadata{}, fmt.Errorf("invalid %s: %w", field, err)
+		}
+		*target = re
+	}
+	if meta.unencryptedSuffix == "" && meta.encryptedSuffix == "" && meta.unencryptedRegex == nil && meta.encryptedRegex == nil {
+		meta.unencryptedSuffix = defaultUnencryptedSuffix
+	}
+	if raw["mac_only_encrypted"] != "" {
+		macOnlyEncrypted, err := strconv.ParseBool(raw["mac_only_encrypted"])
+		if err != nil {
+			return metadata{}, fmt.Errorf("invalid mac_only_encrypted: %w", err)
+		}
+		meta.macOnlyEncrypted = macOnlyEncrypted
+	}
+	if _, ok := raw["shamir_threshold"]; ok {
+		return metadata{}, errors.New("key groups are not supported")
+	}
+
+	// Master keys are flattened to e.g. age__list_0__map_enc
+	byIndex := make(map[string]*MasterKey)
+	var order []string
+	for name, value := range raw {
+		match := masterKeyName.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+		id := match[1] + "/" + match[2]
+		key, ok := byIndex[id]
+		if !ok {
+			key = &MasterKey{Type: match[1], Fields: make(map[string]string)}
+			byIndex[id] = key
+			order = append(order, id)
+		}
+		key.Fields[match[3]] = value
+	}
+	sort.Strings(order)
+	for _, id := range order {
+		meta.masterKeys = append(meta.masterKeys, *byIndex[id])
+	}
+	return meta, nil
+}
+
+// recoverDataKey returns the data key decrypted from the first of
+// masterKeys that one of keys can decrypt.
+func recoverDataKey(ctx context.Context, masterKeys []MasterKey, keys []KeyService) ([]byte, error) {
+	if len(masterKeys) == 0 {
+		return nil, errors.New("file has no master keys")
+	}
+	var errs []error
+	for _, masterKey := range masterKeys {
+		for _, service := range keys {
+			dataKey, err := service.Decrypt(ctx, masterKey)
+			if err == nil {
+				return dataKey, nil
+			}
+			if !errors.Is(err, ErrUnsupportedKey) {
+				errs = append(errs, fmt.Errorf("%s master key: %w", masterKey.Type, err))
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil, errors.New("no key service supports the master keys of the file")
+	}
+	return nil, fmt.Errorf("failed to decrypt the data key: %w", errors.Join(errs...))
+}
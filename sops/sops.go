@@ -0,0 +1,40 @@
+// Package sops decrypts configuration files encrypted with SOPS, so that
+// files holding secrets can be kept in version control and decrypted in
+// memory at startup. Files in SOPS's dotenv format are supported.
+//
+// The data key of a file is recovered from one of its master keys through a
+// KeyService. Age, configured like the sops CLI, handles age keys; other
+// master keys, such as AWS KMS, are handled by adapters implementing
+// KeyService with the provider's SDK.
+package sops
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrUnsupportedKey is wrapped by the errors a KeyService returns for master
+// keys of a type it does not handle.
+var ErrUnsupportedKey = errors.New("unsupported master key")
+
+// MasterKey is a master key recorded in the metadata of an encrypted file,
+// holding the file's data key encrypted for one recipient.
+type MasterKey struct {
+
+	// Type is the kind of master key as named in the metadata, such as
+	// "age", "kms", "gcp_kms", "azure_kv", "hc_vault" or "pgp".
+	Type string
+
+	// Fields holds the metadata of the master key, such as "enc" holding the
+	// encrypted data key, "recipient" for age or "arn" for AWS KMS.
+	Fields map[string]string
+}
+
+// KeyService recovers the data key of an encrypted file from one of its
+// master keys.
+type KeyService interface {
+
+	// Decrypt returns the data key encrypted in key, or an error wrapping
+	// ErrUnsupportedKey if the service does not handle keys of its type.
+	Decrypt(ctx context.Context, key MasterKey) ([]byte, error)
+}
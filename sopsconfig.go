@@ -0,0 +1,78 @@
+package ezapp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+	"github.com/pgvanniekerk/ezapp/sops"
+)
+
+// sopsDecryptTimeout bounds decrypting the file given to WithSOPSFile, which
+// may call a KMS to recover its data key.
+const sopsDecryptTimeout = 30 * time.Second
+
+// WithSOPSFile loads configuration variables from the dotenv file at path,
+// encrypted with SOPS, so that configuration holding secrets can be kept in
+// version control. The file is decrypted in memory before the configuration
+// is loaded, with keys or, if none are given, the age keys configured in
+// SOPS_AGE_KEY or SOPS_AGE_KEY_FILE (see sops.LoadAgeKeys). Its variables
+// populate the Config type and InitCtx.Environ like environment variables,
+// but are not set in the process environment, so child processes do not
+// inherit them. Variables already set in the environment take precedence.
+// A file that cannot be read or decrypted fails the run with ErrConfigLoad.
+//
+// Example:
+//
+//	ezapp.Run(initializer, ezapp.WithSOPSFile("config/production.enc.env"))
+//
+// To recover the data key with AWS KMS instead, pass a sops.KeyService
+// calling KMS with the master key's "arn" and "enc" fields:
+//
+//	ezapp.Run(initializer, ezapp.WithSOPSFile("config/production.enc.env", kmsKeys))
+func WithSOPSFile(path string, keys ...sops.KeyService) RunOption {
+	return func(settings *runopt.Settings) {
+		settings.SOPSFile = path
+		settings.SOPSKeys = keys
+	}
+}
+
+// mergeSOPSFile returns environ with the variables decrypted from the file
+// at path added, keeping those already set in environ, and the names of the
+// variables added.
+func mergeSOPSFile(environ []string, path string, keys []sops.KeyService) ([]string, []string, error) {
+	if len(keys) == 0 {
+		ageKeys, err := sops.LoadAgeKeys()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decrypt %s: %w", path, err)
+		}
+		keys = []sops.KeyService{ageKeys}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sopsDecryptTimeout)
+	defer cancel()
+	decrypted, err := sops.DecryptDotEnvFile(ctx, path, keys...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	set := make(map[string]bool, len(environ))
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		set[name] = true
+	}
+	merged := slices.Clip(environ)
+	var added []string
+	for _, kv := range decrypted {
+		name, _, _ := strings.Cut(kv, "=")
+		if set[name] {
+			continue
+		}
+		merged = append(merged, kv)
+		added = append(added, name)
+	}
+	return merged, added, nil
+}
@@ -0,0 +1,325 @@
+package sqlmetrics
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/logctx"
+	"github.com/pgvanniekerk/ezapp/metrics"
+)
+
+// operation is a kind of statement whose runs are recorded.
+type operation int
+
+const (
+	opQuery operation = iota
+	opExec
+)
+
+func (op operation) String() string {
+	if op == opQuery {
+		return "query"
+	}
+	return "exec"
+}
+
+// operationStats records the runs of an operation.
+type operationStats struct {
+	count    atomic.Int64
+	errors   atomic.Int64
+	duration atomic.Int64 // nanoseconds
+}
+
+// instrumentedDriver wraps a driver, recording the queries and execs run on
+// its connections. Queries are timed until their rows are returned, not
+// until they have been read.
+type instrumentedDriver struct {
+	driver.Driver
+	slowThreshold time.Duration
+	logger        *slog.Logger
+
+	ops  [2]operationStats
+	slow atomic.Int64
+}
+
+func (d *instrumentedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{Conn: conn, driver: d}, nil
+}
+
+// observe records a run of op that started at startedAt and returned err.
+func (d *instrumentedDriver) observe(ctx context.Context, op operation, query string, startedAt time.Time, err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		return // database/sql retries the statement another way
+	}
+	duration := time.Since(startedAt)
+	stats := &d.ops[op]
+	stats.count.Add(1)
+	stats.duration.Add(int64(duration))
+	if err != nil {
+		stats.errors.Add(1)
+	}
+
+	if d.slowThreshold <= 0 || duration < d.slowThreshold {
+		return
+	}
+	d.slow.Add(1)
+	logger, ok := logctx.From(ctx)
+	if !ok {
+		logger = d.logger
+	}
+	logger.LogAttrs(ctx, slog.LevelWarn, "slow sql query",
+		slog.String("operation", op.String()),
+		slog.String("query", query),
+		slog.Duration("duration", duration),
+	)
+}
+
+// samples returns the recorded runs as metrics labelled with db=name.
+func (d *instrumentedDriver) samples(name string) []metrics.Sample {
+	samples := make([]metrics.Sample, 0, 3*len(d.ops)+1)
+	for op := range d.ops {
+		labels := map[string]string{"db": name, "operation": operation(op).String()}
+		stats := &d.ops[op]
+		samples = append(samples,
+			metrics.Sample{Name: "sql_queries_total", Help: "Number of statements run.", Type: metrics.Counter, Labels: labels, Value: float64(stats.count.Load())},
+			metrics.Sample{Name: "sql_query_errors_total", Help: "Number of statements that failed.", Type: metrics.Counter, Labels: labels, Value: float64(stats.errors.Load())},
+			metrics.Sample{Name: "sql_query_duration_seconds_total", Help: "Total time spent running statements.", Type: metrics.Counter, Labels: labels, Value: time.Duration(stats.duration.Load()).Seconds()},
+		)
+	}
+	return append(samples, metrics.Sample{
+		Name:   "sql_slow_queries_total",
+		Help:   "Number of statements slower than the slow query threshold.",
+		Type:   metrics.Counter,
+		Labels: map[string]string{"db": name},
+		Value:  float64(d.slow.Load()),
+	})
+}
+
+// instrumentedConn records the statements run on a connection. The optional
+// interfaces of driver.Conn that database/sql checks for, including the
+// legacy driver.Execer and driver.Queryer, are implemented by delegating to
+// the wrapped connection, falling back to what database/sql does when a
+// connection lacks them.
+type instrumentedConn struct {
+	driver.Conn
+	driver *instrumentedDriver
+}
+
+func (c *instrumentedConn) Prepare(query string) (driver.Stmt, error) {
+	stmt, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return c.wrapStmt(stmt, query), nil
+}
+
+// wrapStmt instruments stmt, prepared from query, keeping the
+// driver.ColumnConverter that database/sql converts arguments with.
+func (c *instrumentedConn) wrapStmt(stmt driver.Stmt, query string) driver.Stmt {
+	instrumented := &instrumentedStmt{Stmt: stmt, query: query, conn: c.Conn, driver: c.driver}
+	if converter, ok := stmt.(driver.ColumnConverter); ok {
+		return &converterStmt{instrumentedStmt: instrumented, converter: converter}
+	}
+	return instrumented
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	preparer, ok := c.Conn.(driver.ConnPrepareContext)
+	if !ok {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return c.Prepare(query)
+	}
+	stmt, err := preparer.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return c.wrapStmt(stmt, query), nil
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != driver.IsolationLevel(0) {
+		return nil, errors.New("sql: driver does not support non-default isolation level")
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("sql: driver does not support read-only transactions")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Conn.Begin()
+}
+
+// ExecContext runs query if the wrapped connection can do so directly, and
+// otherwise returns driver.ErrSkip so database/sql prepares it instead.
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	var (
+		result driver.Result
+		err    error
+	)
+	startedAt := time.Now()
+	switch execer := c.Conn.(type) {
+	case driver.ExecerContext:
+		result, err = execer.ExecContext(ctx, query, args)
+	case driver.Execer:
+		values, convErr := namedValuesToValues(args)
+		if convErr != nil {
+			return nil, convErr
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		result, err = execer.Exec(query, values)
+	default:
+		return nil, driver.ErrSkip
+	}
+	c.driver.observe(ctx, opExec, query, startedAt, err)
+	return result, err
+}
+
+// QueryContext runs query if the wrapped connection can do so directly, and
+// otherwise returns driver.ErrSkip so database/sql prepares it instead.
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	var (
+		rows driver.Rows
+		err  error
+	)
+	startedAt := time.Now()
+	switch queryer := c.Conn.(type) {
+	case driver.QueryerContext:
+		rows, err = queryer.QueryContext(ctx, query, args)
+	case driver.Queryer:
+		values, convErr := namedValuesToValues(args)
+		if convErr != nil {
+			return nil, convErr
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		rows, err = queryer.Query(query, values)
+	default:
+		return nil, driver.ErrSkip
+	}
+	c.driver.observe(ctx, opQuery, query, startedAt, err)
+	return rows, err
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *instrumentedConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// instrumentedStmt records the runs of a prepared statement on conn, the
+// wrapped connection.
+type instrumentedStmt struct {
+	driver.Stmt
+	query  string
+	conn   driver.Conn
+	driver *instrumentedDriver
+}
+
+func (s *instrumentedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	startedAt := time.Now()
+	var (
+		result driver.Result
+		err    error
+	)
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else if values, convErr := namedValuesToValues(args); convErr != nil {
+		return nil, convErr
+	} else {
+		result, err = s.Stmt.Exec(values)
+	}
+	s.driver.observe(ctx, opExec, s.query, startedAt, err)
+	return result, err
+}
+
+func (s *instrumentedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	startedAt := time.Now()
+	var (
+		rows driver.Rows
+		err  error
+	)
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else if values, convErr := namedValuesToValues(args); convErr != nil {
+		return nil, convErr
+	} else {
+		rows, err = s.Stmt.Query(values)
+	}
+	s.driver.observe(ctx, opQuery, s.query, startedAt, err)
+	return rows, err
+}
+
+// CheckNamedValue checks value with the statement's driver.NamedValueChecker
+// or, as database/sql would without one, the connection's.
+func (s *instrumentedStmt) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	if checker, ok := s.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// converterStmt is an instrumentedStmt for a statement that implements
+// driver.ColumnConverter. database/sql converts the arguments with it when
+// CheckNamedValue returns driver.ErrSkip.
+type converterStmt struct {
+	*instrumentedStmt
+	converter driver.ColumnConverter
+}
+
+func (s *converterStmt) ColumnConverter(idx int) driver.ValueConverter {
+	return s.converter.ColumnConverter(idx)
+}
+
+// namedValuesToValues converts arguments for drivers predating named
+// parameters, as database/sql does.
+func namedValuesToValues(named []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(named))
+	for idx, arg := range named {
+		if arg.Name != "" {
+			return nil, errors.New("sql: driver does not support the use of Named Parameters")
+		}
+		values[idx] = arg.Value
+	}
+	return values, nil
+}
@@ -0,0 +1,303 @@
+package sqlmetrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/logctx"
+	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	sql.Register("sqlmetrics-fake", fakeDriver{})
+}
+
+// fakeDriver opens connections that only support prepared statements, as
+// drivers predating the context interfaces do.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return &fakeConn{}, nil
+}
+
+// fakeConnector opens connections that run statements directly.
+type fakeConnector struct{}
+
+func (fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &directConn{}, nil
+}
+
+func (fakeConnector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeConn struct{}
+
+func (*fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{query: query}, nil
+}
+
+func (*fakeConn) Close() error {
+	return nil
+}
+
+func (*fakeConn) Begin() (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+type directConn struct {
+	fakeConn
+}
+
+func (*directConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), run(query)
+}
+
+func (*directConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	return fakeRows{}, run(query)
+}
+
+type fakeStmt struct {
+	query string
+}
+
+func (fakeStmt) Close() error {
+	return nil
+}
+
+func (fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), run(s.query)
+}
+
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return fakeRows{}, run(s.query)
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error {
+	return nil
+}
+
+func (fakeTx) Rollback() error {
+	return nil
+}
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string {
+	return []string{"n"}
+}
+
+func (fakeRows) Close() error {
+	return nil
+}
+
+func (fakeRows) Next([]driver.Value) error {
+	return io.EOF
+}
+
+// run fails queries mentioning "fail" and delays those mentioning "slow".
+func run(query string) error {
+	if strings.Contains(query, "slow") {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if strings.Contains(query, "fail") {
+		return errors.New("query failed")
+	}
+	return nil
+}
+
+func TestInstrumentedDriver(t *testing.T) {
+	for name, open := range map[string]func() (*sql.DB, error){
+		"prepared": func() (*sql.DB, error) { return Open("sqlmetrics-fake", "") },
+		"direct":   func() (*sql.DB, error) { return OpenDB(fakeConnector{}), nil },
+	} {
+		t.Run(name, func(t *testing.T) {
+			db, err := open()
+			require.NoError(t, err)
+			defer db.Close()
+
+			_, err = db.Exec("UPDATE orders SET paid = true")
+			require.NoError(t, err)
+			_, err = db.Exec("UPDATE orders SET fail = true")
+			require.Error(t, err)
+			rows, err := db.Query("SELECT 1")
+			require.NoError(t, err)
+			require.NoError(t, rows.Close())
+
+			tx, err := db.Begin()
+			require.NoError(t, err)
+			_, err = tx.Exec("DELETE FROM orders")
+			require.NoError(t, err)
+			require.NoError(t, tx.Commit())
+
+			instrumented := db.Driver().(*instrumentedDriver)
+			assert.Equal(t, int64(3), instrumented.ops[opExec].count.Load())
+			assert.Equal(t, int64(1), instrumented.ops[opExec].errors.Load())
+			assert.Equal(t, int64(1), instrumented.ops[opQuery].count.Load())
+			assert.Zero(t, instrumented.ops[opQuery].errors.Load())
+		})
+	}
+}
+
+func TestSlowQueryThreshold(t *testing.T) {
+	fallback, fallbackRecords := testutil.NewTestLogger(slog.LevelInfo)
+	db := OpenDB(fakeConnector{}, WithSlowQueryThreshold(10*time.Millisecond), WithLogger(fallback))
+	defer db.Close()
+
+	_, err := db.Exec("SELECT fast")
+	require.NoError(t, err)
+	assert.Empty(t, fallbackRecords.Records())
+
+	_, err = db.Exec("SELECT slow")
+	require.NoError(t, err)
+	query, ok := fallbackRecords.Attr("slow sql query", "query")
+	require.True(t, ok)
+	assert.Equal(t, "SELECT slow", query.String())
+
+	logger, records := testutil.NewTestLogger(slog.LevelInfo)
+	ctx := logctx.With(t.Context(), logger.With("request_id", "abc"))
+	_, err = db.QueryContext(ctx, "SELECT slow")
+	require.NoError(t, err)
+	id, ok := records.Attr("slow sql query", "request_id")
+	require.True(t, ok, "slow queries should be logged with the context's logger")
+	assert.Equal(t, "abc", id.String())
+	assert.Equal(t, int64(2), db.Driver().(*instrumentedDriver).slow.Load())
+}
+
+// connConnector opens the connections returned by open.
+type connConnector func() driver.Conn
+
+func (c connConnector) Connect(context.Context) (driver.Conn, error) {
+	return c(), nil
+}
+
+func (connConnector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+// convertingConn prepares statements that convert their argument with a
+// driver.ColumnConverter and record the value they receive.
+type convertingConn struct {
+	fakeConn
+	received *[]driver.Value
+}
+
+func (c *convertingConn) Prepare(query string) (driver.Stmt, error) {
+	return convertingStmt{fakeStmt: fakeStmt{query: query}, received: c.received}, nil
+}
+
+type convertingStmt struct {
+	fakeStmt
+	received *[]driver.Value
+}
+
+func (convertingStmt) NumInput() int {
+	return 1
+}
+
+func (convertingStmt) ColumnConverter(int) driver.ValueConverter {
+	return doubler{}
+}
+
+func (s convertingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	*s.received = append(*s.received, args...)
+	return s.fakeStmt.Exec(args)
+}
+
+// doubler converts integers to twice their value.
+type doubler struct{}
+
+func (doubler) ConvertValue(v any) (driver.Value, error) {
+	v, err := driver.DefaultParameterConverter.ConvertValue(v)
+	if err != nil {
+		return nil, err
+	}
+	n, ok := v.(int64)
+	if !ok {
+		return nil, errors.New("not an integer")
+	}
+	return 2 * n, nil
+}
+
+// legacyConn runs statements directly through the legacy driver.Execer and
+// driver.Queryer interfaces, rejecting a ping argument with its
+// driver.NamedValueChecker.
+type legacyConn struct {
+	fakeConn
+	direct *int
+}
+
+func (c *legacyConn) Exec(query string, _ []driver.Value) (driver.Result, error) {
+	*c.direct++
+	return driver.RowsAffected(1), run(query)
+}
+
+func (c *legacyConn) Query(query string, _ []driver.Value) (driver.Rows, error) {
+	*c.direct++
+	return fakeRows{}, run(query)
+}
+
+func (*legacyConn) CheckNamedValue(value *driver.NamedValue) error {
+	if value.Value == "ping" {
+		return errors.New("ping is not a valid argument")
+	}
+	return driver.ErrSkip
+}
+
+func TestInstrumentedStmtColumnConverter(t *testing.T) {
+	var received []driver.Value
+	db := OpenDB(connConnector(func() driver.Conn { return &convertingConn{received: &received} }))
+	defer db.Close()
+
+	stmt, err := db.Prepare("UPDATE orders SET quantity = ?")
+	require.NoError(t, err)
+	defer stmt.Close()
+
+	_, err = stmt.Exec(21)
+	require.NoError(t, err)
+	assert.Equal(t, []driver.Value{int64(42)}, received, "arguments should be converted by the statement's ColumnConverter")
+
+	_, err = stmt.Exec("many")
+	assert.ErrorContains(t, err, "not an integer")
+	assert.Equal(t, int64(1), db.Driver().(*instrumentedDriver).ops[opExec].count.Load())
+}
+
+func TestInstrumentedConnLegacyInterfaces(t *testing.T) {
+	var direct int
+	db := OpenDB(connConnector(func() driver.Conn { return &legacyConn{direct: &direct} }))
+	defer db.Close()
+
+	_, err := db.Exec("UPDATE orders SET paid = ?", true)
+	require.NoError(t, err)
+	rows, err := db.Query("SELECT ?", 1)
+	require.NoError(t, err)
+	require.NoError(t, rows.Close())
+	assert.Equal(t, 2, direct, "statements should run through the connection's Execer and Queryer")
+
+	instrumented := db.Driver().(*instrumentedDriver)
+	assert.Equal(t, int64(1), instrumented.ops[opExec].count.Load())
+	assert.Equal(t, int64(1), instrumented.ops[opQuery].count.Load())
+
+	// Prepared statements check their arguments with the connection's
+	// NamedValueChecker
+	stmt, err := db.Prepare("SELECT ?")
+	require.NoError(t, err)
+	defer stmt.Close()
+	_, err = stmt.Exec("ping")
+	assert.ErrorContains(t, err, "ping is not a valid argument")
+}
@@ -0,0 +1,204 @@
+// Package sqlmetrics instruments database/sql connection pools: it wraps the
+// driver to record the duration of queries and to log slow ones, and reports
+// the query and pool statistics as metrics.
+//
+// The wrapped connections and statements keep the optional driver interfaces
+// database/sql looks for, such as driver.ColumnConverter, driver.Execer and
+// driver.NamedValueChecker, so arguments are converted and statements run as
+// they would be without instrumentation. Transactions are not wrapped; the
+// statements run in them are recorded.
+//
+// Example:
+//
+//	cfg, err := sqlmetrics.LoadConfig()
+//	if err != nil {
+//	    return ezapp.AppCtx{}, err
+//	}
+//	db, err := sqlmetrics.OpenFromConfig(cfg, "pgx", ctx.Config.DatabaseURL,
+//	    sqlmetrics.WithLogger(ctx.Logger))
+//	if err != nil {
+//	    return ezapp.AppCtx{}, err
+//	}
+//	ctx.Metrics.Register(sqlmetrics.Collector("orders", db))
+//	return ezapp.Construct(
+//	    ezapp.WithRunners(server.Run),
+//	    ezapp.WithDatabase("orders", db),
+//	)
+package sqlmetrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/config"
+	"github.com/pgvanniekerk/ezapp/metrics"
+)
+
+// Config declares how a database opened with OpenFromConfig is instrumented.
+type Config struct {
+
+	// Instrument wraps the driver to record query durations and log slow
+	// queries. Without it, the pool statistics are still reported by
+	// Collector.
+	Instrument bool `env:"SQL_INSTRUMENT,default=true"`
+
+	// SlowQueryThreshold, if positive, logs queries taking at least as long
+	// at WARN level (see WithSlowQueryThreshold).
+	SlowQueryThreshold time.Duration `env:"SQL_SLOW_QUERY_THRESHOLD"`
+}
+
+// LoadConfig loads a Config from the environment and validates it:
+//
+//   - SQL_INSTRUMENT: record query durations (default: true)
+//   - SQL_SLOW_QUERY_THRESHOLD: log slower queries at WARN (default: none)
+func LoadConfig() (Config, error) {
+	cfg, err := config.LoadVar[Config](config.WithPrefix("SQL_"))
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to load sql configuration from environment: %w", err)
+	}
+	if cfg.SlowQueryThreshold < 0 {
+		return Config{}, fmt.Errorf("SQL_SLOW_QUERY_THRESHOLD must not be negative, got %s", cfg.SlowQueryThreshold)
+	}
+	return cfg, nil
+}
+
+// openOption represents a functional option for configuring an instrumented
+// driver.
+type openOption func(*instrumentedDriver)
+
+// WithSlowQueryThreshold logs queries taking at least threshold at WARN
+// level, with the query and its duration. The logger of the query's context
+// (see ezapp.LoggerFromContext) is used, so a slow query made while handling
+// a request is logged with the request's ID.
+func WithSlowQueryThreshold(threshold time.Duration) openOption {
+	return func(d *instrumentedDriver) {
+		d.slowThreshold = threshold
+	}
+}
+
+// WithLogger sets the logger that slow queries are logged to when their
+// context carries none, instead of slog.Default().
+func WithLogger(logger *slog.Logger) openOption {
+	return func(d *instrumentedDriver) {
+		d.logger = logger
+	}
+}
+
+// Open opens a database like sql.Open, with the driver registered as
+// driverName wrapped to record the duration of every query and exec.
+func Open(driverName, dataSourceName string, options ...openOption) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	base := db.Driver()
+	_ = db.Close() // sql.Open does not connect; only the driver is needed
+
+	var connector driver.Connector = dsnConnector{driver: base, dsn: dataSourceName}
+	if driverCtx, ok := base.(driver.DriverContext); ok {
+		if connector, err = driverCtx.OpenConnector(dataSourceName); err != nil {
+			return nil, err
+		}
+	}
+	return OpenDB(connector, options...), nil
+}
+
+// OpenDB opens a database like sql.OpenDB, with connector wrapped to record
+// the duration of every query and exec.
+func OpenDB(connector driver.Connector, options ...openOption) *sql.DB {
+	instrumented := &instrumentedDriver{Driver: connector.Driver(), logger: slog.Default()}
+	for _, option := range options {
+		option(instrumented)
+	}
+	return sql.OpenDB(&instrumentedConnector{base: connector, driver: instrumented})
+}
+
+// OpenFromConfig opens a database like Open, instrumented as cfg declares.
+// Options are applied after the settings, so they can override them.
+func OpenFromConfig(cfg Config, driverName, dataSourceName string, options ...openOption) (*sql.DB, error) {
+	if !cfg.Instrument {
+		return sql.Open(driverName, dataSourceName)
+	}
+	var configured []openOption
+	if cfg.SlowQueryThreshold > 0 {
+		configured = append(configured, WithSlowQueryThreshold(cfg.SlowQueryThreshold))
+	}
+	return Open(driverName, dataSourceName, append(configured, options...)...)
+}
+
+// dsnConnector adapts a driver that does not implement driver.DriverContext
+// to driver.Connector, as sql.Open does.
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// instrumentedConnector wraps the connections of a driver.Connector.
+type instrumentedConnector struct {
+	base   driver.Connector
+	driver *instrumentedDriver
+}
+
+func (c *instrumentedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{Conn: conn, driver: c.driver}, nil
+}
+
+func (c *instrumentedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Close closes the wrapped connector if it is an io.Closer, as sql.DB.Close
+// would.
+func (c *instrumentedConnector) Close() error {
+	if closer, ok := c.base.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Collector returns a metrics collector reporting the connection pool
+// statistics of db under the label db=name and, if db was opened by this
+// package, the number, errors and total duration of its queries and execs.
+// Pool statistics are read from db.Stats() whenever the metrics are exported.
+//
+// Example:
+//
+//	ctx.Metrics.Register(sqlmetrics.Collector("orders", db))
+func Collector(name string, db *sql.DB) metrics.Collector {
+	labels := map[string]string{"db": name}
+	return func() []metrics.Sample {
+		stats := db.Stats()
+		samples := []metrics.Sample{
+			{Name: "sql_max_open_connections", Help: "Maximum number of open connections to the database.", Type: metrics.Gauge, Labels: labels, Value: float64(stats.MaxOpenConnections)},
+			{Name: "sql_open_connections", Help: "Number of established connections, in use or idle.", Type: metrics.Gauge, Labels: labels, Value: float64(stats.OpenConnections)},
+			{Name: "sql_in_use_connections", Help: "Number of connections in use.", Type: metrics.Gauge, Labels: labels, Value: float64(stats.InUse)},
+			{Name: "sql_idle_connections", Help: "Number of idle connections.", Type: metrics.Gauge, Labels: labels, Value: float64(stats.Idle)},
+			{Name: "sql_wait_count_total", Help: "Number of connections waited for.", Type: metrics.Counter, Labels: labels, Value: float64(stats.WaitCount)},
+			{Name: "sql_wait_duration_seconds_total", Help: "Total time blocked waiting for a connection.", Type: metrics.Counter, Labels: labels, Value: stats.WaitDuration.Seconds()},
+			{Name: "sql_max_idle_closed_total", Help: "Number of connections closed due to the idle connection limit.", Type: metrics.Counter, Labels: labels, Value: float64(stats.MaxIdleClosed)},
+			{Name: "sql_max_idle_time_closed_total", Help: "Number of connections closed due to the idle time limit.", Type: metrics.Counter, Labels: labels, Value: float64(stats.MaxIdleTimeClosed)},
+			{Name: "sql_max_lifetime_closed_total", Help: "Number of connections closed due to the connection lifetime limit.", Type: metrics.Counter, Labels: labels, Value: float64(stats.MaxLifetimeClosed)},
+		}
+		if instrumented, ok := db.Driver().(*instrumentedDriver); ok {
+			samples = append(samples, instrumented.samples(name)...)
+		}
+		return samples
+	}
+}
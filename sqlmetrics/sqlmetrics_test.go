@@ -0,0 +1,70 @@
+package sqlmetrics
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, Config{Instrument: true}, cfg)
+
+	t.Setenv("SQL_SLOW_QUERY_THRESHOLD", "250ms")
+	cfg, err = LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 250*time.Millisecond, cfg.SlowQueryThreshold)
+
+	t.Setenv("SQL_SLOW_QUERY_THRESHOLD", "-1s")
+	_, err = LoadConfig()
+	assert.ErrorContains(t, err, "must not be negative")
+}
+
+func TestOpenFromConfig(t *testing.T) {
+	db, err := OpenFromConfig(Config{Instrument: true, SlowQueryThreshold: time.Second}, "sqlmetrics-fake", "")
+	require.NoError(t, err)
+	defer db.Close()
+	instrumented, ok := db.Driver().(*instrumentedDriver)
+	require.True(t, ok)
+	assert.Equal(t, time.Second, instrumented.slowThreshold)
+
+	plain, err := OpenFromConfig(Config{}, "sqlmetrics-fake", "")
+	require.NoError(t, err)
+	defer plain.Close()
+	assert.IsType(t, fakeDriver{}, plain.Driver())
+
+	_, err = OpenFromConfig(Config{Instrument: true}, "unregistered", "")
+	assert.Error(t, err)
+}
+
+func TestCollector(t *testing.T) {
+	db, err := Open("sqlmetrics-fake", "")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(4)
+	_, err = db.Exec("SELECT 1")
+	require.NoError(t, err)
+
+	values := map[string]float64{}
+	for _, sample := range Collector("orders", db)() {
+		assert.Equal(t, "orders", sample.Labels["db"])
+		values[sample.Name+"/"+sample.Labels["operation"]] = sample.Value
+	}
+	assert.Equal(t, 4.0, values["sql_max_open_connections/"])
+	assert.Equal(t, 1.0, values["sql_open_connections/"])
+	assert.Equal(t, 1.0, values["sql_idle_connections/"])
+	assert.Equal(t, 1.0, values["sql_queries_total/exec"])
+	assert.Equal(t, 0.0, values["sql_queries_total/query"])
+	assert.Contains(t, values, "sql_slow_queries_total/")
+
+	plain, err := sql.Open("sqlmetrics-fake", "")
+	require.NoError(t, err)
+	defer plain.Close()
+	for _, sample := range Collector("plain", plain)() {
+		assert.NotEqual(t, "sql_queries_total", sample.Name, "uninstrumented databases report pool statistics only")
+	}
+}
@@ -0,0 +1,132 @@
+package ezapp
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+	"github.com/pgvanniekerk/ezapp/metrics"
+)
+
+// WithStartupBudget sets a soft budget for startup, from the start of Run or
+// RunE until every runner has been launched, used when the
+// EZAPP_STARTUP_BUDGET environment variable is not set. Unlike the startup
+// timeout it fails nothing: a startup over budget logs a "startup exceeded
+// budget" warning with the time spent in each phase and is reported by the
+// ezapp_startup_budget_exceeded metric, so slow-boot regressions are noticed
+// before they reach the hard timeout. Non-positive values disable the budget.
+//
+// Example:
+//
+//	ezapp.Run(initializer, ezapp.WithStartupBudget(5*time.Second))
+func WithStartupBudget(budget time.Duration) RunOption {
+	return func(settings *runopt.Settings) {
+		settings.StartupBudget = max(budget, 0)
+	}
+}
+
+// startupPhase is the time spent in one phase of startup.
+type startupPhase struct {
+	name     string
+	duration time.Duration
+}
+
+// startupTimer records how long each phase of startup takes.
+type startupTimer struct {
+	mu        sync.Mutex
+	startedAt time.Time
+	last      time.Time
+	phases    []startupPhase
+	budget    time.Duration
+	finished  bool
+}
+
+// newStartupTimer starts timing startup. The budget is disabled until set.
+func newStartupTimer() *startupTimer {
+	now := time.Now()
+	return &startupTimer{startedAt: now, last: now}
+}
+
+// mark ends the current phase, naming it phase.
+func (t *startupTimer) mark(phase string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.finished {
+		return
+	}
+	now := time.Now()
+	t.phases = append(t.phases, startupPhase{name: phase, duration: now.Sub(t.last)})
+	t.last = now
+}
+
+// finish ends the last phase, named phase, and reports the startup time,
+// warning if it exceeded the budget.
+func (t *startupTimer) finish(logger *slog.Logger, phase string) {
+	t.mark(phase)
+
+	t.mu.Lock()
+	t.finished = true
+	total, exceeded := t.total()
+	breakdown := make([]any, 0, len(t.phases))
+	for _, p := range t.phases {
+		breakdown = append(breakdown, slog.Duration(p.name, p.duration))
+	}
+	t.mu.Unlock()
+
+	if exceeded {
+		logger.Warn("startup exceeded budget", "duration", total, "budget", t.budget, slog.Group("phases", breakdown...))
+		return
+	}
+	logger.Debug("startup completed", "duration", total, slog.Group("phases", breakdown...))
+}
+
+// total returns the time spent in the recorded phases and whether it
+// exceeds the budget. It must be called with the lock held.
+func (t *startupTimer) total() (time.Duration, bool) {
+	total := t.last.Sub(t.startedAt)
+	return total, t.budget > 0 && total > t.budget
+}
+
+// collector reports the startup time per phase once startup has finished.
+func (t *startupTimer) collector() metrics.Collector {
+	return func() []metrics.Sample {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if !t.finished {
+			return nil
+		}
+
+		total, exceeded := t.total()
+		samples := []metrics.Sample{
+			{
+				Name:  "ezapp_startup_seconds",
+				Help:  "Time from the start of the application until every runner was launched.",
+				Type:  metrics.Gauge,
+				Value: total.Seconds(),
+			},
+		}
+		for _, p := range t.phases {
+			samples = append(samples, metrics.Sample{
+				Name:   "ezapp_startup_phase_seconds",
+				Help:   "Time spent in each phase of startup.",
+				Type:   metrics.Gauge,
+				Labels: map[string]string{"phase": p.name},
+				Value:  p.duration.Seconds(),
+			})
+		}
+		if t.budget > 0 {
+			var value float64
+			if exceeded {
+				value = 1
+			}
+			samples = append(samples, metrics.Sample{
+				Name:  "ezapp_startup_budget_exceeded",
+				Help:  "Whether startup took longer than the startup budget.",
+				Type:  metrics.Gauge,
+				Value: value,
+			})
+		}
+		return samples
+	}
+}
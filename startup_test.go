@@ -0,0 +1,89 @@
+package ezapp
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/pgvanniekerk/ezapp/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartupTimer(t *testing.T) {
+	logger, handler := testutil.NewTestLogger(slog.LevelDebug)
+	timer := newStartupTimer()
+	timer.budget = 10 * time.Millisecond
+	assert.Empty(t, timer.collector()(), "nothing should be reported before startup finished")
+
+	timer.mark("config")
+	time.Sleep(20 * time.Millisecond)
+	timer.finish(logger, "initializer")
+
+	phases, ok := handler.Attr("startup exceeded budget", "phases")
+	require.True(t, ok)
+	require.Len(t, phases.Group(), 2)
+	assert.Equal(t, "initializer", phases.Group()[1].Key)
+	assert.GreaterOrEqual(t, phases.Group()[1].Value.Duration(), 20*time.Millisecond)
+
+	samples := map[string]metrics.Sample{}
+	for _, s := range timer.collector()() {
+		samples[s.Name+s.Labels["phase"]] = s
+	}
+	assert.Equal(t, float64(1), samples["ezapp_startup_budget_exceeded"].Value)
+	assert.GreaterOrEqual(t, samples["ezapp_startup_phase_secondsinitializer"].Value, 0.02)
+	assert.GreaterOrEqual(t, samples["ezapp_startup_seconds"].Value, 0.02)
+
+	// Marks after startup finished do not change the breakdown
+	timer.mark("late")
+	assert.Len(t, timer.collector()(), 4)
+}
+
+func TestStartupTimerWithinBudget(t *testing.T) {
+	logger, handler := testutil.NewTestLogger(slog.LevelDebug)
+	timer := newStartupTimer()
+	timer.budget = time.Minute
+
+	timer.finish(logger, "runners")
+
+	assert.Contains(t, handler.Messages(), "startup completed")
+	assert.NotContains(t, handler.Messages(), "startup exceeded budget")
+}
+
+func TestRunEStartupBudget(t *testing.T) {
+	t.Setenv("EZAPP_STARTUP_BUDGET", "1ns")
+	var gathered []metrics.Sample
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(WithNamedRunner("probe", func(context.Context) error {
+			gathered = ctx.Metrics.Gather()
+			return nil
+		}))
+	}, WithStartupBudget(time.Minute), WithoutRuntimeMetrics())
+	require.NoError(t, err)
+
+	phases := map[string]bool{}
+	var exceeded float64
+	for _, s := range gathered {
+		switch s.Name {
+		case "ezapp_startup_phase_seconds":
+			phases[s.Labels["phase"]] = true
+		case "ezapp_startup_budget_exceeded":
+			exceeded = s.Value
+		}
+	}
+	assert.Equal(t, map[string]bool{"config": true, "initializer": true, "runners": true}, phases)
+	assert.Equal(t, float64(1), exceeded, "EZAPP_STARTUP_BUDGET should take precedence over the run option")
+}
+
+func TestRunEStartupBudgetInvalid(t *testing.T) {
+	t.Setenv("EZAPP_STARTUP_BUDGET", "soon")
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct()
+	})
+
+	assert.ErrorContains(t, err, "failed to load startup budget")
+}
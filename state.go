@@ -0,0 +1,58 @@
+package ezapp
+
+import "github.com/pgvanniekerk/ezapp/internal/app"
+
+// State is a stage in the application lifecycle. It is the single source of
+// truth for where the application is between startup and exit, intended for
+// extensions such as metrics, health and admin endpoints.
+//
+// States only ever move forward:
+//
+//	Created → Starting → Running → Draining → Stopping → Stopped | Failed
+type State = app.State
+
+const (
+	// StateCreated is the state before the runners are started.
+	StateCreated = app.StateCreated
+
+	// StateStarting is entered while the runners are being launched.
+	StateStarting = app.StateStarting
+
+	// StateRunning is entered once all runners have been launched.
+	StateRunning = app.StateRunning
+
+	// StateDraining is entered when shutdown is initiated by a termination
+	// signal, a failing runner, or all runners returning.
+	StateDraining = app.StateDraining
+
+	// StateStopping is entered once all runners have returned and cleanup
+	// is in progress.
+	StateStopping = app.StateStopping
+
+	// StateStopped is the terminal state of an application that shut down cleanly.
+	StateStopped = app.StateStopped
+
+	// StateFailed is the terminal state of an application whose runners or
+	// cleanup failed.
+	StateFailed = app.StateFailed
+)
+
+// WithStateHook is a functional option that registers a hook called after
+// every lifecycle state transition with the previous and the new state.
+// Hooks are called synchronously and in registration order, so they should
+// return quickly.
+//
+// Example:
+//
+//	appCtx, err := Construct(
+//	    WithRunners(server.Run),
+//	    WithStateHook(func(from, to State) {
+//	        stateGauge.Set(float64(to))
+//	    }),
+//	)
+func WithStateHook(hook func(from, to State)) option {
+	return func(appCtx *AppCtx) error {
+		appCtx.stateHooks = append(appCtx.stateHooks, hook)
+		return nil
+	}
+}
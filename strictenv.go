@@ -0,0 +1,99 @@
+package ezapp
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/pgvanniekerk/ezapp/coordination"
+	"github.com/pgvanniekerk/ezapp/discovery"
+	"github.com/pgvanniekerk/ezapp/httprunner"
+	"github.com/pgvanniekerk/ezapp/internal/config"
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+	"github.com/pgvanniekerk/ezapp/sqlmetrics"
+)
+
+// frameworkVars lists the EZAPP_ variables read by ezapp itself, other than
+// those of the configuration types of its companion packages.
+var frameworkVars = []string{
+	"EZAPP_LOG_LEVEL",
+	"EZAPP_LOG_LEVELS",
+	"EZAPP_ENV",
+	"EZAPP_DEV",
+	"EZAPP_HOLD",
+	"EZAPP_STARTUP_TIMEOUT",
+	"EZAPP_SHUTDOWN_TIMEOUT",
+	"EZAPP_SHUTDOWN_DEADLINE",
+	"EZAPP_STARTUP_BUDGET",
+	"EZAPP_WATCHDOG_GRACE",
+	"EZAPP_CHAOS",
+	"EZAPP_CHAOS_RUNNER_FAILURE_RATE",
+	"EZAPP_CHAOS_MAX_FAILURE_DELAY",
+	"EZAPP_CHAOS_CLEANUP_DELAY",
+	"EZAPP_CHAOS_DROP_SIGNALS",
+}
+
+// WithStrictEnv rejects environment variables that start with EZAPP_ or one
+// of prefixes, such as the application's own "ORDERS_", but are not read by
+// ezapp, its companion packages or the Config type, catching typos like
+// EZAPP_SHUTOWN_TIMEOUT that would otherwise silently fall back to a default.
+// Startup fails with ErrConfigLoad listing the unknown variables, with the
+// closest known name as a suggestion; in development mode they are only
+// logged, since local .env files often carry extra variables.
+//
+// Example:
+//
+//	ezapp.Run(initializer, ezapp.WithStrictEnv("ORDERS_"))
+func WithStrictEnv(prefixes ...string) RunOption {
+	return func(settings *runopt.Settings) {
+		settings.StrictEnvPrefixes = append([]string{"EZAPP_"}, prefixes...)
+	}
+}
+
+// knownVars returns the names of the variables read by ezapp, its companion
+// packages and Config, including aliases and, for variables tagged with
+// config.ResolveTag, their config.FileSuffix forms.
+func knownVars[Config any]() []string {
+	known := append([]string{}, frameworkVars...)
+	for _, schema := range [][]config.Var{
+		config.Schema[Config](),
+		config.Schema[discovery.ConsulConfig](),
+		config.Schema[coordination.ConsulConfig](),
+		config.Schema[httprunner.Config](),
+		config.Schema[sqlmetrics.Config](),
+	} {
+		for _, v := range schema {
+			for _, name := range append([]string{v.Name}, v.Aliases...) {
+				known = append(known, name)
+				if v.Resolve {
+					known = append(known, name+config.FileSuffix)
+				}
+			}
+		}
+	}
+	return known
+}
+
+// checkStrictEnv reports the variables of environ that are unknown and match
+// prefixes, returning an error unless in development mode.
+func checkStrictEnv[Config any](logger *slog.Logger, environ, prefixes []string, devMode bool) error {
+	known := knownVars[Config]()
+	unknown := config.UnknownVars(environ, prefixes, known)
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	described := make([]string, 0, len(unknown))
+	for _, name := range unknown {
+		if suggestion, ok := config.Suggest(name, known); ok {
+			name = fmt.Sprintf("%s (did you mean %s?)", name, suggestion)
+		}
+		described = append(described, name)
+	}
+
+	if devMode {
+		logger.Warn("unknown environment variables", "variables", described)
+		return nil
+	}
+	return fmt.Errorf("unknown environment variables: %s", strings.Join(described, ", "))
+}
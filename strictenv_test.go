@@ -0,0 +1,62 @@
+package ezapp
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunEStrictEnv(t *testing.T) {
+	t.Setenv("EZAPP_SHUTOWN_TIMEOUT", "30s")
+	t.Setenv("EZAPP_SERVICE_NAME", "orders")
+	t.Setenv("TEST_VALUE", "known")
+	t.Setenv("TEST_VALEU", "typo")
+	t.Setenv("TEST_VALUE_FILE", "/run/secrets/test-value")
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		t.Fatal("initializer should not be invoked")
+		return AppCtx{}, nil
+	}, WithStrictEnv("TEST_"))
+
+	require.ErrorIs(t, err, ErrConfigLoad)
+	assert.ErrorContains(t, err, "EZAPP_SHUTOWN_TIMEOUT (did you mean EZAPP_SHUTDOWN_TIMEOUT?)")
+	assert.ErrorContains(t, err, "TEST_VALEU (did you mean TEST_VALUE?)")
+	assert.NotContains(t, err.Error(), "EZAPP_SERVICE_NAME", "variables of companion packages are known")
+	assert.NotContains(t, err.Error(), "TEST_VALUE_FILE", "file variants of known variables are known")
+}
+
+func TestCheckStrictEnvHTTPConfig(t *testing.T) {
+	t.Setenv("HTTP_READ_TIMEOUT", "5s")
+	t.Setenv("HTTP_READ_TIMOUT", "5s")
+	logger, _ := testutil.NewTestLogger(slog.LevelDebug)
+
+	err := checkStrictEnv[TestConfig](logger, os.Environ(), []string{"HTTP_"}, false)
+
+	assert.ErrorContains(t, err, "HTTP_READ_TIMOUT (did you mean HTTP_READ_TIMEOUT?)")
+	assert.Equal(t, 1, strings.Count(err.Error(), "HTTP_READ_TIMEOUT"), "variables of httprunner.Config are known")
+}
+
+func TestCheckStrictEnvDevMode(t *testing.T) {
+	t.Setenv("EZAPP_SHUTOWN_TIMEOUT", "30s")
+	logger, handler := testutil.NewTestLogger(slog.LevelDebug)
+
+	err := checkStrictEnv[TestConfig](logger, os.Environ(), []string{"EZAPP_"}, true)
+
+	assert.NoError(t, err)
+	assert.Contains(t, handler.Messages(), "unknown environment variables")
+}
+
+func TestRunEStrictEnvDisabled(t *testing.T) {
+	t.Setenv("EZAPP_SHUTOWN_TIMEOUT", "30s")
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct()
+	})
+
+	assert.NoError(t, err)
+}
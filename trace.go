@@ -0,0 +1,52 @@
+package ezapp
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/pgvanniekerk/ezapp/internal/trace"
+)
+
+// TraceEvent is a lifecycle event recorded in the trace, such as a state
+// transition, a signal, a pre-shutdown hook, a runner returning or a cleanup
+// step finishing, with the time it happened.
+type TraceEvent = trace.Event
+
+// LifecycleTrace holds the most recent lifecycle events of the application,
+// oldest first, as returned by Runtime.Trace.
+type LifecycleTrace struct {
+	Events []TraceEvent `json:"events"`
+
+	// Dropped is the number of older events that no longer fit the trace.
+	Dropped int `json:"dropped"`
+}
+
+// Trace returns the most recent lifecycle events, up to 256, recorded in an
+// in-memory ring buffer. The order and timing of the events show e.g. which
+// runner or cleanup step an intermittent shutdown hang waited on. The trace
+// is also logged in full when the application exits abnormally and is
+// included in the ShutdownReport.
+func (rt *Runtime) Trace() LifecycleTrace {
+	events, dropped := rt.trace.Events()
+	return LifecycleTrace{Events: events, Dropped: dropped}
+}
+
+// TraceHandler returns an http.Handler that serves the trace returned by
+// Trace as JSON. Mount it on an admin or debug server to inspect a hanging
+// shutdown while it happens.
+//
+// Example:
+//
+//	adminMux.Handle("/admin/trace", ctx.Runtime.TraceHandler())
+func (rt *Runtime) TraceHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(rt.Trace())
+	})
+}
+
+// logTrace logs the lifecycle trace in full to diagnose an abnormal exit.
+func logTrace(logger *slog.Logger, lifecycle LifecycleTrace) {
+	logger.Error("lifecycle trace", "events", lifecycle.Events, "dropped", lifecycle.Dropped)
+}
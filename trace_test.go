@@ -0,0 +1,78 @@
+package ezapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/pgvanniekerk/ezapp/internal/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventNames returns the names and subjects of the events of lifecycle.
+func eventNames(lifecycle LifecycleTrace) []string {
+	names := make([]string, 0, len(lifecycle.Events))
+	for _, event := range lifecycle.Events {
+		names = append(names, event.Name+" "+event.Subject)
+	}
+	return names
+}
+
+func TestRunETraceInShutdownReport(t *testing.T) {
+	var report ShutdownReport
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithNamedRunner("worker", failingRunner),
+			WithCleanupStep("database", func(context.Context) error {
+				return errors.New("close failed")
+			}),
+			WithPostRunHook(func(r ShutdownReport) {
+				report = r
+			}),
+		)
+	})
+
+	require.Error(t, err)
+	names := eventNames(report.Trace)
+	assert.Contains(t, names, "runner returned worker")
+	assert.Contains(t, names, "cleanup step started database")
+	assert.Contains(t, names, "cleanup step finished database")
+	assert.Equal(t, "state changed", report.Trace.Events[len(report.Trace.Events)-1].Name)
+	assert.Equal(t, "stopping -> failed", report.Trace.Events[len(report.Trace.Events)-1].Detail)
+}
+
+func TestTraceHandler(t *testing.T) {
+	rt := &Runtime{trace: trace.New(trace.DefaultSize)}
+	rt.trace.Record("cleanup step started", "database", "")
+
+	recorder := httptest.NewRecorder()
+	rt.TraceHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/admin/trace", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	var lifecycle LifecycleTrace
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &lifecycle))
+	assert.Equal(t, []string{"cleanup step started database"}, eventNames(lifecycle))
+	assert.Zero(t, lifecycle.Dropped)
+}
+
+func TestLogTrace(t *testing.T) {
+	logger, handler := testutil.NewTestLogger(slog.LevelDebug)
+	lifecycle := LifecycleTrace{Events: []TraceEvent{{Name: "shutdown initiated"}}, Dropped: 3}
+
+	logTrace(logger, lifecycle)
+
+	value, ok := handler.Attr("lifecycle trace", "events")
+	require.True(t, ok)
+	assert.Equal(t, lifecycle.Events, value.Any())
+	value, ok = handler.Attr("lifecycle trace", "dropped")
+	require.True(t, ok)
+	assert.Equal(t, int64(3), value.Int64())
+}
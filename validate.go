@@ -0,0 +1,90 @@
+package ezapp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+)
+
+// Command-line flags recognised by Run.
+const (
+	// ValidateFlag makes Run validate the wiring with ValidateWiring and
+	// exit instead of running the application.
+	ValidateFlag = "--validate"
+
+	// ListComponentsFlag makes Run validate the wiring like ValidateFlag
+	// and print the description of the AppCtx, including its components,
+	// to standard output.
+	ListComponentsFlag = "--list-components"
+)
+
+// ValidateWiring checks that the application can be wired without running it:
+// the configuration is loaded from the environment, the initializer is invoked
+// and the AppCtx it returns is checked (unique runner names, component health
+// checks, and names that refer to runners, cleanup steps or components that
+// are not registered). Instead of starting the runners, cleanup is then run
+// to release what the initializer acquired; component init functions,
+// preflight checks and warmups do not run, so no dependency has to be
+// reachable. Failures are returned as they would be from RunE, e.g. wrapping
+// ErrConfigLoad with the names of missing variables.
+//
+// Running it in CI, or starting the binary with the --validate flag (see
+// ValidateFlag), catches configuration and wiring mistakes before deploying.
+//
+// Example:
+//
+//	func TestWiring(t *testing.T) {
+//	    require.NoError(t, ezapp.ValidateWiring(Initialize, ezapptest.WithConfig(testConfig)))
+//	}
+func ValidateWiring[Config any](initializer Initializer[Config], options ...RunOption) error {
+	options = append(slices.Clip(options), func(settings *runopt.Settings) {
+		settings.ValidateOnly = true
+	})
+	return RunE(initializer, options...)
+}
+
+// hasFlag reports whether args, the command-line arguments without the
+// program name, contain flag.
+func hasFlag(args []string, flag string) bool {
+	return slices.Contains(args, flag)
+}
+
+// validated runs the cleanup of an AppCtx built for validation and reports
+// the outcome. The description of the AppCtx and the manifest are written to
+// the writers requested in settings.
+func validated(logger *slog.Logger, metadata AppMetadata, appCtx AppCtx, manifest AppManifest, settings runopt.Settings, shutdownTimeout time.Duration) error {
+	if settings.Describe != nil {
+		if _, err := io.WriteString(settings.Describe, appCtx.Describe()); err != nil {
+			return fmt.Errorf("failed to describe application: %w", err)
+		}
+	}
+	if settings.Manifest != nil {
+		data, err := manifest.encode()
+		if err != nil {
+			return fmt.Errorf("failed to encode manifest: %w", err)
+		}
+		if _, err := settings.Manifest.Write(data); err != nil {
+			return fmt.Errorf("failed to write manifest: %w", err)
+		}
+	}
+
+	if appCtx.HasCleanup() {
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancelShutdown()
+		shutdownCtx = contextWithApp(shutdownCtx, logger, metadata)
+		shutdownCtx = context.WithValue(shutdownCtx, resultsKey{}, &Results{})
+
+		if err := appCtx.cleanup(shutdownCtx, nil); err != nil {
+			logger.Error("cleanup failed", "error", err)
+			return fmt.Errorf("application cleanup failed: %w", err)
+		}
+	}
+
+	logger.Info("wiring validated", "runners", appCtx.RunnerNames(), "cleanup_steps", appCtx.CleanupSteps())
+	return nil
+}
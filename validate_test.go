@@ -0,0 +1,129 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+	"github.com/pgvanniekerk/ezapp/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidateWiring tests that ValidateWiring invokes the initializer without running the app
+// This test verifies that:
+// - Runners are not started
+// - The cleanup function and cleanup steps are run
+func TestValidateWiring(t *testing.T) {
+	var initialized, runnerStarted, cleanedUp, stepRan bool
+
+	err := ValidateWiring(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		initialized = true
+		return Construct(
+			WithRunners(func(context.Context) error {
+				runnerStarted = true
+				return nil
+			}),
+			WithCleanup(createRecorderCleanup(&cleanedUp)),
+			WithCleanupStep("close-db", createRecorderCleanup(&stepRan)),
+		)
+	})
+	require.NoError(t, err)
+
+	assert.True(t, initialized, "Initializer should have been invoked")
+	assert.False(t, runnerStarted, "Runners should not be started")
+	assert.True(t, cleanedUp, "Cleanup function should have been called")
+	assert.True(t, stepRan, "Cleanup steps should have been called")
+}
+
+// TestValidateWiringFailures tests that ValidateWiring reports wiring mistakes
+func TestValidateWiringFailures(t *testing.T) {
+	t.Run("missing config", func(t *testing.T) {
+		type requiredConfig struct {
+			APIKey string `env:"EZAPP_VALIDATE_TEST_API_KEY,required=true"`
+		}
+		t.Setenv("EZAPP_DEV", "")
+
+		err := ValidateWiring(func(ctx InitCtx[requiredConfig]) (AppCtx, error) {
+			t.Fatal("initializer should not be invoked")
+			return AppCtx{}, nil
+		})
+		assert.ErrorIs(t, err, ErrConfigLoad)
+	})
+
+	t.Run("initializer error", func(t *testing.T) {
+		initErr := errors.New("no database provider")
+		err := ValidateWiring(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+			return AppCtx{}, initErr
+		})
+		assert.ErrorIs(t, err, initErr)
+	})
+
+	t.Run("duplicate runner names", func(t *testing.T) {
+		err := ValidateWiring(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+			return Construct(WithNamedRunner("api", successfulRunner), WithNamedRunner("api", successfulRunner))
+		})
+		assert.ErrorContains(t, err, `duplicate runner name "api"`)
+	})
+
+	t.Run("unregistered names", func(t *testing.T) {
+		err := ValidateWiring(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+			return Construct(
+				WithNamedRunner("api", successfulRunner),
+				WithComponentInit("database", retry.Default, func(context.Context) error {
+					t.Fatal("component init should not run")
+					return nil
+				}),
+			)
+		})
+		assert.ErrorContains(t, err, `component "database" of the init function is not registered`)
+
+		err = ValidateWiring(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+			return Construct(WithNamedRunner("api", successfulRunner), WithUnheldRunners("admin"))
+		})
+		assert.ErrorContains(t, err, `unheld runner "admin" is not registered`)
+	})
+
+	t.Run("component init not run", func(t *testing.T) {
+		err := ValidateWiring(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+			return Construct(
+				WithRunners(successfulRunner),
+				WithComponent("database", struct{}{}),
+				WithComponentInit("database", retry.Default, func(context.Context) error {
+					return errors.New("connection refused")
+				}),
+			)
+		})
+		assert.NoError(t, err, "validation should not need the database to be reachable")
+	})
+
+	t.Run("cleanup error", func(t *testing.T) {
+		err := ValidateWiring(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+			return Construct(WithRunners(successfulRunner), WithCleanup(failingCleanup))
+		})
+		assert.ErrorContains(t, err, "application cleanup failed")
+	})
+}
+
+func TestValidateWiringDescribe(t *testing.T) {
+	var out strings.Builder
+
+	err := ValidateWiring(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(WithNamedRunner("http", successfulRunner), WithComponent("database", &testPool{}))
+	}, func(settings *runopt.Settings) {
+		settings.Describe = &out
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, out.String(), "  - http\n")
+	assert.Contains(t, out.String(), "  - database: *ezapp.testPool (healthy)\n")
+}
+
+func TestHasFlag(t *testing.T) {
+	assert.True(t, hasFlag([]string{"--validate"}, ValidateFlag))
+	assert.True(t, hasFlag([]string{"-v", "--list-components"}, ListComponentsFlag))
+	assert.False(t, hasFlag(nil, ValidateFlag))
+	assert.False(t, hasFlag([]string{"--validated"}, ValidateFlag))
+}
@@ -0,0 +1,114 @@
+package ezapp
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+)
+
+// DefaultWatchdogGrace is the time the watchdog gives the runners to return
+// beyond the shutdown budgets before aborting the process.
+const DefaultWatchdogGrace = 10 * time.Second
+
+// WithWatchdogGrace sets the grace period of the shutdown watchdog, used when
+// the EZAPP_WATCHDOG_GRACE environment variable is not set.
+//
+// Once shutdown is initiated, the pre-shutdown hooks and then the runners
+// each have up to the shutdown timeout to finish. If the runners have still
+// not all returned grace after both budgets have expired, the watchdog logs
+// the lifecycle trace, writes a dump of every goroutine's stack to standard
+// error and exits the process with status 1, rather than leaving a runner
+// that ignores its context to keep a terminating pod alive until it is killed
+// without diagnostics. A non-positive grace, or EZAPP_WATCHDOG_GRACE=0,
+// disables the watchdog. The grace defaults to DefaultWatchdogGrace.
+//
+// Example:
+//
+//	ezapp.Run(initializer, ezapp.WithWatchdogGrace(5*time.Second))
+func WithWatchdogGrace(grace time.Duration) RunOption {
+	return func(settings *runopt.Settings) {
+		settings.WatchdogGrace = grace
+		if grace <= 0 {
+			settings.WatchdogGrace = -1
+		}
+	}
+}
+
+// watchdogGrace returns the grace period configured in settings.
+func watchdogGrace(settings runopt.Settings) time.Duration {
+	if settings.WatchdogGrace == 0 {
+		return DefaultWatchdogGrace
+	}
+	return max(settings.WatchdogGrace, 0)
+}
+
+// watchdog aborts the process if the runners have not returned within budget
+// of shutdown being initiated. A nil watchdog does nothing.
+type watchdog struct {
+	logger *slog.Logger
+	budget time.Duration
+	trace  func() LifecycleTrace
+	dump   io.Writer
+	exit   func(code int)
+
+	armOnce  sync.Once
+	stopOnce sync.Once
+	stopped  chan struct{}
+}
+
+// newWatchdog returns a watchdog that has not been armed, dumping to
+// standard error and exiting the process when it fires.
+func newWatchdog(logger *slog.Logger, budget time.Duration, trace func() LifecycleTrace) *watchdog {
+	return &watchdog{
+		logger:  logger,
+		budget:  budget,
+		trace:   trace,
+		dump:    os.Stderr,
+		exit:    os.Exit,
+		stopped: make(chan struct{}),
+	}
+}
+
+// arm starts the budget once shutdown has been initiated. Later calls have no
+// effect.
+func (w *watchdog) arm() {
+	if w == nil {
+		return
+	}
+	w.armOnce.Do(func() {
+		go func() {
+			timer := time.NewTimer(w.budget)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+				w.fire()
+			case <-w.stopped:
+			}
+		}()
+	})
+}
+
+// stop disarms the watchdog once the runners have returned.
+func (w *watchdog) stop() {
+	if w == nil {
+		return
+	}
+	w.stopOnce.Do(func() {
+		close(w.stopped)
+	})
+}
+
+// fire reports the hung shutdown and exits the process.
+func (w *watchdog) fire() {
+	w.logger.Error("runners did not stop within the shutdown budgets, aborting", "budget", w.budget)
+	logTrace(w.logger, w.trace())
+	if err := pprof.Lookup("goroutine").WriteTo(w.dump, 2); err != nil {
+		w.logger.Error("failed to dump goroutines", "error", err)
+	}
+	w.exit(1)
+}
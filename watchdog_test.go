@@ -0,0 +1,92 @@
+package ezapp
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/pgvanniekerk/ezapp/internal/runopt"
+	"github.com/pgvanniekerk/ezapp/internal/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestWatchdog returns a watchdog with the given budget that records its
+// dump in dump and reports its exit code on exited instead of exiting.
+func newTestWatchdog(budget time.Duration, dump *bytes.Buffer, exited chan<- int) (*watchdog, *testutil.TestHandler) {
+	logger, handler := testutil.NewTestLogger(slog.LevelDebug)
+	dog := newWatchdog(logger, budget, func() LifecycleTrace {
+		return LifecycleTrace{Events: []TraceEvent{{Name: "shutdown initiated"}}}
+	})
+	dog.dump = dump
+	dog.exit = func(code int) { exited <- code }
+	return dog, handler
+}
+
+func TestWatchdogFires(t *testing.T) {
+	var dump bytes.Buffer
+	exited := make(chan int, 1)
+	dog, handler := newTestWatchdog(10*time.Millisecond, &dump, exited)
+
+	dog.arm()
+	dog.arm()
+
+	select {
+	case code := <-exited:
+		assert.Equal(t, 1, code)
+	case <-time.After(time.Second):
+		t.Fatal("watchdog should exit once its budget has expired")
+	}
+	assert.Contains(t, handler.Messages(), "runners did not stop within the shutdown budgets, aborting")
+	assert.Contains(t, handler.Messages(), "lifecycle trace")
+	assert.Contains(t, dump.String(), "goroutine ")
+	assert.Contains(t, dump.String(), "TestWatchdogFires", "the dump should include every goroutine's stack")
+}
+
+func TestWatchdogStopped(t *testing.T) {
+	var dump bytes.Buffer
+	exited := make(chan int, 1)
+	dog, _ := newTestWatchdog(20*time.Millisecond, &dump, exited)
+
+	dog.arm()
+	dog.stop()
+	dog.stop()
+
+	select {
+	case <-exited:
+		t.Fatal("a stopped watchdog should not exit")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Empty(t, dump.String())
+}
+
+func TestNilWatchdog(t *testing.T) {
+	var dog *watchdog
+
+	assert.NotPanics(t, func() {
+		dog.arm()
+		dog.stop()
+	})
+}
+
+func TestWithWatchdogGrace(t *testing.T) {
+	var settings runopt.Settings
+	assert.Equal(t, DefaultWatchdogGrace, watchdogGrace(settings))
+
+	WithWatchdogGrace(5 * time.Second)(&settings)
+	assert.Equal(t, 5*time.Second, watchdogGrace(settings))
+
+	WithWatchdogGrace(0)(&settings)
+	assert.Zero(t, watchdogGrace(settings), "a non-positive grace should disable the watchdog")
+}
+
+func TestRunEWatchdogGraceInvalid(t *testing.T) {
+	t.Setenv("EZAPP_WATCHDOG_GRACE", "soon")
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		t.Fatal("initializer should not be invoked")
+		return AppCtx{}, nil
+	})
+
+	assert.ErrorContains(t, err, "failed to load watchdog grace")
+}
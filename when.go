@@ -0,0 +1,106 @@
+package ezapp
+
+import (
+	"errors"
+	"time"
+)
+
+// When is a functional option that adds an optional subsystem named name,
+// such as a queue consumer behind a feature flag, only if enabled is true.
+// The subsystem is built by build, typically with its own call to Construct,
+// so a disabled subsystem is neither constructed nor registered. An error
+// returned by build aborts construction, as does an empty name.
+//
+// The runners, hooks, endpoints and components of the built AppCtx are added
+// as if their options had been passed in place of When. Its cleanup function
+// runs as a cleanup step named after the subsystem, before its own cleanup
+// steps, so it is told apart from the application's own cleanup function and
+// those of other subsystems in CleanupError and WithIndependentCleanup.
+//
+// Example:
+//
+//	appCtx, err := Construct(
+//	    WithNamedRunner("http", server.Run),
+//	    When("kafka", ctx.Config.KafkaEnabled, func() (AppCtx, error) {
+//	        consumer, err := kafka.NewConsumer(ctx.Config.KafkaBrokers)
+//	        if err != nil {
+//	            return AppCtx{}, err
+//	        }
+//	        return Construct(
+//	            WithNamedRunner("kafka-consumer", consumer.Run),
+//	            WithCleanup(consumer.Close),
+//	        )
+//	    }),
+//	)
+func When(name string, enabled bool, build func() (AppCtx, error)) option {
+	return func(appCtx *AppCtx) error {
+		if name == "" {
+			return errors.New("subsystem name cannot be empty")
+		}
+		if !enabled {
+			return nil
+		}
+		sub, err := build()
+		if err != nil {
+			return err
+		}
+		appCtx.merge(name, sub)
+		return nil
+	}
+}
+
+// merge adds the runners, cleanup, hooks, endpoints, components, component
+// init functions, metrics exporters, warmups, preflight checks, smoke checks,
+// shutdown coordination, runner shutdown timeouts and runners exempt from
+// hold mode of the subsystem name to appCtx.
+func (appCtx *AppCtx) merge(name string, sub AppCtx) {
+	for idx, r := range sub.runnerList {
+		appCtx.addRunner(sub.runnerNames[idx], r)
+	}
+
+	// Steps run in reverse order, so the cleanup function is added last to
+	// run before the steps of sub, as it would in sub itself
+	appCtx.cleanupSteps = append(appCtx.cleanupSteps, sub.cleanupSteps...)
+	if sub.cleanupFunc != nil {
+		appCtx.cleanupSteps = append(appCtx.cleanupSteps, cleanupStep{name: name, fn: sub.cleanupFunc})
+	}
+
+	for step := range sub.independentCleanups {
+		// The cleanup function of sub runs as the step name here
+		if step == "cleanup" && sub.cleanupFunc != nil {
+			step = name
+		}
+		if appCtx.independentCleanups == nil {
+			appCtx.independentCleanups = make(map[string]bool)
+		}
+		appCtx.independentCleanups[step] = true
+	}
+
+	appCtx.stateHooks = append(appCtx.stateHooks, sub.stateHooks...)
+	for idx, hook := range sub.preShutdownHooks {
+		appCtx.addPreShutdownHook(sub.preShutdownPriority(idx), hook)
+	}
+	appCtx.postRunHooks = append(appCtx.postRunHooks, sub.postRunHooks...)
+	appCtx.endpoints = append(appCtx.endpoints, sub.endpoints...)
+	appCtx.components = append(appCtx.components, sub.components...)
+	appCtx.metricsExporters = append(appCtx.metricsExporters, sub.metricsExporters...)
+	appCtx.componentInits = append(appCtx.componentInits, sub.componentInits...)
+	appCtx.warmups = append(appCtx.warmups, sub.warmups...)
+	appCtx.preflightChecks = append(appCtx.preflightChecks, sub.preflightChecks...)
+	appCtx.smokeChecks = append(appCtx.smokeChecks, sub.smokeChecks...)
+	if sub.shutdownSlot != nil {
+		appCtx.shutdownSlot = sub.shutdownSlot
+	}
+	for name, timeout := range sub.shutdownTimeouts {
+		if appCtx.shutdownTimeouts == nil {
+			appCtx.shutdownTimeouts = make(map[string]time.Duration)
+		}
+		appCtx.shutdownTimeouts[name] = timeout
+	}
+	for name := range sub.unheldRunners {
+		if appCtx.unheldRunners == nil {
+			appCtx.unheldRunners = make(map[string]bool)
+		}
+		appCtx.unheldRunners[name] = true
+	}
+}
@@ -0,0 +1,173 @@
+package ezapp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhenDisabled(t *testing.T) {
+	appCtx, err := Construct(
+		WithNamedRunner("http", successfulRunner),
+		When("kafka", false, func() (AppCtx, error) {
+			t.Fatal("a disabled subsystem should not be built")
+			return AppCtx{}, nil
+		}),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"http"}, appCtx.RunnerNames())
+}
+
+func TestWhenEnabled(t *testing.T) {
+	var order []string
+	record := func(step string) func(context.Context) error {
+		return func(context.Context) error {
+			order = append(order, step)
+			return nil
+		}
+	}
+
+	appCtx, err := Construct(
+		WithNamedRunner("http", successfulRunner),
+		WithCleanupStep("database", record("database")),
+		When("kafka", true, func() (AppCtx, error) {
+			return Construct(
+				WithNamedRunner("kafka-consumer", successfulRunner),
+				WithCleanup(record("consumer")),
+				WithCleanupStep("producer", record("producer")),
+				WithComponent("broker", struct{}{}),
+			)
+		}),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"http", "kafka-consumer"}, appCtx.RunnerNames())
+	assert.Equal(t, []string{"kafka", "producer", "database"}, appCtx.CleanupSteps())
+	assert.Len(t, appCtx.components, 1)
+
+	require.NoError(t, appCtx.cleanup(context.Background(), nil))
+	assert.Equal(t, []string{"consumer", "producer", "database"}, order)
+}
+
+func TestWhenCleanupNames(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+	appCtx, err := Construct(
+		WithCleanup(noop),
+		When("kafka", true, func() (AppCtx, error) {
+			return Construct(WithCleanup(noop))
+		}),
+		When("search", true, func() (AppCtx, error) {
+			return Construct(WithCleanup(noop))
+		}),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"cleanup", "search", "kafka"}, appCtx.CleanupSteps(),
+		"every cleanup function should run as a step of its own name")
+}
+
+func TestWhenIndependentCleanupFunction(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+	appCtx, err := Construct(When("kafka", true, func() (AppCtx, error) {
+		return Construct(
+			WithCleanup(noop),
+			WithCleanupStep("producer", noop),
+			WithIndependentCleanup("cleanup", "producer"),
+		)
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]bool{"kafka": true, "producer": true}, appCtx.independentCleanups,
+		"the cleanup function of the subsystem should stay independent under its new name")
+}
+
+func TestWhenFailures(t *testing.T) {
+	buildErr := errors.New("no brokers configured")
+	_, err := Construct(When("kafka", true, func() (AppCtx, error) {
+		return AppCtx{}, buildErr
+	}))
+	assert.ErrorIs(t, err, buildErr)
+
+	_, err = Construct(
+		WithNamedRunner("consumer", successfulRunner),
+		When("kafka", true, func() (AppCtx, error) {
+			return Construct(WithNamedRunner("consumer", successfulRunner))
+		}),
+	)
+	assert.ErrorContains(t, err, `duplicate runner name "consumer"`)
+
+	_, err = Construct(When("", true, func() (AppCtx, error) {
+		return Construct()
+	}))
+	assert.ErrorContains(t, err, "subsystem name cannot be empty")
+}
+
+func TestWhenResultRunner(t *testing.T) {
+	var report ShutdownReport
+
+	err := RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+		return Construct(
+			WithResultRunner("extract", func(context.Context) (int, error) { return 1, nil }),
+			When("load", true, func() (AppCtx, error) {
+				return Construct(WithResultRunner("load", func(context.Context) (int, error) { return 2, nil }))
+			}),
+			WithPostRunHook(func(r ShutdownReport) { report = r }),
+		)
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"extract", "load"}, report.Results.Names())
+}
+
+func TestWhenUnheldRunners(t *testing.T) {
+	workerStarted := make(chan struct{})
+	adminStarted := make(chan struct{})
+	var runtime *Runtime
+
+	done := make(chan error, 1)
+	go func() {
+		done <- RunE(func(ctx InitCtx[TestConfig]) (AppCtx, error) {
+			runtime = ctx.Runtime
+			return Construct(
+				WithNamedRunner("worker", func(context.Context) error {
+					close(workerStarted)
+					return nil
+				}),
+				When("admin", true, func() (AppCtx, error) {
+					return Construct(
+						WithNamedRunner("admin", func(context.Context) error {
+							close(adminStarted)
+							<-workerStarted
+							return nil
+						}),
+						WithUnheldRunners("admin"),
+					)
+				}),
+			)
+		}, WithHold())
+	}()
+
+	select {
+	case <-adminStarted:
+	case <-time.After(2 * time.Second):
+		t.Fatal("A runner exempted from hold mode by a subsystem should start while the app is held")
+	}
+	select {
+	case <-workerStarted:
+		t.Fatal("Other runners should stay held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.True(t, runtime.Release())
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not complete after release")
+	}
+}